@@ -43,6 +43,8 @@ type StripeConfig struct {
 	SecretKey      string
 	PublishableKey string
 	WebhookSecret  string
+	// UseFake allows the in-memory fake provider in release mode
+	UseFake bool
 }
 
 type WeatherConfig struct {
@@ -55,6 +57,7 @@ type EmailConfig struct {
 	SMTPPort     string
 	SMTPUsername string
 	SMTPPassword string
+	From         string
 }
 
 type UploadConfig struct {
@@ -89,6 +92,7 @@ func LoadConfig() *Config {
 			SecretKey:      getEnv("STRIPE_SECRET_KEY", ""),
 			PublishableKey: getEnv("STRIPE_PUBLISHABLE_KEY", ""),
 			WebhookSecret:  getEnv("STRIPE_WEBHOOK_SECRET", ""),
+			UseFake:        getEnvAsBool("PAYMENTS_FAKE", false),
 		},
 		Weather: WeatherConfig{
 			APIKey: getEnv("WEATHER_API_KEY", ""),
@@ -99,6 +103,7 @@ func LoadConfig() *Config {
 			SMTPPort:     getEnv("SMTP_PORT", "587"),
 			SMTPUsername: getEnv("SMTP_USERNAME", ""),
 			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			From:         getEnv("SMTP_FROM", ""),
 		},
 		Upload: UploadConfig{
 			MaxSize: getEnvAsInt64("MAX_UPLOAD_SIZE", 10485760), // 10MB
@@ -147,6 +152,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsInt64(key string, defaultValue int64) int64 {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
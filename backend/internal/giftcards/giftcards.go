@@ -0,0 +1,95 @@
+// Package giftcards issues gift card codes and decides how much of a card's
+// balance can be spent. Balances and their history are kept by the caller.
+package giftcards
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// Card statuses.
+const (
+	StatusPending  = "pending"
+	StatusActive   = "active"
+	StatusDisabled = "disabled"
+)
+
+// codeAlphabet leaves out letters and digits that are easily confused when
+// a code is read off a printed card.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Reasons a gift card cannot be spent.
+var (
+	ErrNotActive = errors.New("giftcards: card has not been activated")
+	ErrDisabled  = errors.New("giftcards: card has been disabled")
+	ErrExpired   = errors.New("giftcards: card has expired")
+	ErrNoBalance = errors.New("giftcards: card has no balance left")
+)
+
+// NewCode returns a random code of the form GC followed by 12 characters,
+// about 60 bits of randomness.
+func NewCode() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := []byte("GC")
+	for _, b := range buf {
+		code = append(code, codeAlphabet[int(b)%len(codeAlphabet)])
+	}
+	return string(code), nil
+}
+
+// Normalize puts a code as typed by a customer into its stored form: upper
+// case without spaces or dashes.
+func Normalize(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// Mask hides all but the last four characters of a code.
+func Mask(code string) string {
+	if len(code) <= 4 {
+		return code
+	}
+	return strings.Repeat("*", len(code)-4) + code[len(code)-4:]
+}
+
+// Check reports why a card cannot be spent, or nil if it can.
+func Check(status string, balance float64, expiresAt *time.Time, now time.Time) error {
+	switch status {
+	case StatusActive:
+	case StatusDisabled:
+		return ErrDisabled
+	default:
+		return ErrNotActive
+	}
+	if expiresAt != nil && now.After(*expiresAt) {
+		return ErrExpired
+	}
+	if round(balance) <= 0 {
+		return ErrNoBalance
+	}
+	return nil
+}
+
+// Spend is how much of a balance goes towards an amount owed. A requested
+// amount of zero spends as much as possible.
+func Spend(balance, owed, requested float64) float64 {
+	spend := math.Min(balance, owed)
+	if requested > 0 {
+		spend = math.Min(spend, requested)
+	}
+	return math.Max(round(spend), 0)
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
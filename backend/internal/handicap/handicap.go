@@ -0,0 +1,416 @@
+// Package handicap implements the World Handicap System calculations used to
+// derive a player's Handicap Index from their posted rounds.
+package handicap
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// StandardSlope is the slope rating of a course of standard difficulty.
+	StandardSlope = 113
+	// MaxIndex is the highest Handicap Index that can be issued.
+	MaxIndex = 54.0
+	// MaxRounds is how many of the most recent differentials the index is
+	// calculated from.
+	MaxRounds = 20
+	// MinHolesPlayed is the fewest holes of an 18-hole round that must be
+	// played for the score to count.
+	MinHolesPlayed = 14
+
+	softCapThreshold  = 3.0
+	hardCapThreshold  = 5.0
+	noIndexMaxOverPar = 5
+)
+
+// HoleScore is one hole of a round. Strokes is zero for a hole that was not
+// played.
+type HoleScore struct {
+	Par         int
+	StrokeIndex int
+	Strokes     int
+}
+
+// Round is a posted score together with the ratings of the course it was
+// played on.
+type Round struct {
+	ID           uint
+	PlayedAt     time.Time
+	CourseRating float64
+	SlopeRating  int
+	Par          int
+	Holes        []HoleScore
+}
+
+// Revision is the outcome of posting one round: its differential and the
+// Handicap Index that applies after it.
+type Revision struct {
+	RoundID               uint
+	PlayedAt              time.Time
+	PreviousIndex         *float64
+	CourseHandicap        *int
+	AdjustedGrossScore    int
+	ScoreDifferential     float64
+	HandicapIndex         *float64
+	LowHandicapIndex      *float64
+	DifferentialsUsed     int
+	ExceptionalAdjustment float64
+	CapApplied            string
+}
+
+// lowestDifferentials maps the number of differentials on record to how many
+// of the lowest are averaged and the adjustment added to the average.
+var lowestDifferentials = []struct {
+	count      int
+	adjustment float64
+}{
+	{0, 0},    // 0
+	{0, 0},    // 1
+	{0, 0},    // 2
+	{1, -2.0}, // 3
+	{1, -1.0}, // 4
+	{1, 0},    // 5
+	{2, -1.0}, // 6
+	{2, 0},    // 7
+	{2, 0},    // 8
+	{3, 0},    // 9
+	{3, 0},    // 10
+	{3, 0},    // 11
+	{4, 0},    // 12
+	{4, 0},    // 13
+	{4, 0},    // 14
+	{5, 0},    // 15
+	{5, 0},    // 16
+	{6, 0},    // 17
+	{6, 0},    // 18
+	{7, 0},    // 19
+	{8, 0},    // 20
+}
+
+// RoundToTenth rounds half away from zero to one decimal place.
+func RoundToTenth(value float64) float64 {
+	return math.Round(value*10) / 10
+}
+
+// CourseHandicap converts a Handicap Index into strokes for a set of tees.
+// A negative result is a plus handicap.
+func CourseHandicap(index float64, slope int, courseRating float64, par int) int {
+	if slope <= 0 {
+		slope = StandardSlope
+	}
+	return int(math.Round(index*float64(slope)/StandardSlope + (courseRating - float64(par))))
+}
+
+// StrokesReceived returns the handicap strokes given on a hole with the
+// given stroke index. Plus handicaps give strokes back, starting from the
+// easiest hole.
+func StrokesReceived(courseHandicap, strokeIndex, holes int) int {
+	if holes <= 0 || strokeIndex <= 0 {
+		return 0
+	}
+	if courseHandicap >= 0 {
+		strokes := courseHandicap / holes
+		if strokeIndex <= courseHandicap%holes {
+			strokes++
+		}
+		return strokes
+	}
+
+	plus := -courseHandicap
+	strokes := -(plus / holes)
+	if strokeIndex > holes-plus%holes {
+		strokes--
+	}
+	return strokes
+}
+
+// NetDoubleBogey is the most a player may score on a hole for handicap
+// purposes.
+func NetDoubleBogey(par, strokesReceived int) int {
+	return par + 2 + strokesReceived
+}
+
+// HolesPlayed counts the holes with a score.
+func HolesPlayed(holes []HoleScore) int {
+	played := 0
+	for _, hole := range holes {
+		if hole.Strokes > 0 {
+			played++
+		}
+	}
+	return played
+}
+
+// Acceptable reports whether a round has enough holes played to be used for
+// handicap purposes.
+func Acceptable(round Round) bool {
+	if len(round.Holes) < 18 || round.CourseRating <= 0 || round.SlopeRating <= 0 {
+		return false
+	}
+	return HolesPlayed(round.Holes) >= MinHolesPlayed
+}
+
+// AdjustedGrossScore caps each hole at net double bogey and scores holes
+// that were not played at net par. Without a course handicap, holes are
+// capped at par plus five and unplayed holes count as par.
+func AdjustedGrossScore(holes []HoleScore, courseHandicap *int) int {
+	total := 0
+	for i, hole := range holes {
+		strokeIndex := hole.StrokeIndex
+		if strokeIndex <= 0 {
+			strokeIndex = i + 1
+		}
+
+		received := 0
+		limit := hole.Par + noIndexMaxOverPar
+		if courseHandicap != nil {
+			received = StrokesReceived(*courseHandicap, strokeIndex, len(holes))
+			limit = NetDoubleBogey(hole.Par, received)
+		}
+
+		switch {
+		case hole.Strokes <= 0:
+			total += hole.Par + received
+		case hole.Strokes > limit:
+			total += limit
+		default:
+			total += hole.Strokes
+		}
+	}
+	return total
+}
+
+// ScoreDifferential measures a score against the difficulty of the course,
+// rounded to one decimal place.
+func ScoreDifferential(adjustedGrossScore int, courseRating float64, slope int) float64 {
+	if slope <= 0 {
+		slope = StandardSlope
+	}
+	return RoundToTenth(StandardSlope / float64(slope) * (float64(adjustedGrossScore) - courseRating))
+}
+
+// IndexFromDifferentials averages the lowest differentials of the most
+// recent ones supplied, oldest first. It returns false until at least three
+// differentials are available.
+func IndexFromDifferentials(differentials []float64) (float64, int, bool) {
+	if len(differentials) > MaxRounds {
+		differentials = differentials[len(differentials)-MaxRounds:]
+	}
+	if len(differentials) < 3 {
+		return 0, 0, false
+	}
+
+	rule := lowestDifferentials[len(differentials)]
+	sorted := append([]float64(nil), differentials...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, d := range sorted[:rule.count] {
+		sum += d
+	}
+	index := RoundToTenth(sum/float64(rule.count) + rule.adjustment)
+	return math.Min(index, MaxIndex), rule.count, true
+}
+
+// ApplyCaps limits how far an index can rise above the player's Low
+// Handicap Index: increases beyond 3.0 strokes are halved and the index can
+// never be more than 5.0 above it.
+func ApplyCaps(index, lowIndex float64) (float64, string) {
+	increase := index - lowIndex
+	if increase <= softCapThreshold {
+		return index, ""
+	}
+
+	capped := RoundToTenth(lowIndex + softCapThreshold + (increase-softCapThreshold)/2)
+	if capped > lowIndex+hardCapThreshold {
+		return RoundToTenth(lowIndex + hardCapThreshold), "hard"
+	}
+	return capped, "soft"
+}
+
+// ExceptionalReduction returns the reduction applied to the most recent
+// differentials when a score is at least 7.0 strokes better than the
+// player's index.
+func ExceptionalReduction(differential, index float64) float64 {
+	improvement := RoundToTenth(index - differential)
+	switch {
+	case improvement >= 10.0:
+		return -2.0
+	case improvement >= 7.0:
+		return -1.0
+	default:
+		return 0
+	}
+}
+
+// Calculate replays a player's acceptable rounds in the order played and
+// returns the revision produced by each one.
+func Calculate(rounds []Round) []Revision {
+	sorted := make([]Round, 0, len(rounds))
+	for _, round := range rounds {
+		if Acceptable(round) {
+			sorted = append(sorted, round)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PlayedAt.Before(sorted[j].PlayedAt)
+	})
+
+	type history struct {
+		playedAt time.Time
+		index    float64
+	}
+
+	var (
+		revisions     []Revision
+		differentials []float64
+		adjustments   []float64
+		indexes       []history
+		current       *float64
+	)
+
+	for _, round := range sorted {
+		revision := Revision{RoundID: round.ID, PlayedAt: round.PlayedAt, PreviousIndex: current}
+
+		var courseHandicap *int
+		if current != nil {
+			ch := CourseHandicap(*current, round.SlopeRating, round.CourseRating, round.Par)
+			courseHandicap = &ch
+		}
+		revision.CourseHandicap = courseHandicap
+		revision.AdjustedGrossScore = AdjustedGrossScore(round.Holes, courseHandicap)
+		revision.ScoreDifferential = ScoreDifferential(revision.AdjustedGrossScore, round.CourseRating, round.SlopeRating)
+
+		differentials = append(differentials, revision.ScoreDifferential)
+		adjustments = append(adjustments, 0)
+
+		if current != nil {
+			if reduction := ExceptionalReduction(revision.ScoreDifferential, *current); reduction != 0 {
+				revision.ExceptionalAdjustment = reduction
+				start := len(adjustments) - MaxRounds
+				if start < 0 {
+					start = 0
+				}
+				for i := start; i < len(adjustments); i++ {
+					adjustments[i] += reduction
+				}
+			}
+		}
+
+		adjusted := make([]float64, len(differentials))
+		for i := range differentials {
+			adjusted[i] = differentials[i] + adjustments[i]
+		}
+
+		index, used, ok := IndexFromDifferentials(adjusted)
+		if ok {
+			revision.DifferentialsUsed = used
+
+			// Caps only apply once the player has a full scoring record
+			if len(differentials) >= MaxRounds {
+				yearAgo := round.PlayedAt.AddDate(-1, 0, 0)
+				var low *float64
+				for _, h := range indexes {
+					if h.playedAt.Before(yearAgo) {
+						continue
+					}
+					if low == nil || h.index < *low {
+						value := h.index
+						low = &value
+					}
+				}
+				if low != nil {
+					revision.LowHandicapIndex = low
+					index, revision.CapApplied = ApplyCaps(index, *low)
+				}
+			}
+
+			value := index
+			revision.HandicapIndex = &value
+			current = &value
+			indexes = append(indexes, history{playedAt: round.PlayedAt, index: value})
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions
+}
+
+// Handicap allowances recommended for common formats of play, as a fraction
+// of course handicap.
+var formatAllowances = map[string]float64{
+	"stroke_play":          0.95,
+	"stableford":           0.95,
+	"par_bogey":            0.95,
+	"match_play":           1.00,
+	"four_ball_stroke":     0.85,
+	"four_ball_match":      0.90,
+	"foursomes":            0.50,
+	"greensomes":           0.60,
+	"scramble_two_person":  0.35,
+	"scramble_four_person": 0.25,
+}
+
+// Allowance returns the handicap allowance for a format of play.
+func Allowance(format string) (float64, bool) {
+	allowance, ok := formatAllowances[format]
+	return allowance, ok
+}
+
+// IsMatchFormat reports whether strokes are given relative to the lowest
+// handicap in the match rather than in full.
+func IsMatchFormat(format string) bool {
+	return format == "match_play" || format == "four_ball_match"
+}
+
+// PlayingHandicap applies a format's allowance to a course handicap.
+func PlayingHandicap(courseHandicap int, allowance float64) int {
+	return int(math.Round(float64(courseHandicap) * allowance))
+}
+
+// AllocateStrokes spreads a playing handicap over holes given by their
+// stroke indexes, in the same order.
+func AllocateStrokes(playingHandicap int, strokeIndexes []int) []int {
+	strokes := make([]int, len(strokeIndexes))
+	for i, si := range strokeIndexes {
+		if si <= 0 {
+			si = i + 1
+		}
+		strokes[i] = StrokesReceived(playingHandicap, si, len(strokeIndexes))
+	}
+	return strokes
+}
+
+// Team handicap allowances recommended for scrambles, applied to the
+// players' course handicaps from lowest to highest.
+var scrambleAllowances = map[int][]float64{
+	2: {0.35, 0.15},
+	3: {0.30, 0.20, 0.10},
+	4: {0.25, 0.20, 0.15, 0.10},
+}
+
+// ScrambleAllowances returns the recommended allowances for a scramble team
+// of the given size.
+func ScrambleAllowances(players int) ([]float64, bool) {
+	allowances, ok := scrambleAllowances[players]
+	return allowances, ok
+}
+
+// TeamHandicap combines course handicaps into a single team handicap. The
+// lowest handicap takes the first allowance, the next lowest the second and
+// so on; players beyond the allowances given do not count.
+func TeamHandicap(courseHandicaps []int, allowances []float64) int {
+	sorted := append([]int(nil), courseHandicaps...)
+	sort.Ints(sorted)
+	total := 0.0
+	for i, ch := range sorted {
+		if i >= len(allowances) {
+			break
+		}
+		total += float64(ch) * allowances[i]
+	}
+	return int(math.Round(total))
+}
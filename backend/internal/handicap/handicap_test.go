@@ -0,0 +1,203 @@
+package handicap
+
+import (
+	"testing"
+	"time"
+)
+
+// testRound is a round on a par-72 course rated 72.0 with standard slope,
+// with the first bogeys holes played in one over par and the rest in par,
+// so its differential is the number of bogeys.
+func testRound(id uint, day, bogeys int) Round {
+	holes := make([]HoleScore, 18)
+	for i := range holes {
+		holes[i] = HoleScore{Par: 4, StrokeIndex: i + 1, Strokes: 4}
+		if i < bogeys {
+			holes[i].Strokes = 5
+		}
+	}
+	return Round{
+		ID:           id,
+		PlayedAt:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, day),
+		CourseRating: 72.0,
+		SlopeRating:  StandardSlope,
+		Par:          72,
+		Holes:        holes,
+	}
+}
+
+// testRounds builds one round a day with the given differentials.
+func testRounds(differentials ...int) []Round {
+	rounds := make([]Round, len(differentials))
+	for i, d := range differentials {
+		rounds[i] = testRound(uint(i+1), i, d)
+	}
+	return rounds
+}
+
+func repeat(value, times int) []int {
+	values := make([]int, times)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+func TestIndexFromDifferentials(t *testing.T) {
+	// ascending returns the differentials 1.0, 2.0, ... n, oldest first, so
+	// the lowest k of them average (k+1)/2.
+	ascending := func(n int) []float64 {
+		differentials := make([]float64, n)
+		for i := range differentials {
+			differentials[i] = float64(i + 1)
+		}
+		return differentials
+	}
+
+	tests := []struct {
+		name          string
+		differentials []float64
+		want          float64
+		used          int
+		ok            bool
+	}{
+		{name: "none", differentials: nil},
+		{name: "2 rounds", differentials: ascending(2)},
+		{name: "3 rounds", differentials: ascending(3), want: -1.0, used: 1, ok: true},
+		{name: "4 rounds", differentials: ascending(4), want: 0.0, used: 1, ok: true},
+		{name: "5 rounds", differentials: ascending(5), want: 1.0, used: 1, ok: true},
+		{name: "6 rounds", differentials: ascending(6), want: 0.5, used: 2, ok: true},
+		{name: "8 rounds", differentials: ascending(8), want: 1.5, used: 2, ok: true},
+		{name: "9 rounds", differentials: ascending(9), want: 2.0, used: 3, ok: true},
+		{name: "12 rounds", differentials: ascending(12), want: 2.5, used: 4, ok: true},
+		{name: "15 rounds", differentials: ascending(15), want: 3.0, used: 5, ok: true},
+		{name: "17 rounds", differentials: ascending(17), want: 3.5, used: 6, ok: true},
+		{name: "19 rounds", differentials: ascending(19), want: 4.0, used: 7, ok: true},
+		{name: "20 rounds", differentials: ascending(20), want: 4.5, used: 8, ok: true},
+		{name: "oldest dropped after 20", differentials: ascending(21), want: 5.5, used: 8, ok: true},
+		{name: "limited to maximum", differentials: []float64{60, 60, 60}, want: MaxIndex, used: 1, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, used, ok := IndexFromDifferentials(tt.differentials)
+			if got != tt.want || used != tt.used || ok != tt.ok {
+				t.Fatalf("IndexFromDifferentials() = %.1f, %d, %v, want %.1f, %d, %v", got, used, ok, tt.want, tt.used, tt.ok)
+			}
+		})
+	}
+}
+
+func TestApplyCaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		index    float64
+		lowIndex float64
+		want     float64
+		capType  string
+	}{
+		{name: "lower than low", index: 9.0, lowIndex: 10.0, want: 9.0},
+		{name: "at soft threshold", index: 13.0, lowIndex: 10.0, want: 13.0},
+		{name: "soft", index: 14.0, lowIndex: 10.0, want: 13.5, capType: "soft"},
+		{name: "soft at hard limit", index: 17.0, lowIndex: 10.0, want: 15.0, capType: "soft"},
+		{name: "hard", index: 18.0, lowIndex: 10.0, want: 15.0, capType: "hard"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, capType := ApplyCaps(tt.index, tt.lowIndex)
+			if got != tt.want || capType != tt.capType {
+				t.Fatalf("ApplyCaps(%.1f, %.1f) = %.1f, %q, want %.1f, %q", tt.index, tt.lowIndex, got, capType, tt.want, tt.capType)
+			}
+		})
+	}
+}
+
+func TestExceptionalReduction(t *testing.T) {
+	tests := []struct {
+		differential float64
+		index        float64
+		want         float64
+	}{
+		{differential: 5.0, index: 10.0, want: 0},
+		{differential: 3.1, index: 10.0, want: 0},
+		{differential: 3.0, index: 10.0, want: -1.0},
+		{differential: 0.1, index: 10.0, want: -1.0},
+		{differential: 0.0, index: 10.0, want: -2.0},
+		{differential: -4.0, index: 10.0, want: -2.0},
+	}
+
+	for _, tt := range tests {
+		if got := ExceptionalReduction(tt.differential, tt.index); got != tt.want {
+			t.Errorf("ExceptionalReduction(%.1f, %.1f) = %.1f, want %.1f", tt.differential, tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name   string
+		rounds []Round
+		// want is the index after each round, zero before one is issued
+		want        []float64
+		capApplied  string
+		exceptional map[int]float64
+	}{
+		{
+			name:   "first index after three rounds",
+			rounds: testRounds(10, 12, 14, 9),
+			want:   []float64{0, 0, 8.0, 8.0},
+		},
+		{
+			// The 3-round index of 6.0 is the low point; the caps apply from
+			// the 20th round, which would otherwise lift the index to 14.3
+			name:       "hard cap from the 20th round",
+			rounds:     testRounds(append(repeat(8, 3), repeat(18, 17)...)...),
+			want:       []float64{0, 0, 6.0, 7.0, 8.0, 7.0, 8.0, 8.0, 8.0, 8.0, 8.0, 10.5, 10.5, 10.5, 12.0, 12.0, 13.0, 13.0, 13.7, 11.0},
+			capApplied: "hard",
+		},
+		{
+			name:       "soft cap from the 20th round",
+			rounds:     testRounds(append(repeat(10, 3), repeat(18, 17)...)...),
+			want:       []float64{0, 0, 8.0, 9.0, 10.0, 9.0, 10.0, 10.0, 10.0, 10.0, 10.0, 12.0, 12.0, 12.0, 13.2, 13.2, 14.0, 14.0, 14.6, 13.0},
+			capApplied: "soft",
+		},
+		{
+			// A round 10 strokes better than the index takes 2.0 off it and
+			// every earlier differential, which stays with later rounds
+			name:        "exceptional score",
+			rounds:      testRounds(10, 10, 10, 10, 10, 0, 10),
+			want:        []float64{0, 0, 8.0, 9.0, 10.0, 2.0, 3.0},
+			exceptional: map[int]float64{5: -2.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revisions := Calculate(tt.rounds)
+			if len(revisions) != len(tt.want) {
+				t.Fatalf("got %d revisions, want %d", len(revisions), len(tt.want))
+			}
+			for i, revision := range revisions {
+				got := 0.0
+				if revision.HandicapIndex != nil {
+					got = *revision.HandicapIndex
+				}
+				if got != tt.want[i] {
+					t.Errorf("round %d: index = %.1f, want %.1f", i+1, got, tt.want[i])
+				}
+				if revision.ExceptionalAdjustment != tt.exceptional[i] {
+					t.Errorf("round %d: exceptional adjustment = %.1f, want %.1f", i+1, revision.ExceptionalAdjustment, tt.exceptional[i])
+				}
+
+				wantCap := ""
+				if i == MaxRounds-1 {
+					wantCap = tt.capApplied
+				}
+				if revision.CapApplied != wantCap {
+					t.Errorf("round %d: cap = %q, want %q", i+1, revision.CapApplied, wantCap)
+				}
+			}
+		})
+	}
+}
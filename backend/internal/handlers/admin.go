@@ -3,11 +3,14 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/ledger"
 	"golf-course-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AdminHandler struct{}
@@ -18,18 +21,19 @@ func NewAdminHandler() *AdminHandler {
 
 // Course Management
 type CreateCourseRequest struct {
-	Name         string  `json:"name" binding:"required"`
-	Description  string  `json:"description"`
-	Address      string  `json:"address" binding:"required"`
-	Phone        string  `json:"phone"`
-	Email        string  `json:"email"`
-	Par          int     `json:"par" binding:"required"`
-	TotalHoles   int     `json:"total_holes" binding:"required"`
-	CourseRating float64 `json:"course_rating"`
-	SlopeRating  int     `json:"slope_rating"`
-	GreenFee     float64 `json:"green_fee" binding:"required"`
-	CartFee      float64 `json:"cart_fee"`
-	IsActive     bool    `json:"is_active"`
+	Name         string        `json:"name" binding:"required"`
+	Description  string        `json:"description"`
+	Address      string        `json:"address" binding:"required"`
+	Phone        string        `json:"phone"`
+	Email        string        `json:"email"`
+	Par          int           `json:"par" binding:"required"`
+	TotalHoles   int           `json:"total_holes" binding:"required"`
+	CourseRating float64       `json:"course_rating"`
+	SlopeRating  int           `json:"slope_rating"`
+	GreenFee     float64       `json:"green_fee" binding:"required"`
+	CartFee      float64       `json:"cart_fee"`
+	IsActive     bool          `json:"is_active"`
+	Holes        []HoleRequest `json:"holes" binding:"dive"`
 }
 
 func (h *AdminHandler) CreateCourse(c *gin.Context) {
@@ -59,12 +63,29 @@ func (h *AdminHandler) CreateCourse(c *gin.Context) {
 		course.SlopeRating = &req.SlopeRating
 	}
 
+	if len(req.Holes) > 0 && len(req.Holes) != req.TotalHoles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide every hole of the course or none"})
+		return
+	}
+
 	db := database.DB
-	if err := db.Create(&course).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create course"})
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&course).Error; err != nil {
+			return err
+		}
+		if len(req.Holes) == 0 {
+			return nil
+		}
+		_, err := saveCourseHoles(tx, course, req.Holes)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create course")
 		return
 	}
 
+	db.Preload("Holes", func(db *gorm.DB) *gorm.DB { return db.Order("hole_number ASC") }).First(&course, course.ID)
+
 	c.JSON(http.StatusCreated, course)
 }
 
@@ -106,6 +127,14 @@ func (h *AdminHandler) UpdateCourse(c *gin.Context) {
 		course.SlopeRating = &req.SlopeRating
 	}
 
+	// Par and hole count changes must still fit the holes already set up
+	var holes []models.Hole
+	db.Where("course_id = ?", course.ID).Find(&holes)
+	if err := validateCourseLayout(course, holes); err != nil {
+		respondError(c, err, "Failed to update course")
+		return
+	}
+
 	if err := db.Save(&course).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update course"})
 		return
@@ -205,11 +234,20 @@ func (h *AdminHandler) UpdateEquipment(c *gin.Context) {
 	equipment.ConditionStatus = req.ConditionStatus
 	equipment.IsAvailable = req.IsAvailable
 
-	if err := db.Save(&equipment).Error; err != nil {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&equipment).Error; err != nil {
+			return err
+		}
+		// Variant-stocked equipment derives its quantity from the variants
+		return syncEquipmentQuantity(tx, equipment.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update equipment"})
 		return
 	}
 
+	db.Preload("Variants").First(&equipment, equipment.ID)
+
 	c.JSON(http.StatusOK, equipment)
 }
 
@@ -235,6 +273,285 @@ func (h *AdminHandler) DeleteEquipment(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Equipment deleted successfully"})
 }
 
+// Equipment Variant Management
+type EquipmentVariantRequest struct {
+	SKU               string `json:"sku"`
+	Size              string `json:"size"`
+	Handedness        string `json:"handedness" binding:"omitempty,oneof=right left"`
+	ShaftFlex         string `json:"shaft_flex" binding:"omitempty,oneof=ladies senior regular stiff extra_stiff"`
+	Gender            string `json:"gender" binding:"omitempty,oneof=mens womens unisex junior"`
+	QuantityAvailable int    `json:"quantity_available" binding:"min=0"`
+	IsAvailable       bool   `json:"is_available"`
+}
+
+func (h *AdminHandler) CreateEquipmentVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid equipment ID"})
+		return
+	}
+
+	var req EquipmentVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var equipment models.Equipment
+	if err := db.First(&equipment, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Equipment not found"})
+		return
+	}
+
+	variant := models.EquipmentVariant{
+		EquipmentID:       equipment.ID,
+		SKU:               req.SKU,
+		Size:              req.Size,
+		Handedness:        req.Handedness,
+		ShaftFlex:         req.ShaftFlex,
+		Gender:            req.Gender,
+		QuantityAvailable: req.QuantityAvailable,
+		IsAvailable:       req.IsAvailable,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&variant).Error; err != nil {
+			return err
+		}
+		// A false is_available would otherwise be replaced by the column default
+		if !req.IsAvailable {
+			variant.IsAvailable = false
+			if err := tx.Model(&variant).Update("is_available", false).Error; err != nil {
+				return err
+			}
+		}
+		return syncEquipmentQuantity(tx, equipment.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create variant"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+func (h *AdminHandler) UpdateEquipmentVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("variant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		return
+	}
+
+	var req EquipmentVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var variant models.EquipmentVariant
+	if err := db.First(&variant, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return
+	}
+
+	variant.SKU = req.SKU
+	variant.Size = req.Size
+	variant.Handedness = req.Handedness
+	variant.ShaftFlex = req.ShaftFlex
+	variant.Gender = req.Gender
+	variant.QuantityAvailable = req.QuantityAvailable
+	variant.IsAvailable = req.IsAvailable
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&variant).Error; err != nil {
+			return err
+		}
+		return syncEquipmentQuantity(tx, variant.EquipmentID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update variant"})
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+func (h *AdminHandler) DeleteEquipmentVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("variant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		return
+	}
+
+	db := database.DB
+	var variant models.EquipmentVariant
+	if err := db.First(&variant, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&variant).Error; err != nil {
+			return err
+		}
+		return syncEquipmentQuantity(tx, variant.EquipmentID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete variant"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Variant deleted successfully"})
+}
+
+// Bundle Management
+type BundleItemRequest struct {
+	EquipmentID uint `json:"equipment_id" binding:"required"`
+	Quantity    int  `json:"quantity" binding:"required,min=1"`
+}
+
+type BundleRequest struct {
+	Name              string              `json:"name" binding:"required"`
+	Description       string              `json:"description"`
+	BundlePricePerDay float64             `json:"bundle_price_per_day" binding:"required"`
+	ImageURL          string              `json:"image_url"`
+	IsActive          bool                `json:"is_active"`
+	Items             []BundleItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+func (h *AdminHandler) CreateBundle(c *gin.Context) {
+	var req BundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bundle := models.EquipmentBundle{
+		Name:              req.Name,
+		Description:       req.Description,
+		BundlePricePerDay: req.BundlePricePerDay,
+		ImageURL:          req.ImageURL,
+		IsActive:          req.IsActive,
+	}
+
+	db := database.DB
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&bundle).Error; err != nil {
+			return err
+		}
+		if !req.IsActive {
+			if err := tx.Model(&bundle).Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+		return replaceBundleItems(tx, bundle.ID, req.Items)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create bundle")
+		return
+	}
+
+	db.Preload("Items.Equipment").First(&bundle, bundle.ID)
+
+	c.JSON(http.StatusCreated, bundle)
+}
+
+func (h *AdminHandler) UpdateBundle(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle ID"})
+		return
+	}
+
+	var req BundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var bundle models.EquipmentBundle
+	if err := db.First(&bundle, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bundle not found"})
+		return
+	}
+
+	bundle.Name = req.Name
+	bundle.Description = req.Description
+	bundle.BundlePricePerDay = req.BundlePricePerDay
+	bundle.ImageURL = req.ImageURL
+	bundle.IsActive = req.IsActive
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&bundle).Error; err != nil {
+			return err
+		}
+		return replaceBundleItems(tx, bundle.ID, req.Items)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update bundle")
+		return
+	}
+
+	db.Preload("Items.Equipment").First(&bundle, bundle.ID)
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+func (h *AdminHandler) DeleteBundle(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle ID"})
+		return
+	}
+
+	db := database.DB
+	var bundle models.EquipmentBundle
+	if err := db.First(&bundle, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bundle not found"})
+		return
+	}
+
+	if err := db.Delete(&bundle).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bundle"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bundle deleted successfully"})
+}
+
+// replaceBundleItems swaps a bundle's component list for the requested one.
+func replaceBundleItems(tx *gorm.DB, bundleID uint, items []BundleItemRequest) error {
+	if err := tx.Where("bundle_id = ?", bundleID).Delete(&models.EquipmentBundleItem{}).Error; err != nil {
+		return err
+	}
+
+	seen := make(map[uint]bool, len(items))
+	for _, item := range items {
+		if seen[item.EquipmentID] {
+			return newHTTPError(http.StatusBadRequest, "Each equipment item may appear only once in a bundle")
+		}
+		seen[item.EquipmentID] = true
+
+		var equipment models.Equipment
+		if err := tx.First(&equipment, item.EquipmentID).Error; err != nil {
+			return newHTTPError(http.StatusBadRequest, "Equipment not found: "+strconv.Itoa(int(item.EquipmentID)))
+		}
+
+		bundleItem := models.EquipmentBundleItem{
+			BundleID:    bundleID,
+			EquipmentID: item.EquipmentID,
+			Quantity:    item.Quantity,
+		}
+		if err := tx.Create(&bundleItem).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // User Management
 func (h *AdminHandler) GetAllUsers(c *gin.Context) {
 	db := database.DB
@@ -364,6 +681,8 @@ type AdminStats struct {
 	TotalRentals     int64   `json:"total_rentals"`
 	TotalRevenue     float64 `json:"total_revenue"`
 	MonthlyRevenue   float64 `json:"monthly_revenue"`
+	TotalRefunds     float64 `json:"total_refunds"`
+	MonthlyRefunds   float64 `json:"monthly_refunds"`
 	ActiveRentals    int64   `json:"active_rentals"`
 	UpcomingBookings int64   `json:"upcoming_bookings"`
 }
@@ -379,24 +698,25 @@ func (h *AdminHandler) GetAdminStats(c *gin.Context) {
 	db.Model(&models.TeeTime{}).Count(&stats.TotalBookings)
 	db.Model(&models.EquipmentRental{}).Count(&stats.TotalRentals)
 
-	// Calculate revenue
-	var teeTimeRevenue, equipmentRevenue float64
-	db.Model(&models.TeeTime{}).Select("COALESCE(SUM(total_amount), 0)").Scan(&teeTimeRevenue)
-	db.Model(&models.EquipmentRental{}).Select("COALESCE(SUM(rental_price), 0)").Scan(&equipmentRevenue)
-	stats.TotalRevenue = teeTimeRevenue + equipmentRevenue
+	// Revenue comes from the ledger, net of refunds and excluding sales tax
+	// and deposits held
+	if totals, err := ledgerTotals(time.Time{}, time.Time{}); err == nil {
+		report := ledger.BuildRevenueReport(totals)
+		stats.TotalRevenue = report.NetRevenue
+		stats.TotalRefunds = report.Refunds
+	}
 
 	// Monthly revenue (current month)
-	var monthlyTeeTime, monthlyEquipment float64
-	db.Model(&models.TeeTime{}).Select("COALESCE(SUM(total_amount), 0)").
-		Where("MONTH(created_at) = MONTH(NOW()) AND YEAR(created_at) = YEAR(NOW())").
-		Scan(&monthlyTeeTime)
-	db.Model(&models.EquipmentRental{}).Select("COALESCE(SUM(rental_price), 0)").
-		Where("MONTH(created_at) = MONTH(NOW()) AND YEAR(created_at) = YEAR(NOW())").
-		Scan(&monthlyEquipment)
-	stats.MonthlyRevenue = monthlyTeeTime + monthlyEquipment
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if totals, err := ledgerTotals(monthStart, time.Time{}); err == nil {
+		report := ledger.BuildRevenueReport(totals)
+		stats.MonthlyRevenue = report.NetRevenue
+		stats.MonthlyRefunds = report.Refunds
+	}
 
 	// Active rentals and upcoming bookings
-	db.Model(&models.EquipmentRental{}).Where("return_date IS NULL").Count(&stats.ActiveRentals)
+	db.Model(&models.EquipmentRental{}).Where("returned_at IS NULL AND rental_status IN ?", activeRentalStatuses).Count(&stats.ActiveRentals)
 	db.Model(&models.TeeTime{}).Where("booking_date >= CURDATE()").Count(&stats.UpcomingBookings)
 
 	c.JSON(http.StatusOK, stats)
@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
@@ -211,7 +212,10 @@ func (h *CourseHandler) GetCourse(c *gin.Context) {
 	}
 
 	var course models.Course
-	if err := database.DB.Preload("Holes").First(&course, id).Error; err != nil {
+	if err := database.DB.Preload("Holes", func(db *gorm.DB) *gorm.DB { return db.Order("hole_number ASC") }).
+		Preload("Holes.Tees").
+		Preload("TeeSets", "is_active = ?", true).
+		First(&course, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
 		return
 	}
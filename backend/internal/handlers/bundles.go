@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BundleHandler struct{}
+
+func NewBundleHandler() *BundleHandler {
+	return &BundleHandler{}
+}
+
+type BundleVariantSelection struct {
+	EquipmentID uint `json:"equipment_id" binding:"required"`
+	VariantID   uint `json:"variant_id" binding:"required"`
+}
+
+type BundleRentalRequest struct {
+	RentalDate string                   `json:"rental_date"`
+	ReturnDate string                   `json:"return_date"`
+	Quantity   int                      `json:"quantity" binding:"omitempty,min=1"`
+	TeeTimeID  *uint                    `json:"tee_time_id"`
+	Variants   []BundleVariantSelection `json:"variants"`
+	Notes      string                   `json:"notes"`
+}
+
+type BundleComponentAvailability struct {
+	EquipmentID   uint   `json:"equipment_id"`
+	Name          string `json:"name"`
+	QuantityNeed  int    `json:"quantity_needed"`
+	QuantityAvail int    `json:"quantity_available"`
+	Available     bool   `json:"available"`
+}
+
+type BundleAvailability struct {
+	BundleID   uint                          `json:"bundle_id"`
+	Quantity   int                           `json:"quantity"`
+	Available  bool                          `json:"available"`
+	MaxBundles int                           `json:"max_bundles"`
+	Components []BundleComponentAvailability `json:"components"`
+}
+
+// bundleReservation describes one request to rent a bundle, whether made
+// directly or as part of a tee time booking.
+type bundleReservation struct {
+	UserID     uint
+	TeeTimeID  *uint
+	RentalDate time.Time
+	ReturnDate time.Time
+	Quantity   int
+	Variants   []BundleVariantSelection
+	Notes      string
+}
+
+// @Summary Get rental bundles
+// @Description Get all active equipment bundles with their components
+// @Tags equipment
+// @Produce json
+// @Success 200 {array} models.EquipmentBundle
+// @Router /equipment/bundles [get]
+func (h *BundleHandler) GetBundles(c *gin.Context) {
+	var bundles []models.EquipmentBundle
+	if err := database.DB.Preload("Items.Equipment.Variants").Where("is_active = ?", true).
+		Order("name ASC").Find(&bundles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bundles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bundles)
+}
+
+// @Summary Get bundle by ID
+// @Description Get a specific equipment bundle with its components
+// @Tags equipment
+// @Produce json
+// @Param id path int true "Bundle ID"
+// @Success 200 {object} models.EquipmentBundle
+// @Failure 404 {object} map[string]string
+// @Router /equipment/bundles/{id} [get]
+func (h *BundleHandler) GetBundle(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle ID"})
+		return
+	}
+
+	bundle, err := loadBundle(database.DB, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bundle not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+// @Summary Check bundle availability
+// @Description Check whether every component of a bundle is in stock
+// @Tags equipment
+// @Produce json
+// @Param id path int true "Bundle ID"
+// @Param quantity query int false "Number of bundles (default 1)"
+// @Success 200 {object} BundleAvailability
+// @Failure 404 {object} map[string]string
+// @Router /equipment/bundles/{id}/availability [get]
+func (h *BundleHandler) GetBundleAvailability(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle ID"})
+		return
+	}
+
+	quantity := 1
+	if q := c.Query("quantity"); q != "" {
+		quantity, err = strconv.Atoi(q)
+		if err != nil || quantity < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quantity"})
+			return
+		}
+	}
+
+	bundle, err := loadBundle(database.DB, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bundle not found"})
+		return
+	}
+
+	availability := BundleAvailability{BundleID: bundle.ID, Quantity: quantity, Available: true, MaxBundles: -1}
+	for _, item := range bundle.Items {
+		stock := item.Equipment.QuantityAvailable
+		if !item.Equipment.IsAvailable {
+			stock = 0
+		}
+		needed := item.Quantity * quantity
+		component := BundleComponentAvailability{
+			EquipmentID:   item.EquipmentID,
+			Name:          item.Equipment.Name,
+			QuantityNeed:  needed,
+			QuantityAvail: stock,
+			Available:     stock >= needed,
+		}
+		if !component.Available {
+			availability.Available = false
+		}
+		if fits := stock / item.Quantity; availability.MaxBundles < 0 || fits < availability.MaxBundles {
+			availability.MaxBundles = fits
+		}
+		availability.Components = append(availability.Components, component)
+	}
+	if availability.MaxBundles < 0 {
+		availability.MaxBundles = 0
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// @Summary Rent a bundle
+// @Description Reserve every component of a bundle in one step, optionally attached to a tee time
+// @Tags equipment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Bundle ID"
+// @Param request body BundleRentalRequest true "Bundle rental request"
+// @Success 201 {object} models.BundleRental
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /equipment/bundles/{id}/rentals [post]
+func (h *BundleHandler) RentBundle(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle ID"})
+		return
+	}
+
+	var req BundleRentalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reservation := bundleReservation{
+		UserID:    userID.(uint),
+		TeeTimeID: req.TeeTimeID,
+		Quantity:  req.Quantity,
+		Variants:  req.Variants,
+		Notes:     req.Notes,
+	}
+
+	// Bundles attached to a tee time default to the day of the round
+	if req.TeeTimeID != nil {
+		var teeTime models.TeeTime
+		if err := database.DB.Where("id = ? AND user_id = ?", *req.TeeTimeID, reservation.UserID).
+			First(&teeTime).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tee time not found"})
+			return
+		}
+		if teeTime.BookingStatus == "cancelled" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot attach a bundle to a cancelled tee time"})
+			return
+		}
+		reservation.RentalDate = teeTime.BookingDate
+	}
+
+	if req.RentalDate != "" {
+		reservation.RentalDate, err = time.Parse("2006-01-02", req.RentalDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rental date format"})
+			return
+		}
+	}
+	if reservation.RentalDate.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rental_date or tee_time_id is required"})
+		return
+	}
+
+	reservation.ReturnDate = reservation.RentalDate
+	if req.ReturnDate != "" {
+		reservation.ReturnDate, err = time.Parse("2006-01-02", req.ReturnDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return date format"})
+			return
+		}
+	}
+
+	bundle, err := loadBundle(database.DB, uint(id))
+	if err != nil || !bundle.IsActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bundle not found"})
+		return
+	}
+
+	var rental *models.BundleRental
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		rental, err = reserveBundle(tx, bundle, reservation)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to rent bundle")
+		return
+	}
+
+	database.DB.Preload("Bundle").Preload("Rentals.Equipment").Preload("Rentals.Variant").First(rental, rental.ID)
+
+	c.JSON(http.StatusCreated, rental)
+}
+
+// @Summary Get user's bundle rentals
+// @Description Get all bundle rentals for the authenticated user
+// @Tags equipment
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.BundleRental
+// @Failure 401 {object} map[string]string
+// @Router /equipment/bundles/rentals [get]
+func (h *BundleHandler) GetUserBundleRentals(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var rentals []models.BundleRental
+	if err := database.DB.Preload("Bundle").Preload("Rentals.Equipment").Preload("Rentals.Variant").
+		Where("user_id = ?", userID).Order("rental_date DESC").Find(&rentals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bundle rentals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rentals)
+}
+
+// @Summary Return a bundle
+// @Description Mark every outstanding component of a bundle rental as returned
+// @Tags equipment
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Bundle rental ID"
+// @Success 200 {object} models.BundleRental
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /equipment/bundles/rentals/{id}/return [put]
+func (h *BundleHandler) ReturnBundle(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rental ID"})
+		return
+	}
+
+	var rental models.BundleRental
+	if err := database.DB.Preload("Rentals").Where("id = ? AND user_id = ?", id, userID).
+		First(&rental).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rental not found"})
+		return
+	}
+
+	if rental.RentalStatus == "returned" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bundle already returned"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for i := range rental.Rentals {
+			if rental.Rentals[i].ReturnedAt != nil {
+				continue
+			}
+			if err := markRentalReturned(tx, &rental.Rentals[i], now); err != nil {
+				return err
+			}
+		}
+		rental.ReturnDate = &now
+		rental.RentalStatus = "returned"
+		return tx.Omit("Rentals").Save(&rental).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rental"})
+		return
+	}
+
+	database.DB.Preload("Bundle").Preload("Rentals.Equipment").Preload("Rentals.Variant").First(&rental, rental.ID)
+
+	c.JSON(http.StatusOK, rental)
+}
+
+// releaseTeeTimeBundles calls off the bundle rentals reserved with a tee
+// time. Components that have not been picked up or paid for go back into
+// stock; a paid component stays until it is refunded, which cancels it. A
+// bundle is cancelled once none of its components are left in use.
+func releaseTeeTimeBundles(tx *gorm.DB, teeTimeID uint) ([]string, error) {
+	var bundles []models.BundleRental
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tee_time_id = ? AND rental_status = ?", teeTimeID, "reserved").Find(&bundles).Error; err != nil {
+		return nil, err
+	}
+
+	var voided []string
+	for _, bundle := range bundles {
+		var rentals []models.EquipmentRental
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bundle_rental_id = ?", bundle.ID).Find(&rentals).Error; err != nil {
+			return nil, err
+		}
+		inUse := false
+		for i := range rentals {
+			rental := &rentals[i]
+			if rental.RentalStatus == "cancelled" {
+				continue
+			}
+			if rental.PickedUpAt != nil || rental.ReturnedAt != nil {
+				inUse = true
+				continue
+			}
+			paid, err := chargesPaid(tx, "equipment_rental", rental.ID)
+			if err != nil {
+				return nil, err
+			}
+			if paid > 0 {
+				inUse = true
+				continue
+			}
+			ids, err := cancelRentalBeforePickup(tx, rental)
+			if err != nil {
+				return nil, err
+			}
+			voided = append(voided, ids...)
+		}
+		if !inUse {
+			if err := tx.Model(&bundle).Update("rental_status", "cancelled").Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	return voided, nil
+}
+
+func loadBundle(db *gorm.DB, id uint) (*models.EquipmentBundle, error) {
+	var bundle models.EquipmentBundle
+	if err := db.Preload("Items.Equipment.Variants").First(&bundle, id).Error; err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// reserveBundle prices a bundle rental and reserves stock for every
+// component. It must run inside a transaction: if any component is short the
+// caller rolls back and nothing is reserved. Each component becomes its own
+// EquipmentRental carrying a share of the bundle price and deposit, so the
+// staff pickup/return workflow handles bundle items like any other rental.
+func reserveBundle(tx *gorm.DB, bundle *models.EquipmentBundle, r bundleReservation) (*models.BundleRental, error) {
+	if len(bundle.Items) == 0 {
+		return nil, newHTTPError(http.StatusBadRequest, "Bundle has no components")
+	}
+	if r.Quantity < 1 {
+		r.Quantity = 1
+	}
+	if r.ReturnDate.Before(r.RentalDate) {
+		return nil, newHTTPError(http.StatusBadRequest, "Return date must be after rental date")
+	}
+
+	selected := make(map[uint]uint, len(r.Variants))
+	for _, v := range r.Variants {
+		selected[v.EquipmentID] = v.VariantID
+	}
+
+	days := rentalDays(r.RentalDate, r.ReturnDate)
+	bundlePrice := roundCurrency(bundle.BundlePricePerDay * float64(days) * float64(r.Quantity))
+
+	// Value of each component at its normal daily rate, used to split the bundle price
+	values := make([]float64, len(bundle.Items))
+	var componentValue float64
+	for i, item := range bundle.Items {
+		values[i] = item.Equipment.RentalPricePerDay * float64(item.Quantity*r.Quantity*days)
+		componentValue += values[i]
+	}
+
+	rental := models.BundleRental{
+		UserID:         r.UserID,
+		BundleID:       bundle.ID,
+		TeeTimeID:      r.TeeTimeID,
+		RentalDate:     r.RentalDate,
+		ReturnDate:     &r.ReturnDate,
+		Quantity:       r.Quantity,
+		RentalPrice:    bundlePrice,
+		ComponentValue: roundCurrency(componentValue),
+		DepositAmount:  roundCurrency(bundlePrice * 0.2), // 20% deposit, same as single rentals
+		PaymentStatus:  "pending",
+		RentalStatus:   "reserved",
+		Notes:          r.Notes,
+	}
+	if err := tx.Create(&rental).Error; err != nil {
+		return nil, err
+	}
+
+	var allocatedPrice, allocatedDeposit float64
+	for i, item := range bundle.Items {
+		var variantID *uint
+		if id, ok := selected[item.EquipmentID]; ok {
+			variantID = &id
+		}
+		if err := validateVariantSelection(&item.Equipment, variantID); err != nil {
+			return nil, err
+		}
+
+		quantity := item.Quantity * r.Quantity
+		if err := reserveEquipmentStock(tx, item.EquipmentID, variantID, quantity); err != nil {
+			if errors.Is(err, errInsufficientStock) {
+				return nil, newHTTPError(http.StatusConflict,
+					fmt.Sprintf("%s is not available in the requested quantity", item.Equipment.Name))
+			}
+			return nil, err
+		}
+
+		// The last component takes the rounding remainder so the parts add up
+		share := 1 / float64(len(bundle.Items))
+		if componentValue > 0 {
+			share = values[i] / componentValue
+		}
+		price := roundCurrency(bundlePrice * share)
+		deposit := roundCurrency(rental.DepositAmount * share)
+		if i == len(bundle.Items)-1 {
+			price = roundCurrency(bundlePrice - allocatedPrice)
+			deposit = roundCurrency(rental.DepositAmount - allocatedDeposit)
+		}
+		allocatedPrice += price
+		allocatedDeposit += deposit
+
+		component := models.EquipmentRental{
+			UserID:         r.UserID,
+			EquipmentID:    item.EquipmentID,
+			VariantID:      variantID,
+			BundleRentalID: &rental.ID,
+			RentalDate:     r.RentalDate,
+			ReturnDate:     &r.ReturnDate,
+			Quantity:       quantity,
+			RentalPrice:    price,
+			DepositAmount:  deposit,
+			PaymentStatus:  "pending",
+			RentalStatus:   "rented",
+			Notes:          "Part of bundle: " + bundle.Name,
+		}
+		if err := tx.Create(&component).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &rental, nil
+}
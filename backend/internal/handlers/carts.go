@@ -0,0 +1,619 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CartHandler struct{}
+
+func NewCartHandler() *CartHandler {
+	return &CartHandler{}
+}
+
+// Cart statuses that still count towards the fleet a round can draw from.
+// Charging carts are included because they will be ready by the time later
+// rounds go out.
+var cartFleetStatuses = []string{"available", "in_use", "charging"}
+
+type CartRequest struct {
+	CartNumber   string `json:"cart_number" binding:"required"`
+	CartType     string `json:"cart_type" binding:"omitempty,oneof=two_seater four_seater single_rider"`
+	PowerType    string `json:"power_type" binding:"omitempty,oneof=electric gas"`
+	BatteryLevel *int   `json:"battery_level" binding:"omitempty,min=0,max=100"`
+	FuelLevel    *int   `json:"fuel_level" binding:"omitempty,min=0,max=100"`
+	Status       string `json:"status" binding:"omitempty,oneof=available in_use charging maintenance retired"`
+	Notes        string `json:"notes"`
+}
+
+type CartStatusRequest struct {
+	Status       string `json:"status" binding:"required,oneof=available charging maintenance retired"`
+	BatteryLevel *int   `json:"battery_level" binding:"omitempty,min=0,max=100"`
+	FuelLevel    *int   `json:"fuel_level" binding:"omitempty,min=0,max=100"`
+	Notes        string `json:"notes"`
+}
+
+type CartReturnRequest struct {
+	BatteryLevel *int   `json:"battery_level" binding:"omitempty,min=0,max=100"`
+	FuelLevel    *int   `json:"fuel_level" binding:"omitempty,min=0,max=100"`
+	NeedsService bool   `json:"needs_service"`
+	Notes        string `json:"notes"`
+}
+
+// CheckInRequest may settle the shares of a split booking that players have
+// not paid online, taken at the counter by PaymentMethod.
+type CheckInRequest struct {
+	CartIDs       []uint `json:"cart_ids"`
+	SettleShares  bool   `json:"settle_shares"`
+	PaymentMethod string `json:"payment_method" binding:"omitempty,oneof=cash credit_card debit_card"`
+}
+
+type CartBoardEntry struct {
+	Cart       models.GolfCart        `json:"cart"`
+	Assignment *models.CartAssignment `json:"assignment,omitempty"`
+	DueBack    string                 `json:"due_back,omitempty"`
+}
+
+type CartBoard struct {
+	Date           string           `json:"date"`
+	Summary        map[string]int   `json:"summary"`
+	Out            []CartBoardEntry `json:"out"`
+	Charging       []CartBoardEntry `json:"charging"`
+	Maintenance    []CartBoardEntry `json:"maintenance"`
+	Available      []CartBoardEntry `json:"available"`
+	FleetCapacity  int              `json:"fleet_capacity"`
+	CartsBooked    int              `json:"carts_booked"`
+	PeakDemand     int              `json:"peak_demand"`
+	PeakDemandTime string           `json:"peak_demand_time,omitempty"`
+}
+
+// cartDemand is one booking's claim on the fleet, in minutes after midnight.
+type cartDemand struct {
+	start int
+	carts int
+}
+
+// roundDurationMinutes is how long a cart is out for one round, including
+// turnaround.
+func roundDurationMinutes() int {
+	return getSettingInt("round_duration_minutes", 270)
+}
+
+// cartFleetCapacity counts carts that can go out on a round. The bool is
+// false when no carts have been set up at all, in which case cart bookings
+// are not limited.
+func cartFleetCapacity(db *gorm.DB) (int, bool, error) {
+	var total, capacity int64
+	if err := db.Model(&models.GolfCart{}).Count(&total).Error; err != nil {
+		return 0, false, err
+	}
+	if err := db.Model(&models.GolfCart{}).Where("status IN ?", cartFleetStatuses).Count(&capacity).Error; err != nil {
+		return 0, false, err
+	}
+	return int(capacity), total > 0, nil
+}
+
+// loadCartDemand returns every booking that needs carts on the given date,
+// across all courses.
+func loadCartDemand(db *gorm.DB, date time.Time) ([]cartDemand, error) {
+	var bookings []models.TeeTime
+	if err := db.Select("id", "tee_time", "cart_count").
+		Where("booking_date = ? AND cart_count > 0 AND booking_status != ?", date, "cancelled").
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+
+	demand := make([]cartDemand, 0, len(bookings))
+	for _, b := range bookings {
+		start, err := parseClock(b.TeeTime)
+		if err != nil {
+			continue
+		}
+		demand = append(demand, cartDemand{start: start, carts: b.CartCount})
+	}
+	return demand, nil
+}
+
+// peakCartDemand returns the most carts out at once during a round starting
+// at start. Usage only rises when a round tees off, so it is enough to check
+// the start itself and every other tee time inside the window.
+func peakCartDemand(demand []cartDemand, start, duration int) int {
+	points := []int{start}
+	for _, d := range demand {
+		if d.start > start && d.start < start+duration {
+			points = append(points, d.start)
+		}
+	}
+
+	peak := 0
+	for _, p := range points {
+		out := 0
+		for _, d := range demand {
+			if d.start <= p && p < d.start+duration {
+				out += d.carts
+			}
+		}
+		if out > peak {
+			peak = out
+		}
+	}
+	return peak
+}
+
+// ensureCartCapacity rejects a booking whose carts would push any moment of
+// its round over the fleet size. Locking the fleet rows serialises competing
+// cart bookings so two requests can't both take the last cart.
+func ensureCartCapacity(tx *gorm.DB, date time.Time, teeTime string, carts int) error {
+	if carts <= 0 {
+		return nil
+	}
+
+	var fleet []models.GolfCart
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").Find(&fleet).Error; err != nil {
+		return err
+	}
+	if len(fleet) == 0 {
+		return nil
+	}
+	capacity := 0
+	for _, cart := range fleet {
+		for _, status := range cartFleetStatuses {
+			if cart.Status == status {
+				capacity++
+			}
+		}
+	}
+
+	start, err := parseClock(teeTime)
+	if err != nil {
+		return newHTTPError(http.StatusBadRequest, "Invalid tee time format")
+	}
+	demand, err := loadCartDemand(tx, date)
+	if err != nil {
+		return err
+	}
+
+	duration := roundDurationMinutes()
+	demand = append(demand, cartDemand{start: start, carts: carts})
+	if peak := peakCartDemand(demand, start, duration); peak > capacity {
+		return newHTTPError(http.StatusConflict,
+			fmt.Sprintf("Not enough carts for a %s round: %d needed at peak, fleet has %d", teeTime, peak, capacity))
+	}
+	return nil
+}
+
+// Staff cart board: what's out, charging, in maintenance or ready
+func (h *CartHandler) GetCartBoard(c *gin.Context) {
+	date := time.Now()
+	if d := c.Query("date"); d != "" {
+		var err error
+		date, err = time.Parse("2006-01-02", d)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+			return
+		}
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	db := database.DB
+	var carts []models.GolfCart
+	if err := db.Order("cart_number ASC").Find(&carts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch carts"})
+		return
+	}
+
+	var open []models.CartAssignment
+	db.Preload("TeeTime.Course").Preload("TeeTime.User").Where("returned_at IS NULL").Find(&open)
+	openByCart := make(map[uint]*models.CartAssignment, len(open))
+	for i := range open {
+		openByCart[open[i].CartID] = &open[i]
+	}
+
+	duration := roundDurationMinutes()
+	board := CartBoard{
+		Date:        day.Format("2006-01-02"),
+		Summary:     map[string]int{},
+		Out:         []CartBoardEntry{},
+		Charging:    []CartBoardEntry{},
+		Maintenance: []CartBoardEntry{},
+		Available:   []CartBoardEntry{},
+	}
+
+	for _, cart := range carts {
+		board.Summary[cart.Status]++
+		entry := CartBoardEntry{Cart: cart}
+		switch cart.Status {
+		case "in_use":
+			if a := openByCart[cart.ID]; a != nil {
+				entry.Assignment = a
+				if a.TeeTime != nil {
+					if start, err := parseClock(a.TeeTime.TeeTime); err == nil {
+						entry.DueBack = formatClock((start + duration) % (24 * 60))
+					}
+				}
+			}
+			board.Out = append(board.Out, entry)
+		case "charging":
+			board.Charging = append(board.Charging, entry)
+		case "maintenance":
+			board.Maintenance = append(board.Maintenance, entry)
+		case "available":
+			board.Available = append(board.Available, entry)
+		}
+	}
+
+	// Carts still needed for the day, to plan charging around the busy window
+	capacity, _, _ := cartFleetCapacity(db)
+	board.FleetCapacity = capacity
+	demand, _ := loadCartDemand(db, day)
+	sort.Slice(demand, func(i, j int) bool { return demand[i].start < demand[j].start })
+	for _, d := range demand {
+		board.CartsBooked += d.carts
+		out := 0
+		for _, other := range demand {
+			if other.start <= d.start && d.start < other.start+duration {
+				out += other.carts
+			}
+		}
+		if out > board.PeakDemand {
+			board.PeakDemand = out
+			board.PeakDemandTime = formatClock(d.start)
+		}
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// Check a booking in and hand out its carts
+func (h *CartHandler) CheckInTeeTime(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.SettleShares && req.PaymentMethod == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payment_method is required to settle shares"})
+		return
+	}
+
+	staffID := c.GetUint("user_id")
+	var teeTime models.TeeTime
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&teeTime, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Booking not found")
+		}
+		if teeTime.BookingStatus != "confirmed" {
+			return newHTTPError(http.StatusBadRequest, "Only confirmed bookings can be checked in")
+		}
+		if req.SettleShares {
+			if !teeTime.SplitPayment {
+				return newHTTPError(http.StatusBadRequest, "This booking is not split between players")
+			}
+			if err := settleTeeTimeShares(tx, &teeTime, req.PaymentMethod, staffID); err != nil {
+				return err
+			}
+		}
+
+		needed := teeTime.CartCount
+		if len(req.CartIDs) > 0 {
+			needed = len(req.CartIDs)
+		}
+
+		if needed > 0 {
+			var carts []models.GolfCart
+			query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("status = ?", "available")
+			if len(req.CartIDs) > 0 {
+				query = query.Where("id IN ?", req.CartIDs)
+			} else {
+				// Hand out the fullest carts first
+				query = query.Order("COALESCE(battery_level, fuel_level, 100) DESC").Limit(needed)
+			}
+			if err := query.Find(&carts).Error; err != nil {
+				return err
+			}
+			if len(carts) < needed {
+				return newHTTPError(http.StatusConflict,
+					fmt.Sprintf("Only %d of %d requested carts are available", len(carts), needed))
+			}
+
+			now := time.Now()
+			for _, cart := range carts {
+				level := cart.BatteryLevel
+				if cart.PowerType == "gas" {
+					level = cart.FuelLevel
+				}
+				assignment := models.CartAssignment{
+					CartID:     cart.ID,
+					TeeTimeID:  teeTime.ID,
+					AssignedBy: staffID,
+					AssignedAt: now,
+					LevelOut:   level,
+				}
+				if err := tx.Create(&assignment).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&cart).Update("status", "in_use").Error; err != nil {
+					return err
+				}
+			}
+			teeTime.CartCount = needed
+			teeTime.CartRequired = true
+		}
+
+		now := time.Now()
+		return tx.Model(&teeTime).Updates(map[string]interface{}{
+			"cart_count":     teeTime.CartCount,
+			"cart_required":  teeTime.CartRequired,
+			"checked_in_at":  now,
+			"booking_status": "checked_in",
+		}).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to check in booking")
+		return
+	}
+
+	database.DB.Preload("Course").Preload("User").Preload("CartAssignments.Cart").Preload("Shares").First(&teeTime, teeTime.ID)
+
+	c.JSON(http.StatusOK, teeTime)
+}
+
+// Take a cart back after the round and decide whether it needs charging or service
+func (h *CartHandler) ReturnCart(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart ID"})
+		return
+	}
+
+	var req CartReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cart models.GolfCart
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cart, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Cart not found")
+		}
+		if cart.Status != "in_use" {
+			return newHTTPError(http.StatusBadRequest, "Cart is not out on the course")
+		}
+
+		if req.BatteryLevel != nil {
+			cart.BatteryLevel = req.BatteryLevel
+		}
+		if req.FuelLevel != nil {
+			cart.FuelLevel = req.FuelLevel
+		}
+		cart.Notes = appendNote(cart.Notes, req.Notes)
+
+		level := cart.BatteryLevel
+		if cart.PowerType == "gas" {
+			level = cart.FuelLevel
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.CartAssignment{}).
+			Where("cart_id = ? AND returned_at IS NULL", cart.ID).
+			Updates(map[string]interface{}{"returned_at": now, "level_in": level}).Error; err != nil {
+			return err
+		}
+
+		if req.NeedsService {
+			if err := tx.Save(&cart).Error; err != nil {
+				return err
+			}
+			order := models.WorkOrder{
+				AssetType:   "golf_cart",
+				CartID:      &cart.ID,
+				Title:       workOrderTitle("cart", cart.ID),
+				Description: req.Notes,
+				ReportedBy:  c.GetUint("user_id"),
+			}
+			// The cart is still marked in use, so let the work order take it over
+			if err := openWorkOrder(tx, &order, 1); err != nil {
+				return err
+			}
+			cart.Status = "maintenance"
+			return nil
+		}
+
+		threshold := getSettingInt("cart_charge_threshold", 80)
+		switch {
+		case cart.PowerType == "electric" && (cart.BatteryLevel == nil || *cart.BatteryLevel < threshold):
+			cart.Status = "charging"
+		default:
+			cart.Status = "available"
+		}
+		return tx.Save(&cart).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to return cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// Update a cart's status, e.g. charging finished or sent for repair
+func (h *CartHandler) UpdateCartStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart ID"})
+		return
+	}
+
+	var req CartStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var cart models.GolfCart
+	if err := db.First(&cart, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+		return
+	}
+
+	if cart.Status == "in_use" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cart is out on the course; return it first"})
+		return
+	}
+
+	cart.Status = req.Status
+	if req.BatteryLevel != nil {
+		cart.BatteryLevel = req.BatteryLevel
+	}
+	if req.FuelLevel != nil {
+		cart.FuelLevel = req.FuelLevel
+	}
+	cart.Notes = appendNote(cart.Notes, req.Notes)
+
+	if err := db.Save(&cart).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// Fleet management for admins
+func (h *CartHandler) CreateCart(c *gin.Context) {
+	var req CartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cart := models.GolfCart{
+		CartNumber:   req.CartNumber,
+		CartType:     req.CartType,
+		PowerType:    req.PowerType,
+		BatteryLevel: req.BatteryLevel,
+		FuelLevel:    req.FuelLevel,
+		Status:       req.Status,
+		Notes:        req.Notes,
+	}
+
+	db := database.DB
+	var existing models.GolfCart
+	if err := db.Where("cart_number = ?", req.CartNumber).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cart number already in use"})
+		return
+	}
+
+	if err := db.Create(&cart).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cart"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, cart)
+}
+
+func (h *CartHandler) UpdateCart(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart ID"})
+		return
+	}
+
+	var req CartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var cart models.GolfCart
+	if err := db.First(&cart, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+		return
+	}
+
+	// Carts only go out and come back through check-in and return
+	if req.Status != "" && req.Status != cart.Status && (req.Status == "in_use" || cart.Status == "in_use") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Carts are sent out at check-in and brought back through a return"})
+		return
+	}
+
+	if req.CartNumber != cart.CartNumber {
+		var existing models.GolfCart
+		if err := db.Where("cart_number = ? AND id <> ?", req.CartNumber, cart.ID).First(&existing).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cart number already in use"})
+			return
+		}
+	}
+
+	cart.CartNumber = req.CartNumber
+	if req.CartType != "" {
+		cart.CartType = req.CartType
+	}
+	if req.PowerType != "" {
+		cart.PowerType = req.PowerType
+	}
+	if req.Status != "" {
+		cart.Status = req.Status
+	}
+	if req.BatteryLevel != nil {
+		cart.BatteryLevel = req.BatteryLevel
+	}
+	if req.FuelLevel != nil {
+		cart.FuelLevel = req.FuelLevel
+	}
+	cart.Notes = req.Notes
+
+	if err := db.Save(&cart).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+func (h *CartHandler) DeleteCart(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart ID"})
+		return
+	}
+
+	db := database.DB
+	var cart models.GolfCart
+	if err := db.First(&cart, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+		return
+	}
+
+	if cart.Status == "in_use" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cart is out on the course"})
+		return
+	}
+
+	if err := db.Delete(&cart).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cart deleted successfully"})
+}
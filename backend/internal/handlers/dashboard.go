@@ -48,7 +48,8 @@ func (h *DashboardHandler) GetDashboardStats(c *gin.Context) {
 
 	// Get active equipment rentals count (not yet returned)
 	var equipmentRentals int64
-	db.Model(&models.EquipmentRental{}).Where("user_id = ? AND return_date IS NULL", userID).Count(&equipmentRentals)
+	db.Model(&models.EquipmentRental{}).Where("user_id = ? AND returned_at IS NULL AND rental_status IN ?", userID, activeRentalStatuses).
+		Count(&equipmentRentals)
 
 	// Calculate total spent (from all bookings and rentals)
 	var totalSpent float64
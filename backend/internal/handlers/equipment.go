@@ -1,34 +1,54 @@
 package handlers
 
 import (
+	"fmt"
 	"golf-course-backend/internal/database"
 	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/promotions"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type EquipmentHandler struct{}
+type EquipmentHandler struct {
+	payments *PaymentHandler
+}
 
-func NewEquipmentHandler() *EquipmentHandler {
-	return &EquipmentHandler{}
+func NewEquipmentHandler(payments *PaymentHandler) *EquipmentHandler {
+	return &EquipmentHandler{payments: payments}
 }
 
 type EquipmentRentalRequest struct {
 	EquipmentID uint   `json:"equipment_id" binding:"required"`
+	VariantID   *uint  `json:"variant_id"`
 	RentalDate  string `json:"rental_date" binding:"required"`
 	ReturnDate  string `json:"return_date" binding:"required"`
 	Quantity    int    `json:"quantity" binding:"required,min=1"`
 	Notes       string `json:"notes"`
+	PromoCode   string `json:"promo_code"`
+}
+
+// EquipmentRentalResponse is a new rental with the promo code discount
+// breakdown when one was used.
+type EquipmentRentalResponse struct {
+	models.EquipmentRental
+	Pricing *promotions.Breakdown `json:"pricing,omitempty"`
 }
 
 // @Summary Get all equipment
-// @Description Get all available equipment for rental
+// @Description Get all available equipment for rental, optionally filtered by variant attributes
 // @Tags equipment
 // @Produce json
 // @Param category query string false "Equipment category"
+// @Param size query string false "Variant size (e.g. 10, M, L)"
+// @Param handedness query string false "Variant handedness (right, left)"
+// @Param flex query string false "Variant shaft flex (ladies, senior, regular, stiff, extra_stiff)"
+// @Param gender query string false "Variant gender (mens, womens, junior); unisex variants always match"
+// @Param max_price query number false "Maximum rental price per day"
 // @Success 200 {array} models.Equipment
 // @Router /equipment [get]
 func (h *EquipmentHandler) GetEquipment(c *gin.Context) {
@@ -38,6 +58,24 @@ func (h *EquipmentHandler) GetEquipment(c *gin.Context) {
 	if category != "" {
 		query = query.Where("category = ?", category)
 	}
+	if maxPrice := c.Query("max_price"); maxPrice != "" {
+		price, err := strconv.ParseFloat(maxPrice, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid max_price"})
+			return
+		}
+		query = query.Where("rental_price_per_day <= ?", price)
+	}
+
+	// Variant filters narrow both the equipment list and the variants returned with it
+	variantScope, filtered := variantFilterScope(c)
+	if filtered {
+		matching := database.DB.Model(&models.EquipmentVariant{}).Select("equipment_id").Scopes(variantScope)
+		query = query.Where("id IN (?)", matching)
+	}
+	query = query.Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(variantScope).Order("id ASC")
+	})
 
 	var equipment []models.Equipment
 	if err := query.Find(&equipment).Error; err != nil {
@@ -48,6 +86,41 @@ func (h *EquipmentHandler) GetEquipment(c *gin.Context) {
 	c.JSON(http.StatusOK, equipment)
 }
 
+// variantFilterScope builds a scope selecting rentable variants that match
+// the size/handedness/flex/gender query parameters. The bool reports whether
+// any attribute filter was supplied.
+func variantFilterScope(c *gin.Context) (func(*gorm.DB) *gorm.DB, bool) {
+	filters := []struct {
+		column string
+		value  string
+	}{
+		{"size", c.Query("size")},
+		{"handedness", c.Query("handedness")},
+		{"shaft_flex", c.Query("flex")},
+	}
+	gender := c.Query("gender")
+
+	filtered := gender != ""
+	for _, f := range filters {
+		if f.value != "" {
+			filtered = true
+		}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("is_available = ? AND quantity_available > 0", true)
+		for _, f := range filters {
+			if f.value != "" {
+				db = db.Where(f.column+" = ?", f.value)
+			}
+		}
+		if gender != "" {
+			db = db.Where("gender IN ?", []string{gender, "unisex"})
+		}
+		return db
+	}, filtered
+}
+
 // @Summary Get equipment by ID
 // @Description Get specific equipment details
 // @Tags equipment
@@ -64,7 +137,7 @@ func (h *EquipmentHandler) GetEquipmentByID(c *gin.Context) {
 	}
 
 	var equipment models.Equipment
-	if err := database.DB.First(&equipment, id).Error; err != nil {
+	if err := database.DB.Preload("Variants").First(&equipment, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Equipment not found"})
 		return
 	}
@@ -79,7 +152,7 @@ func (h *EquipmentHandler) GetEquipmentByID(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param request body EquipmentRentalRequest true "Equipment rental request"
-// @Success 201 {object} models.EquipmentRental
+// @Success 201 {object} EquipmentRentalResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /equipment/rentals [post]
@@ -116,18 +189,23 @@ func (h *EquipmentHandler) RentEquipment(c *gin.Context) {
 
 	// Check equipment availability
 	var equipment models.Equipment
-	if err := database.DB.First(&equipment, req.EquipmentID).Error; err != nil {
+	if err := database.DB.Preload("Variants").First(&equipment, req.EquipmentID).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Equipment not found"})
 		return
 	}
 
+	if err := validateVariantSelection(&equipment, req.VariantID); err != nil {
+		respondError(c, err, "Failed to check equipment availability")
+		return
+	}
+
 	if !equipment.IsAvailable || equipment.QuantityAvailable < req.Quantity {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Equipment not available in requested quantity"})
 		return
 	}
 
 	// Calculate rental duration and price
-	duration := int(returnDate.Sub(rentalDate).Hours()/24) + 1 // Include both rental and return dates
+	duration := rentalDays(rentalDate, returnDate)
 	rentalPrice := equipment.RentalPricePerDay * float64(req.Quantity) * float64(duration)
 	depositAmount := rentalPrice * 0.2 // 20% deposit
 
@@ -135,6 +213,7 @@ func (h *EquipmentHandler) RentEquipment(c *gin.Context) {
 	rental := models.EquipmentRental{
 		UserID:        userID.(uint),
 		EquipmentID:   req.EquipmentID,
+		VariantID:     req.VariantID,
 		RentalDate:    rentalDate,
 		ReturnDate:    &returnDate,
 		Quantity:      req.Quantity,
@@ -145,19 +224,70 @@ func (h *EquipmentHandler) RentEquipment(c *gin.Context) {
 		Notes:         req.Notes,
 	}
 
-	if err := database.DB.Create(&rental).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create rental"})
+	// Reserve stock and create the rental together so concurrent requests can't oversell.
+	// The deposit stays on the full price since it covers the equipment.
+	var pricing *promotions.Breakdown
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reserveEquipmentStock(tx, rental.EquipmentID, rental.VariantID, rental.Quantity); err != nil {
+			return err
+		}
+		var promotion *models.Promotion
+		if req.PromoCode != "" {
+			var err error
+			promotion, pricing, err = priceWithPromotion(tx, req.PromoCode, promoBooking{
+				UserID: rental.UserID,
+				At:     rentalDate,
+				Items: []promotions.Item{{
+					Product:     promotions.Rental,
+					Description: fmt.Sprintf("%s x %d for %d days", equipment.Name, req.Quantity, duration),
+					Amount:      rentalPrice,
+				}},
+			})
+			if err != nil {
+				return err
+			}
+			rental.RentalPrice = pricing.Total
+			rental.DiscountAmount = pricing.Discount
+		}
+		if err := tx.Create(&rental).Error; err != nil {
+			return err
+		}
+		if promotion == nil {
+			return nil
+		}
+		return recordRedemption(tx, promotion, rental.UserID, "equipment_rental", rental.ID, pricing)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create rental")
 		return
 	}
 
-	// Update equipment quantity
-	equipment.QuantityAvailable -= req.Quantity
-	database.DB.Save(&equipment)
-
 	// Preload relationships for response
-	database.DB.Preload("User").Preload("Equipment").First(&rental, rental.ID)
+	database.DB.Preload("User").Preload("Equipment").Preload("Variant").First(&rental, rental.ID)
 
-	c.JSON(http.StatusCreated, rental)
+	c.JSON(http.StatusCreated, EquipmentRentalResponse{EquipmentRental: rental, Pricing: pricing})
+}
+
+// validateVariantSelection makes sure a variant is chosen for equipment that
+// is stocked by variant, and that the chosen one belongs to the equipment and
+// is rentable.
+func validateVariantSelection(equipment *models.Equipment, variantID *uint) error {
+	if variantID == nil {
+		if len(equipment.Variants) > 0 {
+			return newHTTPError(http.StatusBadRequest, "Please select a variant (size, handedness, flex) for "+equipment.Name)
+		}
+		return nil
+	}
+
+	for _, variant := range equipment.Variants {
+		if variant.ID == *variantID {
+			if !variant.IsAvailable {
+				return newHTTPError(http.StatusBadRequest, "Selected variant is not available")
+			}
+			return nil
+		}
+	}
+	return newHTTPError(http.StatusBadRequest, "Variant does not belong to this equipment")
 }
 
 // @Summary Get user's equipment rentals
@@ -176,7 +306,7 @@ func (h *EquipmentHandler) GetUserRentals(c *gin.Context) {
 	}
 
 	var rentals []models.EquipmentRental
-	if err := database.DB.Preload("Equipment").Where("user_id = ?", userID).
+	if err := database.DB.Preload("Equipment").Preload("Variant").Where("user_id = ?", userID).
 		Order("rental_date DESC").Find(&rentals).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rentals"})
 		return
@@ -186,7 +316,7 @@ func (h *EquipmentHandler) GetUserRentals(c *gin.Context) {
 }
 
 // @Summary Return equipment
-// @Description Mark equipment as returned
+// @Description Hand picked-up equipment back; staff then inspect it and settle the deposit
 // @Tags equipment
 // @Produce json
 // @Security BearerAuth
@@ -210,32 +340,223 @@ func (h *EquipmentHandler) ReturnEquipment(c *gin.Context) {
 	}
 
 	var rental models.EquipmentRental
-	if err := database.DB.Preload("Equipment").Where("id = ? AND user_id = ?", id, userID).First(&rental).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Rental not found"})
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", id, userID).First(&rental).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Rental not found")
+		}
+		switch {
+		case rental.RentalStatus == "cancelled":
+			return newHTTPError(http.StatusBadRequest, "Rental has been cancelled")
+		case rental.ReturnedAt != nil || rental.InspectedAt != nil:
+			return newHTTPError(http.StatusBadRequest, "Equipment already returned")
+		case rental.PickedUpAt == nil:
+			// Nothing to hand back; cancelling releases the card authorization
+			return newHTTPError(http.StatusBadRequest, "Equipment has not been picked up; cancel the rental instead")
+		}
+		return markRentalReturned(tx, &rental, time.Now())
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update rental")
 		return
 	}
 
-	if rental.RentalStatus == "returned" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Equipment already returned"})
+	database.DB.Preload("Equipment").Preload("Variant").First(&rental, rental.ID)
+
+	c.JSON(http.StatusOK, rental)
+}
+
+// @Summary Cancel equipment rental
+// @Description Cancel a rental that has not been picked up. The units go back into stock and the card authorized at checkout is released; a rental that has been paid for is refunded through a refund request instead.
+// @Tags equipment
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Rental ID"
+// @Success 200 {object} models.EquipmentRental
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /equipment/rentals/{id}/cancel [put]
+func (h *EquipmentHandler) CancelRental(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	// Update rental status
-	now := time.Now()
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rental ID"})
+		return
+	}
+
+	var rental models.EquipmentRental
+	var voided []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", id, userID).First(&rental).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Rental not found")
+		}
+		if rental.PickedUpAt != nil || rental.ReturnedAt != nil ||
+			(rental.RentalStatus != "rented" && rental.RentalStatus != "overdue") {
+			return newHTTPError(http.StatusBadRequest, "Only rentals awaiting pickup can be cancelled")
+		}
+		paid, err := chargesPaid(tx, "equipment_rental", rental.ID)
+		if err != nil {
+			return err
+		}
+		if paid > 0 {
+			return newHTTPError(http.StatusBadRequest, "Rental has been paid for; request a refund instead")
+		}
+		voided, err = cancelRentalBeforePickup(tx, &rental)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to cancel rental")
+		return
+	}
+	h.payments.cancelIntents(c.Request.Context(), voided...)
+
+	database.DB.Preload("Equipment").Preload("Variant").First(&rental, rental.ID)
+
+	c.JSON(http.StatusOK, rental)
+}
+
+// rentalDays counts both the rental and the return date.
+func rentalDays(rentalDate, returnDate time.Time) int {
+	return int(returnDate.Sub(rentalDate).Hours()/24) + 1
+}
+
+// markRentalReturned records a customer-initiated return and restocks the
+// units; damage is assessed later by staff during inspection.
+func markRentalReturned(tx *gorm.DB, rental *models.EquipmentRental, now time.Time) error {
 	rental.ReturnDate = &now
+	rental.ReturnedAt = &now
 	rental.RentalStatus = "returned"
 
-	if err := database.DB.Save(&rental).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rental"})
-		return
+	if err := tx.Save(rental).Error; err != nil {
+		return err
 	}
+	return releaseEquipmentStock(tx, rental.EquipmentID, rental.VariantID, rental.Quantity)
+}
 
-	// Update equipment quantity
-	var equipment models.Equipment
-	if err := database.DB.First(&equipment, rental.EquipmentID).Error; err == nil {
-		equipment.QuantityAvailable += rental.Quantity
-		database.DB.Save(&equipment)
+// cancelRentalBeforePickup drops the card authorized at checkout, along with
+// any payment the customer had started, and calls the rental off. It returns
+// the intents to cancel with the provider once the transaction commits.
+func cancelRentalBeforePickup(tx *gorm.DB, rental *models.EquipmentRental) ([]string, error) {
+	var open []models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("reference_type = ? AND reference_id = ? AND payment_status IN ?",
+			"equipment_rental", rental.ID, []string{"pending", "processing", "failed"}).
+		Find(&open).Error; err != nil {
+		return nil, err
+	}
+	var voided []string
+	for i := range open {
+		if err := finalizePayment(tx, &open[i], "cancelled", "Rental cancelled"); err != nil {
+			return nil, err
+		}
+		voided = append(voided, open[i].StripePaymentIntentID)
 	}
+	return voided, markRentalCancelled(tx, rental)
+}
 
-	c.JSON(http.StatusOK, rental)
+// markRentalCancelled calls off a rental that was never picked up, puts its
+// units back into stock and gives back any promo code used on it. There is
+// nothing to inspect.
+func markRentalCancelled(tx *gorm.DB, rental *models.EquipmentRental) error {
+	rental.RentalStatus = "cancelled"
+	if err := tx.Model(rental).Update("rental_status", "cancelled").Error; err != nil {
+		return err
+	}
+	if err := voidRedemption(tx, "equipment_rental", rental.ID); err != nil {
+		return err
+	}
+	return releaseEquipmentStock(tx, rental.EquipmentID, rental.VariantID, rental.Quantity)
+}
+
+var errInsufficientStock = newHTTPError(http.StatusBadRequest, "Equipment not available in requested quantity")
+
+// reserveEquipmentStock takes units out of the rentable pool. The conditional
+// update makes the check and the decrement a single statement, so two
+// requests racing for the last units can't both succeed.
+func reserveEquipmentStock(tx *gorm.DB, equipmentID uint, variantID *uint, quantity int) error {
+	res := tx.Model(&models.Equipment{}).
+		Where("id = ? AND is_available = ? AND quantity_available >= ?", equipmentID, true, quantity).
+		Update("quantity_available", gorm.Expr("quantity_available - ?", quantity))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errInsufficientStock
+	}
+
+	if variantID != nil {
+		res = tx.Model(&models.EquipmentVariant{}).
+			Where("id = ? AND equipment_id = ? AND is_available = ? AND quantity_available >= ?", *variantID, equipmentID, true, quantity).
+			Update("quantity_available", gorm.Expr("quantity_available - ?", quantity))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errInsufficientStock
+		}
+	}
+	return nil
+}
+
+// releaseEquipmentStock puts units back into the rentable pool.
+func releaseEquipmentStock(tx *gorm.DB, equipmentID uint, variantID *uint, quantity int) error {
+	if quantity <= 0 {
+		return nil
+	}
+	if err := tx.Model(&models.Equipment{}).Where("id = ?", equipmentID).
+		Update("quantity_available", gorm.Expr("quantity_available + ?", quantity)).Error; err != nil {
+		return err
+	}
+	if variantID != nil {
+		return tx.Model(&models.EquipmentVariant{}).Where("id = ?", *variantID).
+			Update("quantity_available", gorm.Expr("quantity_available + ?", quantity)).Error
+	}
+	return nil
+}
+
+// withdrawEquipmentStock takes units out of the rentable pool without going
+// below zero, e.g. when returned units turn out to be damaged.
+func withdrawEquipmentStock(tx *gorm.DB, equipmentID uint, variantID *uint, quantity int) error {
+	if quantity <= 0 {
+		return nil
+	}
+	if err := tx.Model(&models.Equipment{}).Where("id = ?", equipmentID).
+		Update("quantity_available", gorm.Expr("GREATEST(quantity_available - ?, 0)", quantity)).Error; err != nil {
+		return err
+	}
+	if variantID != nil {
+		return tx.Model(&models.EquipmentVariant{}).Where("id = ?", *variantID).
+			Update("quantity_available", gorm.Expr("GREATEST(quantity_available - ?, 0)", quantity)).Error
+	}
+	return nil
+}
+
+// syncEquipmentQuantity recomputes the headline quantity of variant-stocked
+// equipment from its rentable variants. Equipment without variants is left
+// untouched.
+func syncEquipmentQuantity(tx *gorm.DB, equipmentID uint) error {
+	var variantCount int64
+	if err := tx.Model(&models.EquipmentVariant{}).Where("equipment_id = ?", equipmentID).Count(&variantCount).Error; err != nil {
+		return err
+	}
+	if variantCount == 0 {
+		return nil
+	}
+
+	var total int
+	if err := tx.Model(&models.EquipmentVariant{}).
+		Select("COALESCE(SUM(quantity_available), 0)").
+		Where("equipment_id = ? AND is_available = ?", equipmentID, true).
+		Scan(&total).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Equipment{}).Where("id = ?", equipmentID).
+		Update("quantity_available", total).Error
 }
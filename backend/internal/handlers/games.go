@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/handicap"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/scoring"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScoringResponse struct {
+	ScorecardID    uint           `json:"scorecard_id"`
+	Format         string         `json:"format"`
+	Allowance      float64        `json:"allowance"`
+	HandicapIndex  *float64       `json:"handicap_index"`
+	CourseHandicap *int           `json:"course_handicap"`
+	Result         scoring.Result `json:"result"`
+}
+
+type GameRequest struct {
+	TeeTimeID    *uint    `json:"tee_time_id"`
+	ScorecardIDs []uint   `json:"scorecard_ids"`
+	Format       string   `json:"format" binding:"required,oneof=stableford par_bogey skins"`
+	Net          *bool    `json:"net"`
+	Carryover    *bool    `json:"carryover"`
+	Allowance    *float64 `json:"allowance" binding:"omitempty,gt=0,lte=100"`
+}
+
+type GamePlayer struct {
+	ScorecardID     uint     `json:"scorecard_id"`
+	UserID          uint     `json:"user_id"`
+	Name            string   `json:"name"`
+	HandicapIndex   *float64 `json:"handicap_index"`
+	PlayingHandicap int      `json:"playing_handicap"`
+	Skins           int      `json:"skins,omitempty"`
+}
+
+type GameResponse struct {
+	CourseID   uint                 `json:"course_id"`
+	PlayedDate string               `json:"played_date"`
+	Format     string               `json:"format"`
+	Net        bool                 `json:"net"`
+	Allowance  float64              `json:"allowance"`
+	Players    []GamePlayer         `json:"players"`
+	Standings  []scoring.Result     `json:"standings,omitempty"`
+	Skins      *scoring.SkinsResult `json:"skins,omitempty"`
+}
+
+// scoringHoles lays out a course's holes for the scoring engine.
+func scoringHoles(courseHoles []models.Hole) []scoring.Hole {
+	holes := make([]scoring.Hole, 0, len(courseHoles))
+	for _, hole := range courseHoles {
+		holes = append(holes, scoring.Hole{
+			Number:      hole.HoleNumber,
+			Par:         hole.Par,
+			StrokeIndex: hole.HandicapIndex,
+		})
+	}
+	return holes
+}
+
+// scorecardIndex is the Handicap Index a round is played off: the index in
+// force when it was played, or the player's current one for a first round.
+func scorecardIndex(scorecard models.Scorecard) *float64 {
+	if scorecard.HandicapUsed != nil {
+		return scorecard.HandicapUsed
+	}
+	return scorecard.User.Handicap
+}
+
+// scorecardCourseHandicap works out the course handicap for a round from the
+// tees it was played off. It is nil when the player has no index or the
+// course is unrated.
+func scorecardCourseHandicap(scorecard models.Scorecard, holes int) (*float64, *int) {
+	index := scorecardIndex(scorecard)
+	if index == nil {
+		return nil, nil
+	}
+	return index, courseHandicapFor(*index, scorecard.Course, scorecard.TeeSet, holes)
+}
+
+// courseHandicapFor converts an index into a course handicap for a course
+// and tee set, or nil when they have no ratings.
+func courseHandicapFor(index float64, course models.Course, teeSet *models.TeeSet, holes int) *int {
+	rating, slope, ok := courseRatings(course, teeSet)
+	if !ok {
+		return nil
+	}
+	// Nine-hole courses play off half the index
+	if holes == 9 {
+		index = index / 2
+	}
+	ch := handicap.CourseHandicap(index, slope, rating, course.Par)
+	return &ch
+}
+
+// scoringCard converts a scorecard's hole scores into a card for the scoring
+// engine.
+func scoringCard(scorecard models.Scorecard, playingHandicap int) scoring.Card {
+	card := scoring.Card{
+		PlayerID:        scorecard.UserID,
+		Name:            scorecard.User.FirstName + " " + scorecard.User.LastName,
+		PlayingHandicap: playingHandicap,
+		Strokes:         make(map[int]int, len(scorecard.Holes)),
+	}
+	for _, entry := range scorecard.Holes {
+		card.Strokes[entry.Hole.HoleNumber] = entry.Strokes
+	}
+	return card
+}
+
+// scoreCard settles a single card in an individual format.
+func scoreCard(format string, holes []scoring.Hole, card scoring.Card, net bool) scoring.Result {
+	if format == "par_bogey" {
+		return scoring.ParBogey(holes, card, net)
+	}
+	return scoring.Stableford(holes, card, net)
+}
+
+// @Summary Score a round in another format
+// @Description Stableford points or par/bogey results for one of the authenticated user's rounds
+// @Tags scorecards
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Scorecard ID"
+// @Param format query string false "stableford or par_bogey" default(stableford)
+// @Param net query bool false "Apply handicap strokes" default(true)
+// @Param allowance query number false "Handicap allowance in percent"
+// @Success 200 {object} ScoringResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /scorecards/{id}/scoring [get]
+func (h *ScorecardHandler) GetScorecardScoring(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scorecard ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "stableford")
+	if format != "stableford" && format != "par_bogey" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be stableford or par_bogey"})
+		return
+	}
+	net := c.DefaultQuery("net", "true") != "false"
+
+	allowance, _ := handicap.Allowance(format)
+	if value := c.Query("allowance"); value != "" {
+		percent, err := strconv.ParseFloat(value, 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "allowance must be between 0 and 100"})
+			return
+		}
+		allowance = percent / 100
+	}
+
+	db := database.DB
+	scorecard, err := loadScorecard(db.Preload("User"), uint(id))
+	if err != nil || scorecard.UserID != userID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scorecard not found"})
+		return
+	}
+
+	courseHoles, err := loadCourseHoles(db, scorecard.CourseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch holes"})
+		return
+	}
+
+	index, courseHandicap := scorecardCourseHandicap(scorecard, len(courseHoles))
+	if net && courseHandicap == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A handicap index and course rating are needed for net scoring"})
+		return
+	}
+	playingHandicap := 0
+	if courseHandicap != nil {
+		playingHandicap = handicap.PlayingHandicap(*courseHandicap, allowance)
+	}
+
+	holes := scoringHoles(courseHoles)
+	c.JSON(http.StatusOK, ScoringResponse{
+		ScorecardID:    scorecard.ID,
+		Format:         format,
+		Allowance:      allowance,
+		HandicapIndex:  index,
+		CourseHandicap: courseHandicap,
+		Result:         scoreCard(format, holes, scoringCard(scorecard, playingHandicap), net),
+	})
+}
+
+// @Summary Settle a group game
+// @Description Stableford, par/bogey or skins across the rounds of a tee time or a list of scorecards from the same course and day
+// @Tags scorecards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body GameRequest true "Rounds and format"
+// @Success 200 {object} GameResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /scorecards/games [post]
+func (h *ScorecardHandler) SettleGame(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req GameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.TeeTimeID == nil && len(req.ScorecardIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tee_time_id or scorecard_ids is required"})
+		return
+	}
+
+	net := req.Net == nil || *req.Net
+	carryover := req.Carryover == nil || *req.Carryover
+	// Skins are played off full handicap, strokes given from the lowest
+	allowance := 1.0
+	if value, ok := handicap.Allowance(req.Format); ok {
+		allowance = value
+	}
+	if req.Allowance != nil {
+		allowance = *req.Allowance / 100
+	}
+
+	db := database.DB
+	query := db.Preload("User").Preload("Course").Preload("TeeSet").Preload("Holes.Hole")
+	if req.TeeTimeID != nil {
+		query = query.Where("tee_time_id = ?", *req.TeeTimeID)
+	}
+	if len(req.ScorecardIDs) > 0 {
+		query = query.Where("id IN ?", req.ScorecardIDs)
+	}
+
+	var scorecards []models.Scorecard
+	if err := query.Order("id ASC").Find(&scorecards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scorecards"})
+		return
+	}
+	if len(scorecards) == 0 || (len(req.ScorecardIDs) > 0 && len(scorecards) != len(uniqueIDs(req.ScorecardIDs))) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scorecard not found"})
+		return
+	}
+
+	// Players may settle games they took part in; staff may settle any
+	role := c.GetString("user_role")
+	allowed := role == "staff" || role == "admin"
+	first := scorecards[0]
+	seen := make(map[uint]bool, len(scorecards))
+	for _, scorecard := range scorecards {
+		if scorecard.UserID == userID.(uint) {
+			allowed = true
+		}
+		if scorecard.CourseID != first.CourseID || !scorecard.PlayedDate.Equal(first.PlayedDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "All rounds must be on the same course and day"})
+			return
+		}
+		if seen[scorecard.UserID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Each player may only enter one round"})
+			return
+		}
+		seen[scorecard.UserID] = true
+	}
+	if !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scorecard not found"})
+		return
+	}
+
+	courseHoles, err := loadCourseHoles(db, first.CourseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch holes"})
+		return
+	}
+
+	resp := GameResponse{
+		CourseID:   first.CourseID,
+		PlayedDate: first.PlayedDate.Format("2006-01-02"),
+		Format:     req.Format,
+		Net:        net,
+		Allowance:  allowance,
+		Players:    []GamePlayer{},
+	}
+
+	lowest := 0
+	for i, scorecard := range scorecards {
+		index, courseHandicap := scorecardCourseHandicap(scorecard, len(courseHoles))
+		if net && courseHandicap == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A handicap index and course rating are needed for net scoring"})
+			return
+		}
+		player := GamePlayer{
+			ScorecardID:   scorecard.ID,
+			UserID:        scorecard.UserID,
+			Name:          scorecard.User.FirstName + " " + scorecard.User.LastName,
+			HandicapIndex: index,
+		}
+		if net {
+			player.PlayingHandicap = handicap.PlayingHandicap(*courseHandicap, allowance)
+			if i == 0 || player.PlayingHandicap < lowest {
+				lowest = player.PlayingHandicap
+			}
+		}
+		resp.Players = append(resp.Players, player)
+	}
+
+	holes := scoringHoles(courseHoles)
+	cards := make([]scoring.Card, len(scorecards))
+	for i, scorecard := range scorecards {
+		strokes := resp.Players[i].PlayingHandicap
+		if req.Format == "skins" {
+			strokes -= lowest
+			resp.Players[i].PlayingHandicap = strokes
+		}
+		cards[i] = scoringCard(scorecard, strokes)
+	}
+
+	if req.Format == "skins" {
+		skins := scoring.Skins(holes, cards, net, carryover)
+		for i := range resp.Players {
+			resp.Players[i].Skins = skins.Skins[resp.Players[i].UserID]
+		}
+		resp.Skins = &skins
+	} else {
+		for _, card := range cards {
+			resp.Standings = append(resp.Standings, scoreCard(req.Format, holes, card, net))
+		}
+		sort.SliceStable(resp.Standings, func(i, j int) bool {
+			return resp.Standings[i].Total > resp.Standings[j].Total
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
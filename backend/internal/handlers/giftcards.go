@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/giftcards"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/payments"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GiftCardPurchaseRequest struct {
+	Amount          float64 `json:"amount" binding:"required,gt=0"`
+	RecipientName   string  `json:"recipient_name"`
+	RecipientEmail  string  `json:"recipient_email" binding:"omitempty,email"`
+	Message         string  `json:"message"`
+	PaymentMethodID string  `json:"payment_method_id"`
+}
+
+type GiftCardPurchaseResponse struct {
+	GiftCard       models.GiftCard `json:"gift_card"`
+	Payment        models.Payment  `json:"payment"`
+	Provider       string          `json:"provider"`
+	IntentStatus   string          `json:"intent_status"`
+	ClientSecret   string          `json:"client_secret,omitempty"`
+	PublishableKey string          `json:"publishable_key,omitempty"`
+}
+
+// GiftCardSaleRequest is a gift card sold at the pro shop. Code is the
+// number printed on a physical certificate; one is generated when empty.
+type GiftCardSaleRequest struct {
+	Amount         float64 `json:"amount" binding:"required,gt=0"`
+	PaymentMethod  string  `json:"payment_method" binding:"required,oneof=cash credit_card debit_card bank_transfer"`
+	Code           string  `json:"code" binding:"max=32"`
+	UserID         *uint   `json:"user_id"`
+	RecipientName  string  `json:"recipient_name"`
+	RecipientEmail string  `json:"recipient_email" binding:"omitempty,email"`
+	Message        string  `json:"message"`
+	ExpiresAt      string  `json:"expires_at"`
+}
+
+type GiftCardBalanceRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type GiftCardBalance struct {
+	Code         string                       `json:"code"`
+	Balance      float64                      `json:"balance"`
+	Currency     string                       `json:"currency"`
+	CardStatus   string                       `json:"card_status"`
+	ExpiresAt    *time.Time                   `json:"expires_at"`
+	Transactions []models.GiftCardTransaction `json:"transactions"`
+}
+
+type GiftCardStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active disabled"`
+}
+
+// checkGiftCardAmount keeps gift card values within the configured range.
+func checkGiftCardAmount(amount float64) error {
+	lowest := getSettingFloat("gift_card_min_amount", 10)
+	highest := getSettingFloat("gift_card_max_amount", 500)
+	if amount < lowest || amount > highest {
+		return newHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("Gift cards must be between %s and %s", formatMoney(lowest), formatMoney(highest)))
+	}
+	return nil
+}
+
+// createGiftCard saves a new card, generating a code unless it already has
+// one.
+func createGiftCard(tx *gorm.DB, card *models.GiftCard) error {
+	var taken int64
+	if card.Code != "" {
+		card.Code = giftcards.Normalize(card.Code)
+		if err := tx.Model(&models.GiftCard{}).Where("code = ?", card.Code).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return newHTTPError(http.StatusConflict, "A gift card with this code already exists")
+		}
+		return tx.Create(card).Error
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := giftcards.NewCode()
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.GiftCard{}).Where("code = ?", code).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken == 0 {
+			card.Code = code
+			return tx.Create(card).Error
+		}
+	}
+	return errors.New("could not generate a unique gift card code")
+}
+
+// recordGiftCardTransaction moves a card's balance by amount and logs it.
+// The card must be locked by the caller.
+func recordGiftCardTransaction(tx *gorm.DB, card *models.GiftCard, entry models.GiftCardTransaction) error {
+	card.Balance = roundCurrency(card.Balance + entry.Amount)
+	if err := tx.Model(card).Update("balance", card.Balance).Error; err != nil {
+		return err
+	}
+	entry.GiftCardID = card.ID
+	entry.BalanceAfter = card.Balance
+	return tx.Create(&entry).Error
+}
+
+// activateGiftCard loads a card with its value once the payment for it has
+// gone through.
+func activateGiftCard(tx *gorm.DB, payment *models.Payment) error {
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, payment.ReferenceID).Error; err != nil {
+		return err
+	}
+	if card.CardStatus != giftcards.StatusPending {
+		return nil
+	}
+
+	now := time.Now()
+	if err := tx.Model(&card).Updates(map[string]interface{}{
+		"card_status":  giftcards.StatusActive,
+		"activated_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	card.CardStatus = giftcards.StatusActive
+	card.ActivatedAt = &now
+	return recordGiftCardTransaction(tx, &card, models.GiftCardTransaction{
+		TransactionType: "activation",
+		Amount:          card.InitialAmount,
+		PaymentID:       &payment.ID,
+	})
+}
+
+// giftCardMessages tell the customer why their gift card cannot be used.
+var giftCardMessages = map[error]string{
+	giftcards.ErrNotActive: "This gift card has not been activated",
+	giftcards.ErrDisabled:  "This gift card has been disabled",
+	giftcards.ErrExpired:   "This gift card has expired",
+	giftcards.ErrNoBalance: "This gift card has no balance left",
+}
+
+// giftCardError turns a gift card check failure into a 400 for the customer;
+// anything else is passed on.
+func giftCardError(err error) error {
+	if message, ok := giftCardMessages[err]; ok {
+		return newHTTPError(http.StatusBadRequest, message)
+	}
+	return err
+}
+
+// redeemGiftCard spends a gift card on a booking, up to what is owed or the
+// amount asked for, and records it as a completed payment.
+func redeemGiftCard(tx *gorm.DB, code string, userID uint, referenceType string, referenceID uint, owed, requested float64) (*models.Payment, error) {
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", giftcards.Normalize(code)).First(&card).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newHTTPError(http.StatusBadRequest, "Gift card not found")
+		}
+		return nil, err
+	}
+	if err := giftcards.Check(card.CardStatus, card.Balance, card.ExpiresAt, time.Now()); err != nil {
+		return nil, giftCardError(err)
+	}
+	spend := giftcards.Spend(card.Balance, owed, requested)
+	if spend <= 0 {
+		return nil, newHTTPError(http.StatusBadRequest, "Nothing to pay with the gift card")
+	}
+
+	payment := models.Payment{
+		UserID:        userID,
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+		Amount:        spend,
+		Currency:      card.Currency,
+		PaymentType:   "charge",
+		PaymentMethod: "gift_card",
+		GiftCardID:    &card.ID,
+		PaymentStatus: "pending",
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, err
+	}
+	if err := recordGiftCardTransaction(tx, &card, models.GiftCardTransaction{
+		TransactionType: "redemption",
+		Amount:          -spend,
+		PaymentID:       &payment.ID,
+		CreatedBy:       &userID,
+	}); err != nil {
+		return nil, err
+	}
+	if err := finalizePayment(tx, &payment, "succeeded", ""); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// refundToGiftCard puts a refund of a gift card payment back on the card.
+func refundToGiftCard(tx *gorm.DB, payment *models.Payment, refund *models.Refund) error {
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, *payment.GiftCardID).Error; err != nil {
+		return err
+	}
+	return recordGiftCardTransaction(tx, &card, models.GiftCardTransaction{
+		TransactionType: "refund",
+		Amount:          refund.Amount,
+		PaymentID:       &payment.ID,
+		RefundID:        &refund.ID,
+		CreatedBy:       refund.ReviewedBy,
+		Notes:           refund.Notes,
+	})
+}
+
+// @Summary Buy a gift card
+// @Description Buy a gift card online. The card is activated with its balance once the card payment goes through; until then its code cannot be spent.
+// @Tags gift-cards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body GiftCardPurchaseRequest true "Gift card to buy"
+// @Success 201 {object} GiftCardPurchaseResponse
+// @Failure 400 {object} map[string]string
+// @Failure 402 {object} map[string]string
+// @Router /gift-cards [post]
+func (h *PaymentHandler) PurchaseGiftCard(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req GiftCardPurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	amount := roundCurrency(req.Amount)
+	if err := checkGiftCardAmount(amount); err != nil {
+		respondError(c, err, "Failed to buy gift card")
+		return
+	}
+
+	db := database.DB
+	card := models.GiftCard{
+		InitialAmount:  amount,
+		Currency:       "USD",
+		CardStatus:     giftcards.StatusPending,
+		PurchasedBy:    &userID,
+		RecipientName:  req.RecipientName,
+		RecipientEmail: req.RecipientEmail,
+		Message:        req.Message,
+	}
+	var payment models.Payment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := createGiftCard(tx, &card); err != nil {
+			return err
+		}
+		payment = models.Payment{
+			UserID:        userID,
+			ReferenceType: "gift_card",
+			ReferenceID:   card.ID,
+			Amount:        amount,
+			Currency:      card.Currency,
+			PaymentType:   "charge",
+			PaymentMethod: "credit_card",
+			PaymentStatus: "pending",
+		}
+		return tx.Create(&payment).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to buy gift card")
+		return
+	}
+
+	intent, err := h.provider.CreateIntent(c.Request.Context(), payments.IntentParams{
+		Amount:      payments.ToMinorUnits(payment.Amount),
+		Currency:    payment.Currency,
+		Description: "Gift card " + giftcards.Mask(card.Code),
+		Metadata: map[string]string{
+			"payment_id":     strconv.FormatUint(uint64(payment.ID), 10),
+			"reference_type": payment.ReferenceType,
+			"reference_id":   strconv.FormatUint(uint64(payment.ReferenceID), 10),
+		},
+		PaymentMethod:  req.PaymentMethodID,
+		IdempotencyKey: "payment-" + strconv.FormatUint(uint64(payment.ID), 10),
+	})
+	if err != nil {
+		respondProviderError(c, err, "Payment provider is unavailable")
+		return
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return applyIntent(tx, &payment, intent)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+
+	db.First(&card, card.ID)
+	c.JSON(http.StatusCreated, GiftCardPurchaseResponse{
+		GiftCard:       card,
+		Payment:        payment,
+		Provider:       h.provider.Name(),
+		IntentStatus:   intent.Status,
+		ClientSecret:   intent.ClientSecret,
+		PublishableKey: h.publishableKey,
+	})
+}
+
+// @Summary List gift cards bought
+// @Description Gift cards the authenticated user has bought, with their codes and balances
+// @Tags gift-cards
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.GiftCard
+// @Router /gift-cards [get]
+func (h *PaymentHandler) GetGiftCards(c *gin.Context) {
+	var cards []models.GiftCard
+	if err := database.DB.Where("purchased_by = ?", c.GetUint("user_id")).
+		Order("created_at DESC").Find(&cards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch gift cards"})
+		return
+	}
+	c.JSON(http.StatusOK, cards)
+}
+
+// @Summary Check a gift card balance
+// @Description Balance, status and transaction history of a gift card by its code
+// @Tags gift-cards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body GiftCardBalanceRequest true "Gift card code"
+// @Success 200 {object} GiftCardBalance
+// @Failure 404 {object} map[string]string
+// @Router /gift-cards/balance [post]
+func (h *PaymentHandler) GetGiftCardBalance(c *gin.Context) {
+	var req GiftCardBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var card models.GiftCard
+	if err := database.DB.Preload("Transactions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC, id ASC")
+	}).Where("code = ?", giftcards.Normalize(req.Code)).First(&card).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Gift card not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, GiftCardBalance{
+		Code:         giftcards.Mask(card.Code),
+		Balance:      card.Balance,
+		Currency:     card.Currency,
+		CardStatus:   card.CardStatus,
+		ExpiresAt:    card.ExpiresAt,
+		Transactions: card.Transactions,
+	})
+}
+
+// Sell a gift card at the pro shop; it is active straight away
+func (h *PaymentHandler) SellGiftCard(c *gin.Context) {
+	var req GiftCardSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	amount := roundCurrency(req.Amount)
+	if err := checkGiftCardAmount(amount); err != nil {
+		respondError(c, err, "Failed to sell gift card")
+		return
+	}
+
+	staffID := c.GetUint("user_id")
+	card := models.GiftCard{
+		Code:           req.Code,
+		InitialAmount:  amount,
+		Currency:       "USD",
+		CardStatus:     giftcards.StatusPending,
+		PurchasedBy:    req.UserID,
+		IssuedBy:       &staffID,
+		RecipientName:  req.RecipientName,
+		RecipientEmail: req.RecipientEmail,
+		Message:        req.Message,
+	}
+	if req.ExpiresAt != "" {
+		expires, err := time.ParseInLocation("2006-01-02", req.ExpiresAt, time.Local)
+		if err != nil || !expires.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be a future date (YYYY-MM-DD)"})
+			return
+		}
+		expires = expires.Add(24*time.Hour - time.Second)
+		card.ExpiresAt = &expires
+	}
+
+	// The sale is booked to the buyer when known, otherwise to the staff member
+	payer := staffID
+	if req.UserID != nil {
+		var buyer models.User
+		if err := database.DB.First(&buyer, *req.UserID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Customer not found"})
+			return
+		}
+		payer = buyer.ID
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createGiftCard(tx, &card); err != nil {
+			return err
+		}
+		payment := models.Payment{
+			UserID:        payer,
+			ReferenceType: "gift_card",
+			ReferenceID:   card.ID,
+			Amount:        amount,
+			Currency:      card.Currency,
+			PaymentType:   "charge",
+			PaymentMethod: req.PaymentMethod,
+			PaymentStatus: "pending",
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		return finalizePayment(tx, &payment, "succeeded", "")
+	})
+	if err != nil {
+		respondError(c, err, "Failed to sell gift card")
+		return
+	}
+
+	database.DB.Preload("Transactions").First(&card, card.ID)
+	c.JSON(http.StatusCreated, card)
+}
+
+// List gift cards, optionally by code or status
+func (h *PaymentHandler) GetStaffGiftCards(c *gin.Context) {
+	query := database.DB.Order("created_at DESC")
+	if code := c.Query("code"); code != "" {
+		query = query.Where("code = ?", giftcards.Normalize(code))
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("card_status = ?", status)
+	}
+
+	var cards []models.GiftCard
+	if err := query.Limit(200).Find(&cards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch gift cards"})
+		return
+	}
+	c.JSON(http.StatusOK, cards)
+}
+
+// Get a gift card with its full transaction log
+func (h *PaymentHandler) GetGiftCard(c *gin.Context) {
+	var card models.GiftCard
+	if err := database.DB.Preload("Transactions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC, id ASC")
+	}).First(&card, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Gift card not found"})
+		return
+	}
+	c.JSON(http.StatusOK, card)
+}
+
+// Disable a lost or stolen gift card, or enable it again
+func (h *PaymentHandler) UpdateGiftCardStatus(c *gin.Context) {
+	var req GiftCardStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var card models.GiftCard
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, c.Param("id")).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Gift card not found")
+		}
+		if card.ActivatedAt == nil {
+			return newHTTPError(http.StatusBadRequest, "Gift card has not been paid for yet")
+		}
+		card.CardStatus = req.Status
+		return tx.Model(&card).Update("card_status", req.Status).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update gift card")
+		return
+	}
+	c.JSON(http.StatusOK, card)
+}
@@ -0,0 +1,459 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/handicap"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HandicapHandler struct{}
+
+func NewHandicapHandler() *HandicapHandler {
+	return &HandicapHandler{}
+}
+
+type HandicapDifferential struct {
+	ScorecardID       uint    `json:"scorecard_id"`
+	PlayedDate        string  `json:"played_date"`
+	ScoreDifferential float64 `json:"score_differential"`
+	CountsTowardIndex bool    `json:"counts_toward_index"`
+}
+
+type HandicapSummary struct {
+	HandicapIndex     *float64               `json:"handicap_index"`
+	LowHandicapIndex  *float64               `json:"low_handicap_index"`
+	RoundsPosted      int                    `json:"rounds_posted"`
+	DifferentialsUsed int                    `json:"differentials_used"`
+	RecentScores      []HandicapDifferential `json:"recent_scores"`
+}
+
+// handicapRound converts a scorecard into a round for the handicap engine,
+// laying the scores out over every hole of the course.
+func handicapRound(scorecard models.Scorecard, courseHoles []models.Hole) handicap.Round {
+	round := handicap.Round{
+		ID:       scorecard.ID,
+		PlayedAt: scorecard.PlayedDate,
+		Par:      scorecard.Course.Par,
+	}
+	// Rounds without ratings are left at zero and skipped by the engine
+	round.CourseRating, round.SlopeRating, _ = courseRatings(scorecard.Course, scorecard.TeeSet)
+
+	strokes := make(map[uint]int, len(scorecard.Holes))
+	for _, entry := range scorecard.Holes {
+		strokes[entry.HoleID] = entry.Strokes
+	}
+	for _, hole := range courseHoles {
+		round.Holes = append(round.Holes, handicap.HoleScore{
+			Par:         hole.Par,
+			StrokeIndex: hole.HandicapIndex,
+			Strokes:     strokes[hole.ID],
+		})
+	}
+	return round
+}
+
+// recalculateHandicap replays all of a player's rounds through the handicap
+// engine, storing each round's differential, a revision per round and the
+// resulting index on the user. Replaying from scratch keeps the history right
+// when an older round is edited or deleted.
+func recalculateHandicap(tx *gorm.DB, userID uint) error {
+	var scorecards []models.Scorecard
+	if err := tx.Preload("Course").Preload("TeeSet").Preload("Holes").
+		Where("user_id = ? AND total_score IS NOT NULL", userID).
+		Order("played_date ASC, id ASC").Find(&scorecards).Error; err != nil {
+		return err
+	}
+
+	holesByCourse := map[uint][]models.Hole{}
+	rounds := make([]handicap.Round, 0, len(scorecards))
+	for _, scorecard := range scorecards {
+		holes, ok := holesByCourse[scorecard.CourseID]
+		if !ok {
+			if err := tx.Where("course_id = ?", scorecard.CourseID).Order("hole_number ASC").Find(&holes).Error; err != nil {
+				return err
+			}
+			holesByCourse[scorecard.CourseID] = holes
+		}
+		rounds = append(rounds, handicapRound(scorecard, holes))
+	}
+
+	revisions := handicap.Calculate(rounds)
+	byScorecard := make(map[uint]handicap.Revision, len(revisions))
+	for _, revision := range revisions {
+		byScorecard[revision.RoundID] = revision
+	}
+
+	for _, scorecard := range scorecards {
+		updates := map[string]interface{}{
+			"adjusted_gross_score": nil,
+			"score_differential":   nil,
+			"handicap_used":        nil,
+		}
+		if revision, ok := byScorecard[scorecard.ID]; ok {
+			updates["adjusted_gross_score"] = revision.AdjustedGrossScore
+			updates["score_differential"] = revision.ScoreDifferential
+			updates["handicap_used"] = revision.PreviousIndex
+		}
+		if err := tx.Model(&models.Scorecard{}).Where("id = ?", scorecard.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.HandicapRevision{}).Error; err != nil {
+		return err
+	}
+
+	var index *float64
+	for _, revision := range revisions {
+		record := models.HandicapRevision{
+			UserID:                userID,
+			ScorecardID:           revision.RoundID,
+			PlayedDate:            revision.PlayedAt,
+			CourseHandicap:        revision.CourseHandicap,
+			AdjustedGrossScore:    revision.AdjustedGrossScore,
+			ScoreDifferential:     revision.ScoreDifferential,
+			HandicapIndex:         revision.HandicapIndex,
+			LowHandicapIndex:      revision.LowHandicapIndex,
+			DifferentialsUsed:     revision.DifferentialsUsed,
+			ExceptionalAdjustment: revision.ExceptionalAdjustment,
+			CapApplied:            revision.CapApplied,
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		if revision.HandicapIndex != nil {
+			index = revision.HandicapIndex
+		}
+	}
+
+	return tx.Model(&models.User{}).Where("id = ?", userID).Update("handicap", index).Error
+}
+
+// @Summary Get handicap index
+// @Description Get the authenticated user's Handicap Index and the recent scores it is calculated from
+// @Tags handicap
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} HandicapSummary
+// @Failure 401 {object} map[string]string
+// @Router /handicap [get]
+func (h *HandicapHandler) GetHandicap(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	db := database.DB
+	var revisions []models.HandicapRevision
+	if err := db.Where("user_id = ?", userID).Order("played_date DESC, id DESC").Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch handicap"})
+		return
+	}
+
+	summary := HandicapSummary{
+		RoundsPosted: len(revisions),
+		RecentScores: []HandicapDifferential{},
+	}
+	if len(revisions) == 0 {
+		c.JSON(http.StatusOK, summary)
+		return
+	}
+
+	latest := revisions[0]
+	summary.HandicapIndex = latest.HandicapIndex
+	summary.LowHandicapIndex = latest.LowHandicapIndex
+	summary.DifferentialsUsed = latest.DifferentialsUsed
+
+	recent := revisions
+	if len(recent) > handicap.MaxRounds {
+		recent = recent[:handicap.MaxRounds]
+	}
+
+	// Flag the lowest differentials that make up the current index
+	order := make([]int, len(recent))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return recent[order[a]].ScoreDifferential < recent[order[b]].ScoreDifferential
+	})
+	counting := make(map[int]bool, latest.DifferentialsUsed)
+	for _, i := range order[:min(latest.DifferentialsUsed, len(order))] {
+		counting[i] = true
+	}
+
+	for i, revision := range recent {
+		summary.RecentScores = append(summary.RecentScores, HandicapDifferential{
+			ScorecardID:       revision.ScorecardID,
+			PlayedDate:        revision.PlayedDate.Format("2006-01-02"),
+			ScoreDifferential: revision.ScoreDifferential,
+			CountsTowardIndex: counting[i],
+		})
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// @Summary Get handicap revision history
+// @Description List every revision of the authenticated user's Handicap Index, newest first
+// @Tags handicap
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.HandicapRevision
+// @Failure 401 {object} map[string]string
+// @Router /handicap/history [get]
+func (h *HandicapHandler) GetHandicapHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var revisions []models.HandicapRevision
+	if err := database.DB.Preload("Scorecard.Course").Where("user_id = ?", userID).
+		Order("played_date DESC, id DESC").Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch handicap history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+type PlayingHandicapRequest struct {
+	TeeTimeID *uint    `json:"tee_time_id"`
+	CourseID  uint     `json:"course_id"`
+	TeeSetID  *uint    `json:"tee_set_id"`
+	UserIDs   []uint   `json:"user_ids"`
+	Format    string   `json:"format" binding:"omitempty,oneof=stroke_play stableford par_bogey match_play four_ball_stroke four_ball_match foursomes greensomes scramble_two_person scramble_four_person"`
+	Allowance *float64 `json:"allowance" binding:"omitempty,gt=0,lte=100"`
+}
+
+type HoleStrokes struct {
+	HoleNumber    int `json:"hole_number"`
+	Par           int `json:"par"`
+	HandicapIndex int `json:"handicap_index"`
+	Strokes       int `json:"strokes"`
+}
+
+type PlayerHandicap struct {
+	UserID          uint          `json:"user_id"`
+	Name            string        `json:"name"`
+	HandicapIndex   *float64      `json:"handicap_index"`
+	CourseHandicap  *int          `json:"course_handicap"`
+	PlayingHandicap *int          `json:"playing_handicap"`
+	MatchStrokes    *int          `json:"match_strokes,omitempty"`
+	HoleStrokes     []HoleStrokes `json:"hole_strokes,omitempty"`
+}
+
+type PlayingHandicapResponse struct {
+	CourseID     uint             `json:"course_id"`
+	TeeTimeID    *uint            `json:"tee_time_id,omitempty"`
+	TeeSetID     *uint            `json:"tee_set_id,omitempty"`
+	Format       string           `json:"format"`
+	Allowance    float64          `json:"allowance"`
+	CourseRating float64          `json:"course_rating"`
+	SlopeRating  int              `json:"slope_rating"`
+	Par          int              `json:"par"`
+	Players      []PlayerHandicap `json:"players"`
+}
+
+// playingHandicaps works out course and playing handicaps for a group of
+// players on a course, with the strokes each one receives per hole. In match
+// formats strokes are given off the lowest handicap in the group.
+func playingHandicaps(course models.Course, rating float64, slope int, holes []models.Hole, users []models.User, format string, allowance float64) PlayingHandicapResponse {
+	resp := PlayingHandicapResponse{
+		CourseID:     course.ID,
+		Format:       format,
+		Allowance:    allowance,
+		CourseRating: rating,
+		SlopeRating:  slope,
+		Par:          course.Par,
+		Players:      []PlayerHandicap{},
+	}
+
+	strokeIndexes := make([]int, len(holes))
+	for i, hole := range holes {
+		strokeIndexes[i] = hole.HandicapIndex
+	}
+
+	lowest := 0
+	hasLowest := false
+	for _, user := range users {
+		player := PlayerHandicap{
+			UserID:        user.ID,
+			Name:          user.FirstName + " " + user.LastName,
+			HandicapIndex: user.Handicap,
+		}
+		if user.Handicap != nil {
+			index := *user.Handicap
+			// Nine-hole courses play off half the index
+			if len(holes) == 9 {
+				index = index / 2
+			}
+			ch := handicap.CourseHandicap(index, resp.SlopeRating, resp.CourseRating, resp.Par)
+			ph := handicap.PlayingHandicap(ch, allowance)
+			player.CourseHandicap = &ch
+			player.PlayingHandicap = &ph
+			if !hasLowest || ph < lowest {
+				lowest = ph
+				hasLowest = true
+			}
+		}
+		resp.Players = append(resp.Players, player)
+	}
+
+	for i := range resp.Players {
+		player := &resp.Players[i]
+		if player.PlayingHandicap == nil {
+			continue
+		}
+		strokes := *player.PlayingHandicap
+		if handicap.IsMatchFormat(format) {
+			strokes -= lowest
+			player.MatchStrokes = &strokes
+		}
+		allocation := handicap.AllocateStrokes(strokes, strokeIndexes)
+		for j, hole := range holes {
+			player.HoleStrokes = append(player.HoleStrokes, HoleStrokes{
+				HoleNumber:    hole.HoleNumber,
+				Par:           hole.Par,
+				HandicapIndex: hole.HandicapIndex,
+				Strokes:       allocation[j],
+			})
+		}
+	}
+
+	return resp
+}
+
+// @Summary Calculate playing handicaps
+// @Description Course and playing handicaps with per-hole strokes for a tee time's players or a list of users on a course
+// @Tags handicap
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body PlayingHandicapRequest true "Players, course and format"
+// @Success 200 {object} PlayingHandicapResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /handicap/playing [post]
+func (h *HandicapHandler) CalculatePlayingHandicaps(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req PlayingHandicapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = "stroke_play"
+	}
+
+	db := database.DB
+	userIDs := append([]uint(nil), req.UserIDs...)
+	courseID := req.CourseID
+	teeSetID := req.TeeSetID
+
+	if req.TeeTimeID != nil {
+		var teeTime models.TeeTime
+		if err := db.First(&teeTime, *req.TeeTimeID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tee time not found"})
+			return
+		}
+		role := c.GetString("user_role")
+		if teeTime.UserID != userID.(uint) && role != "staff" && role != "admin" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tee time not found"})
+			return
+		}
+		if courseID != 0 && courseID != teeTime.CourseID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Course does not match the tee time"})
+			return
+		}
+		courseID = teeTime.CourseID
+		userIDs = append([]uint{teeTime.UserID}, userIDs...)
+		if teeSetID == nil {
+			teeSetID = teeTime.TeeSetID
+		}
+	}
+
+	if courseID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "course_id or tee_time_id is required"})
+		return
+	}
+	if len(userIDs) == 0 {
+		userIDs = []uint{userID.(uint)}
+	}
+
+	var course models.Course
+	if err := db.First(&course, courseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+	teeSet, err := resolveTeeSet(db, course.ID, teeSetID)
+	if err != nil {
+		respondError(c, err, "Failed to calculate handicaps")
+		return
+	}
+	rating, slope, ok := courseRatings(course, teeSet)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Course has no rating or slope set"})
+		return
+	}
+
+	var holes []models.Hole
+	if err := db.Where("course_id = ?", course.ID).Order("hole_number ASC").Find(&holes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch holes"})
+		return
+	}
+
+	// Keep the requested order and drop duplicates
+	seen := make(map[uint]bool, len(userIDs))
+	ordered := make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			ordered = append(ordered, id)
+		}
+	}
+
+	var found []models.User
+	if err := db.Where("id IN ?", ordered).Find(&found).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch players"})
+		return
+	}
+	byID := make(map[uint]models.User, len(found))
+	for _, user := range found {
+		byID[user.ID] = user
+	}
+	users := make([]models.User, 0, len(ordered))
+	for _, id := range ordered {
+		user, ok := byID[id]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Player not found"})
+			return
+		}
+		users = append(users, user)
+	}
+
+	allowance, _ := handicap.Allowance(req.Format)
+	if req.Allowance != nil {
+		allowance = *req.Allowance / 100
+	}
+
+	resp := playingHandicaps(course, rating, slope, holes, users, req.Format, allowance)
+	resp.TeeTimeID = req.TeeTimeID
+	resp.TeeSetID = teeSetID
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// httpError lets code running inside a transaction abort with a specific
+// status code and client-facing message.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func newHTTPError(status int, message string) error {
+	return &httpError{status: status, message: message}
+}
+
+// respondError writes an httpError as-is and hides anything else behind a
+// generic 500 with the given message.
+func respondError(c *gin.Context, err error, fallback string) {
+	var he *httpError
+	if errors.As(err, &he) {
+		c.JSON(he.status, gin.H{"error": he.message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
+
+// roundCurrency rounds an amount to whole cents.
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// getSettingInt reads an integer system setting, falling back to the given
+// default when it is missing or malformed.
+func getSettingInt(key string, defaultValue int) int {
+	var setting models.SystemSetting
+	if err := database.DB.Where("setting_key = ? AND is_active = ?", key, true).First(&setting).Error; err != nil {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(setting.SettingValue))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getSettingFloat reads a decimal system setting, falling back to the given
+// default when it is missing or malformed.
+func getSettingFloat(key string, defaultValue float64) float64 {
+	var setting models.SystemSetting
+	if err := database.DB.Where("setting_key = ? AND is_active = ?", key, true).First(&setting).Error; err != nil {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(setting.SettingValue), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// parseClock converts a tee sheet time ("07:30" or "07:30:00") to minutes
+// after midnight.
+func parseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 || hours > 23 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return hours*60 + minutes, nil
+}
+
+// formatClock is the inverse of parseClock.
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
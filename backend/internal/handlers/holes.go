@@ -0,0 +1,606 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Hole and tee set management
+type HoleTeeRequest struct {
+	TeeSetID uint `json:"tee_set_id" binding:"required"`
+	Yardage  int  `json:"yardage" binding:"required,min=1"`
+}
+
+type HoleRequest struct {
+	HoleNumber    int              `json:"hole_number" binding:"required,min=1"`
+	Par           int              `json:"par" binding:"required,min=3,max=6"`
+	Yardage       int              `json:"yardage" binding:"min=0"`
+	HandicapIndex int              `json:"handicap_index" binding:"required,min=1"`
+	Description   string           `json:"description"`
+	Tees          []HoleTeeRequest `json:"tees" binding:"dive"`
+}
+
+type BulkHolesRequest struct {
+	Holes []HoleRequest `json:"holes" binding:"required,min=1,dive"`
+}
+
+type TeeSetYardageRequest struct {
+	HoleNumber int `json:"hole_number" binding:"required,min=1"`
+	Yardage    int `json:"yardage" binding:"required,min=1"`
+}
+
+type TeeSetRequest struct {
+	Name         string                 `json:"name" binding:"required"`
+	Color        string                 `json:"color"`
+	CourseRating float64                `json:"course_rating" binding:"required,gt=0"`
+	SlopeRating  int                    `json:"slope_rating" binding:"required,min=55,max=155"`
+	IsDefault    bool                   `json:"is_default"`
+	IsActive     *bool                  `json:"is_active"`
+	Yardages     []TeeSetYardageRequest `json:"yardages" binding:"dive"`
+}
+
+// validateCourseLayout checks a course's holes. Hole numbers and handicap
+// indexes must be unique and in range; once every hole is present the pars
+// must add up to the course par and the handicap indexes must run 1..n.
+func validateCourseLayout(course models.Course, holes []models.Hole) error {
+	numbers := make(map[int]bool, len(holes))
+	indexes := make(map[int]int, len(holes))
+	parTotal := 0
+
+	for _, hole := range holes {
+		if hole.HoleNumber < 1 || hole.HoleNumber > course.TotalHoles {
+			return newHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("Hole number %d is outside 1-%d", hole.HoleNumber, course.TotalHoles))
+		}
+		if numbers[hole.HoleNumber] {
+			return newHTTPError(http.StatusBadRequest, fmt.Sprintf("Hole %d is listed more than once", hole.HoleNumber))
+		}
+		numbers[hole.HoleNumber] = true
+
+		if hole.HandicapIndex < 1 || hole.HandicapIndex > course.TotalHoles {
+			return newHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("Hole %d: handicap index must be between 1 and %d", hole.HoleNumber, course.TotalHoles))
+		}
+		if other, taken := indexes[hole.HandicapIndex]; taken {
+			return newHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("Holes %d and %d share handicap index %d", other, hole.HoleNumber, hole.HandicapIndex))
+		}
+		indexes[hole.HandicapIndex] = hole.HoleNumber
+		parTotal += hole.Par
+	}
+
+	if len(holes) == course.TotalHoles && parTotal != course.Par {
+		return newHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("Hole pars add up to %d but the course par is %d", parTotal, course.Par))
+	}
+	return nil
+}
+
+// mergeHoles overlays requested holes on the existing ones by hole number.
+func mergeHoles(courseID uint, existing []models.Hole, requested []HoleRequest) []models.Hole {
+	byNumber := make(map[int]models.Hole, len(existing)+len(requested))
+	for _, hole := range existing {
+		byNumber[hole.HoleNumber] = hole
+	}
+	for _, req := range requested {
+		hole := byNumber[req.HoleNumber]
+		hole.CourseID = courseID
+		hole.HoleNumber = req.HoleNumber
+		hole.Par = req.Par
+		hole.Yardage = req.Yardage
+		hole.HandicapIndex = req.HandicapIndex
+		hole.Description = req.Description
+		byNumber[req.HoleNumber] = hole
+	}
+
+	merged := make([]models.Hole, 0, len(byNumber))
+	for _, hole := range byNumber {
+		merged = append(merged, hole)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].HoleNumber < merged[j].HoleNumber })
+	return merged
+}
+
+// saveCourseHoles validates and upserts holes for a course, along with any
+// per-tee yardages, and returns the course's holes afterwards.
+func saveCourseHoles(tx *gorm.DB, course models.Course, requested []HoleRequest) ([]models.Hole, error) {
+	var existing []models.Hole
+	if err := tx.Where("course_id = ?", course.ID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	merged := mergeHoles(course.ID, existing, requested)
+	if err := validateCourseLayout(course, merged); err != nil {
+		return nil, err
+	}
+
+	var teeSets []models.TeeSet
+	if err := tx.Where("course_id = ?", course.ID).Find(&teeSets).Error; err != nil {
+		return nil, err
+	}
+	validTeeSets := make(map[uint]bool, len(teeSets))
+	for _, teeSet := range teeSets {
+		validTeeSets[teeSet.ID] = true
+	}
+
+	requestedNumbers := make(map[int]HoleRequest, len(requested))
+	for _, req := range requested {
+		requestedNumbers[req.HoleNumber] = req
+	}
+
+	touched := map[uint]bool{}
+	for i := range merged {
+		hole := &merged[i]
+		req, ok := requestedNumbers[hole.HoleNumber]
+		if !ok {
+			continue
+		}
+		if err := tx.Save(hole).Error; err != nil {
+			return nil, err
+		}
+		for _, tee := range req.Tees {
+			if !validTeeSets[tee.TeeSetID] {
+				return nil, newHTTPError(http.StatusBadRequest,
+					fmt.Sprintf("Hole %d: tee set %d is not on this course", hole.HoleNumber, tee.TeeSetID))
+			}
+			if err := upsertHoleTee(tx, hole.ID, tee.TeeSetID, tee.Yardage); err != nil {
+				return nil, err
+			}
+			touched[tee.TeeSetID] = true
+		}
+	}
+
+	for teeSetID := range touched {
+		if err := refreshTeeSetYardage(tx, teeSetID); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+func upsertHoleTee(tx *gorm.DB, holeID, teeSetID uint, yardage int) error {
+	tee := models.HoleTee{HoleID: holeID, TeeSetID: teeSetID, Yardage: yardage}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hole_id"}, {Name: "tee_set_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"yardage"}),
+	}).Create(&tee).Error
+}
+
+// refreshTeeSetYardage recomputes a tee set's total from its hole yardages.
+func refreshTeeSetYardage(tx *gorm.DB, teeSetID uint) error {
+	var total int
+	if err := tx.Model(&models.HoleTee{}).Select("COALESCE(SUM(yardage), 0)").
+		Where("tee_set_id = ?", teeSetID).Scan(&total).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.TeeSet{}).Where("id = ?", teeSetID).Update("total_yardage", total).Error
+}
+
+// resolveTeeSet checks that a selected tee set belongs to the course and is
+// in use. A nil selection is passed through.
+func resolveTeeSet(db *gorm.DB, courseID uint, teeSetID *uint) (*models.TeeSet, error) {
+	if teeSetID == nil {
+		return nil, nil
+	}
+	var teeSet models.TeeSet
+	if err := db.Where("id = ? AND course_id = ?", *teeSetID, courseID).First(&teeSet).Error; err != nil {
+		return nil, newHTTPError(http.StatusBadRequest, "Tee set is not on this course")
+	}
+	if !teeSet.IsActive {
+		return nil, newHTTPError(http.StatusBadRequest, "Tee set is not in use")
+	}
+	return &teeSet, nil
+}
+
+// courseRatings returns the rating and slope to use for a round: the tee
+// set's when one was chosen, otherwise the course's own.
+func courseRatings(course models.Course, teeSet *models.TeeSet) (float64, int, bool) {
+	if teeSet != nil {
+		return teeSet.CourseRating, teeSet.SlopeRating, true
+	}
+	if course.CourseRating == nil || course.SlopeRating == nil {
+		return 0, 0, false
+	}
+	return *course.CourseRating, *course.SlopeRating, true
+}
+
+func loadCourseHoles(db *gorm.DB, courseID uint) ([]models.Hole, error) {
+	var holes []models.Hole
+	err := db.Preload("Tees").Where("course_id = ?", courseID).Order("hole_number ASC").Find(&holes).Error
+	return holes, err
+}
+
+// Replace or add several holes of a course at once
+func (h *AdminHandler) UpsertCourseHoles(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		return
+	}
+
+	var req BulkHolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var course models.Course
+	if err := db.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		_, err := saveCourseHoles(tx, course, req.Holes)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to save holes")
+		return
+	}
+
+	holes, _ := loadCourseHoles(db, course.ID)
+
+	c.JSON(http.StatusOK, holes)
+}
+
+func (h *AdminHandler) CreateHole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		return
+	}
+
+	var req HoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var course models.Course
+	if err := db.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	var existing int64
+	db.Model(&models.Hole{}).Where("course_id = ? AND hole_number = ?", course.ID, req.HoleNumber).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Hole already exists"})
+		return
+	}
+
+	var hole models.Hole
+	err = db.Transaction(func(tx *gorm.DB) error {
+		holes, err := saveCourseHoles(tx, course, []HoleRequest{req})
+		if err != nil {
+			return err
+		}
+		for _, saved := range holes {
+			if saved.HoleNumber == req.HoleNumber {
+				hole = saved
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create hole")
+		return
+	}
+
+	db.Preload("Tees").First(&hole, hole.ID)
+
+	c.JSON(http.StatusCreated, hole)
+}
+
+func (h *AdminHandler) UpdateHole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hole ID"})
+		return
+	}
+
+	var req HoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var hole models.Hole
+	if err := db.First(&hole, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hole not found"})
+		return
+	}
+	if req.HoleNumber != hole.HoleNumber {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hole number cannot be changed"})
+		return
+	}
+
+	var course models.Course
+	if err := db.First(&course, hole.CourseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		_, err := saveCourseHoles(tx, course, []HoleRequest{req})
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update hole")
+		return
+	}
+
+	db.Preload("Tees").First(&hole, hole.ID)
+
+	c.JSON(http.StatusOK, hole)
+}
+
+func (h *AdminHandler) DeleteHole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hole ID"})
+		return
+	}
+
+	db := database.DB
+	var hole models.Hole
+	if err := db.First(&hole, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hole not found"})
+		return
+	}
+
+	var scored int64
+	db.Model(&models.ScorecardHole{}).Where("hole_id = ?", hole.ID).Count(&scored)
+	if scored > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hole has recorded scores and cannot be deleted"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var teeSetIDs []uint
+		if err := tx.Model(&models.HoleTee{}).Where("hole_id = ?", hole.ID).Pluck("tee_set_id", &teeSetIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("hole_id = ?", hole.ID).Delete(&models.HoleTee{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&hole).Error; err != nil {
+			return err
+		}
+		for _, teeSetID := range teeSetIDs {
+			if err := refreshTeeSetYardage(tx, teeSetID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete hole"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hole deleted successfully"})
+}
+
+// saveTeeSetYardages writes per-hole yardages for a tee set by hole number.
+func saveTeeSetYardages(tx *gorm.DB, teeSet models.TeeSet, yardages []TeeSetYardageRequest) error {
+	if len(yardages) == 0 {
+		return nil
+	}
+
+	var holes []models.Hole
+	if err := tx.Where("course_id = ?", teeSet.CourseID).Find(&holes).Error; err != nil {
+		return err
+	}
+	holeIDs := make(map[int]uint, len(holes))
+	for _, hole := range holes {
+		holeIDs[hole.HoleNumber] = hole.ID
+	}
+
+	for _, y := range yardages {
+		holeID, ok := holeIDs[y.HoleNumber]
+		if !ok {
+			return newHTTPError(http.StatusBadRequest, fmt.Sprintf("Hole %d does not exist on this course", y.HoleNumber))
+		}
+		if err := upsertHoleTee(tx, holeID, teeSet.ID, y.Yardage); err != nil {
+			return err
+		}
+	}
+	return refreshTeeSetYardage(tx, teeSet.ID)
+}
+
+// clearDefaultTeeSet makes sure a course has at most one default tee set.
+func clearDefaultTeeSet(tx *gorm.DB, courseID, keepID uint) error {
+	return tx.Model(&models.TeeSet{}).Where("course_id = ? AND id != ?", courseID, keepID).
+		Update("is_default", false).Error
+}
+
+func (h *AdminHandler) CreateTeeSet(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		return
+	}
+
+	var req TeeSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var course models.Course
+	if err := db.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	teeSet := models.TeeSet{
+		CourseID:     course.ID,
+		Name:         req.Name,
+		Color:        req.Color,
+		CourseRating: req.CourseRating,
+		SlopeRating:  req.SlopeRating,
+		IsDefault:    req.IsDefault,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&teeSet).Error; err != nil {
+			return err
+		}
+		if req.IsActive != nil && !*req.IsActive {
+			if err := tx.Model(&teeSet).Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+		if teeSet.IsDefault {
+			if err := clearDefaultTeeSet(tx, course.ID, teeSet.ID); err != nil {
+				return err
+			}
+		}
+		return saveTeeSetYardages(tx, teeSet, req.Yardages)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create tee set")
+		return
+	}
+
+	db.Preload("HoleTees").First(&teeSet, teeSet.ID)
+
+	c.JSON(http.StatusCreated, teeSet)
+}
+
+func (h *AdminHandler) UpdateTeeSet(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tee set ID"})
+		return
+	}
+
+	var req TeeSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var teeSet models.TeeSet
+	if err := db.First(&teeSet, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tee set not found"})
+		return
+	}
+
+	teeSet.Name = req.Name
+	teeSet.Color = req.Color
+	teeSet.CourseRating = req.CourseRating
+	teeSet.SlopeRating = req.SlopeRating
+	teeSet.IsDefault = req.IsDefault
+	if req.IsActive != nil {
+		teeSet.IsActive = *req.IsActive
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&teeSet).Error; err != nil {
+			return err
+		}
+		if teeSet.IsDefault {
+			if err := clearDefaultTeeSet(tx, teeSet.CourseID, teeSet.ID); err != nil {
+				return err
+			}
+		}
+		return saveTeeSetYardages(tx, teeSet, req.Yardages)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update tee set")
+		return
+	}
+
+	db.Preload("HoleTees").First(&teeSet, teeSet.ID)
+
+	c.JSON(http.StatusOK, teeSet)
+}
+
+func (h *AdminHandler) DeleteTeeSet(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tee set ID"})
+		return
+	}
+
+	db := database.DB
+	var teeSet models.TeeSet
+	if err := db.First(&teeSet, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tee set not found"})
+		return
+	}
+
+	// Tee sets already played keep their ratings for handicap history
+	var used int64
+	db.Model(&models.Scorecard{}).Where("tee_set_id = ?", teeSet.ID).Count(&used)
+	if used > 0 {
+		if err := db.Model(&teeSet).Updates(map[string]interface{}{"is_active": false, "is_default": false}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tee set"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Tee set has recorded rounds and was deactivated instead"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tee_set_id = ?", teeSet.ID).Delete(&models.HoleTee{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&teeSet).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tee set"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tee set deleted successfully"})
+}
+
+// @Summary Get course tee sets
+// @Description Get the tee sets of a course with their ratings and per-hole yardages
+// @Tags courses
+// @Produce json
+// @Param id path int true "Course ID"
+// @Success 200 {array} models.TeeSet
+// @Failure 404 {object} map[string]string
+// @Router /courses/{id}/tee-sets [get]
+func (h *CourseHandler) GetCourseTeeSets(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		return
+	}
+
+	db := database.DB
+	var course models.Course
+	if err := db.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	var teeSets []models.TeeSet
+	if err := db.Preload("HoleTees").Where("course_id = ? AND is_active = ?", course.ID, true).
+		Order("is_default DESC, total_yardage DESC").Find(&teeSets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tee sets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, teeSets)
+}
@@ -0,0 +1,729 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golf-course-backend/internal/config"
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/giftcards"
+	"golf-course-backend/internal/ledger"
+	"golf-course-backend/internal/mail"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/pdf"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceHandler struct {
+	mailer *mail.SMTPSender
+}
+
+func NewInvoiceHandler(emailConfig config.EmailConfig) *InvoiceHandler {
+	return &InvoiceHandler{mailer: mail.NewSMTPSender(emailConfig)}
+}
+
+type PeriodInvoiceRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+type CustomerInvoiceRequest struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	From   string `json:"from" binding:"required"`
+	To     string `json:"to" binding:"required"`
+}
+
+type InvoiceEmailRequest struct {
+	// Email defaults to the customer's address
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// nextInvoiceNumber hands out the next number in the sequence. The counter
+// row stays locked until the invoice's transaction commits, so numbers are
+// never skipped or issued twice.
+func nextInvoiceNumber(tx *gorm.DB) (string, error) {
+	var counter models.SystemSetting
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("setting_key = ?", "invoice_next_number").First(&counter).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		counter = models.SystemSetting{
+			SettingKey:   "invoice_next_number",
+			SettingValue: "1",
+			Description:  "Next invoice number; advanced under a row lock as invoices are issued",
+			IsActive:     true,
+		}
+		err = tx.Create(&counter).Error
+	}
+	if err != nil {
+		return "", err
+	}
+
+	next, err := strconv.Atoi(strings.TrimSpace(counter.SettingValue))
+	if err != nil || next < 1 {
+		next = 1
+	}
+	if err := tx.Model(&counter).Update("setting_value", strconv.Itoa(next+1)).Error; err != nil {
+		return "", err
+	}
+
+	prefix := "INV-"
+	var setting models.SystemSetting
+	if err := tx.Where("setting_key = ? AND is_active = ?", "invoice_prefix", true).First(&setting).Error; err == nil {
+		prefix = setting.SettingValue
+	}
+	return fmt.Sprintf("%s%06d", prefix, next), nil
+}
+
+// bookingDescription describes what a payment was for, with the number of
+// players for a tee time.
+func bookingDescription(tx *gorm.DB, payment *models.Payment) (string, int) {
+	switch payment.ReferenceType {
+	case "tee_time":
+		var teeTime models.TeeTime
+		if err := tx.Preload("Course").First(&teeTime, payment.ReferenceID).Error; err == nil {
+			return fmt.Sprintf("%s, %s %s", teeTime.Course.Name, teeTime.BookingDate.Format("Jan 2, 2006"),
+				teeTime.TeeTime), teeTime.PlayersCount
+		}
+	case "tee_time_share":
+		var share models.TeeTimeShare
+		if err := tx.Preload("TeeTime.Course").First(&share, payment.ReferenceID).Error; err == nil {
+			return fmt.Sprintf("%s, %s %s (player %d share)", share.TeeTime.Course.Name,
+				share.TeeTime.BookingDate.Format("Jan 2, 2006"), share.TeeTime.TeeTime, share.PlayerNumber), 1
+		}
+	case "range_session":
+		var session models.RangeSession
+		if err := tx.First(&session, payment.ReferenceID).Error; err == nil {
+			return fmt.Sprintf("Range session, %s %s (%s bucket)", session.SessionDate.Format("Jan 2, 2006"),
+				session.StartTime, session.BallBucketSize), 1
+		}
+	case "equipment_rental":
+		var rental models.EquipmentRental
+		if err := tx.Preload("Equipment").First(&rental, payment.ReferenceID).Error; err == nil {
+			return fmt.Sprintf("Rental of %s, %s", rental.Equipment.Name, rental.RentalDate.Format("Jan 2, 2006")),
+				rental.Quantity
+		}
+	case "tournament":
+		var tournament models.Tournament
+		if err := tx.First(&tournament, payment.ReferenceID).Error; err == nil {
+			return "Entry fee, " + tournament.Name, 1
+		}
+	case "gift_card":
+		var card models.GiftCard
+		if err := tx.First(&card, payment.ReferenceID).Error; err == nil {
+			return "Gift card " + giftcards.Mask(card.Code), 1
+		}
+	}
+	return fmt.Sprintf("Payment #%d", payment.ID), 1
+}
+
+func invoiceLine(payment *models.Payment, description string, quantity int, amount, tax float64) models.InvoiceLine {
+	if quantity < 1 {
+		quantity = 1
+	}
+	return models.InvoiceLine{
+		Description: description,
+		Quantity:    quantity,
+		UnitPrice:   roundCurrency(amount / float64(quantity)),
+		Amount:      roundCurrency(amount),
+		TaxAmount:   roundCurrency(tax),
+		PaymentID:   &payment.ID,
+	}
+}
+
+// journalInvoiceLines turns a ledger entry into invoice lines: one per
+// revenue account for a sale, with the tax split between them, and a
+// negative line for anything given back.
+func journalInvoiceLines(tx *gorm.DB, entry models.JournalEntry, payment *models.Payment) []models.InvoiceLine {
+	description, quantity := bookingDescription(tx, payment)
+
+	var tax, total float64
+	revenue := make(map[string]float64)
+	for _, line := range entry.Lines {
+		switch {
+		case line.AccountCode == ledger.SalesTax:
+			tax += line.Credit - line.Debit
+		case line.AccountCode == ledger.Refunds:
+			total -= line.Debit
+		case line.AccountCode == ledger.CustomerDeposits && entry.EntryType == "deposit_received":
+			total += line.Credit
+		case line.AccountCode == ledger.CustomerDeposits && entry.EntryType == "deposit_returned":
+			total -= line.Debit
+		case line.AccountCode == ledger.GiftCards && entry.EntryType == "gift_card_sold":
+			total += line.Credit
+		case line.Credit > 0 && line.AccountCode != ledger.Cash && line.AccountCode != ledger.CustomerDeposits:
+			revenue[line.AccountCode] += line.Credit
+		}
+	}
+
+	switch entry.EntryType {
+	case "sale", "deposit_applied":
+		codes := make([]string, 0, len(revenue))
+		for code := range revenue {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		taxes := ledger.Allocate(tax, revenue)
+		var lines []models.InvoiceLine
+		for _, code := range codes {
+			text, qty := description, 1
+			account, _ := ledger.Lookup(code)
+			if len(codes) > 1 || entry.EntryType == "deposit_applied" {
+				text = account.Name + " - " + description
+			}
+			if code == ledger.GreenFees || code == ledger.EquipmentRentals {
+				qty = quantity
+			}
+			lines = append(lines, invoiceLine(payment, text, qty, revenue[code], taxes[code]))
+		}
+		return lines
+	case "refund":
+		return []models.InvoiceLine{invoiceLine(payment, "Refund - "+description, 1, total, tax)}
+	case "deposit_received":
+		return []models.InvoiceLine{invoiceLine(payment, "Refundable deposit - "+description, 1, total, 0)}
+	case "deposit_returned":
+		return []models.InvoiceLine{invoiceLine(payment, "Deposit returned - "+description, 1, total, 0)}
+	case "gift_card_sold":
+		return []models.InvoiceLine{invoiceLine(payment, description, 1, total, 0)}
+	}
+	return nil
+}
+
+// paymentInvoiceLines lists what a payment covered, and anything since
+// refunded, from the ledger. Payments the ledger has not seen are shown as
+// a single line.
+func paymentInvoiceLines(tx *gorm.DB, payment *models.Payment) ([]models.InvoiceLine, error) {
+	var entries []models.JournalEntry
+	if err := tx.Preload("Lines").Where("payment_id = ?", payment.ID).
+		Order("posted_at ASC, id ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	var lines []models.InvoiceLine
+	for _, entry := range entries {
+		lines = append(lines, journalInvoiceLines(tx, entry, payment)...)
+	}
+	if len(lines) == 0 {
+		description, _ := bookingDescription(tx, payment)
+		net, tax := payment.Amount, 0.0
+		if payment.PaymentType == "charge" {
+			net, tax = ledger.SplitTax(payment.Amount, getSettingFloat("sales_tax_rate", 0))
+		}
+		lines = append(lines, invoiceLine(payment, description, 1, net, tax))
+	}
+	return lines, nil
+}
+
+// issueInvoice numbers an invoice, totals its lines and saves it.
+func issueInvoice(tx *gorm.DB, invoice *models.Invoice, lines []models.InvoiceLine) error {
+	var user models.User
+	if err := tx.First(&user, invoice.UserID).Error; err != nil {
+		return newHTTPError(http.StatusNotFound, "Customer not found")
+	}
+	number, err := nextInvoiceNumber(tx)
+	if err != nil {
+		return err
+	}
+
+	invoice.InvoiceNumber = number
+	invoice.BillingName = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	invoice.BillingEmail = user.Email
+	invoice.IssuedAt = time.Now()
+	invoice.Lines = lines
+	invoice.Subtotal, invoice.TaxAmount = 0, 0
+	for _, line := range lines {
+		invoice.Subtotal += line.Amount
+		invoice.TaxAmount += line.TaxAmount
+	}
+	invoice.Subtotal = roundCurrency(invoice.Subtotal)
+	invoice.TaxAmount = roundCurrency(invoice.TaxAmount)
+	invoice.Total = roundCurrency(invoice.Subtotal + invoice.TaxAmount)
+	return tx.Create(invoice).Error
+}
+
+// paymentReceipt returns the receipt for a payment, issuing it the first
+// time it is asked for.
+func paymentReceipt(paymentID uint, userID *uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var payment models.Payment
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", paymentID)
+		if userID != nil {
+			query = query.Where("user_id = ?", *userID)
+		}
+		if err := query.First(&payment).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Payment not found")
+		}
+		if payment.PaymentStatus != "succeeded" && payment.PaymentStatus != "partially_refunded" &&
+			payment.PaymentStatus != "refunded" {
+			return newHTTPError(http.StatusBadRequest, "Receipts are only available for completed payments")
+		}
+
+		err := tx.Preload("Lines").Where("payment_id = ?", payment.ID).First(&invoice).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		lines, err := paymentInvoiceLines(tx, &payment)
+		if err != nil {
+			return err
+		}
+		invoice = models.Invoice{UserID: payment.UserID, PaymentID: &payment.ID, Currency: payment.Currency}
+		return issueInvoice(tx, &invoice, lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// periodInvoice returns a customer's invoice for a date range, issuing it
+// from the ledger the first time. Only periods that have ended can be
+// invoiced, so an issued invoice does not go stale.
+func periodInvoice(userID uint, fromValue, toValue string) (*models.Invoice, bool, error) {
+	from, err := time.ParseInLocation("2006-01-02", fromValue, time.Local)
+	if err != nil {
+		return nil, false, newHTTPError(http.StatusBadRequest, "Invalid from date format")
+	}
+	to, err := time.ParseInLocation("2006-01-02", toValue, time.Local)
+	if err != nil {
+		return nil, false, newHTTPError(http.StatusBadRequest, "Invalid to date format")
+	}
+	if to.Before(from) {
+		return nil, false, newHTTPError(http.StatusBadRequest, "from must not be after to")
+	}
+	if to.AddDate(-1, 0, 0).After(from) {
+		return nil, false, newHTTPError(http.StatusBadRequest, "An invoice can cover at most one year")
+	}
+	today := time.Now()
+	if !to.Before(time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)) {
+		return nil, false, newHTTPError(http.StatusBadRequest, "Invoices can only be issued for periods that have ended")
+	}
+
+	var invoice models.Invoice
+	created := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Preload("Lines").Where("user_id = ? AND period_start = ? AND period_end = ?", userID, from, to).
+			First(&invoice).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var entries []models.JournalEntry
+		if err := tx.Preload("Lines").
+			Joins("JOIN payments ON payments.id = journal_entries.payment_id").
+			Where("payments.user_id = ? AND journal_entries.entry_type IN ?", userID, []string{"sale", "refund", "deposit_applied"}).
+			Where("journal_entries.posted_at >= ? AND journal_entries.posted_at < ?", from, to.AddDate(0, 0, 1)).
+			Order("journal_entries.posted_at ASC, journal_entries.id ASC").Find(&entries).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return newHTTPError(http.StatusBadRequest, "No payments in this period")
+		}
+
+		paymentsByID := make(map[uint]*models.Payment)
+		var lines []models.InvoiceLine
+		for _, entry := range entries {
+			payment, ok := paymentsByID[*entry.PaymentID]
+			if !ok {
+				payment = &models.Payment{}
+				if err := tx.First(payment, *entry.PaymentID).Error; err != nil {
+					return err
+				}
+				paymentsByID[payment.ID] = payment
+			}
+			lines = append(lines, journalInvoiceLines(tx, entry, payment)...)
+		}
+
+		invoice = models.Invoice{UserID: userID, PeriodStart: &from, PeriodEnd: &to, Currency: "USD"}
+		created = true
+		return issueInvoice(tx, &invoice, lines)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &invoice, created, nil
+}
+
+// sellerName is the business name printed on invoices.
+func sellerName() string {
+	var names []string
+	database.DB.Model(&models.Course{}).Order("id ASC").Limit(1).Pluck("name", &names)
+	if len(names) == 0 || names[0] == "" {
+		return "Golf Course"
+	}
+	return names[0]
+}
+
+// fitText shortens text with an ellipsis until it fits the width.
+func fitText(text string, size, width float64) string {
+	if pdf.TextWidth(text, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.TextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "..."
+}
+
+func formatMoney(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("-$%.2f", math.Abs(amount))
+	}
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+// renderInvoice lays out an invoice as a PDF.
+func renderInvoice(invoice *models.Invoice, seller string) []byte {
+	title := "Invoice"
+	if invoice.PaymentID != nil {
+		title = "Receipt"
+	}
+	doc := pdf.New(title + " " + invoice.InvoiceNumber)
+
+	const left, right = 50.0, pdf.LetterWidth - 50
+	columns := []float64{right - 230, right - 160, right - 80, right}
+
+	page := doc.AddPage()
+	y := pdf.LetterHeight - 60
+	page.Text(left, y, 18, true, seller)
+	page.TextRight(right, y, 18, true, strings.ToUpper(title))
+	y -= 36
+
+	details := [][2]string{
+		{title + " number", invoice.InvoiceNumber},
+		{"Date issued", invoice.IssuedAt.Format("January 2, 2006")},
+	}
+	if invoice.PeriodStart != nil && invoice.PeriodEnd != nil {
+		details = append(details, [2]string{"Period",
+			invoice.PeriodStart.Format("Jan 2, 2006") + " - " + invoice.PeriodEnd.Format("Jan 2, 2006")})
+	}
+	if invoice.PaymentID != nil {
+		details = append(details, [2]string{"Payment", fmt.Sprintf("#%d", *invoice.PaymentID)})
+	}
+	for _, detail := range details {
+		page.Text(left, y, 10, true, detail[0])
+		page.Text(left+110, y, 10, false, detail[1])
+		y -= 15
+	}
+	y -= 10
+	page.Text(left, y, 10, true, "Billed to")
+	y -= 15
+	page.Text(left, y, 10, false, invoice.BillingName)
+	y -= 15
+	page.Text(left, y, 10, false, invoice.BillingEmail)
+	y -= 30
+
+	header := func() {
+		page.Shade(left, y-6, right-left, 20, 0.9)
+		page.Text(left+4, y, 10, true, "Description")
+		page.TextRight(columns[0], y, 10, true, "Qty")
+		page.TextRight(columns[1], y, 10, true, "Unit price")
+		page.TextRight(columns[2], y, 10, true, "Tax")
+		page.TextRight(columns[3]-4, y, 10, true, "Amount")
+		y -= 22
+	}
+	header()
+	for _, line := range invoice.Lines {
+		if y < 120 {
+			page = doc.AddPage()
+			y = pdf.LetterHeight - 60
+			header()
+		}
+		page.Text(left+4, y, 10, false, fitText(line.Description, 10, columns[0]-left-40))
+		page.TextRight(columns[0], y, 10, false, strconv.Itoa(line.Quantity))
+		page.TextRight(columns[1], y, 10, false, formatMoney(line.UnitPrice))
+		page.TextRight(columns[2], y, 10, false, formatMoney(line.TaxAmount))
+		page.TextRight(columns[3]-4, y, 10, false, formatMoney(line.Amount))
+		y -= 16
+	}
+
+	page.Line(columns[1]-40, y+8, right, y+8, 0.5)
+	y -= 8
+	for _, total := range []struct {
+		label  string
+		amount float64
+		bold   bool
+	}{
+		{"Subtotal", invoice.Subtotal, false},
+		{"Tax", invoice.TaxAmount, false},
+		{"Total (" + invoice.Currency + ")", invoice.Total, true},
+	} {
+		page.TextRight(columns[2], y, 10, total.bold, total.label)
+		page.TextRight(columns[3]-4, y, 10, total.bold, formatMoney(total.amount))
+		y -= 16
+	}
+
+	page.Text(left, 50, 9, false, "Thank you for playing at "+seller+".")
+	return doc.Bytes()
+}
+
+// loadInvoice finds an invoice the caller may see: their own, or any for
+// staff.
+func loadInvoice(c *gin.Context) (*models.Invoice, bool) {
+	query := database.DB.Preload("Lines").Where("id = ?", c.Param("id"))
+	if role := c.GetString("user_role"); role != "staff" && role != "admin" {
+		query = query.Where("user_id = ?", c.GetUint("user_id"))
+	}
+	var invoice models.Invoice
+	if err := query.First(&invoice).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+		return nil, false
+	}
+	return &invoice, true
+}
+
+func sendPDF(c *gin.Context, invoice *models.Invoice) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", invoice.InvoiceNumber+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", renderInvoice(invoice, sellerName()))
+}
+
+// emailInvoice sends an invoice as a PDF attachment and records where it
+// went.
+func (h *InvoiceHandler) emailInvoice(c *gin.Context, invoice *models.Invoice) {
+	var req InvoiceEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.mailer.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Email delivery is not configured"})
+		return
+	}
+	to := req.Email
+	if to == "" {
+		to = invoice.BillingEmail
+	}
+
+	seller := sellerName()
+	kind := "invoice"
+	if invoice.PaymentID != nil {
+		kind = "receipt"
+	}
+	err := h.mailer.Send(mail.Message{
+		To:      []string{to},
+		Subject: fmt.Sprintf("Your %s %s from %s", kind, invoice.InvoiceNumber, seller),
+		Body: fmt.Sprintf("Hello %s,\n\nPlease find your %s %s for %s attached.\n\nThank you,\n%s\n",
+			invoice.BillingName, kind, invoice.InvoiceNumber, formatMoney(invoice.Total), seller),
+		Attachments: []mail.Attachment{{
+			Filename:    invoice.InvoiceNumber + ".pdf",
+			ContentType: "application/pdf",
+			Data:        renderInvoice(invoice, seller),
+		}},
+	})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send email"})
+		return
+	}
+
+	now := time.Now()
+	database.DB.Model(invoice).Updates(map[string]interface{}{"emailed_to": to, "emailed_at": now})
+	invoice.EmailedTo = to
+	invoice.EmailedAt = &now
+	c.JSON(http.StatusOK, invoice)
+}
+
+// receiptForRequest issues or fetches the receipt for the payment in the
+// path.
+func receiptForRequest(c *gin.Context) (*models.Invoice, bool) {
+	paymentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return nil, false
+	}
+	var userID *uint
+	if role := c.GetString("user_role"); role != "staff" && role != "admin" {
+		uid := c.GetUint("user_id")
+		userID = &uid
+	}
+	invoice, err := paymentReceipt(uint(paymentID), userID)
+	if err != nil {
+		respondError(c, err, "Failed to issue receipt")
+		return nil, false
+	}
+	return invoice, true
+}
+
+// @Summary Download a receipt
+// @Description PDF receipt for a completed payment. The receipt is numbered the first time it is downloaded.
+// @Tags invoices
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path int true "Payment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/{id}/receipt [get]
+func (h *InvoiceHandler) GetReceipt(c *gin.Context) {
+	invoice, ok := receiptForRequest(c)
+	if !ok {
+		return
+	}
+	sendPDF(c, invoice)
+}
+
+// @Summary Email a receipt
+// @Description Send the PDF receipt for a payment to the customer or another address
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Payment ID"
+// @Param email body InvoiceEmailRequest false "Recipient"
+// @Success 200 {object} models.Invoice
+// @Failure 503 {object} map[string]string
+// @Router /payments/{id}/receipt/email [post]
+func (h *InvoiceHandler) EmailReceipt(c *gin.Context) {
+	invoice, ok := receiptForRequest(c)
+	if !ok {
+		return
+	}
+	h.emailInvoice(c, invoice)
+}
+
+// @Summary List invoices
+// @Description The authenticated user's receipts and invoices, newest first
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Invoice
+// @Router /invoices [get]
+func (h *InvoiceHandler) GetInvoices(c *gin.Context) {
+	var invoices []models.Invoice
+	if err := database.DB.Where("user_id = ?", c.GetUint("user_id")).
+		Order("issued_at DESC").Find(&invoices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invoices"})
+		return
+	}
+	c.JSON(http.StatusOK, invoices)
+}
+
+// @Summary Get an invoice
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} models.Invoice
+// @Failure 404 {object} map[string]string
+// @Router /invoices/{id} [get]
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	invoice, ok := loadInvoice(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, invoice)
+}
+
+// @Summary Download an invoice
+// @Tags invoices
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path int true "Invoice ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Router /invoices/{id}/pdf [get]
+func (h *InvoiceHandler) GetInvoicePDF(c *gin.Context) {
+	invoice, ok := loadInvoice(c)
+	if !ok {
+		return
+	}
+	sendPDF(c, invoice)
+}
+
+// @Summary Email an invoice
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Invoice ID"
+// @Param email body InvoiceEmailRequest false "Recipient"
+// @Success 200 {object} models.Invoice
+// @Failure 503 {object} map[string]string
+// @Router /invoices/{id}/email [post]
+func (h *InvoiceHandler) EmailInvoice(c *gin.Context) {
+	invoice, ok := loadInvoice(c)
+	if !ok {
+		return
+	}
+	h.emailInvoice(c, invoice)
+}
+
+// @Summary Invoice a period
+// @Description Issue an invoice covering everything the user paid for, and was refunded, between two dates. Asking again for the same period returns the same invoice.
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param period body PeriodInvoiceRequest true "Period"
+// @Success 201 {object} models.Invoice
+// @Failure 400 {object} map[string]string
+// @Router /invoices [post]
+func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
+	var req PeriodInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoice, created, err := periodInvoice(c.GetUint("user_id"), req.From, req.To)
+	if err != nil {
+		respondError(c, err, "Failed to issue invoice")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, invoice)
+}
+
+// Invoice a customer for a period, e.g. a corporate account's month
+func (h *InvoiceHandler) CreateCustomerInvoice(c *gin.Context) {
+	var req CustomerInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoice, created, err := periodInvoice(req.UserID, req.From, req.To)
+	if err != nil {
+		respondError(c, err, "Failed to issue invoice")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, invoice)
+}
+
+// List a customer's invoices
+func (h *InvoiceHandler) GetCustomerInvoices(c *gin.Context) {
+	query := database.DB.Order("issued_at DESC")
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var invoices []models.Invoice
+	if err := query.Limit(500).Find(&invoices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invoices"})
+		return
+	}
+	c.JSON(http.StatusOK, invoices)
+}
@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/handicap"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/scoring"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeaderboardEntry struct {
+	scoring.Standing
+	ParticipantID   uint     `json:"participant_id"`
+	Handicap        *float64 `json:"handicap"`
+	PlayingHandicap *int     `json:"playing_handicap,omitempty"`
+	Started         bool     `json:"started"`
+}
+
+type LeaderboardFlight struct {
+	Name        string             `json:"name"`
+	MinHandicap *float64           `json:"min_handicap,omitempty"`
+	MaxHandicap *float64           `json:"max_handicap,omitempty"`
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+type Leaderboard struct {
+	TournamentID uint                `json:"tournament_id"`
+	Tournament   string              `json:"tournament"`
+	Status       string              `json:"status"`
+	Mode         string              `json:"mode"`
+	Rounds       int                 `json:"rounds"`
+	Par          int                 `json:"par"`
+	Flights      []LeaderboardFlight `json:"flights"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+type FinalizeTournamentRequest struct {
+	Mode    string    `json:"mode" binding:"omitempty,oneof=gross net"`
+	Flights string    `json:"flights"`
+	Payouts []float64 `json:"payouts" binding:"dive,gt=0,lte=100"`
+}
+
+// handicapRange is one flight of a leaderboard.
+type handicapRange struct {
+	min, max float64
+}
+
+// parseFlights reads flights given as handicap ranges, e.g. "0-9.9,10-18.9,19-54".
+func parseFlights(value string) ([]handicapRange, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var ranges []handicapRange
+	for _, part := range strings.Split(value, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid flight %q", part)
+		}
+		low, err := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flight %q", part)
+		}
+		high, err := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+		if err != nil || high < low {
+			return nil, fmt.Errorf("invalid flight %q", part)
+		}
+		ranges = append(ranges, handicapRange{min: low, max: high})
+	}
+	return ranges, nil
+}
+
+// tournamentRounds is the number of rounds in a tournament: one per day
+// unless more have been recorded.
+func tournamentRounds(tournament models.Tournament, scorecards []models.Scorecard) int {
+	rounds := int(tournament.EndDate.Sub(tournament.StartDate).Hours()/24) + 1
+	for _, scorecard := range scorecards {
+		if scorecard.TournamentRound != nil && *scorecard.TournamentRound > rounds {
+			rounds = *scorecard.TournamentRound
+		}
+	}
+	return max(rounds, 1)
+}
+
+// buildLeaderboard totals every entrant's tournament rounds, gross or net,
+// and ranks them within each flight. Net scores use 95% of the course
+// handicap from the index recorded at registration.
+func buildLeaderboard(db *gorm.DB, tournament models.Tournament, mode string, flights []handicapRange) (Leaderboard, error) {
+	board := Leaderboard{
+		TournamentID: tournament.ID,
+		Tournament:   tournament.Name,
+		Status:       tournament.Status,
+		Mode:         mode,
+		Flights:      []LeaderboardFlight{},
+		UpdatedAt:    time.Now(),
+	}
+
+	var participants []models.TournamentParticipant
+	if err := db.Preload("User").Where("tournament_id = ?", tournament.ID).Order("id ASC").Find(&participants).Error; err != nil {
+		return board, err
+	}
+	courseHoles, err := loadCourseHoles(db, tournament.CourseID)
+	if err != nil {
+		return board, err
+	}
+	var scorecards []models.Scorecard
+	if err := db.Preload("Course").Preload("TeeSet").Preload("Holes.Hole").
+		Where("tournament_id = ? AND is_tournament_round = ?", tournament.ID, true).
+		Find(&scorecards).Error; err != nil {
+		return board, err
+	}
+
+	board.Rounds = tournamentRounds(tournament, scorecards)
+	for _, hole := range courseHoles {
+		board.Par += hole.Par
+	}
+
+	cards := make(map[uint]map[int]models.Scorecard, len(participants))
+	for _, scorecard := range scorecards {
+		if scorecard.TournamentRound == nil {
+			continue
+		}
+		if cards[scorecard.UserID] == nil {
+			cards[scorecard.UserID] = make(map[int]models.Scorecard)
+		}
+		cards[scorecard.UserID][*scorecard.TournamentRound] = scorecard
+	}
+
+	strokeIndexes := make([]int, len(courseHoles))
+	for i, hole := range courseHoles {
+		strokeIndexes[i] = hole.HandicapIndex
+	}
+	allowance, _ := handicap.Allowance("stroke_play")
+
+	entries := make([]LeaderboardEntry, 0, len(participants))
+	for _, participant := range participants {
+		entry := LeaderboardEntry{
+			Standing: scoring.Standing{
+				PlayerID: participant.UserID,
+				Name:     participant.User.FirstName + " " + participant.User.LastName,
+				Rounds:   []int{},
+				Finished: true,
+			},
+			ParticipantID: participant.ID,
+			Handicap:      participant.HandicapAtRegistration,
+		}
+
+		for round := 1; round <= board.Rounds; round++ {
+			scorecard, ok := cards[participant.UserID][round]
+			if !ok {
+				entry.Finished = false
+				continue
+			}
+
+			received := make([]int, len(courseHoles))
+			if mode == "net" && participant.HandicapAtRegistration != nil {
+				if ch := courseHandicapFor(*participant.HandicapAtRegistration, scorecard.Course, scorecard.TeeSet, len(courseHoles)); ch != nil {
+					ph := handicap.PlayingHandicap(*ch, allowance)
+					entry.PlayingHandicap = &ph
+					received = handicap.AllocateStrokes(ph, strokeIndexes)
+				}
+			}
+
+			strokes := make(map[uint]int, len(scorecard.Holes))
+			for _, hole := range scorecard.Holes {
+				strokes[hole.HoleID] = hole.Strokes
+			}
+
+			total, played := 0, 0
+			holeScores := make([]int, len(courseHoles))
+			for i, hole := range courseHoles {
+				if strokes[hole.ID] <= 0 {
+					continue
+				}
+				score := strokes[hole.ID] - received[i]
+				holeScores[i] = score
+				total += score
+				entry.ToPar += score - hole.Par
+				played++
+			}
+			if played == 0 {
+				entry.Finished = false
+				continue
+			}
+
+			entry.Started = true
+			entry.Strokes += total
+			entry.Rounds = append(entry.Rounds, total)
+			entry.Thru = played
+			entry.LastRound = holeScores
+			if played < len(courseHoles) {
+				entry.Finished = false
+			}
+		}
+		if !entry.Started {
+			entry.Finished = false
+		}
+		entries = append(entries, entry)
+	}
+
+	// Place each player in a flight; players without a handicap go last
+	buckets := make([][]LeaderboardEntry, max(len(flights), 1))
+	for _, entry := range entries {
+		bucket := len(buckets) - 1
+		if entry.Handicap != nil {
+			for i, r := range flights {
+				if *entry.Handicap >= r.min && *entry.Handicap <= r.max {
+					bucket = i
+					break
+				}
+			}
+		}
+		buckets[bucket] = append(buckets[bucket], entry)
+	}
+
+	for i, bucket := range buckets {
+		flight := LeaderboardFlight{Name: "Overall", Entries: rankEntries(bucket)}
+		if len(flights) > 0 {
+			low, high := flights[i].min, flights[i].max
+			flight.Name = fmt.Sprintf("Flight %d", i+1)
+			flight.MinHandicap = &low
+			flight.MaxHandicap = &high
+		}
+		board.Flights = append(board.Flights, flight)
+	}
+	return board, nil
+}
+
+// rankEntries ranks the players who have started; those who have not are
+// listed afterwards without a position.
+func rankEntries(entries []LeaderboardEntry) []LeaderboardEntry {
+	var standings []scoring.Standing
+	byPlayer := make(map[uint]LeaderboardEntry, len(entries))
+	var waiting []LeaderboardEntry
+	for _, entry := range entries {
+		if !entry.Started {
+			waiting = append(waiting, entry)
+			continue
+		}
+		standings = append(standings, entry.Standing)
+		byPlayer[entry.PlayerID] = entry
+	}
+	scoring.Rank(standings)
+
+	ranked := make([]LeaderboardEntry, 0, len(entries))
+	for _, standing := range standings {
+		entry := byPlayer[standing.PlayerID]
+		entry.Standing = standing
+		ranked = append(ranked, entry)
+	}
+	return append(ranked, waiting...)
+}
+
+// leaderboardRequest reads the tournament and options shared by the
+// leaderboard endpoints.
+func leaderboardRequest(c *gin.Context) (models.Tournament, string, []handicapRange, bool) {
+	var tournament models.Tournament
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return tournament, "", nil, false
+	}
+	mode := c.DefaultQuery("mode", "gross")
+	if mode != "gross" && mode != "net" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be gross or net"})
+		return tournament, "", nil, false
+	}
+	flights, err := parseFlights(c.Query("flights"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return tournament, "", nil, false
+	}
+	if err := database.DB.First(&tournament, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return tournament, "", nil, false
+	}
+	return tournament, mode, flights, true
+}
+
+// @Summary Get tournament leaderboard
+// @Description Gross or net standings with card-off tie-breaks, optionally split into handicap flights
+// @Tags tournaments
+// @Produce json
+// @Param id path int true "Tournament ID"
+// @Param mode query string false "gross or net" default(gross)
+// @Param flights query string false "Handicap ranges, e.g. 0-9.9,10-18.9,19-54"
+// @Success 200 {object} Leaderboard
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id}/leaderboard [get]
+func (h *TournamentHandler) GetLeaderboard(c *gin.Context) {
+	tournament, mode, flights, ok := leaderboardRequest(c)
+	if !ok {
+		return
+	}
+
+	board, err := buildLeaderboard(database.DB, tournament, mode, flights)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build leaderboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// @Summary Stream tournament leaderboard
+// @Description Server-sent events carrying the leaderboard whenever it changes
+// @Tags tournaments
+// @Produce text/event-stream
+// @Param id path int true "Tournament ID"
+// @Param mode query string false "gross or net" default(gross)
+// @Param flights query string false "Handicap ranges, e.g. 0-9.9,10-18.9,19-54"
+// @Success 200 {object} Leaderboard
+// @Router /tournaments/{id}/leaderboard/stream [get]
+func (h *TournamentHandler) StreamLeaderboard(c *gin.Context) {
+	tournament, mode, flights, ok := leaderboardRequest(c)
+	if !ok {
+		return
+	}
+
+	interval := time.Duration(getSettingInt("leaderboard_refresh_seconds", 15)) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	var last []byte
+	c.Stream(func(w io.Writer) bool {
+		board, err := buildLeaderboard(database.DB, tournament, mode, flights)
+		if err == nil {
+			// Only the standings matter when deciding whether to send
+			board.UpdatedAt = time.Time{}
+			data, _ := json.Marshal(board)
+			if string(data) != string(last) {
+				last = data
+				board.UpdatedAt = time.Now()
+				c.SSEvent("leaderboard", board)
+			} else {
+				io.WriteString(w, ": keep-alive\n\n")
+			}
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+// Record final scores, positions and prize money and complete the tournament
+func (h *TournamentHandler) FinalizeTournament(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	var req FinalizeTournamentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = "gross"
+	}
+	flights, err := parseFlights(req.Flights)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	share := 0.0
+	for _, payout := range req.Payouts {
+		share += payout
+	}
+	if share > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payouts cannot exceed the prize pool"})
+		return
+	}
+
+	db := database.DB
+	var board Leaderboard
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var tournament models.Tournament
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tournament, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Tournament not found")
+		}
+		if tournament.Status == "cancelled" || tournament.Status == "completed" {
+			return newHTTPError(http.StatusBadRequest, "Tournament is "+tournament.Status)
+		}
+		if len(req.Payouts) > 0 && tournament.PrizePool == nil {
+			return newHTTPError(http.StatusBadRequest, "Tournament has no prize pool")
+		}
+
+		board, err = buildLeaderboard(tx, tournament, req.Mode, flights)
+		if err != nil {
+			return err
+		}
+
+		// The pool is shared equally between flights
+		pool := 0.0
+		if tournament.PrizePool != nil {
+			pool = *tournament.PrizePool / float64(len(board.Flights))
+		}
+
+		// Only players who completed every round take a final position
+		for f, flight := range board.Flights {
+			var finished, unfinished []LeaderboardEntry
+			for _, entry := range flight.Entries {
+				if entry.Finished {
+					finished = append(finished, entry)
+				} else {
+					entry.Position, entry.Display = 0, ""
+					unfinished = append(unfinished, entry)
+				}
+			}
+			finished = rankEntries(finished)
+
+			for i, entry := range finished {
+				updates := map[string]interface{}{
+					"final_score":    entry.Strokes,
+					"final_position": entry.Position,
+					"prize_amount":   nil,
+				}
+				if prize := finishPrize(finished, i, req.Payouts, pool); prize > 0 {
+					updates["prize_amount"] = prize
+				}
+				if err := tx.Model(&models.TournamentParticipant{}).Where("id = ?", entry.ParticipantID).
+					Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			for _, entry := range unfinished {
+				updates := map[string]interface{}{
+					"final_score":    nil,
+					"final_position": nil,
+					"prize_amount":   nil,
+				}
+				if entry.Started {
+					updates["final_score"] = entry.Strokes
+				}
+				if err := tx.Model(&models.TournamentParticipant{}).Where("id = ?", entry.ParticipantID).
+					Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			board.Flights[f].Entries = append(finished, unfinished...)
+		}
+
+		board.Status = "completed"
+		return tx.Model(&tournament).Update("status", "completed").Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to finalize tournament")
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// finishPrize is the prize for the entry at index i of a ranked field.
+// Players still tied after card-off share the payouts for the places they
+// occupy.
+func finishPrize(entries []LeaderboardEntry, i int, payouts []float64, pool float64) float64 {
+	position := entries[i].Position
+	tied := 0
+	for _, entry := range entries {
+		if entry.Position == position {
+			tied++
+		}
+	}
+	total := 0.0
+	for place := position; place < position+tied && place <= len(payouts); place++ {
+		total += payouts[place-1]
+	}
+	return roundCurrency(pool * total / 100 / float64(tied))
+}
@@ -0,0 +1,1043 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/handicap"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/scoring"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeagueHandler struct{}
+
+func NewLeagueHandler() *LeagueHandler {
+	return &LeagueHandler{}
+}
+
+type LeagueRequest struct {
+	Name                string    `json:"name" binding:"required"`
+	Description         string    `json:"description"`
+	CourseID            uint      `json:"course_id" binding:"required"`
+	DayOfWeek           *int      `json:"day_of_week" binding:"required,min=0,max=6"`
+	FirstTeeTime        string    `json:"first_tee_time" binding:"required"`
+	TeeTimes            int       `json:"tee_times" binding:"omitempty,min=1,max=40"`
+	ScoringFormat       string    `json:"scoring_format" binding:"omitempty,oneof=net_stroke stableford"`
+	HandicapAllowance   *float64  `json:"handicap_allowance" binding:"omitempty,gt=0,lte=100"`
+	PointsTable         []float64 `json:"points_table" binding:"dive,min=0"`
+	ParticipationPoints *float64  `json:"participation_points" binding:"omitempty,min=0"`
+	AbsencePoints       *float64  `json:"absence_points" binding:"omitempty,min=0"`
+	IsActive            *bool     `json:"is_active"`
+}
+
+type LeagueSeasonRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	StartDate string   `json:"start_date" binding:"required"`
+	EndDate   string   `json:"end_date" binding:"required"`
+	SkipDates []string `json:"skip_dates"`
+}
+
+type LeagueMemberRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+type LeagueAbsenceRequest struct {
+	MemberID         uint   `json:"member_id"`
+	SubstituteUserID *uint  `json:"substitute_user_id"`
+	Reason           string `json:"reason"`
+}
+
+type LeagueStanding struct {
+	MemberID    uint     `json:"member_id"`
+	UserID      uint     `json:"user_id"`
+	Name        string   `json:"name"`
+	Active      bool     `json:"active"`
+	Points      float64  `json:"points"`
+	Played      int      `json:"played"`
+	Substituted int      `json:"substituted"`
+	Absent      int      `json:"absent"`
+	NoShows     int      `json:"no_shows"`
+	Wins        int      `json:"wins"`
+	BestNet     *int     `json:"best_net,omitempty"`
+	AverageNet  *float64 `json:"average_net,omitempty"`
+	Position    int      `json:"position"`
+}
+
+type LeagueStandings struct {
+	SeasonID       uint             `json:"season_id"`
+	Season         string           `json:"season"`
+	League         string           `json:"league"`
+	Status         string           `json:"status"`
+	WeeksCompleted int              `json:"weeks_completed"`
+	WeeksRemaining int              `json:"weeks_remaining"`
+	Standings      []LeagueStanding `json:"standings"`
+}
+
+// parsePointsTable reads a league's points for first, second, third place
+// and so on.
+func parsePointsTable(value string) []float64 {
+	var table []float64
+	for _, part := range strings.Split(value, ",") {
+		if points, err := strconv.ParseFloat(strings.TrimSpace(part), 64); err == nil {
+			table = append(table, points)
+		}
+	}
+	return table
+}
+
+// leagueTeeBlock is the span of the tee sheet a league holds each week.
+func leagueTeeBlock(league models.League) (int, int, error) {
+	first, err := parseClock(league.FirstTeeTime)
+	if err != nil {
+		return 0, 0, newHTTPError(http.StatusBadRequest, "Invalid first tee time")
+	}
+	if first < teeSheetOpen || (first-teeSheetOpen)%teeSheetInterval != 0 {
+		return 0, 0, newHTTPError(http.StatusBadRequest, "First tee time must be a tee sheet slot")
+	}
+	end := first + max(league.TeeTimes, 1)*teeSheetInterval
+	if end > teeSheetClose {
+		return 0, 0, newHTTPError(http.StatusBadRequest, "League tee times run past the last tee time")
+	}
+	return first, end, nil
+}
+
+func applyLeagueRequest(db *gorm.DB, league *models.League, req LeagueRequest) error {
+	var course models.Course
+	if err := db.First(&course, req.CourseID).Error; err != nil {
+		return newHTTPError(http.StatusBadRequest, "Course not found")
+	}
+
+	league.Name = req.Name
+	league.Description = req.Description
+	league.CourseID = req.CourseID
+	league.DayOfWeek = *req.DayOfWeek
+	league.FirstTeeTime = req.FirstTeeTime
+	if req.TeeTimes > 0 {
+		league.TeeTimes = req.TeeTimes
+	}
+	if req.ScoringFormat != "" {
+		league.ScoringFormat = req.ScoringFormat
+	}
+	if req.HandicapAllowance != nil {
+		league.HandicapAllowance = *req.HandicapAllowance
+	}
+	if len(req.PointsTable) > 0 {
+		league.PointsTable = formatNumbers(req.PointsTable)
+	}
+	if req.ParticipationPoints != nil {
+		league.ParticipationPoints = *req.ParticipationPoints
+	}
+	if req.AbsencePoints != nil {
+		league.AbsencePoints = *req.AbsencePoints
+	}
+
+	if _, _, err := leagueTeeBlock(*league); err != nil {
+		return err
+	}
+	first, _ := parseClock(league.FirstTeeTime)
+	league.FirstTeeTime = formatClock(first)
+	return nil
+}
+
+// seasonDates lists the league's day of the week between two dates,
+// leaving out any skipped dates.
+func seasonDates(league models.League, start, end time.Time, skip map[string]bool) []time.Time {
+	var dates []time.Time
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		if int(date.Weekday()) == league.DayOfWeek && !skip[date.Format("2006-01-02")] {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+func leagueRequestID(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// loadWeekLeague fetches a week together with its season and league.
+func loadWeekLeague(tx *gorm.DB, week models.LeagueWeek) (models.LeagueSeason, models.League, error) {
+	var season models.LeagueSeason
+	var league models.League
+	if err := tx.First(&season, week.SeasonID).Error; err != nil {
+		return season, league, err
+	}
+	if err := tx.First(&league, season.LeagueID).Error; err != nil {
+		return season, league, err
+	}
+	return season, league, nil
+}
+
+// leagueScorecards picks each player's completed round on the league course
+// that day, the latest if there is more than one.
+func leagueScorecards(tx *gorm.DB, league models.League, date time.Time, userIDs []uint, holes int) (map[uint]models.Scorecard, error) {
+	cards := make(map[uint]models.Scorecard, len(userIDs))
+	if len(userIDs) == 0 {
+		return cards, nil
+	}
+	var scorecards []models.Scorecard
+	if err := tx.Preload("User").Preload("Course").Preload("TeeSet").Preload("Holes.Hole").
+		Where("course_id = ? AND played_date = ? AND user_id IN ?", league.CourseID, date, userIDs).
+		Order("id ASC").Find(&scorecards).Error; err != nil {
+		return nil, err
+	}
+	for _, scorecard := range scorecards {
+		played := 0
+		for _, hole := range scorecard.Holes {
+			if hole.Strokes > 0 {
+				played++
+			}
+		}
+		if played == holes {
+			cards[scorecard.UserID] = scorecard
+		}
+	}
+	return cards, nil
+}
+
+// scoreLeagueWeek works out every roster member's result for a week.
+// Players are ranked on net score or Stableford points with the league's
+// handicap allowance; a substitute plays for the member they replace.
+func scoreLeagueWeek(tx *gorm.DB, week models.LeagueWeek, league models.League) ([]models.LeagueResult, error) {
+	var members []models.LeagueMember
+	if err := tx.Where("season_id = ? AND is_active = ?", week.SeasonID, true).Order("id ASC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	var absences []models.LeagueAbsence
+	if err := tx.Where("week_id = ?", week.ID).Find(&absences).Error; err != nil {
+		return nil, err
+	}
+	absent := make(map[uint]models.LeagueAbsence, len(absences))
+	for _, absence := range absences {
+		absent[absence.MemberID] = absence
+	}
+
+	courseHoles, err := loadCourseHoles(tx, league.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(courseHoles) == 0 {
+		return nil, newHTTPError(http.StatusBadRequest, "League course has no holes set up")
+	}
+	holes := scoringHoles(courseHoles)
+
+	results := make([]models.LeagueResult, len(members))
+	var players []uint
+	for i, member := range members {
+		results[i] = models.LeagueResult{WeekID: week.ID, MemberID: member.ID, Status: "played"}
+		player := member.UserID
+		if absence, ok := absent[member.ID]; ok {
+			if absence.SubstituteUserID == nil {
+				results[i].Status = "absent"
+				results[i].Points = league.AbsencePoints
+				continue
+			}
+			results[i].Status = "substitute"
+			player = *absence.SubstituteUserID
+		}
+		results[i].UserID = &player
+		players = append(players, player)
+	}
+
+	cards, err := leagueScorecards(tx, league, week.PlayDate, players, len(courseHoles))
+	if err != nil {
+		return nil, err
+	}
+
+	allowance := league.HandicapAllowance / 100
+	stableford := league.ScoringFormat == "stableford"
+	var ranked []int
+	var scores []int
+	for i := range results {
+		if results[i].UserID == nil {
+			continue
+		}
+		scorecard, ok := cards[*results[i].UserID]
+		if !ok {
+			results[i].Status = "no_show"
+			continue
+		}
+
+		playing := 0
+		if _, ch := scorecardCourseHandicap(scorecard, len(courseHoles)); ch != nil {
+			playing = handicap.PlayingHandicap(*ch, allowance)
+		}
+		card := scoringCard(scorecard, playing)
+		gross := scoring.StrokePlay(holes, card, false).Total
+		net := gross - playing
+		points := scoring.Stableford(holes, card, true).Total
+
+		results[i].ScorecardID = &scorecard.ID
+		results[i].GrossScore = &gross
+		results[i].PlayingHandicap = &playing
+		results[i].NetScore = &net
+		results[i].StablefordPoints = &points
+		ranked = append(ranked, i)
+		if stableford {
+			scores = append(scores, points)
+		} else {
+			scores = append(scores, net)
+		}
+	}
+
+	positions, points := scoring.PositionPoints(scores, stableford, parsePointsTable(league.PointsTable))
+	for n, i := range ranked {
+		position := positions[n]
+		results[i].Position = &position
+		results[i].Points = roundCurrency(points[n] + league.ParticipationPoints)
+	}
+	return results, nil
+}
+
+// buildLeagueStandings totals the points from every completed week of a
+// season. Ties on points share a position.
+func buildLeagueStandings(db *gorm.DB, season models.LeagueSeason) (LeagueStandings, error) {
+	standings := LeagueStandings{
+		SeasonID:  season.ID,
+		Season:    season.Name,
+		Status:    season.Status,
+		Standings: []LeagueStanding{},
+	}
+	if season.League != nil {
+		standings.League = season.League.Name
+	}
+
+	var weeks []models.LeagueWeek
+	if err := db.Where("season_id = ?", season.ID).Find(&weeks).Error; err != nil {
+		return standings, err
+	}
+	var completed []uint
+	for _, week := range weeks {
+		switch week.Status {
+		case "completed":
+			completed = append(completed, week.ID)
+			standings.WeeksCompleted++
+		case "scheduled":
+			standings.WeeksRemaining++
+		}
+	}
+
+	var members []models.LeagueMember
+	if err := db.Preload("User").Where("season_id = ?", season.ID).Order("id ASC").Find(&members).Error; err != nil {
+		return standings, err
+	}
+	var results []models.LeagueResult
+	if len(completed) > 0 {
+		if err := db.Where("week_id IN ?", completed).Find(&results).Error; err != nil {
+			return standings, err
+		}
+	}
+
+	byMember := make(map[uint]*LeagueStanding, len(members))
+	nets := make(map[uint][]int, len(members))
+	for _, member := range members {
+		byMember[member.ID] = &LeagueStanding{
+			MemberID: member.ID,
+			UserID:   member.UserID,
+			Name:     member.User.FirstName + " " + member.User.LastName,
+			Active:   member.IsActive,
+		}
+	}
+	for _, result := range results {
+		standing, ok := byMember[result.MemberID]
+		if !ok {
+			continue
+		}
+		standing.Points += result.Points
+		switch result.Status {
+		case "played":
+			standing.Played++
+			if result.NetScore != nil {
+				nets[result.MemberID] = append(nets[result.MemberID], *result.NetScore)
+			}
+		case "substitute":
+			standing.Substituted++
+		case "absent":
+			standing.Absent++
+		case "no_show":
+			standing.NoShows++
+		}
+		if result.Position != nil && *result.Position == 1 {
+			standing.Wins++
+		}
+	}
+
+	for _, member := range members {
+		standing := byMember[member.ID]
+		// Members who left without playing drop off the table
+		if !standing.Active && len(nets[member.ID]) == 0 && standing.Points == 0 {
+			continue
+		}
+		if scores := nets[member.ID]; len(scores) > 0 {
+			best, total := scores[0], 0
+			for _, score := range scores {
+				best = min(best, score)
+				total += score
+			}
+			average := math.Round(float64(total)/float64(len(scores))*10) / 10
+			standing.BestNet = &best
+			standing.AverageNet = &average
+		}
+		standing.Points = roundCurrency(standing.Points)
+		standings.Standings = append(standings.Standings, *standing)
+	}
+
+	sort.SliceStable(standings.Standings, func(i, j int) bool {
+		a, b := standings.Standings[i], standings.Standings[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		return a.Name < b.Name
+	})
+	for i := range standings.Standings {
+		if i > 0 && standings.Standings[i].Points == standings.Standings[i-1].Points {
+			standings.Standings[i].Position = standings.Standings[i-1].Position
+		} else {
+			standings.Standings[i].Position = i + 1
+		}
+	}
+	return standings, nil
+}
+
+// @Summary List leagues
+// @Description Active leagues with their course and seasons
+// @Tags leagues
+// @Produce json
+// @Param course_id query int false "Course ID"
+// @Success 200 {array} models.League
+// @Router /leagues [get]
+func (h *LeagueHandler) GetLeagues(c *gin.Context) {
+	query := database.DB.Preload("Course").Preload("Seasons", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_date DESC")
+	}).Where("is_active = ?", true)
+	if courseID := c.Query("course_id"); courseID != "" {
+		query = query.Where("course_id = ?", courseID)
+	}
+
+	var leagues []models.League
+	if err := query.Order("name ASC").Find(&leagues).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leagues"})
+		return
+	}
+	c.JSON(http.StatusOK, leagues)
+}
+
+// @Summary Get league
+// @Description League details with its seasons
+// @Tags leagues
+// @Produce json
+// @Param id path int true "League ID"
+// @Success 200 {object} models.League
+// @Failure 404 {object} map[string]string
+// @Router /leagues/{id} [get]
+func (h *LeagueHandler) GetLeague(c *gin.Context) {
+	id, ok := leagueRequestID(c, "league")
+	if !ok {
+		return
+	}
+
+	var league models.League
+	if err := database.DB.Preload("Course").Preload("Seasons", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_date DESC")
+	}).First(&league, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "League not found"})
+		return
+	}
+	c.JSON(http.StatusOK, league)
+}
+
+// @Summary Get league season
+// @Description A season's roster and weekly schedule
+// @Tags leagues
+// @Produce json
+// @Param id path int true "Season ID"
+// @Success 200 {object} models.LeagueSeason
+// @Failure 404 {object} map[string]string
+// @Router /league-seasons/{id} [get]
+func (h *LeagueHandler) GetSeason(c *gin.Context) {
+	id, ok := leagueRequestID(c, "season")
+	if !ok {
+		return
+	}
+
+	var season models.LeagueSeason
+	if err := database.DB.Preload("League.Course").
+		Preload("Members", "is_active = ?", true).Preload("Members.User").
+		Preload("Weeks", func(db *gorm.DB) *gorm.DB { return db.Order("week_number ASC") }).
+		First(&season, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Season not found"})
+		return
+	}
+	c.JSON(http.StatusOK, season)
+}
+
+// @Summary Get league standings
+// @Description Cumulative points table for a season from its completed weeks
+// @Tags leagues
+// @Produce json
+// @Param id path int true "Season ID"
+// @Success 200 {object} LeagueStandings
+// @Failure 404 {object} map[string]string
+// @Router /league-seasons/{id}/standings [get]
+func (h *LeagueHandler) GetStandings(c *gin.Context) {
+	id, ok := leagueRequestID(c, "season")
+	if !ok {
+		return
+	}
+
+	db := database.DB
+	var season models.LeagueSeason
+	if err := db.Preload("League").First(&season, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Season not found"})
+		return
+	}
+
+	standings, err := buildLeagueStandings(db, season)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build standings"})
+		return
+	}
+	c.JSON(http.StatusOK, standings)
+}
+
+// @Summary Get league week
+// @Description A week's absences, substitutes and results
+// @Tags leagues
+// @Produce json
+// @Param id path int true "Week ID"
+// @Success 200 {object} models.LeagueWeek
+// @Failure 404 {object} map[string]string
+// @Router /league-weeks/{id} [get]
+func (h *LeagueHandler) GetWeek(c *gin.Context) {
+	id, ok := leagueRequestID(c, "week")
+	if !ok {
+		return
+	}
+
+	var week models.LeagueWeek
+	if err := database.DB.Preload("Season.League").
+		Preload("Absences.Member.User").Preload("Absences.Substitute").
+		Preload("Results", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position IS NULL, position ASC, id ASC")
+		}).
+		Preload("Results.Member.User").Preload("Results.User").
+		First(&week, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Week not found"})
+		return
+	}
+	c.JSON(http.StatusOK, week)
+}
+
+// @Summary Report a league absence
+// @Description Mark the authenticated member absent for a week, optionally naming a substitute. Staff may report for any member.
+// @Tags leagues
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Week ID"
+// @Param absence body LeagueAbsenceRequest true "Absence"
+// @Success 200 {object} models.LeagueAbsence
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /league-weeks/{id}/absence [post]
+func (h *LeagueHandler) ReportAbsence(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, ok := leagueRequestID(c, "week")
+	if !ok {
+		return
+	}
+
+	var req LeagueAbsenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role := c.GetString("user_role")
+	if req.MemberID != 0 && role != "staff" && role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only staff can report absences for other members"})
+		return
+	}
+
+	var absence models.LeagueAbsence
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var week models.LeagueWeek
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&week, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Week not found")
+		}
+		if week.Status != "scheduled" {
+			return newHTTPError(http.StatusBadRequest, "Week is "+week.Status)
+		}
+
+		var member models.LeagueMember
+		query := tx.Where("season_id = ? AND is_active = ?", week.SeasonID, true)
+		if req.MemberID != 0 {
+			query = query.Where("id = ?", req.MemberID)
+		} else {
+			query = query.Where("user_id = ?", userID.(uint))
+		}
+		if err := query.First(&member).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Not on this season's roster")
+		}
+
+		if req.SubstituteUserID != nil {
+			var substitute models.User
+			if err := tx.First(&substitute, *req.SubstituteUserID).Error; err != nil {
+				return newHTTPError(http.StatusBadRequest, "Substitute not found")
+			}
+			var count int64
+			tx.Model(&models.LeagueMember{}).
+				Where("season_id = ? AND user_id = ? AND is_active = ?", week.SeasonID, substitute.ID, true).Count(&count)
+			if count > 0 {
+				return newHTTPError(http.StatusBadRequest, "Substitute is already on the roster")
+			}
+			tx.Model(&models.LeagueAbsence{}).
+				Where("week_id = ? AND substitute_user_id = ? AND member_id <> ?", week.ID, substitute.ID, member.ID).Count(&count)
+			if count > 0 {
+				return newHTTPError(http.StatusConflict, "Substitute is already playing for another member")
+			}
+		}
+
+		absence = models.LeagueAbsence{
+			WeekID:           week.ID,
+			MemberID:         member.ID,
+			SubstituteUserID: req.SubstituteUserID,
+			Reason:           req.Reason,
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "week_id"}, {Name: "member_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"substitute_user_id", "reason"}),
+		}).Create(&absence).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to report absence")
+		return
+	}
+
+	database.DB.Preload("Member.User").Preload("Substitute").
+		Where("week_id = ? AND member_id = ?", absence.WeekID, absence.MemberID).First(&absence)
+	c.JSON(http.StatusOK, absence)
+}
+
+// @Summary Withdraw a league absence
+// @Description The authenticated member will play the week after all
+// @Tags leagues
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Week ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /league-weeks/{id}/absence [delete]
+func (h *LeagueHandler) CancelAbsence(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	id, ok := leagueRequestID(c, "week")
+	if !ok {
+		return
+	}
+
+	db := database.DB
+	var week models.LeagueWeek
+	if err := db.First(&week, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Week not found"})
+		return
+	}
+	if week.Status != "scheduled" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Week is " + week.Status})
+		return
+	}
+
+	result := db.Where("week_id = ? AND member_id IN (?)", week.ID,
+		db.Model(&models.LeagueMember{}).Select("id").Where("season_id = ? AND user_id = ?", week.SeasonID, userID.(uint)),
+	).Delete(&models.LeagueAbsence{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw absence"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No absence reported for this week"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Absence withdrawn"})
+}
+
+// Create a league
+func (h *LeagueHandler) CreateLeague(c *gin.Context) {
+	var req LeagueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var league models.League
+	if err := applyLeagueRequest(db, &league, req); err != nil {
+		respondError(c, err, "Failed to create league")
+		return
+	}
+	if err := db.Create(&league).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create league"})
+		return
+	}
+	if req.IsActive != nil && !*req.IsActive {
+		db.Model(&league).Update("is_active", false)
+	}
+
+	c.JSON(http.StatusCreated, league)
+}
+
+// Update a league's schedule and scoring; seasons already drawn keep their tee times
+func (h *LeagueHandler) UpdateLeague(c *gin.Context) {
+	id, ok := leagueRequestID(c, "league")
+	if !ok {
+		return
+	}
+	var req LeagueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var league models.League
+	if err := db.First(&league, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "League not found"})
+		return
+	}
+	if err := applyLeagueRequest(db, &league, req); err != nil {
+		respondError(c, err, "Failed to update league")
+		return
+	}
+	if req.IsActive != nil {
+		league.IsActive = *req.IsActive
+	}
+	if err := db.Save(&league).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update league"})
+		return
+	}
+
+	c.JSON(http.StatusOK, league)
+}
+
+// Delete a league with its seasons, results and tee sheet blocks
+func (h *LeagueHandler) DeleteLeague(c *gin.Context) {
+	id, ok := leagueRequestID(c, "league")
+	if !ok {
+		return
+	}
+
+	result := database.DB.Delete(&models.League{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete league"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "League not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "League deleted successfully"})
+}
+
+// Schedule a season: one week on the league day between the dates, each with its tee times blocked
+func (h *LeagueHandler) CreateSeason(c *gin.Context) {
+	id, ok := leagueRequestID(c, "league")
+	if !ok {
+		return
+	}
+	var req LeagueSeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+		return
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date must not be before the start date"})
+		return
+	}
+	skip := make(map[string]bool, len(req.SkipDates))
+	for _, value := range req.SkipDates {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skip date " + value})
+			return
+		}
+		skip[date.Format("2006-01-02")] = true
+	}
+
+	staffID := c.GetUint("user_id")
+	var season models.LeagueSeason
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var league models.League
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&league, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "League not found")
+		}
+		first, end, err := leagueTeeBlock(league)
+		if err != nil {
+			return err
+		}
+
+		var overlapping int64
+		tx.Model(&models.LeagueSeason{}).
+			Where("league_id = ? AND start_date <= ? AND end_date >= ?", league.ID, endDate, startDate).Count(&overlapping)
+		if overlapping > 0 {
+			return newHTTPError(http.StatusConflict, "Season overlaps another season of this league")
+		}
+
+		dates := seasonDates(league, startDate, endDate, skip)
+		if len(dates) == 0 {
+			return newHTTPError(http.StatusBadRequest, "No league days fall between these dates")
+		}
+
+		season = models.LeagueSeason{LeagueID: league.ID, Name: req.Name, StartDate: startDate, EndDate: endDate}
+		if err := tx.Create(&season).Error; err != nil {
+			return err
+		}
+		for i, date := range dates {
+			week := models.LeagueWeek{SeasonID: season.ID, WeekNumber: i + 1, PlayDate: date}
+			if err := tx.Create(&week).Error; err != nil {
+				return err
+			}
+			if _, err := blockTeeSheet(tx, models.TeeTime{
+				CourseID:        league.CourseID,
+				UserID:          staffID,
+				LeagueWeekID:    &week.ID,
+				BookingDate:     date,
+				SpecialRequests: fmt.Sprintf("League: %s, week %d", league.Name, week.WeekNumber),
+			}, first, end); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create season")
+		return
+	}
+
+	database.DB.Preload("Weeks", func(db *gorm.DB) *gorm.DB { return db.Order("week_number ASC") }).First(&season, season.ID)
+	c.JSON(http.StatusCreated, season)
+}
+
+// Delete a season and release its tee times
+func (h *LeagueHandler) DeleteSeason(c *gin.Context) {
+	id, ok := leagueRequestID(c, "season")
+	if !ok {
+		return
+	}
+
+	result := database.DB.Delete(&models.LeagueSeason{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete season"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Season not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Season deleted successfully"})
+}
+
+// Add a player to a season's roster
+func (h *LeagueHandler) AddMember(c *gin.Context) {
+	id, ok := leagueRequestID(c, "season")
+	if !ok {
+		return
+	}
+	var req LeagueMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var season models.LeagueSeason
+	if err := db.First(&season, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Season not found"})
+		return
+	}
+	if season.Status == "completed" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Season is completed"})
+		return
+	}
+	var user models.User
+	if err := db.First(&user, req.UserID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Players who left earlier in the season are reinstated
+	member := models.LeagueMember{SeasonID: season.ID, UserID: user.ID, IsActive: true}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "season_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"is_active": true}),
+	}).Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+
+	db.Preload("User").Where("season_id = ? AND user_id = ?", season.ID, user.ID).First(&member)
+	c.JSON(http.StatusCreated, member)
+}
+
+// Take a player off a season's roster; their results so far stay on record
+func (h *LeagueHandler) RemoveMember(c *gin.Context) {
+	id, ok := leagueRequestID(c, "season")
+	if !ok {
+		return
+	}
+
+	db := database.DB
+	result := db.Model(&models.LeagueMember{}).
+		Where("season_id = ? AND user_id = ? AND is_active = ?", id, c.Param("user_id"), true).
+		Update("is_active", false)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed from the roster"})
+}
+
+// Score a week from the rounds posted on league day and award points; re-running replaces earlier results
+func (h *LeagueHandler) ScoreWeek(c *gin.Context) {
+	id, ok := leagueRequestID(c, "week")
+	if !ok {
+		return
+	}
+
+	var week models.LeagueWeek
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&week, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Week not found")
+		}
+		if week.Status == "cancelled" {
+			return newHTTPError(http.StatusBadRequest, "Week is cancelled")
+		}
+		if week.PlayDate.After(time.Now()) {
+			return newHTTPError(http.StatusBadRequest, "Week has not been played yet")
+		}
+		season, league, err := loadWeekLeague(tx, week)
+		if err != nil {
+			return err
+		}
+
+		results, err := scoreLeagueWeek(tx, week, league)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("week_id = ?", week.ID).Delete(&models.LeagueResult{}).Error; err != nil {
+			return err
+		}
+		if len(results) > 0 {
+			if err := tx.Create(&results).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&week).Update("status", "completed").Error; err != nil {
+			return err
+		}
+		return updateSeasonStatus(tx, season)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to score week")
+		return
+	}
+
+	database.DB.Preload("Results", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position IS NULL, position ASC, id ASC")
+	}).Preload("Results.Member.User").Preload("Results.User").First(&week, week.ID)
+	c.JSON(http.StatusOK, week)
+}
+
+// Cancel a week, e.g. for weather, releasing its tee times
+func (h *LeagueHandler) CancelWeek(c *gin.Context) {
+	id, ok := leagueRequestID(c, "week")
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var week models.LeagueWeek
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&week, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Week not found")
+		}
+		if week.Status == "cancelled" {
+			return newHTTPError(http.StatusBadRequest, "Week is already cancelled")
+		}
+		season, _, err := loadWeekLeague(tx, week)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("week_id = ?", week.ID).Delete(&models.LeagueResult{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("league_week_id = ? AND booking_status = ?", week.ID, "blocked").Delete(&models.TeeTime{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&week).Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+		return updateSeasonStatus(tx, season)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to cancel week")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Week cancelled"})
+}
+
+// updateSeasonStatus moves a season on once its first week is scored and
+// completes it when no weeks are left to play.
+func updateSeasonStatus(tx *gorm.DB, season models.LeagueSeason) error {
+	var scheduled, completed int64
+	tx.Model(&models.LeagueWeek{}).Where("season_id = ? AND status = ?", season.ID, "scheduled").Count(&scheduled)
+	tx.Model(&models.LeagueWeek{}).Where("season_id = ? AND status = ?", season.ID, "completed").Count(&completed)
+
+	status := "upcoming"
+	switch {
+	case scheduled == 0:
+		status = "completed"
+	case completed > 0:
+		status = "active"
+	}
+	if status == season.Status {
+		return nil
+	}
+	return tx.Model(&season).Update("status", status).Error
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/ledger"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/promotions"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerHandler struct{}
+
+func NewLedgerHandler() *LedgerHandler {
+	return &LedgerHandler{}
+}
+
+type LedgerBackfillResponse struct {
+	EntriesPosted int64 `json:"entries_posted"`
+}
+
+func paymentSourceKey(paymentID uint) string {
+	return fmt.Sprintf("payment:%d", paymentID)
+}
+
+func refundSourceKey(refundID uint) string {
+	return fmt.Sprintf("refund:%d", refundID)
+}
+
+// postJournal writes an entry and its lines to the ledger. Each source is
+// posted once; posting it again does nothing.
+func postJournal(tx *gorm.DB, entry models.JournalEntry, lines []ledger.Line) error {
+	if err := ledger.Validate(lines); err != nil {
+		return err
+	}
+	result := tx.Omit("Lines").Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	rows := make([]models.JournalLine, len(lines))
+	for i, line := range lines {
+		rows[i] = models.JournalLine{
+			EntryID:     entry.ID,
+			AccountCode: line.Account,
+			Debit:       line.Debit,
+			Credit:      line.Credit,
+		}
+	}
+	return tx.Create(&rows).Error
+}
+
+// saleRevenue works out which revenue accounts a charge is for. A tee time,
+// or a player's share of one, is split between green and cart fees in
+// proportion to the course's fees, less any promo code discount on each.
+func saleRevenue(tx *gorm.DB, payment *models.Payment) map[string]float64 {
+	switch payment.ReferenceType {
+	case "tee_time", "tee_time_share":
+		teeTimeID := payment.ReferenceID
+		if payment.ReferenceType == "tee_time_share" {
+			var share models.TeeTimeShare
+			if err := tx.First(&share, payment.ReferenceID).Error; err == nil {
+				teeTimeID = share.TeeTimeID
+			}
+		}
+		var teeTime models.TeeTime
+		if err := tx.Preload("Course").First(&teeTime, teeTimeID).Error; err == nil {
+			pricing := redemptionBreakdown(tx, "tee_time", teeTime.ID)
+			weights := map[string]float64{
+				ledger.GreenFees: teeTime.Course.GreenFee * float64(teeTime.PlayersCount),
+			}
+			if pricing != nil {
+				weights[ledger.GreenFees] -= pricing.DiscountFor(promotions.GreenFee)
+			}
+			if teeTime.CartRequired {
+				weights[ledger.CartFees] = teeTime.Course.CartFee * float64(max(teeTime.CartCount, 1))
+				if pricing != nil {
+					weights[ledger.CartFees] -= pricing.DiscountFor(promotions.Cart)
+				}
+			}
+			if weights[ledger.GreenFees]+weights[ledger.CartFees] > 0 {
+				return weights
+			}
+		}
+		return map[string]float64{ledger.GreenFees: 1}
+	case "range_session":
+		return map[string]float64{ledger.RangeSales: 1}
+	case "equipment_rental":
+		return map[string]float64{ledger.EquipmentRentals: 1}
+	case "tournament":
+		return map[string]float64{ledger.TournamentFees: 1}
+	}
+	return map[string]float64{ledger.OtherRevenue: 1}
+}
+
+// postPaymentLedger records a payment that has gone through.
+func postPaymentLedger(tx *gorm.DB, payment *models.Payment, postedAt time.Time) error {
+	entry := models.JournalEntry{
+		SourceKey:     paymentSourceKey(payment.ID),
+		PaymentID:     &payment.ID,
+		ReferenceType: payment.ReferenceType,
+		ReferenceID:   &payment.ReferenceID,
+		PostedAt:      postedAt,
+	}
+
+	var lines []ledger.Line
+	switch payment.PaymentType {
+	case "charge":
+		if payment.ReferenceType == "gift_card" {
+			entry.EntryType = "gift_card_sold"
+			entry.Description = fmt.Sprintf("Gift card #%d sold", payment.ReferenceID)
+			lines = ledger.GiftCardSold(payment.Amount)
+			break
+		}
+		entry.EntryType = "sale"
+		entry.Description = fmt.Sprintf("Payment for %s #%d", payment.ReferenceType, payment.ReferenceID)
+		lines = ledger.TenderedSale(paymentTender(payment), payment.Amount, getSettingFloat("sales_tax_rate", 0), saleRevenue(tx, payment))
+	case "deposit":
+		entry.EntryType = "deposit_received"
+		entry.Description = fmt.Sprintf("Deposit for %s #%d", payment.ReferenceType, payment.ReferenceID)
+		lines = ledger.DepositReceived(payment.Amount)
+	case "damage_charge":
+		entry.Description = fmt.Sprintf("Damage charge for %s #%d", payment.ReferenceType, payment.ReferenceID)
+		if payment.FromDeposit {
+			// Kept out of the rental's deposit
+			entry.EntryType = "deposit_applied"
+			lines = ledger.DepositApplied(payment.Amount, ledger.DamageCharges)
+			break
+		}
+		// Damage the deposit did not cover, paid like any other sale
+		entry.EntryType = "sale"
+		lines = ledger.TenderedSale(paymentTender(payment), payment.Amount, 0, map[string]float64{ledger.DamageCharges: 1})
+	case "deposit_refund":
+		// Deposit returns recorded before refunds existed
+		entry.EntryType = "deposit_returned"
+		entry.Description = fmt.Sprintf("Deposit returned for %s #%d", payment.ReferenceType, payment.ReferenceID)
+		lines = ledger.DepositReturned(payment.Amount)
+	default:
+		return nil
+	}
+	return postJournal(tx, entry, lines)
+}
+
+// postRefundLedger records a refund paid out. Tax is given back in the same
+// proportion it was collected on the original payment.
+func postRefundLedger(tx *gorm.DB, refund *models.Refund, payment *models.Payment, postedAt time.Time) error {
+	entry := models.JournalEntry{
+		SourceKey:     refundSourceKey(refund.ID),
+		PaymentID:     &payment.ID,
+		RefundID:      &refund.ID,
+		ReferenceType: payment.ReferenceType,
+		ReferenceID:   &payment.ReferenceID,
+		PostedAt:      postedAt,
+	}
+
+	if payment.PaymentType == "deposit" {
+		entry.EntryType = "deposit_returned"
+		entry.Description = fmt.Sprintf("Deposit returned for %s #%d", payment.ReferenceType, payment.ReferenceID)
+		return postJournal(tx, entry, ledger.DepositReturned(refund.Amount))
+	}
+
+	var taxCollected float64
+	if err := tx.Model(&models.JournalLine{}).Select("COALESCE(SUM(journal_lines.credit), 0)").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+		Where("journal_entries.source_key = ? AND journal_lines.account_code = ?", paymentSourceKey(payment.ID), ledger.SalesTax).
+		Scan(&taxCollected).Error; err != nil {
+		return err
+	}
+	var tax float64
+	if payment.Amount > 0 {
+		tax = roundCurrency(refund.Amount * taxCollected / payment.Amount)
+	}
+
+	entry.EntryType = "refund"
+	entry.Description = fmt.Sprintf("Refund (%s) for %s #%d", refund.ReasonCode, payment.ReferenceType, payment.ReferenceID)
+	return postJournal(tx, entry, ledger.TenderedRefund(paymentTender(payment), refund.Amount, tax))
+}
+
+// paymentTender is the account money for a payment came from: a gift card
+// balance, or otherwise cash and card takings.
+func paymentTender(payment *models.Payment) string {
+	if payment.PaymentMethod == "gift_card" {
+		return ledger.GiftCards
+	}
+	return ledger.Cash
+}
+
+// ledgerTotals sums debits and credits per account for entries posted in
+// [from, to). Either bound may be zero.
+func ledgerTotals(from, to time.Time) (map[string]ledger.Totals, error) {
+	query := database.DB.Model(&models.JournalLine{}).
+		Select("journal_lines.account_code AS account_code, COALESCE(SUM(journal_lines.debit), 0) AS debit, COALESCE(SUM(journal_lines.credit), 0) AS credit").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+		Group("journal_lines.account_code")
+	if !from.IsZero() {
+		query = query.Where("journal_entries.posted_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("journal_entries.posted_at < ?", to)
+	}
+
+	var rows []struct {
+		AccountCode string
+		Debit       float64
+		Credit      float64
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	totals := make(map[string]ledger.Totals, len(rows))
+	for _, row := range rows {
+		totals[row.AccountCode] = ledger.Totals{Debit: row.Debit, Credit: row.Credit}
+	}
+	return totals, nil
+}
+
+// ledgerPeriod reads an inclusive from/to date range from the query string.
+func ledgerPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	if value := c.Query("from"); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format"})
+			return from, to, false
+		}
+		from = date
+	}
+	if value := c.Query("to"); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format"})
+			return from, to, false
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+	return from, to, true
+}
+
+// Revenue by account, less refunds, from the ledger
+func (h *LedgerHandler) GetRevenueReport(c *gin.Context) {
+	from, to, ok := ledgerPeriod(c)
+	if !ok {
+		return
+	}
+	totals, err := ledgerTotals(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build revenue report"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":   c.Query("from"),
+		"to":     c.Query("to"),
+		"report": ledger.BuildRevenueReport(totals),
+	})
+}
+
+// Every account's balance, to check the ledger balances
+func (h *LedgerHandler) GetTrialBalance(c *gin.Context) {
+	from, to, ok := ledgerPeriod(c)
+	if !ok {
+		return
+	}
+	totals, err := ledgerTotals(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build trial balance"})
+		return
+	}
+	c.JSON(http.StatusOK, ledger.BuildTrialBalance(totals))
+}
+
+// Journal entries with their lines, newest first
+func (h *LedgerHandler) GetJournalEntries(c *gin.Context) {
+	from, to, ok := ledgerPeriod(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Preload("Lines")
+	if !from.IsZero() {
+		query = query.Where("posted_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("posted_at < ?", to)
+	}
+	if account := c.Query("account"); account != "" {
+		query = query.Where("id IN (?)", database.DB.Model(&models.JournalLine{}).
+			Select("entry_id").Where("account_code = ?", account))
+	}
+	if referenceType := c.Query("reference_type"); referenceType != "" {
+		query = query.Where("reference_type = ? AND reference_id = ?", referenceType, c.Query("reference_id"))
+	}
+
+	var entries []models.JournalEntry
+	if err := query.Order("posted_at DESC, id DESC").Limit(500).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch journal entries"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// Post any payments and refunds made before the ledger existed
+func (h *LedgerHandler) BackfillLedger(c *gin.Context) {
+	db := database.DB
+	var before, after int64
+	db.Model(&models.JournalEntry{}).Count(&before)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var paid []models.Payment
+		if err := tx.Where("payment_status IN ?", []string{"succeeded", "partially_refunded", "refunded"}).
+			Where("payment_type <> ? OR payment_status = ?", "deposit_refund", "refunded").
+			Order("id ASC").Find(&paid).Error; err != nil {
+			return err
+		}
+		for i := range paid {
+			postedAt := paid[i].CreatedAt
+			if paid[i].ProcessedAt != nil {
+				postedAt = *paid[i].ProcessedAt
+			}
+			if err := postPaymentLedger(tx, &paid[i], postedAt); err != nil {
+				return err
+			}
+		}
+
+		var refunds []models.Refund
+		if err := tx.Preload("Payment").Where("refund_status = ?", "succeeded").
+			Order("id ASC").Find(&refunds).Error; err != nil {
+			return err
+		}
+		for i := range refunds {
+			postedAt := refunds[i].CreatedAt
+			if refunds[i].ProcessedAt != nil {
+				postedAt = *refunds[i].ProcessedAt
+			}
+			if err := postRefundLedger(tx, &refunds[i], refunds[i].Payment, postedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to backfill ledger"})
+		return
+	}
+
+	db.Model(&models.JournalEntry{}).Count(&after)
+	c.JSON(http.StatusOK, LedgerBackfillResponse{EntriesPosted: after - before})
+}
@@ -0,0 +1,529 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MaintenanceHandler struct{}
+
+func NewMaintenanceHandler() *MaintenanceHandler {
+	return &MaintenanceHandler{}
+}
+
+type WorkOrderRequest struct {
+	AssetType   string  `json:"asset_type" binding:"required,oneof=equipment golf_cart"`
+	EquipmentID *uint   `json:"equipment_id"`
+	VariantID   *uint   `json:"variant_id"`
+	CartID      *uint   `json:"cart_id"`
+	Quantity    int     `json:"quantity" binding:"omitempty,min=1"`
+	Title       string  `json:"title" binding:"required"`
+	Description string  `json:"description"`
+	Priority    string  `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	AssigneeID  *uint   `json:"assignee_id"`
+	LaborCost   float64 `json:"labor_cost" binding:"min=0"`
+}
+
+type WorkOrderUpdateRequest struct {
+	Title       *string  `json:"title"`
+	Description *string  `json:"description"`
+	Priority    *string  `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	Status      *string  `json:"status" binding:"omitempty,oneof=open in_progress"`
+	AssigneeID  *uint    `json:"assignee_id"`
+	LaborCost   *float64 `json:"labor_cost" binding:"omitempty,min=0"`
+}
+
+type WorkOrderPartRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	PartNumber string  `json:"part_number"`
+	Quantity   int     `json:"quantity" binding:"omitempty,min=1"`
+	UnitCost   float64 `json:"unit_cost" binding:"min=0"`
+}
+
+type WorkOrderCloseRequest struct {
+	Resolution string   `json:"resolution" binding:"required,oneof=repaired replaced written_off"`
+	Notes      string   `json:"notes"`
+	LaborCost  *float64 `json:"labor_cost" binding:"omitempty,min=0"`
+}
+
+type MaintenanceHistory struct {
+	WorkOrders  []models.WorkOrder `json:"work_orders"`
+	OpenCount   int                `json:"open_count"`
+	ClosedCount int                `json:"closed_count"`
+	TotalCost   float64            `json:"total_cost"`
+	LastClosed  *time.Time         `json:"last_closed,omitempty"`
+}
+
+// Work order statuses that keep an asset out of service
+var openWorkOrderStatuses = []string{"open", "in_progress"}
+
+// openWorkOrder saves a new work order and takes its asset out of service:
+// equipment units leave the rentable stock and carts go to maintenance.
+// alreadyWithdrawn is the number of units that are already off the shelf,
+// e.g. damaged units that were never restocked after a rental.
+func openWorkOrder(tx *gorm.DB, order *models.WorkOrder, alreadyWithdrawn int) error {
+	if order.Priority == "" {
+		order.Priority = "medium"
+	}
+	if order.Quantity <= 0 {
+		order.Quantity = 1
+	}
+	order.Status = "open"
+
+	switch order.AssetType {
+	case "equipment":
+		if order.EquipmentID == nil {
+			return newHTTPError(http.StatusBadRequest, "equipment_id is required for equipment work orders")
+		}
+		order.CartID = nil
+		toWithdraw := order.Quantity - alreadyWithdrawn
+		if toWithdraw > 0 {
+			if err := reserveEquipmentStock(tx, *order.EquipmentID, order.VariantID, toWithdraw); err != nil {
+				if errors.Is(err, errInsufficientStock) {
+					return newHTTPError(http.StatusConflict, "Not enough units on the shelf to take out of service")
+				}
+				return err
+			}
+		}
+		order.UnitsWithdrawn = order.Quantity
+	case "golf_cart":
+		if order.CartID == nil {
+			return newHTTPError(http.StatusBadRequest, "cart_id is required for cart work orders")
+		}
+		order.EquipmentID = nil
+		order.VariantID = nil
+		order.Quantity = 1
+
+		var cart models.GolfCart
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cart, *order.CartID).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Cart not found")
+		}
+		if cart.Status == "in_use" && alreadyWithdrawn == 0 {
+			return newHTTPError(http.StatusBadRequest, "Cart is out on the course; return it first")
+		}
+		if err := tx.Model(&cart).Update("status", "maintenance").Error; err != nil {
+			return err
+		}
+	}
+
+	order.TotalCost = roundCurrency(order.LaborCost + order.PartsCost)
+	return tx.Create(order).Error
+}
+
+// validateWorkOrderAsset checks that the asset a work order points at exists.
+func validateWorkOrderAsset(db *gorm.DB, req WorkOrderRequest) error {
+	switch req.AssetType {
+	case "equipment":
+		if req.EquipmentID == nil {
+			return newHTTPError(http.StatusBadRequest, "equipment_id is required for equipment work orders")
+		}
+		var equipment models.Equipment
+		if err := db.First(&equipment, *req.EquipmentID).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Equipment not found")
+		}
+		if req.VariantID != nil {
+			var variant models.EquipmentVariant
+			if err := db.Where("id = ? AND equipment_id = ?", *req.VariantID, equipment.ID).First(&variant).Error; err != nil {
+				return newHTTPError(http.StatusBadRequest, "Variant does not belong to this equipment")
+			}
+		}
+	case "golf_cart":
+		if req.CartID == nil {
+			return newHTTPError(http.StatusBadRequest, "cart_id is required for cart work orders")
+		}
+	}
+	return nil
+}
+
+// validateAssignee makes sure work is only assigned to staff or admins.
+func validateAssignee(db *gorm.DB, assigneeID *uint) error {
+	if assigneeID == nil {
+		return nil
+	}
+	var user models.User
+	if err := db.First(&user, *assigneeID).Error; err != nil {
+		return newHTTPError(http.StatusBadRequest, "Assignee not found")
+	}
+	if user.Role != "staff" && user.Role != "admin" {
+		return newHTTPError(http.StatusBadRequest, "Work orders can only be assigned to staff")
+	}
+	return nil
+}
+
+func preloadWorkOrder(db *gorm.DB) *gorm.DB {
+	return db.Preload("Equipment").Preload("Variant").Preload("Cart").Preload("Assignee").Preload("Parts")
+}
+
+// Open a work order and take the asset out of service
+func (h *MaintenanceHandler) CreateWorkOrder(c *gin.Context) {
+	var req WorkOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	if err := validateWorkOrderAsset(db, req); err != nil {
+		respondError(c, err, "Failed to create work order")
+		return
+	}
+	if err := validateAssignee(db, req.AssigneeID); err != nil {
+		respondError(c, err, "Failed to create work order")
+		return
+	}
+
+	order := models.WorkOrder{
+		AssetType:   req.AssetType,
+		EquipmentID: req.EquipmentID,
+		VariantID:   req.VariantID,
+		CartID:      req.CartID,
+		Quantity:    req.Quantity,
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    req.Priority,
+		ReportedBy:  c.GetUint("user_id"),
+		AssigneeID:  req.AssigneeID,
+		LaborCost:   roundCurrency(req.LaborCost),
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return openWorkOrder(tx, &order, 0)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create work order")
+		return
+	}
+
+	preloadWorkOrder(db).First(&order, order.ID)
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// List work orders with optional filters
+func (h *MaintenanceHandler) GetWorkOrders(c *gin.Context) {
+	db := database.DB
+	query := preloadWorkOrder(db).Model(&models.WorkOrder{})
+
+	if status := c.Query("status"); status != "" {
+		if status == "active" {
+			query = query.Where("status IN ?", openWorkOrderStatuses)
+		} else {
+			query = query.Where("status = ?", status)
+		}
+	}
+	if assetType := c.Query("asset_type"); assetType != "" {
+		query = query.Where("asset_type = ?", assetType)
+	}
+	if priority := c.Query("priority"); priority != "" {
+		query = query.Where("priority = ?", priority)
+	}
+	if assignee := c.Query("assignee_id"); assignee != "" {
+		if assignee == "me" {
+			query = query.Where("assignee_id = ?", c.GetUint("user_id"))
+		} else {
+			query = query.Where("assignee_id = ?", assignee)
+		}
+	}
+
+	var orders []models.WorkOrder
+	if err := query.Order("FIELD(priority, 'urgent', 'high', 'medium', 'low'), created_at ASC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch work orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *MaintenanceHandler) GetWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work order ID"})
+		return
+	}
+
+	var order models.WorkOrder
+	if err := preloadWorkOrder(database.DB).First(&order, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Work order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Update details, assignment or progress of an open work order
+func (h *MaintenanceHandler) UpdateWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work order ID"})
+		return
+	}
+
+	var req WorkOrderUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var order models.WorkOrder
+	if err := db.First(&order, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Work order not found"})
+		return
+	}
+
+	if order.Status == "closed" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Work order is closed"})
+		return
+	}
+
+	if err := validateAssignee(db, req.AssigneeID); err != nil {
+		respondError(c, err, "Failed to update work order")
+		return
+	}
+
+	if req.Title != nil {
+		order.Title = *req.Title
+	}
+	if req.Description != nil {
+		order.Description = *req.Description
+	}
+	if req.Priority != nil {
+		order.Priority = *req.Priority
+	}
+	if req.AssigneeID != nil {
+		order.AssigneeID = req.AssigneeID
+	}
+	if req.LaborCost != nil {
+		order.LaborCost = roundCurrency(*req.LaborCost)
+	}
+	if req.Status != nil {
+		order.Status = *req.Status
+		if order.Status == "in_progress" && order.StartedAt == nil {
+			now := time.Now()
+			order.StartedAt = &now
+		}
+	}
+	order.TotalCost = roundCurrency(order.LaborCost + order.PartsCost)
+
+	if err := db.Save(&order).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update work order"})
+		return
+	}
+
+	preloadWorkOrder(db).First(&order, order.ID)
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Record parts used on a work order
+func (h *MaintenanceHandler) AddWorkOrderPart(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work order ID"})
+		return
+	}
+
+	var req WorkOrderPartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	var order models.WorkOrder
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Work order not found")
+		}
+		if order.Status == "closed" {
+			return newHTTPError(http.StatusBadRequest, "Work order is closed")
+		}
+
+		part := models.WorkOrderPart{
+			WorkOrderID: order.ID,
+			Name:        req.Name,
+			PartNumber:  req.PartNumber,
+			Quantity:    req.Quantity,
+			UnitCost:    roundCurrency(req.UnitCost),
+			TotalCost:   roundCurrency(req.UnitCost * float64(req.Quantity)),
+		}
+		if err := tx.Create(&part).Error; err != nil {
+			return err
+		}
+
+		order.PartsCost = roundCurrency(order.PartsCost + part.TotalCost)
+		order.TotalCost = roundCurrency(order.LaborCost + order.PartsCost)
+		return tx.Save(&order).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to add part")
+		return
+	}
+
+	preloadWorkOrder(database.DB).First(&order, order.ID)
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// Close a work order and put the asset back into service unless it was written off
+func (h *MaintenanceHandler) CloseWorkOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work order ID"})
+		return
+	}
+
+	var req WorkOrderCloseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order models.WorkOrder
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Work order not found")
+		}
+		if order.Status == "closed" {
+			return newHTTPError(http.StatusBadRequest, "Work order already closed")
+		}
+
+		now := time.Now()
+		backInService := req.Resolution != "written_off"
+		switch order.AssetType {
+		case "equipment":
+			if backInService && order.EquipmentID != nil {
+				if err := releaseEquipmentStock(tx, *order.EquipmentID, order.VariantID, order.UnitsWithdrawn); err != nil {
+					return err
+				}
+			}
+		case "golf_cart":
+			if order.CartID != nil {
+				// Another open order on the same cart keeps it in maintenance
+				var others int64
+				if err := tx.Model(&models.WorkOrder{}).
+					Where("cart_id = ? AND id != ? AND status IN ?", *order.CartID, order.ID, openWorkOrderStatuses).
+					Count(&others).Error; err != nil {
+					return err
+				}
+				updates := map[string]interface{}{"last_service_at": now}
+				if !backInService {
+					updates["status"] = "retired"
+				} else if others == 0 {
+					updates["status"] = "available"
+				}
+				if err := tx.Model(&models.GolfCart{}).Where("id = ?", *order.CartID).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if req.LaborCost != nil {
+			order.LaborCost = roundCurrency(*req.LaborCost)
+		}
+		order.TotalCost = roundCurrency(order.LaborCost + order.PartsCost)
+		order.Status = "closed"
+		order.Resolution = req.Resolution
+		order.ResolutionNote = req.Notes
+		order.ClosedAt = &now
+		if order.StartedAt == nil {
+			order.StartedAt = &now
+		}
+		return tx.Save(&order).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to close work order")
+		return
+	}
+
+	preloadWorkOrder(database.DB).First(&order, order.ID)
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Maintenance history for a piece of equipment
+func (h *MaintenanceHandler) GetEquipmentMaintenanceHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid equipment ID"})
+		return
+	}
+
+	var equipment models.Equipment
+	if err := database.DB.First(&equipment, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Equipment not found"})
+		return
+	}
+
+	h.respondHistory(c, "equipment_id = ?", equipment.ID)
+}
+
+// Maintenance history for a golf cart
+func (h *MaintenanceHandler) GetCartMaintenanceHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart ID"})
+		return
+	}
+
+	var cart models.GolfCart
+	if err := database.DB.First(&cart, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+		return
+	}
+
+	h.respondHistory(c, "cart_id = ?", cart.ID)
+}
+
+func (h *MaintenanceHandler) respondHistory(c *gin.Context, condition string, id uint) {
+	var orders []models.WorkOrder
+	if err := preloadWorkOrder(database.DB).Where(condition, id).Order("created_at DESC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance history"})
+		return
+	}
+
+	history := MaintenanceHistory{WorkOrders: orders}
+	for _, order := range orders {
+		history.TotalCost += order.TotalCost
+		if order.Status == "closed" {
+			history.ClosedCount++
+			if order.ClosedAt != nil && (history.LastClosed == nil || order.ClosedAt.After(*history.LastClosed)) {
+				history.LastClosed = order.ClosedAt
+			}
+		} else {
+			history.OpenCount++
+		}
+	}
+	history.TotalCost = roundCurrency(history.TotalCost)
+
+	c.JSON(http.StatusOK, history)
+}
+
+// workOrderTitle builds a default title for work orders raised automatically.
+func workOrderTitle(source string, id uint) string {
+	return fmt.Sprintf("Damage reported on %s #%d", source, id)
+}
+
+// workOrderPriority maps a damage severity onto a work order priority.
+func workOrderPriority(severity string) string {
+	switch severity {
+	case "severe":
+		return "high"
+	case "moderate":
+		return "medium"
+	default:
+		return "low"
+	}
+}
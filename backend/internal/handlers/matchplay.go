@@ -0,0 +1,640 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/handicap"
+	"golf-course-backend/internal/matchplay"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BracketRequest struct {
+	TeeSetID     *uint `json:"tee_set_id"`
+	UseHandicaps *bool `json:"use_handicaps"`
+}
+
+type MatchScheduleRequest struct {
+	Date    string `json:"date" binding:"required"`
+	TeeTime string `json:"tee_time" binding:"required"`
+}
+
+type MatchHoleRequest struct {
+	HoleNumber     int    `json:"hole_number" binding:"required,min=1,max=40"`
+	Player1Strokes *int   `json:"player1_strokes" binding:"omitempty,min=1"`
+	Player2Strokes *int   `json:"player2_strokes" binding:"omitempty,min=1"`
+	ConcededTo     string `json:"conceded_to" binding:"omitempty,oneof=player1 player2"`
+}
+
+type MatchDecisionRequest struct {
+	Winner string `json:"winner" binding:"required,oneof=player1 player2"`
+}
+
+type MatchView struct {
+	models.TournamentMatch
+	State *matchplay.State `json:"state,omitempty"`
+}
+
+type BracketRound struct {
+	RoundNumber int         `json:"round_number"`
+	Name        string      `json:"name"`
+	Matches     []MatchView `json:"matches"`
+}
+
+type Bracket struct {
+	TournamentID uint           `json:"tournament_id"`
+	Tournament   string         `json:"tournament"`
+	Rounds       []BracketRound `json:"rounds"`
+	ChampionID   *uint          `json:"champion_id"`
+}
+
+var matchSides = map[string]matchplay.Side{
+	"halved":  matchplay.Halved,
+	"player1": matchplay.Player1,
+	"player2": matchplay.Player2,
+}
+
+func sideName(side matchplay.Side) string {
+	switch side {
+	case matchplay.Player1:
+		return "player1"
+	case matchplay.Player2:
+		return "player2"
+	default:
+		return "halved"
+	}
+}
+
+// sidePlayer returns the participant playing on a side of a match.
+func sidePlayer(match models.TournamentMatch, side matchplay.Side) *uint {
+	if side == matchplay.Player1 {
+		return match.Player1ID
+	}
+	return match.Player2ID
+}
+
+// roundName labels the closing rounds of a bracket.
+func roundName(round, rounds int) string {
+	switch rounds - round {
+	case 0:
+		return "Final"
+	case 1:
+		return "Semi-finals"
+	case 2:
+		return "Quarter-finals"
+	default:
+		return "Round of " + strconv.Itoa(1<<(rounds-round+1))
+	}
+}
+
+// defaultTeeSet returns the course's default tee set, if it has one.
+func defaultTeeSet(db *gorm.DB, courseID uint) *models.TeeSet {
+	var teeSet models.TeeSet
+	if err := db.Where("course_id = ? AND is_default = ? AND is_active = ?", courseID, true, true).First(&teeSet).Error; err != nil {
+		return nil
+	}
+	return &teeSet
+}
+
+// setMatchStrokes gives the higher handicap the full difference in playing
+// handicaps once both players of a match are known.
+func setMatchStrokes(tx *gorm.DB, match *models.TournamentMatch, course models.Course) error {
+	match.StrokesGiven = 0
+	match.StrokesTo = nil
+	if !match.UseHandicaps || match.Player1ID == nil || match.Player2ID == nil {
+		return nil
+	}
+
+	var players []models.TournamentParticipant
+	if err := tx.Where("id IN ?", []uint{*match.Player1ID, *match.Player2ID}).Find(&players).Error; err != nil {
+		return err
+	}
+	teeSet := defaultTeeSet(tx, course.ID)
+	if match.TeeSetID != nil {
+		if found, err := resolveTeeSet(tx, course.ID, match.TeeSetID); err == nil && found != nil {
+			teeSet = found
+		}
+	}
+
+	allowance, _ := handicap.Allowance("match_play")
+	playing := make(map[uint]int, 2)
+	for _, player := range players {
+		if player.HandicapAtRegistration == nil {
+			continue
+		}
+		if ch := courseHandicapFor(*player.HandicapAtRegistration, course, teeSet, course.TotalHoles); ch != nil {
+			playing[player.ID] = handicap.PlayingHandicap(*ch, allowance)
+		}
+	}
+
+	diff := playing[*match.Player1ID] - playing[*match.Player2ID]
+	switch {
+	case diff > 0:
+		to := "player1"
+		match.StrokesGiven, match.StrokesTo = diff, &to
+	case diff < 0:
+		to := "player2"
+		match.StrokesGiven, match.StrokesTo = -diff, &to
+	}
+	return nil
+}
+
+// advanceWinner puts a match's winner into their slot in the next round.
+func advanceWinner(tx *gorm.DB, match models.TournamentMatch, course models.Course) error {
+	nextNumber, side := matchplay.NextMatch(match.MatchNumber)
+	var next models.TournamentMatch
+	err := tx.Where("tournament_id = ? AND round_number = ? AND match_number = ?",
+		match.TournamentID, match.RoundNumber+1, nextNumber).First(&next).Error
+	if err == gorm.ErrRecordNotFound {
+		// The final has been decided
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	seed := match.Seed1
+	if match.WinnerID != nil && match.Player2ID != nil && *match.WinnerID == *match.Player2ID {
+		seed = match.Seed2
+	}
+	if side == matchplay.Player1 {
+		next.Player1ID, next.Seed1 = match.WinnerID, seed
+	} else {
+		next.Player2ID, next.Seed2 = match.WinnerID, seed
+	}
+	if err := setMatchStrokes(tx, &next, course); err != nil {
+		return err
+	}
+	return tx.Save(&next).Error
+}
+
+// decideMatch records the winner of a match and sends them through.
+func decideMatch(tx *gorm.DB, match *models.TournamentMatch, winner matchplay.Side, result, decidedBy string, course models.Course) error {
+	match.WinnerID = sidePlayer(*match, winner)
+	match.Result = result
+	match.DecidedBy = &decidedBy
+	match.Status = "completed"
+	if err := tx.Save(match).Error; err != nil {
+		return err
+	}
+	return advanceWinner(tx, *match, course)
+}
+
+// matchState replays the holes recorded for a match.
+func matchState(match models.TournamentMatch, holes int) matchplay.State {
+	sort.Slice(match.Holes, func(i, j int) bool { return match.Holes[i].HoleNumber < match.Holes[j].HoleNumber })
+	results := make([]matchplay.Side, 0, len(match.Holes))
+	for _, hole := range match.Holes {
+		results = append(results, matchSides[hole.Winner])
+	}
+	return matchplay.Score(results, holes)
+}
+
+// loadBracket reads every match of a tournament grouped by round.
+func loadBracket(db *gorm.DB, tournament models.Tournament) (Bracket, error) {
+	bracket := Bracket{TournamentID: tournament.ID, Tournament: tournament.Name, Rounds: []BracketRound{}}
+
+	var matches []models.TournamentMatch
+	if err := db.Preload("Player1.User").Preload("Player2.User").
+		Preload("Holes", func(db *gorm.DB) *gorm.DB { return db.Order("hole_number ASC") }).
+		Where("tournament_id = ?", tournament.ID).
+		Order("round_number ASC, match_number ASC").
+		Find(&matches).Error; err != nil {
+		return bracket, err
+	}
+	if len(matches) == 0 {
+		return bracket, nil
+	}
+
+	var course models.Course
+	if err := db.First(&course, tournament.CourseID).Error; err != nil {
+		return bracket, err
+	}
+	rounds := matches[len(matches)-1].RoundNumber
+
+	for _, match := range matches {
+		if len(bracket.Rounds) < match.RoundNumber {
+			bracket.Rounds = append(bracket.Rounds, BracketRound{
+				RoundNumber: match.RoundNumber,
+				Name:        roundName(match.RoundNumber, rounds),
+				Matches:     []MatchView{},
+			})
+		}
+		view := MatchView{TournamentMatch: match}
+		if len(match.Holes) > 0 {
+			state := matchState(match, course.TotalHoles)
+			view.State = &state
+		}
+		round := &bracket.Rounds[match.RoundNumber-1]
+		round.Matches = append(round.Matches, view)
+
+		if match.RoundNumber == rounds && match.Status == "completed" {
+			bracket.ChampionID = match.WinnerID
+		}
+	}
+	return bracket, nil
+}
+
+// lockMatch loads a match for update together with its tournament's course.
+func lockMatch(tx *gorm.DB, id int) (models.TournamentMatch, models.Course, error) {
+	var match models.TournamentMatch
+	var course models.Course
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Holes").First(&match, id).Error; err != nil {
+		return match, course, newHTTPError(http.StatusNotFound, "Match not found")
+	}
+	var tournament models.Tournament
+	if err := tx.First(&tournament, match.TournamentID).Error; err != nil {
+		return match, course, err
+	}
+	if err := tx.First(&course, tournament.CourseID).Error; err != nil {
+		return match, course, err
+	}
+	return match, course, nil
+}
+
+func respondMatch(c *gin.Context, id uint) {
+	var match models.TournamentMatch
+	db := database.DB
+	if err := db.Preload("Player1.User").Preload("Player2.User").
+		Preload("Holes", func(db *gorm.DB) *gorm.DB { return db.Order("hole_number ASC") }).
+		First(&match, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch match"})
+		return
+	}
+	var tournament models.Tournament
+	db.Preload("Course").First(&tournament, match.TournamentID)
+
+	view := MatchView{TournamentMatch: match}
+	if len(match.Holes) > 0 {
+		state := matchState(match, tournament.Course.TotalHoles)
+		view.State = &state
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// Seed the field by handicap and draw the knockout bracket
+func (h *TournamentHandler) GenerateBracket(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	var req BracketRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	useHandicaps := req.UseHandicaps == nil || *req.UseHandicaps
+
+	db := database.DB
+	var tournament models.Tournament
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Course").First(&tournament, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Tournament not found")
+		}
+		if tournament.TournamentType != "match_play" {
+			return newHTTPError(http.StatusBadRequest, "Tournament is not match play")
+		}
+		if tournament.Status == "cancelled" || tournament.Status == "completed" {
+			return newHTTPError(http.StatusBadRequest, "Tournament is "+tournament.Status)
+		}
+		if registrationOpen(tournament, time.Now()) {
+			return newHTTPError(http.StatusBadRequest, "Registration is still open")
+		}
+		if _, err := resolveTeeSet(tx, tournament.CourseID, req.TeeSetID); err != nil {
+			return err
+		}
+
+		// A bracket can be redrawn until the first match is under way
+		var started int64
+		tx.Model(&models.TournamentMatch{}).
+			Where("tournament_id = ? AND (status = ? OR decided_by IN ?)", tournament.ID,
+				"in_progress", []string{"holes", "concession", "walkover"}).
+			Count(&started)
+		if started > 0 {
+			return newHTTPError(http.StatusConflict, "Matches have already been played")
+		}
+		if err := tx.Where("tournament_id = ?", tournament.ID).Delete(&models.TournamentMatch{}).Error; err != nil {
+			return err
+		}
+
+		var players []models.TournamentParticipant
+		if err := tx.Where("tournament_id = ? AND payment_status = ?", tournament.ID, "paid").
+			Order("id ASC").Find(&players).Error; err != nil {
+			return err
+		}
+		if len(players) < 2 {
+			return newHTTPError(http.StatusBadRequest, "At least two paid entries are needed")
+		}
+		// Lowest handicap is the top seed
+		sort.SliceStable(players, func(i, j int) bool { return handicapLess(players[i], players[j]) })
+
+		rounds := matchplay.Rounds(len(players))
+		size := matchplay.BracketSize(len(players))
+		for round := 1; round <= rounds; round++ {
+			for number := 1; number <= size>>round; number++ {
+				match := models.TournamentMatch{
+					TournamentID: tournament.ID,
+					RoundNumber:  round,
+					MatchNumber:  number,
+					TeeSetID:     req.TeeSetID,
+					UseHandicaps: true,
+					Status:       "pending",
+				}
+				if err := tx.Create(&match).Error; err != nil {
+					return err
+				}
+				if !useHandicaps {
+					if err := tx.Model(&match).Update("use_handicaps", false).Error; err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		for i, pairing := range matchplay.FirstRound(len(players)) {
+			var match models.TournamentMatch
+			if err := tx.Where("tournament_id = ? AND round_number = ? AND match_number = ?",
+				tournament.ID, 1, i+1).First(&match).Error; err != nil {
+				return err
+			}
+			seed1, seed2 := pairing.Seed1, pairing.Seed2
+			match.Player1ID, match.Seed1 = &players[seed1-1].ID, &seed1
+			if seed2 > len(players) {
+				// Top seeds without an opponent go straight through
+				if err := decideMatch(tx, &match, matchplay.Player1, "Bye", "bye", tournament.Course); err != nil {
+					return err
+				}
+				continue
+			}
+			match.Player2ID, match.Seed2 = &players[seed2-1].ID, &seed2
+			if err := setMatchStrokes(tx, &match, tournament.Course); err != nil {
+				return err
+			}
+			if err := tx.Save(&match).Error; err != nil {
+				return err
+			}
+		}
+
+		if tournament.Status == "upcoming" {
+			return tx.Model(&tournament).Update("status", "active").Error
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to generate bracket")
+		return
+	}
+
+	bracket, err := loadBracket(db, tournament)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bracket"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bracket)
+}
+
+// @Summary Get match play bracket
+// @Description Every match by round with the live state of matches in progress
+// @Tags tournaments
+// @Produce json
+// @Param id path int true "Tournament ID"
+// @Success 200 {object} Bracket
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id}/bracket [get]
+func (h *TournamentHandler) GetBracket(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	db := database.DB
+	var tournament models.Tournament
+	if err := db.First(&tournament, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return
+	}
+
+	bracket, err := loadBracket(db, tournament)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bracket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bracket)
+}
+
+// Set the date and tee time of a match
+func (h *TournamentHandler) ScheduleMatch(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	var req MatchScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		return
+	}
+	minutes, err := parseClock(req.TeeTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tee time"})
+		return
+	}
+
+	var match models.TournamentMatch
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if match, _, err = lockMatch(tx, id); err != nil {
+			return err
+		}
+		if match.Status == "completed" {
+			return newHTTPError(http.StatusBadRequest, "Match is already decided")
+		}
+
+		var tournament models.Tournament
+		if err := tx.First(&tournament, match.TournamentID).Error; err != nil {
+			return err
+		}
+		day := date.Format("2006-01-02")
+		if day < tournament.StartDate.Format("2006-01-02") || day > tournament.EndDate.Format("2006-01-02") {
+			return newHTTPError(http.StatusBadRequest, "Match must be played during the tournament")
+		}
+
+		teeTime := formatClock(minutes)
+		match.ScheduledDate = &date
+		match.TeeTime = &teeTime
+		if match.Status == "pending" {
+			match.Status = "scheduled"
+		}
+		return tx.Save(&match).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to schedule match")
+		return
+	}
+
+	respondMatch(c, match.ID)
+}
+
+// Record a hole of a match; the match ends once it can no longer be halved
+func (h *TournamentHandler) RecordMatchHole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	var req MatchHoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ConcededTo == "" && (req.Player1Strokes == nil || req.Player2Strokes == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Both scores are needed unless the hole was conceded"})
+		return
+	}
+
+	var match models.TournamentMatch
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var course models.Course
+		if match, course, err = lockMatch(tx, id); err != nil {
+			return err
+		}
+		if match.Player1ID == nil || match.Player2ID == nil {
+			return newHTTPError(http.StatusBadRequest, "Match is waiting for its players")
+		}
+		if match.Status == "completed" {
+			return newHTTPError(http.StatusBadRequest, "Match is already decided")
+		}
+		// Holes are entered in order; earlier ones may be corrected
+		if req.HoleNumber > len(match.Holes)+1 {
+			return newHTTPError(http.StatusBadRequest, "Record hole "+strconv.Itoa(len(match.Holes)+1)+" first")
+		}
+
+		courseHoles, err := loadCourseHoles(tx, course.ID)
+		if err != nil {
+			return err
+		}
+		if len(courseHoles) == 0 {
+			return newHTTPError(http.StatusBadRequest, "Course has no holes set up")
+		}
+
+		hole := models.TournamentMatchHole{
+			MatchID:        match.ID,
+			HoleNumber:     req.HoleNumber,
+			Player1Strokes: req.Player1Strokes,
+			Player2Strokes: req.Player2Strokes,
+			Conceded:       req.ConcededTo != "",
+		}
+		if hole.Conceded {
+			hole.Winner = req.ConcededTo
+		} else {
+			// Extra holes are played over the course again from the first
+			courseHole := courseHoles[(req.HoleNumber-1)%len(courseHoles)]
+			received1, received2 := 0, 0
+			if match.StrokesTo != nil {
+				strokes := handicap.StrokesReceived(match.StrokesGiven, courseHole.HandicapIndex, len(courseHoles))
+				if *match.StrokesTo == "player1" {
+					received1 = strokes
+				} else {
+					received2 = strokes
+				}
+			}
+			hole.Winner = sideName(matchplay.HoleWinner(*req.Player1Strokes, *req.Player2Strokes, received1, received2))
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "match_id"}, {Name: "hole_number"}},
+			DoUpdates: clause.AssignmentColumns([]string{"player1_strokes", "player2_strokes", "winner", "conceded"}),
+		}).Create(&hole).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("match_id = ?", match.ID).Find(&match.Holes).Error; err != nil {
+			return err
+		}
+		state := matchState(match, len(courseHoles))
+		if state.Decided {
+			// Holes entered after the match ended are not part of it
+			if err := tx.Where("match_id = ? AND hole_number > ?", match.ID, state.HolesPlayed).
+				Delete(&models.TournamentMatchHole{}).Error; err != nil {
+				return err
+			}
+			return decideMatch(tx, &match, state.Winner, state.Result, "holes", course)
+		}
+		match.Status = "in_progress"
+		return tx.Save(&match).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to record hole")
+		return
+	}
+
+	respondMatch(c, match.ID)
+}
+
+// Record a player conceding the match
+func (h *TournamentHandler) ConcedeMatch(c *gin.Context) {
+	h.settleMatch(c, "concession", "Conceded")
+}
+
+// Award a match to a player whose opponent did not turn up
+func (h *TournamentHandler) AwardWalkover(c *gin.Context) {
+	h.settleMatch(c, "walkover", "W/O")
+}
+
+func (h *TournamentHandler) settleMatch(c *gin.Context, decidedBy, result string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	var req MatchDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var match models.TournamentMatch
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var course models.Course
+		if match, course, err = lockMatch(tx, id); err != nil {
+			return err
+		}
+		if match.Player1ID == nil || match.Player2ID == nil {
+			return newHTTPError(http.StatusBadRequest, "Match is waiting for its players")
+		}
+		if match.Status == "completed" {
+			return newHTTPError(http.StatusBadRequest, "Match is already decided")
+		}
+		if decidedBy == "walkover" && len(match.Holes) > 0 {
+			return newHTTPError(http.StatusBadRequest, "Match has already started; record a concession instead")
+		}
+		return decideMatch(tx, &match, matchSides[req.Winner], result, decidedBy, course)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to settle match")
+		return
+	}
+
+	respondMatch(c, match.ID)
+}
@@ -0,0 +1,536 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PairingRequest struct {
+	RoundNumber     int    `json:"round_number" binding:"omitempty,min=1"`
+	Method          string `json:"method" binding:"omitempty,oneof=random flights previous_round"`
+	GroupSize       int    `json:"group_size" binding:"omitempty,min=2,max=4"`
+	Flights         int    `json:"flights" binding:"omitempty,min=1,max=10"`
+	StartType       string `json:"start_type" binding:"omitempty,oneof=consecutive shotgun"`
+	Date            string `json:"date"`
+	FirstTeeTime    string `json:"first_tee_time"`
+	IntervalMinutes int    `json:"interval_minutes" binding:"omitempty,min=5,max=30"`
+	Seed            *int64 `json:"seed"`
+}
+
+type PairingPlayer struct {
+	ParticipantID uint     `json:"participant_id"`
+	UserID        uint     `json:"user_id"`
+	Name          string   `json:"name"`
+	Handicap      *float64 `json:"handicap"`
+}
+
+type PairingGroup struct {
+	GroupNumber  int             `json:"group_number"`
+	Flight       *int            `json:"flight,omitempty"`
+	TeeTime      string          `json:"tee_time"`
+	StartingHole int             `json:"starting_hole"`
+	Players      []PairingPlayer `json:"players"`
+}
+
+type PairingSheet struct {
+	TournamentID uint           `json:"tournament_id"`
+	Tournament   string         `json:"tournament"`
+	CourseName   string         `json:"course_name"`
+	RoundNumber  int            `json:"round_number"`
+	PlayDate     string         `json:"play_date"`
+	Groups       []PairingGroup `json:"groups"`
+}
+
+// pairingGroup is a group being built, before it is written out.
+type pairingGroup struct {
+	flight  *int
+	players []models.TournamentParticipant
+}
+
+// groupSizes splits n players into the fewest groups of at most size
+// players, keeping groups within one player of each other.
+func groupSizes(n, size int) []int {
+	if n == 0 {
+		return nil
+	}
+	groups := (n + size - 1) / size
+	sizes := make([]int, groups)
+	for i := range sizes {
+		sizes[i] = n / groups
+		if i < n%groups {
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+// chunkPlayers lays ordered players out into groups.
+func chunkPlayers(players []models.TournamentParticipant, size int, flight *int) []pairingGroup {
+	var groups []pairingGroup
+	offset := 0
+	for _, n := range groupSizes(len(players), size) {
+		groups = append(groups, pairingGroup{flight: flight, players: players[offset : offset+n]})
+		offset += n
+	}
+	return groups
+}
+
+// handicapLess orders players by handicap, those without one last.
+func handicapLess(a, b models.TournamentParticipant) bool {
+	switch {
+	case a.HandicapAtRegistration == nil:
+		return false
+	case b.HandicapAtRegistration == nil:
+		return true
+	default:
+		return *a.HandicapAtRegistration < *b.HandicapAtRegistration
+	}
+}
+
+// buildPairings orders the field for the chosen method and splits it into
+// groups. Flights split the field by handicap and are paired separately;
+// after the first round players go out in reverse order of score so the
+// leaders finish last.
+func buildPairings(tx *gorm.DB, tournament models.Tournament, players []models.TournamentParticipant, req PairingRequest) ([]pairingGroup, error) {
+	switch req.Method {
+	case "flights":
+		sort.SliceStable(players, func(i, j int) bool { return handicapLess(players[i], players[j]) })
+		flights := min(req.Flights, len(players))
+		var groups []pairingGroup
+		offset := 0
+		for f, n := range groupSizes(len(players), (len(players)+flights-1)/flights) {
+			flight := f + 1
+			groups = append(groups, chunkPlayers(players[offset:offset+n], req.GroupSize, &flight)...)
+			offset += n
+		}
+		return groups, nil
+
+	case "previous_round":
+		if req.RoundNumber < 2 {
+			return nil, newHTTPError(http.StatusBadRequest, "Pairing by score needs a previous round")
+		}
+		var scores []struct {
+			UserID     uint
+			TotalScore *int
+		}
+		if err := tx.Model(&models.Scorecard{}).
+			Select("user_id, total_score").
+			Where("tournament_id = ? AND tournament_round = ?", tournament.ID, req.RoundNumber-1).
+			Scan(&scores).Error; err != nil {
+			return nil, err
+		}
+		byUser := make(map[uint]int, len(scores))
+		for _, row := range scores {
+			if row.TotalScore != nil {
+				byUser[row.UserID] = *row.TotalScore
+			}
+		}
+		sort.SliceStable(players, func(i, j int) bool {
+			a, okA := byUser[players[i].UserID]
+			b, okB := byUser[players[j].UserID]
+			switch {
+			case okA != okB:
+				return !okA
+			case a != b:
+				return a > b
+			default:
+				return handicapLess(players[j], players[i])
+			}
+		})
+		return chunkPlayers(players, req.GroupSize, nil), nil
+
+	default:
+		seed := time.Now().UnixNano()
+		if req.Seed != nil {
+			seed = *req.Seed
+		}
+		r := rand.New(rand.NewSource(seed))
+		r.Shuffle(len(players), func(i, j int) { players[i], players[j] = players[j], players[i] })
+		return chunkPlayers(players, req.GroupSize, nil), nil
+	}
+}
+
+// blockTeeSheet takes the tee sheet slots in [from, to) out of general
+// booking and returns the blocking rows by start time. The template gives
+// the course, date, owner and label of every block.
+func blockTeeSheet(tx *gorm.DB, template models.TeeTime, from, to int) (map[int]uint, error) {
+	var slots []string
+	for t := from; t < to && t < teeSheetClose; t += teeSheetInterval {
+		slots = append(slots, formatClock(t))
+	}
+
+	var taken []models.TeeTime
+	if err := tx.Where("course_id = ? AND booking_date = ? AND tee_time IN ?", template.CourseID, template.BookingDate, slots).
+		Order("tee_time ASC").Find(&taken).Error; err != nil {
+		return nil, err
+	}
+	if len(taken) > 0 {
+		minutes, _ := parseClock(taken[0].TeeTime)
+		return nil, newHTTPError(http.StatusConflict, fmt.Sprintf("Tee time %s is already booked", formatClock(minutes)))
+	}
+
+	blocks := make(map[int]uint, len(slots))
+	for _, slot := range slots {
+		block := template
+		block.TeeTime = slot
+		block.PlayersCount = 4
+		block.PaymentStatus = "paid"
+		block.BookingStatus = "blocked"
+		if err := tx.Create(&block).Error; err != nil {
+			return nil, err
+		}
+		minutes, _ := parseClock(slot)
+		blocks[minutes] = block.ID
+	}
+	return blocks, nil
+}
+
+// clearPairings removes a round's groups and frees the tee sheet slots
+// blocked for them.
+func clearPairings(tx *gorm.DB, tournamentID uint, round int) error {
+	var groups []models.TournamentGroup
+	if err := tx.Where("tournament_id = ? AND round_number = ?", tournamentID, round).Find(&groups).Error; err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(groups))
+	for i, group := range groups {
+		ids[i] = group.ID
+	}
+	if err := tx.Where("group_id IN ?", ids).Delete(&models.TournamentGroupPlayer{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&groups).Error; err != nil {
+		return err
+	}
+	return tx.Where("tournament_id = ? AND booking_date = ? AND booking_status = ?",
+		tournamentID, groups[0].PlayDate, "blocked").Delete(&models.TeeTime{}).Error
+}
+
+// loadPairingSheet reads back a round's groups in tee order.
+func loadPairingSheet(db *gorm.DB, tournament models.Tournament, round int) (PairingSheet, error) {
+	sheet := PairingSheet{
+		TournamentID: tournament.ID,
+		Tournament:   tournament.Name,
+		CourseName:   tournament.Course.Name,
+		RoundNumber:  round,
+		Groups:       []PairingGroup{},
+	}
+
+	var groups []models.TournamentGroup
+	if err := db.Preload("Players", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Players.Participant.User").
+		Where("tournament_id = ? AND round_number = ?", tournament.ID, round).
+		Order("group_number ASC").
+		Find(&groups).Error; err != nil {
+		return sheet, err
+	}
+
+	for _, group := range groups {
+		sheet.PlayDate = group.PlayDate.Format("2006-01-02")
+		minutes, _ := parseClock(group.TeeTime)
+		entry := PairingGroup{
+			GroupNumber:  group.GroupNumber,
+			Flight:       group.Flight,
+			TeeTime:      formatClock(minutes),
+			StartingHole: group.StartingHole,
+			Players:      []PairingPlayer{},
+		}
+		for _, player := range group.Players {
+			user := player.Participant.User
+			entry.Players = append(entry.Players, PairingPlayer{
+				ParticipantID: player.ParticipantID,
+				UserID:        user.ID,
+				Name:          user.FirstName + " " + user.LastName,
+				Handicap:      player.Participant.HandicapAtRegistration,
+			})
+		}
+		sheet.Groups = append(sheet.Groups, entry)
+	}
+	return sheet, nil
+}
+
+func pairingRound(c *gin.Context) (int, bool) {
+	round, err := strconv.Atoi(c.DefaultQuery("round", "1"))
+	if err != nil || round < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid round"})
+		return 0, false
+	}
+	return round, true
+}
+
+// Generate pairings and tee times for a round, replacing any already made
+func (h *TournamentHandler) GeneratePairings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	var req PairingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.RoundNumber == 0 {
+		req.RoundNumber = 1
+	}
+	if req.Method == "" {
+		req.Method = "random"
+	}
+	if req.GroupSize == 0 {
+		req.GroupSize = 4
+	}
+	if req.Flights == 0 {
+		req.Flights = 3
+	}
+	if req.StartType == "" {
+		req.StartType = "consecutive"
+	}
+	if req.FirstTeeTime == "" {
+		req.FirstTeeTime = "08:00"
+	}
+	if req.IntervalMinutes == 0 {
+		req.IntervalMinutes = teeSheetInterval
+	}
+
+	first, err := parseClock(req.FirstTeeTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid first tee time"})
+		return
+	}
+	if first < teeSheetOpen || first >= teeSheetClose || first%teeSheetInterval != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "First tee time must be a slot on the tee sheet"})
+		return
+	}
+
+	staffID := c.GetUint("user_id")
+	db := database.DB
+	var tournament models.Tournament
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Course").First(&tournament, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Tournament not found")
+		}
+		if tournament.Status == "cancelled" || tournament.Status == "completed" {
+			return newHTTPError(http.StatusBadRequest, "Tournament is "+tournament.Status)
+		}
+		if registrationOpen(tournament, time.Now()) {
+			return newHTTPError(http.StatusBadRequest, "Registration is still open")
+		}
+
+		date := tournament.StartDate.AddDate(0, 0, req.RoundNumber-1)
+		if req.Date != "" {
+			if date, err = time.Parse("2006-01-02", req.Date); err != nil {
+				return newHTTPError(http.StatusBadRequest, "Invalid date format")
+			}
+		}
+		day := date.Format("2006-01-02")
+		if day < tournament.StartDate.Format("2006-01-02") || day > tournament.EndDate.Format("2006-01-02") {
+			return newHTTPError(http.StatusBadRequest, "Round must be played during the tournament")
+		}
+
+		var players []models.TournamentParticipant
+		if err := tx.Where("tournament_id = ? AND payment_status = ?", tournament.ID, "paid").
+			Order("id ASC").Find(&players).Error; err != nil {
+			return err
+		}
+		if len(players) == 0 {
+			return newHTTPError(http.StatusBadRequest, "No paid entries to pair")
+		}
+
+		if err := clearPairings(tx, tournament.ID, req.RoundNumber); err != nil {
+			return err
+		}
+
+		groups, err := buildPairings(tx, tournament, players, req)
+		if err != nil {
+			return err
+		}
+
+		// Work out each group's start, then block the slots they cover
+		starts := make([]int, len(groups))
+		holes := make([]int, len(groups))
+		end := first + roundDurationMinutes()
+		if req.StartType == "shotgun" {
+			courseHoles := tournament.Course.TotalHoles
+			if len(groups) > 2*courseHoles {
+				return newHTTPError(http.StatusBadRequest, "Too many groups for a shotgun start")
+			}
+			for i := range groups {
+				starts[i] = first
+				holes[i] = i%courseHoles + 1
+			}
+		} else {
+			for i := range groups {
+				starts[i] = first + i*req.IntervalMinutes
+				holes[i] = 1
+			}
+			last := starts[len(starts)-1]
+			if last >= teeSheetClose {
+				return newHTTPError(http.StatusBadRequest, "Not enough tee times left in the day")
+			}
+			end = last + req.IntervalMinutes
+		}
+
+		label := fmt.Sprintf("Tournament: %s, round %d", tournament.Name, req.RoundNumber)
+		blocks, err := blockTeeSheet(tx, models.TeeTime{
+			CourseID:        tournament.CourseID,
+			UserID:          staffID,
+			TournamentID:    &tournament.ID,
+			BookingDate:     date,
+			SpecialRequests: label,
+		}, first, end)
+		if err != nil {
+			return err
+		}
+
+		for i, group := range groups {
+			slot := starts[i] - (starts[i]-teeSheetOpen)%teeSheetInterval
+			row := models.TournamentGroup{
+				TournamentID: tournament.ID,
+				RoundNumber:  req.RoundNumber,
+				GroupNumber:  i + 1,
+				Flight:       group.flight,
+				PlayDate:     date,
+				TeeTime:      formatClock(starts[i]),
+				StartingHole: holes[i],
+			}
+			if blockID, ok := blocks[slot]; ok {
+				row.TeeTimeID = &blockID
+			}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+			for position, player := range group.players {
+				member := models.TournamentGroupPlayer{GroupID: row.ID, ParticipantID: player.ID, Position: position + 1}
+				if err := tx.Create(&member).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err, "Failed to generate pairings")
+		return
+	}
+
+	sheet, err := loadPairingSheet(db, tournament, req.RoundNumber)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pairings"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sheet)
+}
+
+// Remove a round's pairings and release its tee times
+func (h *TournamentHandler) DeletePairings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+	round, ok := pairingRound(c)
+	if !ok {
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var tournament models.Tournament
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tournament, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Tournament not found")
+		}
+		return clearPairings(tx, tournament.ID, round)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to delete pairings")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pairings deleted successfully"})
+}
+
+// @Summary Get tournament pairings
+// @Description Groups, tee times and starting holes for a round; format=csv downloads the pairing sheet
+// @Tags tournaments
+// @Produce json
+// @Produce text/csv
+// @Param id path int true "Tournament ID"
+// @Param round query int false "Round number" default(1)
+// @Param format query string false "json or csv"
+// @Success 200 {object} PairingSheet
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id}/pairings [get]
+func (h *TournamentHandler) GetPairings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+	round, ok := pairingRound(c)
+	if !ok {
+		return
+	}
+
+	db := database.DB
+	var tournament models.Tournament
+	if err := db.Preload("Course").First(&tournament, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return
+	}
+
+	sheet, err := loadPairingSheet(db, tournament, round)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pairings"})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, sheet)
+		return
+	}
+
+	filename := fmt.Sprintf("tournament-%d-round-%d-pairings.csv", tournament.ID, round)
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"Group", "Flight", "Date", "Tee Time", "Starting Hole", "Player", "Handicap"})
+	for _, group := range sheet.Groups {
+		flight := ""
+		if group.Flight != nil {
+			flight = strconv.Itoa(*group.Flight)
+		}
+		for _, player := range group.Players {
+			handicap := ""
+			if player.Handicap != nil {
+				handicap = strconv.FormatFloat(*player.Handicap, 'f', 1, 64)
+			}
+			w.Write([]string{
+				strconv.Itoa(group.GroupNumber),
+				flight,
+				sheet.PlayDate,
+				group.TeeTime,
+				strconv.Itoa(group.StartingHole),
+				player.Name,
+				handicap,
+			})
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,812 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golf-course-backend/internal/config"
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/mail"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/payments"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentHandler struct {
+	provider       payments.PaymentProvider
+	publishableKey string
+	webhookSecret  string
+	mailer         *mail.SMTPSender
+	frontendURL    string
+}
+
+func NewPaymentHandler(provider payments.PaymentProvider, stripeConfig config.StripeConfig, emailConfig config.EmailConfig, frontendURL string) *PaymentHandler {
+	return &PaymentHandler{
+		provider:       provider,
+		publishableKey: stripeConfig.PublishableKey,
+		webhookSecret:  stripeConfig.WebhookSecret,
+		mailer:         mail.NewSMTPSender(emailConfig),
+		frontendURL:    strings.TrimRight(frontendURL, "/"),
+	}
+}
+
+// CheckoutRequest may put a gift card towards the booking. The card pays as
+// much as it can, or GiftCardAmount, and the rest is charged to a card.
+type CheckoutRequest struct {
+	ReferenceType   string  `json:"reference_type" binding:"required,oneof=tee_time range_session equipment_rental"`
+	ReferenceID     uint    `json:"reference_id" binding:"required"`
+	PaymentMethodID string  `json:"payment_method_id"`
+	GiftCardCode    string  `json:"gift_card_code"`
+	GiftCardAmount  float64 `json:"gift_card_amount" binding:"omitempty,gt=0"`
+}
+
+// CheckoutResponse describes the card payment, if anything is left to pay
+// by card, and the gift card payment when one was used.
+type CheckoutResponse struct {
+	Payment         *models.Payment `json:"payment,omitempty"`
+	GiftCardPayment *models.Payment `json:"gift_card_payment,omitempty"`
+	Provider        string          `json:"provider,omitempty"`
+	IntentStatus    string          `json:"intent_status,omitempty"`
+	ClientSecret    string          `json:"client_secret,omitempty"`
+	PublishableKey  string          `json:"publishable_key,omitempty"`
+}
+
+// checkoutItem is what a user owes on a booking at checkout.
+type checkoutItem struct {
+	Amount        float64
+	Description   string
+	PaymentType   string
+	ManualCapture bool
+}
+
+// checkoutCharge works out what is owed for a booking the user is paying
+// for. Equipment rentals are only authorized at checkout and captured when
+// the equipment is picked up; once a rental is over, what is owed is any
+// damage the deposit did not cover.
+func checkoutCharge(tx *gorm.DB, referenceType string, referenceID, userID uint) (checkoutItem, error) {
+	item := checkoutItem{PaymentType: "charge"}
+	var status string
+
+	switch referenceType {
+	case "tee_time":
+		var teeTime models.TeeTime
+		if err := tx.Where("id = ? AND user_id = ?", referenceID, userID).First(&teeTime).Error; err != nil {
+			return item, newHTTPError(http.StatusNotFound, "Tee time not found")
+		}
+		if teeTime.BookingStatus == "cancelled" || teeTime.BookingStatus == "blocked" {
+			return item, newHTTPError(http.StatusBadRequest, "Booking is "+teeTime.BookingStatus)
+		}
+		if teeTime.SplitPayment {
+			return item, newHTTPError(http.StatusBadRequest, "This booking is split between the players; pay a share instead")
+		}
+		item.Amount, status = teeTime.TotalAmount, teeTime.PaymentStatus
+		item.Description = fmt.Sprintf("Tee time %s %s", teeTime.BookingDate.Format("2006-01-02"), teeTime.TeeTime)
+	case "tee_time_share":
+		// Anyone holding the share's payment link may pay it, so the caller
+		// has already looked it up by token
+		var share models.TeeTimeShare
+		if err := tx.Preload("TeeTime").First(&share, referenceID).Error; err != nil {
+			return item, newHTTPError(http.StatusNotFound, "Share not found")
+		}
+		if share.TeeTime.BookingStatus == "cancelled" || share.TeeTime.BookingStatus == "blocked" {
+			return item, newHTTPError(http.StatusBadRequest, "Booking is "+share.TeeTime.BookingStatus)
+		}
+		item.Amount, status = share.Amount, share.PaymentStatus
+		item.Description = fmt.Sprintf("Tee time %s %s, player %d share", share.TeeTime.BookingDate.Format("2006-01-02"),
+			share.TeeTime.TeeTime, share.PlayerNumber)
+	case "range_session":
+		var session models.RangeSession
+		if err := tx.Where("id = ? AND user_id = ?", referenceID, userID).First(&session).Error; err != nil {
+			return item, newHTTPError(http.StatusNotFound, "Range session not found")
+		}
+		if session.SessionStatus == "cancelled" {
+			return item, newHTTPError(http.StatusBadRequest, "Range session is cancelled")
+		}
+		item.Amount, status = session.BucketPrice, session.PaymentStatus
+		item.Description = fmt.Sprintf("Range session %s %s", session.SessionDate.Format("2006-01-02"), session.StartTime)
+	case "equipment_rental":
+		var rental models.EquipmentRental
+		if err := tx.Preload("Equipment").Where("id = ? AND user_id = ?", referenceID, userID).First(&rental).Error; err != nil {
+			return item, newHTTPError(http.StatusNotFound, "Rental not found")
+		}
+		if rental.ReturnedAt != nil {
+			var owed models.Payment
+			if err := tx.Where("reference_type = ? AND reference_id = ? AND payment_type = ? AND payment_status IN ?",
+				"equipment_rental", rental.ID, "damage_charge", []string{"pending", "processing", "failed"}).
+				Order("id DESC").First(&owed).Error; err != nil {
+				return item, newHTTPError(http.StatusBadRequest, "Rental has already been returned")
+			}
+			item.Amount, item.PaymentType = owed.Amount, "damage_charge"
+			item.Description = fmt.Sprintf("Damage charge for rental of %s", rental.Equipment.Name)
+			return item, nil
+		}
+		item.Amount, status = rental.RentalPrice, rental.PaymentStatus
+		item.Description = fmt.Sprintf("Rental of %s", rental.Equipment.Name)
+		item.ManualCapture = true
+	case "tournament":
+		var participant models.TournamentParticipant
+		if err := tx.Preload("Tournament").Where("tournament_id = ? AND user_id = ?", referenceID, userID).
+			First(&participant).Error; err != nil {
+			return item, newHTTPError(http.StatusNotFound, "Registration not found")
+		}
+		if participant.Tournament.Status == "cancelled" {
+			return item, newHTTPError(http.StatusBadRequest, "Tournament is cancelled")
+		}
+		if participant.PaymentStatus == "paid" {
+			return item, newHTTPError(http.StatusBadRequest, "Entry fee already paid")
+		}
+		if participant.Tournament.EntryFee == nil || roundCurrency(*participant.Tournament.EntryFee) <= 0 {
+			return item, newHTTPError(http.StatusBadRequest, "No entry fee is due")
+		}
+		// Every entrant pays their own fee, so nothing paid by others counts
+		item.Amount = roundCurrency(*participant.Tournament.EntryFee)
+		item.Description = fmt.Sprintf("Entry fee for %s", participant.Tournament.Name)
+		return item, nil
+	}
+
+	if status == "paid" || status == "refunded" {
+		return item, newHTTPError(http.StatusBadRequest, "Booking is already "+status)
+	}
+	// Part may already be paid, e.g. by gift card
+	paid, err := chargesPaid(tx, referenceType, referenceID)
+	if err != nil {
+		return item, err
+	}
+	item.Amount = roundCurrency(item.Amount - paid)
+	if item.Amount <= 0 {
+		return item, newHTTPError(http.StatusBadRequest, "Nothing to pay for this booking")
+	}
+	return item, nil
+}
+
+// bookingAmount is the price of a booking that is paid for with charges.
+// Tournament entries and gift cards are paid in one go and report false.
+func bookingAmount(tx *gorm.DB, referenceType string, referenceID uint) (float64, bool) {
+	var model interface{}
+	var column string
+	switch referenceType {
+	case "tee_time":
+		model, column = &models.TeeTime{}, "total_amount"
+	case "tee_time_share":
+		model, column = &models.TeeTimeShare{}, "amount"
+	case "range_session":
+		model, column = &models.RangeSession{}, "bucket_price"
+	case "equipment_rental":
+		model, column = &models.EquipmentRental{}, "rental_price"
+	default:
+		return 0, false
+	}
+	var amounts []float64
+	if err := tx.Model(model).Where("id = ?", referenceID).Pluck(column, &amounts).Error; err != nil || len(amounts) == 0 {
+		return 0, false
+	}
+	return roundCurrency(amounts[0]), true
+}
+
+// chargesPaid totals the charges that have gone through for a booking,
+// across every tender used.
+func chargesPaid(tx *gorm.DB, referenceType string, referenceID uint) (float64, error) {
+	var paid float64
+	err := tx.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").
+		Where("reference_type = ? AND reference_id = ? AND payment_type = ? AND payment_status IN ?",
+			referenceType, referenceID, "charge", []string{"succeeded", "partially_refunded"}).
+		Scan(&paid).Error
+	return roundCurrency(paid), err
+}
+
+// intentPaymentStatus maps a gateway intent onto the status of a Payment.
+func intentPaymentStatus(intent *payments.Intent) string {
+	switch intent.Status {
+	case payments.StatusSucceeded:
+		return "succeeded"
+	case payments.StatusProcessing, payments.StatusRequiresCapture:
+		return "processing"
+	case payments.StatusCanceled:
+		return "cancelled"
+	case payments.StatusRequiresPaymentMethod:
+		// A declined card returns the intent to this state with an error
+		if intent.FailureReason != "" {
+			return "failed"
+		}
+	}
+	return "pending"
+}
+
+// finalizePayment is the one place a payment changes status. It records the
+// outcome on the payment, posts money taken to the ledger and carries the
+// outcome through to the booking, entry or rental the payment is for.
+func finalizePayment(tx *gorm.DB, payment *models.Payment, status, failureReason string) error {
+	if payment.PaymentStatus == status && payment.FailureReason == failureReason {
+		return nil
+	}
+	updates := map[string]interface{}{
+		"payment_status": status,
+		"failure_reason": failureReason,
+	}
+	if status == "succeeded" || status == "failed" || status == "refunded" {
+		now := time.Now()
+		updates["processed_at"] = now
+		payment.ProcessedAt = &now
+	}
+	if err := tx.Model(payment).Updates(updates).Error; err != nil {
+		return err
+	}
+	payment.PaymentStatus = status
+	payment.FailureReason = failureReason
+
+	if status == "succeeded" {
+		if err := postPaymentLedger(tx, payment, *payment.ProcessedAt); err != nil {
+			return err
+		}
+	}
+
+	if payment.ReferenceType == "gift_card" {
+		if status == "succeeded" {
+			return activateGiftCard(tx, payment)
+		}
+		return nil
+	}
+
+	// Deposits and damage charges do not settle the booking itself
+	if payment.PaymentType != "charge" {
+		return nil
+	}
+	referenceStatus := map[string]string{
+		"succeeded": "paid",
+		"failed":    "failed",
+		"refunded":  "refunded",
+	}[status]
+	if referenceStatus == "" {
+		return nil
+	}
+
+	// A booking paid in parts, e.g. partly by gift card, is paid once the
+	// parts cover it and refunded only once none of them stand
+	if total, ok := bookingAmount(tx, payment.ReferenceType, payment.ReferenceID); ok && referenceStatus != "failed" {
+		paid, err := chargesPaid(tx, payment.ReferenceType, payment.ReferenceID)
+		if err != nil {
+			return err
+		}
+		if (referenceStatus == "paid" && paid < total) || (referenceStatus == "refunded" && paid > 0) {
+			return nil
+		}
+	}
+
+	var query *gorm.DB
+	switch payment.ReferenceType {
+	case "tee_time":
+		query = tx.Model(&models.TeeTime{}).Where("id = ?", payment.ReferenceID)
+	case "range_session":
+		query = tx.Model(&models.RangeSession{}).Where("id = ?", payment.ReferenceID)
+	case "equipment_rental":
+		query = tx.Model(&models.EquipmentRental{}).Where("id = ?", payment.ReferenceID)
+	case "tee_time_share":
+		return updateTeeTimeShare(tx, payment, referenceStatus)
+	case "tournament":
+		if referenceStatus == "refunded" {
+			return nil
+		}
+		query = tx.Model(&models.TournamentParticipant{}).
+			Where("tournament_id = ? AND user_id = ?", payment.ReferenceID, payment.UserID)
+	default:
+		return nil
+	}
+	// A later failed attempt must not undo an earlier successful one
+	if referenceStatus == "failed" {
+		query = query.Where("payment_status <> ?", "paid")
+	}
+	return query.Update("payment_status", referenceStatus).Error
+}
+
+// applyIntent records the gateway's view of a payment.
+func applyIntent(tx *gorm.DB, payment *models.Payment, intent *payments.Intent) error {
+	if payment.StripePaymentIntentID != intent.ID {
+		if err := tx.Model(payment).Update("stripe_payment_intent_id", intent.ID).Error; err != nil {
+			return err
+		}
+		payment.StripePaymentIntentID = intent.ID
+	}
+	status := intentPaymentStatus(intent)
+	reason := ""
+	if status == "failed" {
+		reason = intent.FailureReason
+	}
+	return finalizePayment(tx, payment, status, reason)
+}
+
+// respondProviderError reports a gateway failure: a declined request is the
+// client's problem, anything else a bad gateway.
+func respondProviderError(c *gin.Context, err error, message string) {
+	var providerErr *payments.Error
+	if errors.As(err, &providerErr) && providerErr.StatusCode >= 400 && providerErr.StatusCode < 500 {
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": providerErr.Message})
+		return
+	}
+	c.JSON(http.StatusBadGateway, gin.H{"error": message})
+}
+
+// @Summary Check out a booking
+// @Description Start paying for a tee time, range session or equipment rental. A gift card code pays what it can straight away. Anything left returns a client secret to confirm the card with, or is confirmed straight away when a payment method is given. Rentals are authorized now and charged at pickup.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param checkout body CheckoutRequest true "Booking to pay for"
+// @Success 201 {object} CheckoutResponse
+// @Failure 400 {object} map[string]string
+// @Failure 402 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/checkout [post]
+func (h *PaymentHandler) Checkout(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.checkout(c, userID.(uint), req)
+}
+
+// checkout takes payment for a booking: any gift card first, then a card
+// payment for the rest.
+func (h *PaymentHandler) checkout(c *gin.Context, userID uint, req CheckoutRequest) {
+	db := database.DB
+	var payment models.Payment
+	var giftCardPayment *models.Payment
+	var description, replacedIntentID string
+	var manualCapture bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		item, err := checkoutCharge(tx, req.ReferenceType, req.ReferenceID, userID)
+		if err != nil {
+			return err
+		}
+		amount := item.Amount
+		description, manualCapture = item.Description, item.ManualCapture
+		if item.PaymentType != "charge" && req.GiftCardCode != "" {
+			return newHTTPError(http.StatusBadRequest, "Gift cards cannot be used for damage charges")
+		}
+
+		// Checking out again picks up the open payment rather than charging
+		// twice. A damage charge is raised when the deposit is settled, so it
+		// is always there to pick up, even after a declined card.
+		statuses := []string{"pending", "processing"}
+		if item.PaymentType == "damage_charge" {
+			statuses = append(statuses, "failed")
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference_type = ? AND reference_id = ? AND user_id = ? AND payment_type = ? AND payment_status IN ?",
+				req.ReferenceType, req.ReferenceID, userID, item.PaymentType, statuses).
+			Order("id DESC").First(&payment).Error
+		open := err == nil
+		if open && payment.PaymentStatus == "processing" {
+			return newHTTPError(http.StatusConflict, "A payment for this booking is already being processed")
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if open && payment.PaymentMethod != "credit_card" {
+			// e.g. a damage charge raised against a deposit paid in cash
+			if err := tx.Model(&payment).Update("payment_method", "credit_card").Error; err != nil {
+				return err
+			}
+		}
+
+		if req.GiftCardCode != "" {
+			giftCardPayment, err = redeemGiftCard(tx, req.GiftCardCode, userID, req.ReferenceType, req.ReferenceID, amount, req.GiftCardAmount)
+			if err != nil {
+				return err
+			}
+			amount = roundCurrency(amount - giftCardPayment.Amount)
+			// The open payment was for the amount before the gift card
+			if open {
+				replacedIntentID = payment.StripePaymentIntentID
+				if err := finalizePayment(tx, &payment, "cancelled", "Replaced after a gift card was applied"); err != nil {
+					return err
+				}
+				open = false
+			}
+			if amount <= 0 {
+				return nil
+			}
+		}
+		if open {
+			return nil
+		}
+
+		payment = models.Payment{
+			UserID:        userID,
+			ReferenceType: req.ReferenceType,
+			ReferenceID:   req.ReferenceID,
+			Amount:        amount,
+			Currency:      "USD",
+			PaymentType:   item.PaymentType,
+			PaymentMethod: "credit_card",
+			PaymentStatus: "pending",
+		}
+		return tx.Create(&payment).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to start checkout")
+		return
+	}
+
+	ctx := c.Request.Context()
+	h.cancelIntents(ctx, replacedIntentID)
+	if payment.ID == 0 || payment.PaymentStatus == "cancelled" {
+		// The gift card covered everything
+		c.JSON(http.StatusCreated, CheckoutResponse{GiftCardPayment: giftCardPayment})
+		return
+	}
+
+	var intent *payments.Intent
+	if payment.StripePaymentIntentID != "" && req.PaymentMethodID == "" {
+		intent, err = h.provider.RetrieveIntent(ctx, payment.StripePaymentIntentID)
+	} else {
+		if payment.StripePaymentIntentID != "" {
+			// The earlier attempt is voided first so both cannot go through
+			if _, err := h.provider.CancelIntent(ctx, payment.StripePaymentIntentID); err != nil {
+				previous, retrieveErr := h.provider.RetrieveIntent(ctx, payment.StripePaymentIntentID)
+				if retrieveErr != nil || previous.Status != payments.StatusCanceled {
+					respondProviderError(c, err, "Payment provider is unavailable")
+					return
+				}
+			}
+		}
+		// A new attempt with a card gets its own intent and idempotency key
+		key := "payment-" + strconv.FormatUint(uint64(payment.ID), 10)
+		if req.PaymentMethodID != "" {
+			key += "-" + req.PaymentMethodID + "-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		}
+		intent, err = h.provider.CreateIntent(ctx, payments.IntentParams{
+			Amount:      payments.ToMinorUnits(payment.Amount),
+			Currency:    payment.Currency,
+			Description: description,
+			Metadata: map[string]string{
+				"payment_id":     strconv.FormatUint(uint64(payment.ID), 10),
+				"reference_type": payment.ReferenceType,
+				"reference_id":   strconv.FormatUint(uint64(payment.ReferenceID), 10),
+			},
+			PaymentMethod:  req.PaymentMethodID,
+			ManualCapture:  manualCapture,
+			IdempotencyKey: key,
+		})
+	}
+	if err != nil {
+		respondProviderError(c, err, "Payment provider is unavailable")
+		return
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return applyIntent(tx, &payment, intent)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CheckoutResponse{
+		Payment:         &payment,
+		GiftCardPayment: giftCardPayment,
+		Provider:        h.provider.Name(),
+		IntentStatus:    intent.Status,
+		ClientSecret:    intent.ClientSecret,
+		PublishableKey:  h.publishableKey,
+	})
+}
+
+// cancelIntents cancels the provider intents of payments cancelled here.
+// This is best effort; if an intent went through after all, the webhook
+// refunds it.
+func (h *PaymentHandler) cancelIntents(ctx context.Context, intentIDs ...string) {
+	for _, intentID := range intentIDs {
+		if intentID != "" {
+			h.provider.CancelIntent(ctx, intentID)
+		}
+	}
+}
+
+// @Summary List payments
+// @Description The authenticated user's payments, newest first
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Payment status"
+// @Success 200 {array} models.Payment
+// @Router /payments [get]
+func (h *PaymentHandler) GetPayments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := database.DB.Where("user_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("payment_status = ?", status)
+	}
+
+	var list []models.Payment
+	if err := query.Order("created_at DESC").Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payments"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// @Summary Refresh a payment
+// @Description Fetch the latest status of a payment from the provider, e.g. after the card was confirmed in the browser
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Payment ID"
+// @Success 200 {object} models.Payment
+// @Failure 404 {object} map[string]string
+// @Router /payments/{id}/refresh [post]
+func (h *PaymentHandler) RefreshPayment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	db := database.DB
+	var payment models.Payment
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&payment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+	if payment.StripePaymentIntentID == "" {
+		c.JSON(http.StatusOK, payment)
+		return
+	}
+
+	intent, err := h.provider.RetrieveIntent(c.Request.Context(), payment.StripePaymentIntentID)
+	if err != nil {
+		respondProviderError(c, err, "Payment provider is unavailable")
+		return
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, payment.ID).Error; err != nil {
+			return err
+		}
+		if !webhookTransitionAllowed(payment.PaymentStatus, intentPaymentStatus(intent)) {
+			return nil
+		}
+		return applyIntent(tx, &payment, intent)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// Capture an authorized card payment, e.g. a rental charge when the equipment is picked up
+func (h *PaymentHandler) CapturePayment(c *gin.Context) {
+	db := database.DB
+	var payment models.Payment
+	if err := db.First(&payment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+	if payment.PaymentStatus != "processing" || payment.StripePaymentIntentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payment is not awaiting capture"})
+		return
+	}
+
+	intent, err := h.provider.CaptureIntent(c.Request.Context(), payment.StripePaymentIntentID, 0)
+	if err != nil {
+		respondProviderError(c, err, "Payment provider is unavailable")
+		return
+	}
+	if err := recordCapture(&payment, intent); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// recordCapture records a captured intent against its payment. The payment
+// is locked first, as the webhook for the capture may be recording it at the
+// same time.
+func recordCapture(payment *models.Payment, intent *payments.Intent) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(payment, payment.ID).Error; err != nil {
+			return err
+		}
+		if !webhookTransitionAllowed(payment.PaymentStatus, intentPaymentStatus(intent)) {
+			return nil
+		}
+		return applyIntent(tx, payment, intent)
+	})
+}
+
+// webhookStatuses maps payment intent events onto payment statuses.
+var webhookStatuses = map[string]string{
+	"payment_intent.succeeded":                 "succeeded",
+	"payment_intent.payment_failed":            "failed",
+	"payment_intent.canceled":                  "cancelled",
+	"payment_intent.processing":                "processing",
+	"payment_intent.amount_capturable_updated": "processing",
+}
+
+// webhookTransitionAllowed stops events that arrive out of order from
+// undoing a later outcome: once a payment has succeeded only refunds move
+// it on, and those are recorded as Refunds rather than status changes. A
+// payment cancelled here, e.g. replaced by a split or a gift card, stays
+// cancelled even if its intent went through; see refundSuperseded.
+func webhookTransitionAllowed(from, to string) bool {
+	switch from {
+	case "succeeded", "partially_refunded", "refunded":
+		return false
+	case "cancelled":
+		return to != "succeeded"
+	}
+	return true
+}
+
+// refundSuperseded gives back a payment whose intent went through after the
+// payment was cancelled here, e.g. because the customer confirmed the card
+// as the booking was split. Nothing was sold on the payment, so the refund
+// is recorded against it without touching the ledger.
+func (h *PaymentHandler) refundSuperseded(ctx context.Context, tx *gorm.DB, payment *models.Payment) error {
+	var refunded int64
+	if err := tx.Model(&models.Refund{}).
+		Where("payment_id = ? AND refund_status = ?", payment.ID, "succeeded").Count(&refunded).Error; err != nil {
+		return err
+	}
+	if refunded > 0 {
+		return nil
+	}
+
+	result, err := h.provider.RefundIntent(ctx, payments.RefundParams{
+		IntentID:       payment.StripePaymentIntentID,
+		Amount:         payments.ToMinorUnits(payment.Amount),
+		Reason:         "duplicate",
+		IdempotencyKey: "superseded-" + strconv.FormatUint(uint64(payment.ID), 10),
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return tx.Create(&models.Refund{
+		PaymentID:        payment.ID,
+		Amount:           payment.Amount,
+		ReasonCode:       "duplicate",
+		Notes:            "Paid after the payment was cancelled: " + payment.FailureReason,
+		RefundStatus:     "succeeded",
+		ProviderRefundID: result.ID,
+		ProcessedAt:      &now,
+	}).Error
+}
+
+// applyWebhookEvent carries an event through to the payment it concerns and
+// returns that payment, and whether it went through after being cancelled
+// here and must be refunded. Events for payments this system did not create
+// are ignored.
+func applyWebhookEvent(tx *gorm.DB, event *payments.Event) (*models.Payment, bool, error) {
+	var intentID, status, reason string
+	var charge *payments.Charge
+	switch {
+	case webhookStatuses[event.Type] != "":
+		intent, err := event.Intent()
+		if err != nil {
+			return nil, false, err
+		}
+		intentID, status = intent.ID, webhookStatuses[event.Type]
+		if status == "failed" {
+			reason = intent.FailureReason
+			if reason == "" {
+				reason = "Payment failed"
+			}
+		}
+	case event.Type == "charge.refunded":
+		var err error
+		if charge, err = event.Charge(); err != nil {
+			return nil, false, err
+		}
+		intentID = charge.IntentID
+	default:
+		return nil, false, nil
+	}
+
+	var payment models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("stripe_payment_intent_id = ?", intentID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if charge != nil {
+		// Nothing was sold on a cancelled payment; see refundSuperseded
+		if payment.PaymentStatus == "cancelled" {
+			return &payment, false, nil
+		}
+		return &payment, false, reconcileProviderRefunds(tx, &payment, payments.FromMinorUnits(charge.AmountRefunded))
+	}
+	if !webhookTransitionAllowed(payment.PaymentStatus, status) {
+		return &payment, payment.PaymentStatus == "cancelled" && status == "succeeded", nil
+	}
+	return &payment, false, finalizePayment(tx, &payment, status, reason)
+}
+
+// @Summary Payment webhook
+// @Description Receives signed payment events from Stripe. Each event is applied once; redeliveries are acknowledged and ignored.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param Stripe-Signature header string true "Webhook signature"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /payments/webhook [post]
+func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
+	if h.webhookSecret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Payment webhooks are not configured"})
+		return
+	}
+
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	if err := payments.VerifySignature(payload, c.GetHeader("Stripe-Signature"), h.webhookSecret,
+		payments.DefaultTolerance, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook signature"})
+		return
+	}
+	event, err := payments.ParseEvent(payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook event"})
+		return
+	}
+
+	duplicate := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		record := models.ProcessedWebhookEvent{EventID: event.ID, EventType: event.Type}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			duplicate = true
+			return nil
+		}
+
+		payment, superseded, err := applyWebhookEvent(tx, event)
+		if err != nil {
+			return err
+		}
+		if payment == nil {
+			return nil
+		}
+		if superseded {
+			// Refunded before the event is recorded, so a failure is retried
+			if err := h.refundSuperseded(c.Request.Context(), tx, payment); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&record).Update("payment_id", payment.ID).Error
+	})
+	if err != nil {
+		// The event is not recorded, so the provider's retry will apply it
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": true, "duplicate": duplicate})
+}
@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/promotions"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PromotionHandler struct{}
+
+func NewPromotionHandler() *PromotionHandler {
+	return &PromotionHandler{}
+}
+
+type PromotionRequest struct {
+	Code           string   `json:"code" binding:"required,max=50"`
+	Name           string   `json:"name" binding:"required"`
+	Description    string   `json:"description"`
+	DiscountType   string   `json:"discount_type" binding:"required,oneof=percentage fixed"`
+	DiscountValue  float64  `json:"discount_value" binding:"required,gt=0"`
+	AppliesTo      []string `json:"applies_to" binding:"dive,oneof=green_fee cart range_bucket rental"`
+	ValidFrom      string   `json:"valid_from"`
+	ValidUntil     string   `json:"valid_until"`
+	DaysOfWeek     []int    `json:"days_of_week" binding:"dive,min=0,max=6"`
+	StartTime      string   `json:"start_time"`
+	EndTime        string   `json:"end_time"`
+	MaxRedemptions *int     `json:"max_redemptions" binding:"omitempty,min=1"`
+	MaxPerUser     *int     `json:"max_per_user" binding:"omitempty,min=1"`
+	MembersOnly    bool     `json:"members_only"`
+	IsActive       *bool    `json:"is_active"`
+}
+
+// applyTo copies a validated request onto a promotion. Codes are
+// matched case-insensitively, so they are stored upper case. Dates cover
+// whole days.
+func (req *PromotionRequest) applyTo(promotion *models.Promotion) error {
+	if req.DiscountType == promotions.Percentage && req.DiscountValue > 100 {
+		return newHTTPError(http.StatusBadRequest, "A percentage discount cannot be more than 100")
+	}
+
+	promotion.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	promotion.Name = req.Name
+	promotion.Description = req.Description
+	promotion.DiscountType = req.DiscountType
+	promotion.DiscountValue = roundCurrency(req.DiscountValue)
+	promotion.AppliesTo = strings.Join(req.AppliesTo, ",")
+	promotion.MaxRedemptions = req.MaxRedemptions
+	promotion.MaxPerUser = req.MaxPerUser
+	promotion.MembersOnly = req.MembersOnly
+	if req.IsActive != nil {
+		promotion.IsActive = *req.IsActive
+	}
+	if promotion.Code == "" {
+		return newHTTPError(http.StatusBadRequest, "Promo code is required")
+	}
+
+	promotion.ValidFrom, promotion.ValidUntil = nil, nil
+	if req.ValidFrom != "" {
+		from, err := time.ParseInLocation("2006-01-02", req.ValidFrom, time.Local)
+		if err != nil {
+			return newHTTPError(http.StatusBadRequest, "Invalid valid_from date format")
+		}
+		promotion.ValidFrom = &from
+	}
+	if req.ValidUntil != "" {
+		until, err := time.ParseInLocation("2006-01-02", req.ValidUntil, time.Local)
+		if err != nil {
+			return newHTTPError(http.StatusBadRequest, "Invalid valid_until date format")
+		}
+		until = until.Add(24*time.Hour - time.Second)
+		promotion.ValidUntil = &until
+	}
+	if promotion.ValidFrom != nil && promotion.ValidUntil != nil && promotion.ValidUntil.Before(*promotion.ValidFrom) {
+		return newHTTPError(http.StatusBadRequest, "valid_until must not be before valid_from")
+	}
+
+	days := make([]string, len(req.DaysOfWeek))
+	for i, day := range req.DaysOfWeek {
+		days[i] = strconv.Itoa(day)
+	}
+	promotion.DaysOfWeek = strings.Join(days, ",")
+
+	promotion.StartTime, promotion.EndTime = nil, nil
+	if req.StartTime != "" || req.EndTime != "" {
+		start, err := parseClock(req.StartTime)
+		if err != nil {
+			return newHTTPError(http.StatusBadRequest, "Invalid start_time")
+		}
+		end, err := parseClock(req.EndTime)
+		if err != nil {
+			return newHTTPError(http.StatusBadRequest, "Invalid end_time")
+		}
+		if end <= start {
+			return newHTTPError(http.StatusBadRequest, "end_time must be after start_time")
+		}
+		startClock, endClock := formatClock(start), formatClock(end)
+		promotion.StartTime, promotion.EndTime = &startClock, &endClock
+	}
+	return nil
+}
+
+// promotionRule turns a stored promotion into the terms the promotions
+// package checks.
+func promotionRule(promotion *models.Promotion) promotions.Rule {
+	rule := promotions.Rule{
+		Code:          promotion.Code,
+		DiscountType:  promotion.DiscountType,
+		DiscountValue: promotion.DiscountValue,
+		ValidFrom:     promotion.ValidFrom,
+		ValidUntil:    promotion.ValidUntil,
+		MembersOnly:   promotion.MembersOnly,
+		Active:        promotion.IsActive,
+	}
+	for _, product := range strings.Split(promotion.AppliesTo, ",") {
+		if product = strings.TrimSpace(product); product != "" {
+			rule.Products = append(rule.Products, product)
+		}
+	}
+	for _, day := range strings.Split(promotion.DaysOfWeek, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(day)); err == nil && n >= 0 && n <= 6 {
+			rule.Days = append(rule.Days, time.Weekday(n))
+		}
+	}
+	if promotion.StartTime != nil && promotion.EndTime != nil {
+		start, startErr := parseClock(*promotion.StartTime)
+		end, endErr := parseClock(*promotion.EndTime)
+		if startErr == nil && endErr == nil {
+			rule.StartMinute, rule.EndMinute = start, end
+		}
+	}
+	if promotion.MaxRedemptions != nil {
+		rule.MaxRedemptions = *promotion.MaxRedemptions
+	}
+	if promotion.MaxPerUser != nil {
+		rule.MaxPerUser = *promotion.MaxPerUser
+	}
+	return rule
+}
+
+// activeMember reports whether a user holds a membership that has not
+// expired.
+func activeMember(user *models.User) bool {
+	return user.MembershipExpiry != nil && !user.MembershipExpiry.Before(time.Now())
+}
+
+// promoBooking is a booking a promo code is being used on.
+type promoBooking struct {
+	UserID    uint
+	At        time.Time
+	TimeKnown bool
+	Items     []promotions.Item
+}
+
+// promoCodeMessages tell the customer why their promo code was turned down.
+var promoCodeMessages = map[error]string{
+	promotions.ErrInactive:         "This promo code is not active",
+	promotions.ErrNotStarted:       "This promo code is not valid yet",
+	promotions.ErrExpired:          "This promo code has expired",
+	promotions.ErrWrongDay:         "This promo code is not valid on that day",
+	promotions.ErrWrongTime:        "This promo code is not valid at that time",
+	promotions.ErrMembersOnly:      "This promo code is for members only",
+	promotions.ErrLimitReached:     "This promo code has been fully redeemed",
+	promotions.ErrUserLimitReached: "You have already used this promo code",
+	promotions.ErrNotApplicable:    "This promo code does not apply to this booking",
+}
+
+// promoCodeError turns a promo code check failure into a 400 for the
+// customer; anything else is passed on.
+func promoCodeError(err error) error {
+	if message, ok := promoCodeMessages[err]; ok {
+		return newHTTPError(http.StatusBadRequest, message)
+	}
+	return err
+}
+
+// priceWithPromotion checks a promo code against a booking and works out the
+// discount. The promotion row stays locked until the transaction ends, so
+// redemption limits hold when several bookings use the code at once.
+func priceWithPromotion(tx *gorm.DB, code string, booking promoBooking) (*models.Promotion, *promotions.Breakdown, error) {
+	var promotion models.Promotion
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", strings.ToUpper(strings.TrimSpace(code))).First(&promotion).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, newHTTPError(http.StatusBadRequest, "Promo code not found")
+		}
+		return nil, nil, err
+	}
+
+	var user models.User
+	if err := tx.First(&user, booking.UserID).Error; err != nil {
+		return nil, nil, err
+	}
+	ctx := promotions.Context{
+		Now:       time.Now(),
+		At:        booking.At,
+		TimeKnown: booking.TimeKnown,
+		IsMember:  activeMember(&user),
+	}
+	var total, byUser int64
+	if err := tx.Model(&models.PromotionRedemption{}).
+		Where("promotion_id = ? AND voided_at IS NULL", promotion.ID).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Model(&models.PromotionRedemption{}).
+		Where("promotion_id = ? AND user_id = ? AND voided_at IS NULL", promotion.ID, booking.UserID).Count(&byUser).Error; err != nil {
+		return nil, nil, err
+	}
+	ctx.Redemptions, ctx.UserRedemptions = int(total), int(byUser)
+
+	rule := promotionRule(&promotion)
+	if err := rule.Check(ctx); err != nil {
+		return nil, nil, promoCodeError(err)
+	}
+	breakdown, err := rule.Apply(booking.Items)
+	if err != nil {
+		return nil, nil, promoCodeError(err)
+	}
+	return &promotion, &breakdown, nil
+}
+
+// recordRedemption counts a promo code against the booking it was used on.
+func recordRedemption(tx *gorm.DB, promotion *models.Promotion, userID uint, referenceType string, referenceID uint, breakdown *promotions.Breakdown) error {
+	encoded, err := json.Marshal(breakdown)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.PromotionRedemption{
+		PromotionID:    promotion.ID,
+		UserID:         userID,
+		ReferenceType:  referenceType,
+		ReferenceID:    referenceID,
+		Subtotal:       breakdown.Subtotal,
+		DiscountAmount: breakdown.Discount,
+		Breakdown:      string(encoded),
+	}).Error
+}
+
+// voidRedemption gives a promo code back when the booking it was used on is
+// cancelled or fully refunded, so it no longer counts towards the code's
+// limits. The row is kept because the refund still needs its discount.
+func voidRedemption(tx *gorm.DB, referenceType string, referenceID uint) error {
+	return tx.Model(&models.PromotionRedemption{}).
+		Where("reference_type = ? AND reference_id = ? AND voided_at IS NULL", referenceType, referenceID).
+		Update("voided_at", time.Now()).Error
+}
+
+// redemptionBreakdown loads the discount given on a booking, if any.
+func redemptionBreakdown(tx *gorm.DB, referenceType string, referenceID uint) *promotions.Breakdown {
+	var redemption models.PromotionRedemption
+	if err := tx.Where("reference_type = ? AND reference_id = ?", referenceType, referenceID).
+		First(&redemption).Error; err != nil {
+		return nil
+	}
+	var breakdown promotions.Breakdown
+	if err := json.Unmarshal([]byte(redemption.Breakdown), &breakdown); err != nil {
+		return nil
+	}
+	return &breakdown
+}
+
+// GetPromotions lists promo codes with how often each has been used.
+func (h *PromotionHandler) GetPromotions(c *gin.Context) {
+	query := database.DB.Order("created_at DESC")
+	if active := c.Query("active"); active != "" {
+		query = query.Where("is_active = ?", active == "true")
+	}
+
+	var list []models.Promotion
+	if err := query.Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch promotions"})
+		return
+	}
+
+	type redemptionCount struct {
+		PromotionID uint
+		Count       int
+		Discount    float64
+	}
+	var counts []redemptionCount
+	database.DB.Model(&models.PromotionRedemption{}).
+		Select("promotion_id, COUNT(*) AS count, COALESCE(SUM(discount_amount), 0) AS discount").
+		Where("voided_at IS NULL").Group("promotion_id").Scan(&counts)
+	byPromotion := make(map[uint]redemptionCount, len(counts))
+	for _, count := range counts {
+		byPromotion[count.PromotionID] = count
+	}
+
+	result := make([]gin.H, len(list))
+	for i, promotion := range list {
+		usage := byPromotion[promotion.ID]
+		result[i] = gin.H{
+			"promotion":      promotion,
+			"redemptions":    usage.Count,
+			"total_discount": roundCurrency(usage.Discount),
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPromotion returns a promo code and its redemptions.
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	var promotion models.Promotion
+	if err := database.DB.First(&promotion, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+	var redemptions []models.PromotionRedemption
+	if err := database.DB.Preload("User").Where("promotion_id = ?", promotion.ID).
+		Order("created_at DESC").Find(&redemptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch redemptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promotion": promotion, "redemptions": redemptions})
+}
+
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promotion := models.Promotion{IsActive: true}
+	if err := req.applyTo(&promotion); err != nil {
+		respondError(c, err, "Failed to create promotion")
+		return
+	}
+	if adminID := c.GetUint("user_id"); adminID != 0 {
+		promotion.CreatedBy = &adminID
+	}
+
+	db := database.DB
+	var existing int64
+	db.Model(&models.Promotion{}).Where("code = ?", promotion.Code).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A promotion with this code already exists"})
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&promotion).Error; err != nil {
+			return err
+		}
+		if !promotion.IsActive {
+			return tx.Model(&promotion).Update("is_active", false).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create promotion"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var promotion models.Promotion
+	if err := db.First(&promotion, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+	if err := req.applyTo(&promotion); err != nil {
+		respondError(c, err, "Failed to update promotion")
+		return
+	}
+
+	var clash int64
+	db.Model(&models.Promotion{}).Where("code = ? AND id <> ?", promotion.Code, promotion.ID).Count(&clash)
+	if clash > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A promotion with this code already exists"})
+		return
+	}
+	if err := db.Save(&promotion).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update promotion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// DeletePromotion removes a promo code that has never been used. One with
+// redemptions is deactivated instead so booking discounts keep their record.
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid promotion ID"})
+		return
+	}
+
+	db := database.DB
+	var promotion models.Promotion
+	if err := db.First(&promotion, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	var used int64
+	db.Model(&models.PromotionRedemption{}).Where("promotion_id = ?", promotion.ID).Count(&used)
+	if used > 0 {
+		if err := db.Model(&promotion).Update("is_active", false).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate promotion"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Promotion has %d redemptions and was deactivated instead", used)})
+		return
+	}
+
+	if err := db.Delete(&promotion).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete promotion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted successfully"})
+}
@@ -3,11 +3,13 @@ package handlers
 import (
 	"golf-course-backend/internal/database"
 	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/promotions"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type RangeHandler struct{}
@@ -22,6 +24,14 @@ type RangeSessionRequest struct {
 	DurationMinutes int    `json:"duration_minutes"`
 	BallBucketSize  string `json:"ball_bucket_size" binding:"required"`
 	BayNumber       int    `json:"bay_number"`
+	PromoCode       string `json:"promo_code"`
+}
+
+// RangeSessionBookingResponse is a new range session with the promo code
+// discount breakdown when one was used.
+type RangeSessionBookingResponse struct {
+	models.RangeSession
+	Pricing *promotions.Breakdown `json:"pricing,omitempty"`
 }
 
 // @Summary Book range session
@@ -31,7 +41,7 @@ type RangeSessionRequest struct {
 // @Produce json
 // @Security BearerAuth
 // @Param request body RangeSessionRequest true "Range session request"
-// @Success 201 {object} models.RangeSession
+// @Success 201 {object} RangeSessionBookingResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /range/sessions [post]
@@ -79,15 +89,47 @@ func (h *RangeHandler) BookRangeSession(c *gin.Context) {
 		rangeSession.BayNumber = &req.BayNumber
 	}
 
-	if err := database.DB.Create(&rangeSession).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to book range session"})
+	var pricing *promotions.Breakdown
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var promotion *models.Promotion
+		if req.PromoCode != "" {
+			minutes, err := parseClock(req.StartTime)
+			if err != nil {
+				return newHTTPError(http.StatusBadRequest, "Invalid start time")
+			}
+			promotion, pricing, err = priceWithPromotion(tx, req.PromoCode, promoBooking{
+				UserID:    rangeSession.UserID,
+				At:        sessionDate.Add(time.Duration(minutes) * time.Minute),
+				TimeKnown: true,
+				Items: []promotions.Item{{
+					Product:     promotions.RangeBucket,
+					Description: req.BallBucketSize + " bucket",
+					Amount:      bucketPrice,
+				}},
+			})
+			if err != nil {
+				return err
+			}
+			rangeSession.BucketPrice = pricing.Total
+			rangeSession.DiscountAmount = pricing.Discount
+		}
+		if err := tx.Create(&rangeSession).Error; err != nil {
+			return err
+		}
+		if promotion == nil {
+			return nil
+		}
+		return recordRedemption(tx, promotion, rangeSession.UserID, "range_session", rangeSession.ID, pricing)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to book range session")
 		return
 	}
 
 	// Preload user for response
 	database.DB.Preload("User").First(&rangeSession, rangeSession.ID)
 
-	c.JSON(http.StatusCreated, rangeSession)
+	c.JSON(http.StatusCreated, RangeSessionBookingResponse{RangeSession: rangeSession, Pricing: pricing})
 }
 
 // @Summary Get user's range sessions
@@ -0,0 +1,622 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/payments"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefundRequest struct {
+	// Amount defaults to whatever is left of the payment
+	Amount     float64 `json:"amount" binding:"min=0"`
+	ReasonCode string  `json:"reason_code" binding:"required,oneof=customer_request cancellation weather service_issue duplicate other"`
+	Notes      string  `json:"notes"`
+}
+
+type RefundReviewRequest struct {
+	Notes string `json:"notes"`
+}
+
+// BookingBalance is what has been paid and returned for one booking,
+// derived from its payments and their refunds.
+type BookingBalance struct {
+	ReferenceType  string           `json:"reference_type"`
+	ReferenceID    uint             `json:"reference_id"`
+	Paid           float64          `json:"paid"`
+	Refunded       float64          `json:"refunded"`
+	PendingRefunds float64          `json:"pending_refunds"`
+	Net            float64          `json:"net"`
+	DepositHeld    float64          `json:"deposit_held"`
+	Payments       []models.Payment `json:"payments"`
+}
+
+// refundTotals sums a payment's refunds: succeeded is what has gone back to
+// the customer, committed also counts refunds waiting for approval or the
+// provider.
+func refundTotals(tx *gorm.DB, paymentID uint) (succeeded, committed float64, err error) {
+	var totals struct {
+		Succeeded float64
+		Committed float64
+	}
+	err = tx.Model(&models.Refund{}).
+		Select("COALESCE(SUM(CASE WHEN refund_status = 'succeeded' THEN amount ELSE 0 END), 0) AS succeeded, "+
+			"COALESCE(SUM(amount), 0) AS committed").
+		Where("payment_id = ? AND refund_status IN ?", paymentID, []string{"pending_approval", "approved", "succeeded"}).
+		Scan(&totals).Error
+	return roundCurrency(totals.Succeeded), roundCurrency(totals.Committed), err
+}
+
+// openRefund records a refund against a payment, holding the payment's row
+// lock so concurrent requests cannot together refund more than was paid.
+// Refunds issued by staff (no userID) are approved on the spot. A customer's
+// request cancels the booking once it is paid out, and waits for approval
+// when it takes the payment's refunds over the threshold or the money was
+// paid at the counter.
+func openRefund(tx *gorm.DB, paymentID uint, userID *uint, req RefundRequest, requestedBy uint) (*models.Refund, error) {
+	var payment models.Payment
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", paymentID)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.First(&payment).Error; err != nil {
+		return nil, newHTTPError(http.StatusNotFound, "Payment not found")
+	}
+	if payment.PaymentType == "deposit" {
+		return nil, newHTTPError(http.StatusBadRequest, "Deposits are returned when the rental is settled")
+	}
+	if payment.PaymentType != "charge" && payment.PaymentType != "damage_charge" {
+		return nil, newHTTPError(http.StatusBadRequest, "This payment cannot be refunded")
+	}
+	if payment.ReferenceType == "gift_card" {
+		return nil, newHTTPError(http.StatusBadRequest, "Gift card purchases cannot be refunded")
+	}
+	if payment.PaymentStatus != "succeeded" && payment.PaymentStatus != "partially_refunded" {
+		return nil, newHTTPError(http.StatusBadRequest, "Only completed payments can be refunded")
+	}
+
+	_, committed, err := refundTotals(tx, payment.ID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := roundCurrency(payment.Amount - committed)
+	amount := roundCurrency(req.Amount)
+	if amount == 0 {
+		amount = remaining
+	}
+	if remaining <= 0 {
+		return nil, newHTTPError(http.StatusBadRequest, "Payment has already been refunded")
+	}
+	if amount > remaining {
+		return nil, newHTTPError(http.StatusBadRequest, "Refund exceeds the unrefunded amount of "+strconv.FormatFloat(remaining, 'f', 2, 64))
+	}
+
+	refund := models.Refund{
+		PaymentID:    payment.ID,
+		Amount:       amount,
+		ReasonCode:   req.ReasonCode,
+		Notes:        req.Notes,
+		RefundStatus: "approved",
+		RequestedBy:  &requestedBy,
+	}
+	if userID == nil {
+		now := time.Now()
+		refund.ReviewedBy = &requestedBy
+		refund.ReviewedAt = &now
+	} else {
+		if err := refundableBooking(tx, &payment); err != nil {
+			return nil, err
+		}
+		refund.CancelsBooking = true
+		if payment.StripePaymentIntentID == "" && payment.GiftCardID == nil {
+			// Staff hand the money back at the counter
+			refund.RefundStatus = "pending_approval"
+		} else if roundCurrency(committed+amount) > float64(getSettingInt("refund_approval_threshold", 50)) {
+			// Counted with earlier refunds, so splitting a request up does
+			// not get round approval
+			refund.RefundStatus = "pending_approval"
+		}
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// refundableBooking refuses a customer's refund while the booking it paid
+// for is under way, and for entries and damage charges, which have their own
+// routes.
+func refundableBooking(tx *gorm.DB, payment *models.Payment) error {
+	if payment.PaymentType == "damage_charge" {
+		return newHTTPError(http.StatusBadRequest, "Damage charges are refunded by staff")
+	}
+
+	switch payment.ReferenceType {
+	case "tee_time":
+		var teeTime models.TeeTime
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&teeTime, payment.ReferenceID).Error; err != nil {
+			return err
+		}
+		if teeTime.BookingStatus == "checked_in" {
+			return newHTTPError(http.StatusBadRequest, "The round has already started")
+		}
+	case "tee_time_share":
+		var share models.TeeTimeShare
+		if err := tx.Preload("TeeTime").First(&share, payment.ReferenceID).Error; err != nil {
+			return err
+		}
+		if share.TeeTime.BookingStatus == "confirmed" || share.TeeTime.BookingStatus == "checked_in" {
+			return newHTTPError(http.StatusBadRequest, "Shares of a split booking are refunded once the booking is cancelled")
+		}
+	case "range_session":
+		var session models.RangeSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, payment.ReferenceID).Error; err != nil {
+			return err
+		}
+		if session.SessionStatus == "active" {
+			return newHTTPError(http.StatusBadRequest, "The session has already started")
+		}
+	case "equipment_rental":
+		var rental models.EquipmentRental
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rental, payment.ReferenceID).Error; err != nil {
+			return err
+		}
+		if rental.PickedUpAt != nil && rental.ReturnedAt == nil {
+			return newHTTPError(http.StatusBadRequest, "Return the equipment before asking for a refund")
+		}
+	case "tournament":
+		var entered int64
+		if err := tx.Model(&models.TournamentParticipant{}).
+			Joins("JOIN tournaments ON tournaments.id = tournament_participants.tournament_id").
+			Where("tournament_participants.tournament_id = ? AND tournament_participants.user_id = ? AND tournaments.status IN ?",
+				payment.ReferenceID, payment.UserID, []string{"upcoming", "active"}).
+			Count(&entered).Error; err != nil {
+			return err
+		}
+		if entered > 0 {
+			return newHTTPError(http.StatusBadRequest, "Withdraw from the tournament to have the entry fee refunded")
+		}
+	}
+	return nil
+}
+
+// cancelRefundedBooking cancels the booking a customer's refund has been paid
+// out for, so it cannot be refunded and still used. The money has already
+// gone back by then, so a booking that got under way in the meantime is left
+// as it is. It returns the intents to cancel with the provider once the
+// transaction commits.
+func cancelRefundedBooking(tx *gorm.DB, payment *models.Payment) ([]string, error) {
+	switch payment.ReferenceType {
+	case "tee_time":
+		var teeTime models.TeeTime
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&teeTime, payment.ReferenceID).Error; err != nil {
+			return nil, err
+		}
+		if teeTime.BookingStatus == "confirmed" {
+			return cancelTeeTime(tx, &teeTime)
+		}
+	case "range_session":
+		var session models.RangeSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, payment.ReferenceID).Error; err != nil {
+			return nil, err
+		}
+		if session.SessionStatus == "booked" {
+			if err := tx.Model(&session).Update("session_status", "cancelled").Error; err != nil {
+				return nil, err
+			}
+			return nil, voidRedemption(tx, "range_session", session.ID)
+		}
+	case "equipment_rental":
+		var rental models.EquipmentRental
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rental, payment.ReferenceID).Error; err != nil {
+			return nil, err
+		}
+		// Never collected, so the units go straight back into stock
+		if rental.PickedUpAt == nil && rental.ReturnedAt == nil &&
+			(rental.RentalStatus == "rented" || rental.RentalStatus == "overdue") {
+			return cancelRentalBeforePickup(tx, &rental)
+		}
+	}
+	return nil, nil
+}
+
+// settleRefund marks a refund as paid out and moves its payment to
+// partially refunded or, once nothing is left, refunded, which also gives
+// back any promo code used on the booking.
+func settleRefund(tx *gorm.DB, refund *models.Refund, providerRefundID string) error {
+	now := time.Now()
+	if err := tx.Model(refund).Updates(map[string]interface{}{
+		"refund_status":      "succeeded",
+		"provider_refund_id": providerRefundID,
+		"failure_reason":     "",
+		"processed_at":       now,
+	}).Error; err != nil {
+		return err
+	}
+	refund.RefundStatus = "succeeded"
+	refund.ProviderRefundID = providerRefundID
+	refund.ProcessedAt = &now
+
+	var payment models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, refund.PaymentID).Error; err != nil {
+		return err
+	}
+	if err := postRefundLedger(tx, refund, &payment, now); err != nil {
+		return err
+	}
+	if payment.GiftCardID != nil {
+		if err := refundToGiftCard(tx, &payment, refund); err != nil {
+			return err
+		}
+	}
+	succeeded, _, err := refundTotals(tx, payment.ID)
+	if err != nil {
+		return err
+	}
+	status := "partially_refunded"
+	if succeeded >= roundCurrency(payment.Amount) {
+		status = "refunded"
+		if payment.PaymentType == "charge" {
+			if err := voidRedemption(tx, payment.ReferenceType, payment.ReferenceID); err != nil {
+				return err
+			}
+		}
+	}
+	return finalizePayment(tx, &payment, status, "")
+}
+
+// completeRefund pays out an approved refund. Card payments go back through
+// the provider and gift card payments back onto the gift card; anything else
+// was paid at the counter and is returned there.
+func (h *PaymentHandler) completeRefund(ctx context.Context, refund *models.Refund) error {
+	db := database.DB
+	var payment models.Payment
+	if err := db.First(&payment, refund.PaymentID).Error; err != nil {
+		return err
+	}
+
+	providerRefundID := ""
+	if payment.StripePaymentIntentID != "" {
+		result, err := h.provider.RefundIntent(ctx, payments.RefundParams{
+			IntentID:       payment.StripePaymentIntentID,
+			Amount:         payments.ToMinorUnits(refund.Amount),
+			Reason:         refund.ReasonCode,
+			IdempotencyKey: "refund-" + strconv.FormatUint(uint64(refund.ID), 10),
+		})
+		if err != nil {
+			// A failed refund no longer holds back part of the payment
+			db.Model(refund).Updates(map[string]interface{}{"refund_status": "failed", "failure_reason": err.Error()})
+			refund.RefundStatus = "failed"
+			refund.FailureReason = err.Error()
+			return err
+		}
+		providerRefundID = result.ID
+	}
+
+	var voided []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := settleRefund(tx, refund, providerRefundID); err != nil {
+			return err
+		}
+		if !refund.CancelsBooking {
+			return nil
+		}
+		var err error
+		voided, err = cancelRefundedBooking(tx, &payment)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	h.cancelIntents(ctx, voided...)
+	return nil
+}
+
+// respondRefund reports the outcome of paying out a refund.
+func (h *PaymentHandler) respondRefund(c *gin.Context, refund *models.Refund, status int) {
+	if refund.RefundStatus == "approved" {
+		if err := h.completeRefund(c.Request.Context(), refund); err != nil {
+			if refund.RefundStatus == "failed" {
+				respondProviderError(c, err, "Payment provider is unavailable")
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record refund"})
+			return
+		}
+	}
+	c.JSON(status, refund)
+}
+
+// reconcileProviderRefunds records refunds made directly with the provider,
+// e.g. from its dashboard, so the payment's refunds add up to what the
+// provider reports as returned.
+func reconcileProviderRefunds(tx *gorm.DB, payment *models.Payment, providerRefunded float64) error {
+	_, committed, err := refundTotals(tx, payment.ID)
+	if err != nil {
+		return err
+	}
+	var pending float64
+	if err := tx.Model(&models.Refund{}).Select("COALESCE(SUM(amount), 0)").
+		Where("payment_id = ? AND refund_status = ?", payment.ID, "pending_approval").Scan(&pending).Error; err != nil {
+		return err
+	}
+	// Requests still waiting for approval have not reached the provider
+	missing := roundCurrency(providerRefunded - (committed - pending))
+	if missing <= 0 {
+		return nil
+	}
+	refund := models.Refund{
+		PaymentID:    payment.ID,
+		Amount:       missing,
+		ReasonCode:   "other",
+		Notes:        "Refunded with the payment provider",
+		RefundStatus: "approved",
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return err
+	}
+	return settleRefund(tx, &refund, "")
+}
+
+// bookingBalance adds up the payments for a booking, optionally only those of
+// one user.
+func bookingBalance(referenceType string, referenceID uint, userID *uint) (*BookingBalance, error) {
+	query := database.DB.Preload("Refunds").
+		Where("reference_type = ? AND reference_id = ?", referenceType, referenceID)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	balance := BookingBalance{ReferenceType: referenceType, ReferenceID: referenceID}
+	if err := query.Order("created_at ASC").Find(&balance.Payments).Error; err != nil {
+		return nil, err
+	}
+
+	for _, payment := range balance.Payments {
+		settled := payment.PaymentStatus == "succeeded" || payment.PaymentStatus == "partially_refunded" ||
+			payment.PaymentStatus == "refunded"
+		if !settled {
+			continue
+		}
+		var refunded, pending float64
+		for _, refund := range payment.Refunds {
+			switch refund.RefundStatus {
+			case "succeeded":
+				refunded += refund.Amount
+			case "pending_approval", "approved":
+				pending += refund.Amount
+			}
+		}
+
+		switch payment.PaymentType {
+		case "charge", "damage_charge":
+			balance.Paid += payment.Amount
+			balance.Refunded += refunded
+			balance.PendingRefunds += pending
+		case "deposit":
+			balance.DepositHeld += payment.Amount - refunded
+		case "deposit_refund":
+			// Deposit returns recorded before refunds existed
+			balance.DepositHeld -= payment.Amount
+		}
+	}
+	balance.Paid = roundCurrency(balance.Paid)
+	balance.Refunded = roundCurrency(balance.Refunded)
+	balance.PendingRefunds = roundCurrency(balance.PendingRefunds)
+	balance.Net = roundCurrency(balance.Paid - balance.Refunded)
+	balance.DepositHeld = roundCurrency(balance.DepositHeld)
+	return &balance, nil
+}
+
+// balanceQuery reads the booking a balance is asked for.
+func balanceQuery(c *gin.Context) (string, uint, bool) {
+	referenceType := c.Query("reference_type")
+	referenceID, err := strconv.ParseUint(c.Query("reference_id"), 10, 64)
+	if referenceType == "" || err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reference_type and reference_id are required"})
+		return "", 0, false
+	}
+	return referenceType, uint(referenceID), true
+}
+
+// @Summary Request a refund
+// @Description Ask for all or part of a payment back. The booking it paid for is cancelled once the refund is paid out. Card and gift card refunds that keep the payment's refunds within the approval threshold are made straight away; larger ones, and money paid at the counter, wait for staff approval.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Payment ID"
+// @Param refund body RefundRequest true "Refund"
+// @Success 201 {object} models.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /payments/{id}/refunds [post]
+func (h *PaymentHandler) RequestRefund(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	paymentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid := userID.(uint)
+	var refund *models.Refund
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		refund, err = openRefund(tx, uint(paymentID), &uid, req, uid)
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to request refund")
+		return
+	}
+
+	h.respondRefund(c, refund, http.StatusCreated)
+}
+
+// @Summary Booking balance
+// @Description What the user has paid and had refunded for one booking
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param reference_type query string true "tee_time, range_session, equipment_rental or tournament"
+// @Param reference_id query int true "Booking ID"
+// @Success 200 {object} BookingBalance
+// @Failure 400 {object} map[string]string
+// @Router /payments/balance [get]
+func (h *PaymentHandler) GetBookingBalance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	referenceType, referenceID, ok := balanceQuery(c)
+	if !ok {
+		return
+	}
+
+	uid := userID.(uint)
+	balance, err := bookingBalance(referenceType, referenceID, &uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch balance"})
+		return
+	}
+	c.JSON(http.StatusOK, balance)
+}
+
+// Balance of any booking across all of its payments
+func (h *PaymentHandler) GetStaffBookingBalance(c *gin.Context) {
+	referenceType, referenceID, ok := balanceQuery(c)
+	if !ok {
+		return
+	}
+
+	balance, err := bookingBalance(referenceType, referenceID, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch balance"})
+		return
+	}
+	c.JSON(http.StatusOK, balance)
+}
+
+// List refunds, e.g. those waiting for approval
+func (h *PaymentHandler) GetRefunds(c *gin.Context) {
+	query := database.DB.Preload("Payment.User")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("refund_status = ?", status)
+	}
+
+	var refunds []models.Refund
+	if err := query.Order("created_at DESC").Find(&refunds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+	c.JSON(http.StatusOK, refunds)
+}
+
+// Refund a payment on the customer's behalf, e.g. after a cancellation
+func (h *PaymentHandler) IssueRefund(c *gin.Context) {
+	paymentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var refund *models.Refund
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		refund, err = openRefund(tx, uint(paymentID), nil, req, c.GetUint("user_id"))
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to issue refund")
+		return
+	}
+
+	h.respondRefund(c, refund, http.StatusCreated)
+}
+
+// reviewRefund records a staff decision on a refund waiting for approval.
+func reviewRefund(c *gin.Context, status string) (*models.Refund, bool) {
+	var req RefundReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	staffID := c.GetUint("user_id")
+	var refund models.Refund
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, c.Param("id")).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Refund not found")
+		}
+		if refund.RefundStatus != "pending_approval" {
+			return newHTTPError(http.StatusBadRequest, "Refund is not waiting for approval")
+		}
+		if status == "approved" && refund.CancelsBooking {
+			// The booking may have got under way while the request waited
+			var payment models.Payment
+			if err := tx.First(&payment, refund.PaymentID).Error; err != nil {
+				return err
+			}
+			if err := refundableBooking(tx, &payment); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		refund.RefundStatus = status
+		refund.ReviewedBy = &staffID
+		refund.ReviewedAt = &now
+		refund.Notes = appendNote(refund.Notes, req.Notes)
+		return tx.Save(&refund).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to review refund")
+		return nil, false
+	}
+	return &refund, true
+}
+
+// Approve a refund over the threshold and pay it out
+func (h *PaymentHandler) ApproveRefund(c *gin.Context) {
+	refund, ok := reviewRefund(c, "approved")
+	if !ok {
+		return
+	}
+	h.respondRefund(c, refund, http.StatusOK)
+}
+
+// Turn down a refund request
+func (h *PaymentHandler) RejectRefund(c *gin.Context) {
+	refund, ok := reviewRefund(c, "rejected")
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, refund)
+}
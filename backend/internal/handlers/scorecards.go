@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScorecardHandler struct{}
+
+func NewScorecardHandler() *ScorecardHandler {
+	return &ScorecardHandler{}
+}
+
+type ScorecardRequest struct {
+	CourseID          uint               `json:"course_id"`
+	TeeTimeID         *uint              `json:"tee_time_id"`
+	TeeSetID          *uint              `json:"tee_set_id"`
+	TournamentID      *uint              `json:"tournament_id"`
+	TournamentRound   int                `json:"tournament_round" binding:"min=0"`
+	PlayedDate        string             `json:"played_date"`
+	WeatherConditions string             `json:"weather_conditions"`
+	Notes             string             `json:"notes"`
+	Holes             []HoleScoreRequest `json:"holes" binding:"dive"`
+}
+
+type HoleScoreRequest struct {
+	HoleID            uint `json:"hole_id" binding:"required"`
+	Strokes           int  `json:"strokes" binding:"required,min=1,max=20"`
+	Putts             int  `json:"putts" binding:"min=0,max=10"`
+	FairwayHit        bool `json:"fairway_hit"`
+	GreenInRegulation bool `json:"green_in_regulation"`
+	SandAttempts      int  `json:"sand_attempts" binding:"min=0"`
+	SandSaves         int  `json:"sand_saves" binding:"min=0"`
+	Penalties         int  `json:"penalties" binding:"min=0"`
+}
+
+type HoleScoresRequest struct {
+	Holes []HoleScoreRequest `json:"holes" binding:"required,min=1,dive"`
+}
+
+// loadScorecard fetches a scorecard with its holes in playing order.
+func loadScorecard(db *gorm.DB, id uint) (models.Scorecard, error) {
+	var scorecard models.Scorecard
+	if err := db.Preload("Course").Preload("TeeSet").Preload("Holes.Hole").First(&scorecard, id).Error; err != nil {
+		return scorecard, err
+	}
+	sort.Slice(scorecard.Holes, func(i, j int) bool {
+		return scorecard.Holes[i].Hole.HoleNumber < scorecard.Holes[j].Hole.HoleNumber
+	})
+	return scorecard, nil
+}
+
+// saveHoleScores validates hole scores against the scorecard's course and
+// inserts or replaces them, then recomputes the card's totals and the
+// player's handicap.
+func saveHoleScores(tx *gorm.DB, scorecard *models.Scorecard, scores []HoleScoreRequest) error {
+	var holes []models.Hole
+	if err := tx.Where("course_id = ?", scorecard.CourseID).Find(&holes).Error; err != nil {
+		return err
+	}
+	holesByID := make(map[uint]models.Hole, len(holes))
+	for _, hole := range holes {
+		holesByID[hole.ID] = hole
+	}
+
+	seen := make(map[uint]bool, len(scores))
+	for _, score := range scores {
+		hole, ok := holesByID[score.HoleID]
+		if !ok {
+			return newHTTPError(http.StatusBadRequest, fmt.Sprintf("Hole %d is not on this scorecard's course", score.HoleID))
+		}
+		if seen[score.HoleID] {
+			return newHTTPError(http.StatusBadRequest, fmt.Sprintf("Hole %d is scored more than once", hole.HoleNumber))
+		}
+		seen[score.HoleID] = true
+
+		if score.Putts > score.Strokes {
+			return newHTTPError(http.StatusBadRequest, fmt.Sprintf("Hole %d: putts cannot exceed strokes", hole.HoleNumber))
+		}
+		if score.SandSaves > score.SandAttempts {
+			return newHTTPError(http.StatusBadRequest, fmt.Sprintf("Hole %d: sand saves cannot exceed sand attempts", hole.HoleNumber))
+		}
+		if score.Penalties >= score.Strokes {
+			return newHTTPError(http.StatusBadRequest, fmt.Sprintf("Hole %d: penalties must be fewer than strokes", hole.HoleNumber))
+		}
+
+		entry := models.ScorecardHole{
+			ScorecardID:       scorecard.ID,
+			HoleID:            hole.ID,
+			Strokes:           score.Strokes,
+			Putts:             score.Putts,
+			FairwayHit:        score.FairwayHit && hole.Par > 3,
+			GreenInRegulation: score.GreenInRegulation,
+			SandAttempts:      score.SandAttempts,
+			SandSaves:         score.SandSaves,
+			Penalties:         score.Penalties,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "scorecard_id"}, {Name: "hole_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"strokes", "putts", "fairway_hit", "green_in_regulation", "sand_attempts", "sand_saves", "penalties"}),
+		}).Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := recomputeScorecardTotals(tx, scorecard); err != nil {
+		return err
+	}
+	return recalculateHandicap(tx, scorecard.UserID)
+}
+
+// recomputeScorecardTotals derives the card's totals from its hole scores.
+// Fairways only count on par 4s and 5s.
+func recomputeScorecardTotals(tx *gorm.DB, scorecard *models.Scorecard) error {
+	var entries []models.ScorecardHole
+	if err := tx.Preload("Hole").Where("scorecard_id = ?", scorecard.ID).Find(&entries).Error; err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		scorecard.TotalScore = nil
+		scorecard.TotalPutts = nil
+		scorecard.FairwaysHit = nil
+		scorecard.GreensInRegulation = nil
+	} else {
+		var score, putts, fairways, greens int
+		for _, entry := range entries {
+			score += entry.Strokes
+			putts += entry.Putts
+			if entry.FairwayHit && entry.Hole.Par > 3 {
+				fairways++
+			}
+			if entry.GreenInRegulation {
+				greens++
+			}
+		}
+		scorecard.TotalScore = &score
+		scorecard.TotalPutts = &putts
+		scorecard.FairwaysHit = &fairways
+		scorecard.GreensInRegulation = &greens
+	}
+
+	return tx.Model(scorecard).Select("total_score", "total_putts", "fairways_hit", "greens_in_regulation").Updates(scorecard).Error
+}
+
+// @Summary Start a scorecard
+// @Description Start a scorecard for a course, optionally from a tee time booking, with any holes already played
+// @Tags scorecards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ScorecardRequest true "Scorecard request"
+// @Success 201 {object} models.Scorecard
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /scorecards [post]
+func (h *ScorecardHandler) CreateScorecard(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ScorecardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	scorecard := models.Scorecard{
+		UserID:            userID.(uint),
+		CourseID:          req.CourseID,
+		TeeTimeID:         req.TeeTimeID,
+		TeeSetID:          req.TeeSetID,
+		WeatherConditions: req.WeatherConditions,
+		Notes:             req.Notes,
+	}
+
+	if req.TeeTimeID != nil {
+		var teeTime models.TeeTime
+		if err := db.Where("id = ? AND user_id = ?", *req.TeeTimeID, scorecard.UserID).First(&teeTime).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tee time not found"})
+			return
+		}
+		if teeTime.BookingStatus == "cancelled" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tee time was cancelled"})
+			return
+		}
+		if req.CourseID != 0 && req.CourseID != teeTime.CourseID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Course does not match the tee time"})
+			return
+		}
+
+		var existing int64
+		db.Model(&models.Scorecard{}).Where("tee_time_id = ? AND user_id = ?", teeTime.ID, scorecard.UserID).Count(&existing)
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A scorecard already exists for this tee time"})
+			return
+		}
+
+		scorecard.CourseID = teeTime.CourseID
+		scorecard.PlayedDate = teeTime.BookingDate
+		if scorecard.TeeSetID == nil {
+			scorecard.TeeSetID = teeTime.TeeSetID
+		}
+	}
+
+	var tournament *models.Tournament
+	if req.TournamentID != nil {
+		tournament = &models.Tournament{}
+		if err := db.First(tournament, *req.TournamentID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+			return
+		}
+		if scorecard.CourseID == 0 {
+			scorecard.CourseID = tournament.CourseID
+		}
+		if scorecard.CourseID != tournament.CourseID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tournament is played on another course"})
+			return
+		}
+
+		var entered int64
+		db.Model(&models.TournamentParticipant{}).
+			Where("tournament_id = ? AND user_id = ?", tournament.ID, scorecard.UserID).
+			Count(&entered)
+		if entered == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Not registered for this tournament"})
+			return
+		}
+
+		round := max(req.TournamentRound, 1)
+		var existing int64
+		db.Model(&models.Scorecard{}).
+			Where("tournament_id = ? AND tournament_round = ? AND user_id = ?", tournament.ID, round, scorecard.UserID).
+			Count(&existing)
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A scorecard already exists for this tournament round"})
+			return
+		}
+
+		scorecard.TournamentID = &tournament.ID
+		scorecard.TournamentRound = &round
+		scorecard.IsTournamentRound = true
+	}
+
+	if scorecard.CourseID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "course_id or tee_time_id is required"})
+		return
+	}
+
+	var course models.Course
+	if err := db.First(&course, scorecard.CourseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	if _, err := resolveTeeSet(db, course.ID, scorecard.TeeSetID); err != nil {
+		respondError(c, err, "Failed to create scorecard")
+		return
+	}
+
+	if req.PlayedDate != "" {
+		playedDate, err := time.Parse("2006-01-02", req.PlayedDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid played date format"})
+			return
+		}
+		scorecard.PlayedDate = playedDate
+	}
+	if scorecard.PlayedDate.IsZero() {
+		now := time.Now()
+		scorecard.PlayedDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if scorecard.PlayedDate.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot record a round in the future"})
+		return
+	}
+	if tournament != nil {
+		played := scorecard.PlayedDate.Format("2006-01-02")
+		if played < tournament.StartDate.Format("2006-01-02") || played > tournament.EndDate.Format("2006-01-02") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Round was not played during the tournament"})
+			return
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&scorecard).Error; err != nil {
+			return err
+		}
+		if len(req.Holes) == 0 {
+			return nil
+		}
+		return saveHoleScores(tx, &scorecard, req.Holes)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create scorecard")
+		return
+	}
+
+	scorecard, _ = loadScorecard(db, scorecard.ID)
+
+	c.JSON(http.StatusCreated, scorecard)
+}
+
+// @Summary Record hole scores
+// @Description Add or replace hole-by-hole scores on a scorecard; totals are recomputed
+// @Tags scorecards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Scorecard ID"
+// @Param request body HoleScoresRequest true "Hole scores"
+// @Success 200 {object} models.Scorecard
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /scorecards/{id}/holes [put]
+func (h *ScorecardHandler) UpdateHoleScores(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scorecard ID"})
+		return
+	}
+
+	var req HoleScoresRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var scorecard models.Scorecard
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", id, userID).First(&scorecard).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Scorecard not found")
+		}
+		return saveHoleScores(tx, &scorecard, req.Holes)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to save hole scores")
+		return
+	}
+
+	scorecard, _ = loadScorecard(database.DB, scorecard.ID)
+
+	c.JSON(http.StatusOK, scorecard)
+}
+
+// @Summary Get user's scorecards
+// @Description List the authenticated user's rounds, newest first
+// @Tags scorecards
+// @Produce json
+// @Security BearerAuth
+// @Param course_id query int false "Course ID"
+// @Success 200 {array} models.Scorecard
+// @Failure 401 {object} map[string]string
+// @Router /scorecards [get]
+func (h *ScorecardHandler) GetUserScorecards(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := database.DB.Preload("Course").Where("user_id = ?", userID)
+	if courseID := c.Query("course_id"); courseID != "" {
+		query = query.Where("course_id = ?", courseID)
+	}
+
+	var scorecards []models.Scorecard
+	if err := query.Order("played_date DESC, created_at DESC").Find(&scorecards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scorecards"})
+		return
+	}
+
+	c.JSON(http.StatusOK, scorecards)
+}
+
+// @Summary Get scorecard
+// @Description Get a scorecard with its hole-by-hole scores
+// @Tags scorecards
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Scorecard ID"
+// @Success 200 {object} models.Scorecard
+// @Failure 404 {object} map[string]string
+// @Router /scorecards/{id} [get]
+func (h *ScorecardHandler) GetScorecard(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scorecard ID"})
+		return
+	}
+
+	scorecard, err := loadScorecard(database.DB, uint(id))
+	if err != nil || scorecard.UserID != userID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scorecard not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, scorecard)
+}
+
+// @Summary Delete scorecard
+// @Description Delete one of the authenticated user's rounds
+// @Tags scorecards
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Scorecard ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /scorecards/{id} [delete]
+func (h *ScorecardHandler) DeleteScorecard(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scorecard ID"})
+		return
+	}
+
+	db := database.DB
+	var scorecard models.Scorecard
+	if err := db.Where("id = ? AND user_id = ?", id, userID).First(&scorecard).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scorecard not found"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scorecard_id = ?", scorecard.ID).Delete(&models.ScorecardHole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&scorecard).Error; err != nil {
+			return err
+		}
+		return recalculateHandicap(tx, scorecard.UserID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete scorecard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Scorecard deleted successfully"})
+}
@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/mail"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SplitPlayer is a player invited to pay their share of a tee time. A
+// player without an email address is sent the link by the organizer.
+type SplitPlayer struct {
+	Name  string `json:"name" binding:"max=100"`
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// SplitPaymentRequest lists the players joining the organizer, who always
+// takes the first share.
+type SplitPaymentRequest struct {
+	Players []SplitPlayer `json:"players" binding:"dive"`
+}
+
+type ShareCheckoutRequest struct {
+	PaymentMethodID string  `json:"payment_method_id"`
+	GiftCardCode    string  `json:"gift_card_code"`
+	GiftCardAmount  float64 `json:"gift_card_amount" binding:"omitempty,gt=0"`
+}
+
+type TeeTimeShareView struct {
+	models.TeeTimeShare
+	PaymentLink string `json:"payment_link"`
+}
+
+// SplitPaymentSummary shows who has paid their share of a tee time.
+type SplitPaymentSummary struct {
+	TeeTimeID     uint               `json:"tee_time_id"`
+	TotalAmount   float64            `json:"total_amount"`
+	Paid          float64            `json:"paid"`
+	Outstanding   float64            `json:"outstanding"`
+	PaidCount     int                `json:"paid_count"`
+	PlayersCount  int                `json:"players_count"`
+	PaymentStatus string             `json:"payment_status"`
+	Shares        []TeeTimeShareView `json:"shares"`
+}
+
+// ShareInvitation is what the holder of a payment link sees before paying.
+type ShareInvitation struct {
+	PlayerNumber  int       `json:"player_number"`
+	PlayerName    string    `json:"player_name"`
+	Amount        float64   `json:"amount"`
+	PaymentStatus string    `json:"payment_status"`
+	CourseName    string    `json:"course_name"`
+	BookingDate   time.Time `json:"booking_date"`
+	TeeTime       string    `json:"tee_time"`
+	PlayersCount  int       `json:"players_count"`
+	OrganizerName string    `json:"organizer_name"`
+	BookingStatus string    `json:"booking_status"`
+}
+
+// splitAmount divides an amount into equal shares to the cent. The first
+// share, the organizer's, takes any cents left over.
+func splitAmount(total float64, shares int) []float64 {
+	cents := int64(math.Round(total * 100))
+	each, rest := cents/int64(shares), cents%int64(shares)
+	amounts := make([]float64, shares)
+	for i := range amounts {
+		amounts[i] = float64(each) / 100
+	}
+	amounts[0] = float64(each+rest) / 100
+	return amounts
+}
+
+func newShareToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (h *PaymentHandler) shareLink(share *models.TeeTimeShare) string {
+	return h.frontendURL + "/tee-time-shares/" + share.PaymentToken
+}
+
+// updateTeeTimeShare carries a payment outcome through to a player's share
+// and then to the tee time: it is paid once every share is, and refunded
+// once no share is paid any more.
+func updateTeeTimeShare(tx *gorm.DB, payment *models.Payment, status string) error {
+	var share models.TeeTimeShare
+	if err := tx.First(&share, payment.ReferenceID).Error; err != nil {
+		return err
+	}
+	// Shares of one booking settle one at a time
+	var teeTime models.TeeTime
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&teeTime, share.TeeTimeID).Error; err != nil {
+		return err
+	}
+	if status == "failed" && share.PaymentStatus == "paid" {
+		return nil
+	}
+
+	updates := map[string]interface{}{"payment_status": status}
+	if status == "paid" {
+		updates["paid_by"] = payment.UserID
+		updates["paid_at"] = payment.ProcessedAt
+	}
+	if err := tx.Model(&share).Updates(updates).Error; err != nil {
+		return err
+	}
+	if status == "failed" {
+		return nil
+	}
+
+	var unpaid, paid int64
+	if err := tx.Model(&models.TeeTimeShare{}).Where("tee_time_id = ? AND payment_status <> ?", teeTime.ID, "paid").
+		Count(&unpaid).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.TeeTimeShare{}).Where("tee_time_id = ? AND payment_status = ?", teeTime.ID, "paid").
+		Count(&paid).Error; err != nil {
+		return err
+	}
+	switch {
+	case status == "paid" && unpaid == 0:
+		return tx.Model(&teeTime).Update("payment_status", "paid").Error
+	case status == "refunded" && paid == 0:
+		return tx.Model(&teeTime).Update("payment_status", "refunded").Error
+	}
+	return nil
+}
+
+// settleTeeTimeShares records payment taken at the counter for every share
+// still unpaid, booked to the organizer.
+func settleTeeTimeShares(tx *gorm.DB, teeTime *models.TeeTime, method string, staffID uint) error {
+	var shares []models.TeeTimeShare
+	if err := tx.Where("tee_time_id = ? AND payment_status IN ?", teeTime.ID, []string{"pending", "failed"}).
+		Order("player_number").Find(&shares).Error; err != nil {
+		return err
+	}
+	for _, share := range shares {
+		// Part of a share may already be paid, e.g. by gift card
+		paid, err := chargesPaid(tx, "tee_time_share", share.ID)
+		if err != nil {
+			return err
+		}
+		payment := models.Payment{
+			UserID:        teeTime.UserID,
+			ReferenceType: "tee_time_share",
+			ReferenceID:   share.ID,
+			Amount:        roundCurrency(share.Amount - paid),
+			Currency:      "USD",
+			PaymentType:   "charge",
+			PaymentMethod: method,
+			PaymentStatus: "pending",
+		}
+		if payment.Amount <= 0 {
+			continue
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		if err := finalizePayment(tx, &payment, "succeeded", ""); err != nil {
+			return err
+		}
+		if err := tx.Model(&share).Updates(map[string]interface{}{
+			"paid_by":    nil,
+			"settled_by": staffID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitSummary adds up a split booking's shares.
+func (h *PaymentHandler) splitSummary(teeTimeID uint) (*SplitPaymentSummary, error) {
+	var teeTime models.TeeTime
+	if err := database.DB.Preload("Shares", func(db *gorm.DB) *gorm.DB {
+		return db.Order("player_number")
+	}).First(&teeTime, teeTimeID).Error; err != nil {
+		return nil, err
+	}
+
+	summary := SplitPaymentSummary{
+		TeeTimeID:     teeTime.ID,
+		TotalAmount:   teeTime.TotalAmount,
+		PlayersCount:  len(teeTime.Shares),
+		PaymentStatus: teeTime.PaymentStatus,
+		Shares:        make([]TeeTimeShareView, len(teeTime.Shares)),
+	}
+	for i := range teeTime.Shares {
+		share := teeTime.Shares[i]
+		paid, err := chargesPaid(database.DB, "tee_time_share", share.ID)
+		if err != nil {
+			return nil, err
+		}
+		summary.Paid += paid
+		if share.PaymentStatus == "paid" {
+			summary.PaidCount++
+		}
+		summary.Shares[i] = TeeTimeShareView{TeeTimeShare: share, PaymentLink: h.shareLink(&share)}
+	}
+	summary.Paid = roundCurrency(summary.Paid)
+	summary.Outstanding = math.Max(roundCurrency(summary.TotalAmount-summary.Paid), 0)
+	return &summary, nil
+}
+
+// inviteShare emails a player the link to pay their share.
+func (h *PaymentHandler) inviteShare(share *models.TeeTimeShare, teeTime *models.TeeTime) error {
+	name := share.PlayerName
+	if name == "" {
+		name = "there"
+	}
+	seller := sellerName()
+	err := h.mailer.Send(mail.Message{
+		To:      []string{share.PlayerEmail},
+		Subject: fmt.Sprintf("Your share of a tee time at %s", teeTime.Course.Name),
+		Body: fmt.Sprintf("Hello %s,\n\n%s %s has booked a tee time for %d players at %s on %s at %s "+
+			"and split the cost. Your share is %s.\n\nPay your share here:\n%s\n\nThank you,\n%s\n",
+			name, teeTime.User.FirstName, teeTime.User.LastName, teeTime.PlayersCount, teeTime.Course.Name,
+			teeTime.BookingDate.Format("Monday, Jan 2"), teeTime.TeeTime, formatMoney(share.Amount),
+			h.shareLink(share), seller),
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	share.InvitedAt = &now
+	return database.DB.Model(share).Update("invited_at", now).Error
+}
+
+// @Summary Split a tee time between the players
+// @Description Turn an unpaid tee time into one share per player. The organizer takes the first share; each invited player with an email address is sent a link to pay theirs.
+// @Tags tee-times
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tee time ID"
+// @Param request body SplitPaymentRequest true "Players to invite"
+// @Success 201 {object} SplitPaymentSummary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tee-times/{id}/split [post]
+func (h *PaymentHandler) SplitTeeTime(c *gin.Context) {
+	var req SplitPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var teeTime models.TeeTime
+	var replacedIntentIDs []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("user_id")).First(&teeTime).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Tee time not found")
+		}
+		switch {
+		case teeTime.BookingStatus != "confirmed":
+			return newHTTPError(http.StatusBadRequest, "Only confirmed bookings can be split")
+		case teeTime.SplitPayment:
+			return newHTTPError(http.StatusBadRequest, "This booking is already split")
+		case teeTime.PlayersCount < 2:
+			return newHTTPError(http.StatusBadRequest, "A booking for one player cannot be split")
+		case len(req.Players) > teeTime.PlayersCount-1:
+			return newHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("This booking has room for %d other players", teeTime.PlayersCount-1))
+		}
+
+		paid, err := chargesPaid(tx, "tee_time", teeTime.ID)
+		if err != nil {
+			return err
+		}
+		if paid > 0 {
+			return newHTTPError(http.StatusBadRequest, "Payment has already been taken for this booking")
+		}
+		// A checkout already started for the whole booking is dropped
+		var open []models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference_type = ? AND reference_id = ? AND payment_type = ? AND payment_status IN ?",
+				"tee_time", teeTime.ID, "charge", []string{"pending", "processing"}).Find(&open).Error; err != nil {
+			return err
+		}
+		for i := range open {
+			if open[i].PaymentStatus == "processing" {
+				return newHTTPError(http.StatusConflict, "A payment for this booking is already being processed")
+			}
+			if open[i].StripePaymentIntentID != "" {
+				replacedIntentIDs = append(replacedIntentIDs, open[i].StripePaymentIntentID)
+			}
+			if err := finalizePayment(tx, &open[i], "cancelled", "Replaced by split payment"); err != nil {
+				return err
+			}
+		}
+
+		for i, amount := range splitAmount(teeTime.TotalAmount, teeTime.PlayersCount) {
+			token, err := newShareToken()
+			if err != nil {
+				return err
+			}
+			share := models.TeeTimeShare{
+				TeeTimeID:     teeTime.ID,
+				PlayerNumber:  i + 1,
+				Amount:        amount,
+				PaymentToken:  token,
+				PaymentStatus: "pending",
+			}
+			if i > 0 && i <= len(req.Players) {
+				share.PlayerName = req.Players[i-1].Name
+				share.PlayerEmail = req.Players[i-1].Email
+			}
+			if err := tx.Create(&share).Error; err != nil {
+				return err
+			}
+		}
+		teeTime.SplitPayment = true
+		return tx.Model(&teeTime).Updates(map[string]interface{}{
+			"split_payment":  true,
+			"payment_status": "pending",
+		}).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to split booking")
+		return
+	}
+
+	h.cancelIntents(c.Request.Context(), replacedIntentIDs...)
+
+	// Invitations that fail to send can be sent again from the share
+	database.DB.Preload("Course").Preload("User").Preload("Shares").First(&teeTime, teeTime.ID)
+	for i := range teeTime.Shares {
+		if teeTime.Shares[i].PlayerNumber > 1 && teeTime.Shares[i].PlayerEmail != "" && h.mailer.Enabled() {
+			h.inviteShare(&teeTime.Shares[i], &teeTime)
+		}
+	}
+
+	summary, err := h.splitSummary(teeTime.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shares"})
+		return
+	}
+	c.JSON(http.StatusCreated, summary)
+}
+
+// @Summary Get the shares of a split tee time
+// @Description Who has paid their share of a split booking, with each share's payment link
+// @Tags tee-times
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tee time ID"
+// @Success 200 {object} SplitPaymentSummary
+// @Failure 404 {object} map[string]string
+// @Router /tee-times/{id}/shares [get]
+func (h *PaymentHandler) GetTeeTimeShares(c *gin.Context) {
+	var teeTime models.TeeTime
+	if err := database.DB.Where("id = ? AND user_id = ? AND split_payment = ?", c.Param("id"), c.GetUint("user_id"), true).
+		First(&teeTime).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Split booking not found"})
+		return
+	}
+
+	summary, err := h.splitSummary(teeTime.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shares"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// @Summary Invite a player to pay a share
+// @Description Set who a share is for and email them the payment link, e.g. to invite a late addition or resend a lost email
+// @Tags tee-times
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tee time ID"
+// @Param share_id path int true "Share ID"
+// @Param request body SplitPlayer true "Player"
+// @Success 200 {object} TeeTimeShareView
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tee-times/{id}/shares/{share_id}/invite [post]
+func (h *PaymentHandler) InviteSharePlayer(c *gin.Context) {
+	var req SplitPlayer
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var teeTime models.TeeTime
+	if err := db.Preload("Course").Preload("User").
+		Where("id = ? AND user_id = ? AND split_payment = ?", c.Param("id"), c.GetUint("user_id"), true).
+		First(&teeTime).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Split booking not found"})
+		return
+	}
+	var share models.TeeTimeShare
+	if err := db.Where("id = ? AND tee_time_id = ?", c.Param("share_id"), teeTime.ID).First(&share).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return
+	}
+	if share.PaymentStatus == "paid" || share.PaymentStatus == "refunded" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This share is already " + share.PaymentStatus})
+		return
+	}
+
+	if req.Name != "" || req.Email != "" {
+		if req.Name != "" {
+			share.PlayerName = req.Name
+		}
+		if req.Email != "" {
+			share.PlayerEmail = req.Email
+		}
+		if err := db.Model(&share).Updates(map[string]interface{}{
+			"player_name":  share.PlayerName,
+			"player_email": share.PlayerEmail,
+		}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update share"})
+			return
+		}
+	}
+	if share.PlayerEmail == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An email address is needed to send the invitation"})
+		return
+	}
+	if !h.mailer.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Email delivery is not configured"})
+		return
+	}
+
+	if err := h.inviteShare(&share, &teeTime); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send email"})
+		return
+	}
+	c.JSON(http.StatusOK, TeeTimeShareView{TeeTimeShare: share, PaymentLink: h.shareLink(&share)})
+}
+
+// @Summary View a share payment link
+// @Description The booking and amount behind a payment link sent to an invited player
+// @Tags tee-times
+// @Produce json
+// @Param token path string true "Payment token"
+// @Success 200 {object} ShareInvitation
+// @Failure 404 {object} map[string]string
+// @Router /tee-time-shares/{token} [get]
+func (h *PaymentHandler) GetShareInvitation(c *gin.Context) {
+	var share models.TeeTimeShare
+	if err := database.DB.Preload("TeeTime.Course").Preload("TeeTime.User").
+		Where("payment_token = ?", c.Param("token")).First(&share).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment link not found"})
+		return
+	}
+
+	teeTime := share.TeeTime
+	c.JSON(http.StatusOK, ShareInvitation{
+		PlayerNumber:  share.PlayerNumber,
+		PlayerName:    share.PlayerName,
+		Amount:        share.Amount,
+		PaymentStatus: share.PaymentStatus,
+		CourseName:    teeTime.Course.Name,
+		BookingDate:   teeTime.BookingDate,
+		TeeTime:       teeTime.TeeTime,
+		PlayersCount:  teeTime.PlayersCount,
+		OrganizerName: teeTime.User.FirstName + " " + teeTime.User.LastName,
+		BookingStatus: teeTime.BookingStatus,
+	})
+}
+
+// @Summary Pay a share of a tee time
+// @Description Pay the share behind a payment link, in the same way as checking out a booking
+// @Tags tee-times
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token path string true "Payment token"
+// @Param checkout body ShareCheckoutRequest true "Payment"
+// @Success 201 {object} CheckoutResponse
+// @Failure 400 {object} map[string]string
+// @Failure 402 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tee-time-shares/{token}/checkout [post]
+func (h *PaymentHandler) CheckoutShare(c *gin.Context) {
+	var req ShareCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var share models.TeeTimeShare
+	if err := database.DB.Where("payment_token = ?", c.Param("token")).First(&share).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment link not found"})
+		return
+	}
+
+	h.checkout(c, c.GetUint("user_id"), CheckoutRequest{
+		ReferenceType:   "tee_time_share",
+		ReferenceID:     share.ID,
+		PaymentMethodID: req.PaymentMethodID,
+		GiftCardCode:    req.GiftCardCode,
+		GiftCardAmount:  req.GiftCardAmount,
+	})
+}
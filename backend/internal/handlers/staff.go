@@ -4,16 +4,28 @@ import (
 	"net/http"
 	"strconv"
 
+	"golf-course-backend/internal/config"
 	"golf-course-backend/internal/database"
 	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/payments"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type StaffHandler struct{}
+type StaffHandler struct {
+	uploadPath    string
+	maxUploadSize int64
+	provider      payments.PaymentProvider
+}
 
-func NewStaffHandler() *StaffHandler {
-	return &StaffHandler{}
+func NewStaffHandler(uploadCfg config.UploadConfig, provider payments.PaymentProvider) *StaffHandler {
+	return &StaffHandler{
+		uploadPath:    uploadCfg.Path,
+		maxUploadSize: uploadCfg.MaxSize,
+		provider:      provider,
+	}
 }
 
 // Equipment management for staff
@@ -75,18 +87,41 @@ func (h *StaffHandler) UpdateBookingStatus(c *gin.Context) {
 		return
 	}
 
-	db := database.DB
 	var booking models.TeeTime
-	if err := db.First(&booking, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+	var voided []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Booking not found")
+		}
+		if req.BookingStatus == "cancelled" && booking.BookingStatus != "cancelled" {
+			var err error
+			voided, err = cancelTeeTime(tx, &booking)
+			return err
+		}
+		if booking.BookingStatus == "cancelled" && req.BookingStatus != "cancelled" {
+			// The slot may have been booked again since
+			var taken int64
+			if err := tx.Model(&models.TeeTime{}).
+				Where("course_id = ? AND booking_date = ? AND tee_time = ? AND booking_status != 'cancelled'",
+					booking.CourseID, booking.BookingDate, booking.TeeTime).
+				Count(&taken).Error; err != nil {
+				return err
+			}
+			if taken > 0 {
+				return newHTTPError(http.StatusConflict, "Tee time not available")
+			}
+		}
+		booking.BookingStatus = req.BookingStatus
+		return tx.Save(&booking).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update booking")
 		return
 	}
-
-	booking.BookingStatus = req.BookingStatus
-
-	if err := db.Save(&booking).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking"})
-		return
+	for _, intentID := range voided {
+		if intentID != "" {
+			h.provider.CancelIntent(c.Request.Context(), intentID)
+		}
 	}
 
 	c.JSON(http.StatusOK, booking)
@@ -142,14 +177,27 @@ func (h *StaffHandler) GetTodaysBookings(c *gin.Context) {
 	c.JSON(http.StatusOK, bookings)
 }
 
-// Get active rentals for staff
+// Get active rentals for staff, optionally narrowed to a stage of the
+// pickup/return workflow
 func (h *StaffHandler) GetActiveRentals(c *gin.Context) {
 	db := database.DB
 	var rentals []models.EquipmentRental
 
-	if err := db.Preload("User").Preload("Equipment").
-		Where("return_date IS NULL").
-		Find(&rentals).Error; err != nil {
+	query := db.Preload("User").Preload("Equipment").Preload("Variant")
+	switch c.Query("stage") {
+	case "awaiting_pickup":
+		query = query.Where("picked_up_at IS NULL AND returned_at IS NULL AND rental_status IN ?", activeRentalStatuses)
+	case "out":
+		query = query.Where("picked_up_at IS NOT NULL AND returned_at IS NULL")
+	case "awaiting_inspection":
+		query = query.Where("returned_at IS NOT NULL AND inspected_at IS NULL")
+	case "deposit_held":
+		query = query.Where("inspected_at IS NOT NULL AND deposit_status = ?", "held")
+	default:
+		query = query.Where("returned_at IS NULL AND rental_status IN ?", activeRentalStatuses)
+	}
+
+	if err := query.Order("rental_date ASC").Find(&rentals).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch active rentals"})
 		return
 	}
@@ -157,11 +205,14 @@ func (h *StaffHandler) GetActiveRentals(c *gin.Context) {
 	c.JSON(http.StatusOK, rentals)
 }
 
+var activeRentalStatuses = []string{"rented", "overdue"}
+
 // Staff dashboard stats
 type StaffStats struct {
 	TodaysBookings  int64 `json:"todays_bookings"`
 	ActiveRentals   int64 `json:"active_rentals"`
 	EquipmentIssues int64 `json:"equipment_issues"`
+	OpenWorkOrders  int64 `json:"open_work_orders"`
 	CourseClosures  int64 `json:"course_closures"`
 }
 
@@ -173,11 +224,14 @@ func (h *StaffHandler) GetStaffStats(c *gin.Context) {
 	db.Model(&models.TeeTime{}).Where("DATE(booking_date) = CURDATE()").Count(&stats.TodaysBookings)
 
 	// Active rentals
-	db.Model(&models.EquipmentRental{}).Where("return_date IS NULL").Count(&stats.ActiveRentals)
+	db.Model(&models.EquipmentRental{}).Where("returned_at IS NULL AND rental_status IN ?", activeRentalStatuses).Count(&stats.ActiveRentals)
 
 	// Equipment with issues (maintenance status)
 	db.Model(&models.Equipment{}).Where("condition_status = 'maintenance'").Count(&stats.EquipmentIssues)
 
+	// Work orders still open or in progress
+	db.Model(&models.WorkOrder{}).Where("status IN ?", openWorkOrderStatuses).Count(&stats.OpenWorkOrders)
+
 	// Inactive courses
 	db.Model(&models.Course{}).Where("is_active = false").Count(&stats.CourseClosures)
 
@@ -0,0 +1,551 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RentalPickupRequest says how money taken at the counter was paid: the
+// deposit, and the rental itself when no card was authorized for it at
+// checkout. Card methods mean the card was presented at the terminal.
+type RentalPickupRequest struct {
+	PaymentMethod string `json:"payment_method" binding:"omitempty,oneof=cash credit_card debit_card"`
+	Notes         string `json:"notes"`
+}
+
+type RentalInspectionRequest struct {
+	Condition    string   `json:"condition" binding:"required,oneof=excellent good fair damaged"`
+	Severity     string   `json:"severity" binding:"omitempty,oneof=minor moderate severe"`
+	Notes        string   `json:"notes"`
+	DamageCharge float64  `json:"damage_charge" binding:"min=0"`
+	UnitsDamaged int      `json:"units_damaged" binding:"min=0"`
+	PhotoURLs    []string `json:"photo_urls"`
+}
+
+type DepositSettlementRequest struct {
+	Settlement   string  `json:"settlement" binding:"omitempty,oneof=full_refund partial forfeit"`
+	RefundAmount float64 `json:"refund_amount" binding:"min=0"`
+	Notes        string  `json:"notes"`
+}
+
+type DepositSettlementResponse struct {
+	Rental   models.EquipmentRental `json:"rental"`
+	Refund   *models.Refund         `json:"refund,omitempty"`
+	Payments []models.Payment       `json:"payments"`
+}
+
+var allowedPhotoExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+	".heic": true,
+}
+
+// Get a single rental with its damage reports and payments
+func (h *StaffHandler) GetRental(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rental ID"})
+		return
+	}
+
+	db := database.DB
+	var rental models.EquipmentRental
+	if err := db.Preload("User").Preload("Equipment").Preload("Variant").Preload("DamageReports.Photos").
+		First(&rental, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rental not found"})
+		return
+	}
+
+	var payments []models.Payment
+	db.Preload("Refunds").Where("reference_type = ? AND reference_id = ?", "equipment_rental", rental.ID).
+		Order("created_at ASC").Find(&payments)
+
+	c.JSON(http.StatusOK, gin.H{"rental": rental, "payments": payments})
+}
+
+// Hand a rental to the customer, capture the card authorized at checkout or
+// take payment at the counter, and collect the deposit
+func (h *StaffHandler) PickupRental(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rental ID"})
+		return
+	}
+
+	var req RentalPickupRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rental models.EquipmentRental
+	if err := database.DB.First(&rental, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rental not found"})
+		return
+	}
+	if rental.PickedUpAt != nil || rental.ReturnedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rental is not awaiting pickup"})
+		return
+	}
+
+	// The rental is charged now; the card was only authorized at checkout
+	var authorized []models.Payment
+	database.DB.Where("reference_type = ? AND reference_id = ? AND payment_type = ? AND payment_status = ? AND stripe_payment_intent_id <> ?",
+		"equipment_rental", rental.ID, "charge", "processing", "").Find(&authorized)
+	for i := range authorized {
+		intent, err := h.provider.CaptureIntent(c.Request.Context(), authorized[i].StripePaymentIntentID, 0)
+		if err != nil {
+			respondProviderError(c, err, "Payment provider is unavailable")
+			return
+		}
+		if err := recordCapture(&authorized[i], intent); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+			return
+		}
+	}
+
+	staffID := c.GetUint("user_id")
+	var replaced []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rental, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Rental not found")
+		}
+		if rental.PickedUpAt != nil {
+			return newHTTPError(http.StatusBadRequest, "Rental already picked up")
+		}
+		if rental.ReturnedAt != nil || (rental.RentalStatus != "rented" && rental.RentalStatus != "overdue") {
+			return newHTTPError(http.StatusBadRequest, "Rental is not awaiting pickup")
+		}
+		if rental.DepositAmount > 0 && req.PaymentMethod == "" {
+			return newHTTPError(http.StatusBadRequest, "Give the payment method the deposit was taken with")
+		}
+
+		// Whatever the captured authorization did not cover is paid now
+		paid, err := chargesPaid(tx, "equipment_rental", rental.ID)
+		if err != nil {
+			return err
+		}
+		if owed := roundCurrency(rental.RentalPrice - paid); owed > 0 {
+			if req.PaymentMethod == "" {
+				return newHTTPError(http.StatusBadRequest, "Rental has not been paid for; take payment at the counter and give the payment method")
+			}
+			intentIDs, err := recordCounterCharge(tx, &rental, owed, req.PaymentMethod)
+			if err != nil {
+				return err
+			}
+			replaced = intentIDs
+		}
+
+		now := time.Now()
+		rental.PickedUpAt = &now
+		rental.PickedUpBy = &staffID
+		rental.Notes = appendNote(rental.Notes, req.Notes)
+		rental.DepositStatus = "not_required"
+
+		if rental.DepositAmount > 0 {
+			deposit := models.Payment{
+				UserID:        rental.UserID,
+				ReferenceType: "equipment_rental",
+				ReferenceID:   rental.ID,
+				Amount:        rental.DepositAmount,
+				Currency:      "USD",
+				PaymentType:   "deposit",
+				PaymentMethod: req.PaymentMethod,
+				PaymentStatus: "succeeded",
+				ProcessedAt:   &now,
+			}
+			if err := tx.Create(&deposit).Error; err != nil {
+				return err
+			}
+			if err := postPaymentLedger(tx, &deposit, now); err != nil {
+				return err
+			}
+			rental.DepositStatus = "held"
+		}
+
+		// The payment status was brought up to date with the charges above
+		return tx.Model(&rental).Select("picked_up_at", "picked_up_by", "notes", "deposit_status").Updates(&rental).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to record pickup")
+		return
+	}
+	for _, intentID := range replaced {
+		if intentID != "" {
+			h.provider.CancelIntent(c.Request.Context(), intentID)
+		}
+	}
+
+	database.DB.Preload("User").Preload("Equipment").Preload("Variant").First(&rental, rental.ID)
+
+	c.JSON(http.StatusOK, rental)
+}
+
+// recordCounterCharge records the rental being paid at the counter. Card
+// payments the customer started online are dropped for it; the intents
+// returned are to be cancelled with the provider once this commits.
+func recordCounterCharge(tx *gorm.DB, rental *models.EquipmentRental, amount float64, method string) ([]string, error) {
+	var open []models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("reference_type = ? AND reference_id = ? AND payment_type = ? AND payment_status IN ?",
+			"equipment_rental", rental.ID, "charge", []string{"pending", "processing", "failed"}).
+		Find(&open).Error; err != nil {
+		return nil, err
+	}
+	var intentIDs []string
+	for i := range open {
+		if err := finalizePayment(tx, &open[i], "cancelled", "Paid at the counter"); err != nil {
+			return nil, err
+		}
+		intentIDs = append(intentIDs, open[i].StripePaymentIntentID)
+	}
+
+	payment := models.Payment{
+		UserID:        rental.UserID,
+		ReferenceType: "equipment_rental",
+		ReferenceID:   rental.ID,
+		Amount:        amount,
+		Currency:      "USD",
+		PaymentType:   "charge",
+		PaymentMethod: method,
+		PaymentStatus: "pending",
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, err
+	}
+	return intentIDs, finalizePayment(tx, &payment, "succeeded", "")
+}
+
+// Check a returned rental, record any damage and restock the good units
+func (h *StaffHandler) InspectRentalReturn(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rental ID"})
+		return
+	}
+
+	var req RentalInspectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staffID := c.GetUint("user_id")
+	var rental models.EquipmentRental
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rental, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Rental not found")
+		}
+		if rental.PickedUpAt == nil {
+			return newHTTPError(http.StatusBadRequest, "Rental has not been picked up")
+		}
+		if rental.InspectedAt != nil {
+			return newHTTPError(http.StatusBadRequest, "Rental already inspected")
+		}
+		if req.UnitsDamaged > rental.Quantity {
+			return newHTTPError(http.StatusBadRequest, "Damaged units cannot exceed rented quantity")
+		}
+
+		now := time.Now()
+		if rental.ReturnedAt == nil {
+			// Returned at the desk: only the undamaged units go back on the shelf
+			rental.ReturnDate = &now
+			rental.ReturnedAt = &now
+			if err := releaseEquipmentStock(tx, rental.EquipmentID, rental.VariantID, rental.Quantity-req.UnitsDamaged); err != nil {
+				return err
+			}
+		} else {
+			// Already returned by the customer, so the stock was restored in full
+			if err := withdrawEquipmentStock(tx, rental.EquipmentID, rental.VariantID, req.UnitsDamaged); err != nil {
+				return err
+			}
+		}
+
+		damaged := req.Condition == "damaged" || req.UnitsDamaged > 0 || req.DamageCharge > 0
+		if damaged {
+			report := models.RentalDamageReport{
+				RentalID:     rental.ID,
+				ReportedBy:   staffID,
+				Severity:     req.Severity,
+				Notes:        req.Notes,
+				UnitsDamaged: req.UnitsDamaged,
+				DamageCharge: roundCurrency(req.DamageCharge),
+			}
+			if report.Severity == "" {
+				report.Severity = "minor"
+			}
+			for _, url := range req.PhotoURLs {
+				if strings.TrimSpace(url) != "" {
+					report.Photos = append(report.Photos, models.RentalDamagePhoto{URL: url})
+				}
+			}
+			if err := tx.Create(&report).Error; err != nil {
+				return err
+			}
+
+			// Damaged units stay off the shelf until a work order puts them back
+			if req.UnitsDamaged > 0 {
+				order := models.WorkOrder{
+					AssetType:   "equipment",
+					EquipmentID: &rental.EquipmentID,
+					VariantID:   rental.VariantID,
+					RentalID:    &rental.ID,
+					Quantity:    req.UnitsDamaged,
+					Title:       workOrderTitle("rental", rental.ID),
+					Description: req.Notes,
+					Priority:    workOrderPriority(report.Severity),
+					ReportedBy:  staffID,
+				}
+				if err := openWorkOrder(tx, &order, req.UnitsDamaged); err != nil {
+					return err
+				}
+			}
+		}
+
+		rental.InspectedAt = &now
+		rental.InspectedBy = &staffID
+		rental.ReturnCondition = req.Condition
+		rental.DamageCharge = roundCurrency(req.DamageCharge)
+		rental.RentalStatus = "returned"
+		if damaged {
+			rental.RentalStatus = "damaged"
+		}
+
+		return tx.Save(&rental).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to record inspection")
+		return
+	}
+
+	database.DB.Preload("User").Preload("Equipment").Preload("Variant").Preload("DamageReports.Photos").First(&rental, rental.ID)
+
+	c.JSON(http.StatusOK, rental)
+}
+
+// Refund, partially refund or forfeit the deposit after inspection
+func (h *StaffHandler) SettleRentalDeposit(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rental ID"})
+		return
+	}
+
+	var req DepositSettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staffID := c.GetUint("user_id")
+	var rental models.EquipmentRental
+	var refundRecord *models.Refund
+	var created []models.Payment
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rental, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Rental not found")
+		}
+		if rental.InspectedAt == nil {
+			return newHTTPError(http.StatusBadRequest, "Rental must be inspected before settling the deposit")
+		}
+		if rental.DepositStatus != "held" {
+			return newHTTPError(http.StatusBadRequest, "No deposit is held for this rental")
+		}
+
+		var deposit models.Payment
+		if err := tx.Where("reference_type = ? AND reference_id = ? AND payment_type = ?",
+			"equipment_rental", rental.ID, "deposit").First(&deposit).Error; err != nil {
+			return newHTTPError(http.StatusBadRequest, "Deposit payment not found")
+		}
+
+		settlement := req.Settlement
+		if settlement == "" {
+			switch {
+			case rental.DamageCharge <= 0:
+				settlement = "full_refund"
+			case rental.DamageCharge >= deposit.Amount:
+				settlement = "forfeit"
+			default:
+				settlement = "partial"
+			}
+		}
+
+		var refund float64
+		switch settlement {
+		case "full_refund":
+			refund = deposit.Amount
+			rental.DepositStatus = "refunded"
+		case "forfeit":
+			refund = 0
+			rental.DepositStatus = "forfeited"
+		case "partial":
+			refund = roundCurrency(deposit.Amount - rental.DamageCharge)
+			if req.RefundAmount > 0 {
+				refund = roundCurrency(req.RefundAmount)
+			}
+			if refund <= 0 || refund >= deposit.Amount {
+				return newHTTPError(http.StatusBadRequest, "Partial refund must be between zero and the deposit amount")
+			}
+			rental.DepositStatus = "partially_refunded"
+		}
+		retained := roundCurrency(deposit.Amount - refund)
+
+		now := time.Now()
+		newPayment := func(paymentType, status string, amount float64, fromDeposit bool) error {
+			p := models.Payment{
+				UserID:        rental.UserID,
+				ReferenceType: "equipment_rental",
+				ReferenceID:   rental.ID,
+				Amount:        amount,
+				Currency:      deposit.Currency,
+				PaymentType:   paymentType,
+				PaymentMethod: deposit.PaymentMethod,
+				FromDeposit:   fromDeposit,
+				PaymentStatus: status,
+			}
+			if status != "pending" {
+				p.ProcessedAt = &now
+			}
+			if err := tx.Create(&p).Error; err != nil {
+				return err
+			}
+			if status == "succeeded" {
+				if err := postPaymentLedger(tx, &p, now); err != nil {
+					return err
+				}
+			}
+			created = append(created, p)
+			return nil
+		}
+
+		// The deposit was taken at the counter, so it is returned there
+		if refund > 0 {
+			refundRecord = &models.Refund{
+				PaymentID:    deposit.ID,
+				Amount:       refund,
+				ReasonCode:   "deposit_return",
+				Notes:        req.Notes,
+				RefundStatus: "approved",
+				RequestedBy:  &staffID,
+				ReviewedBy:   &staffID,
+				ReviewedAt:   &now,
+			}
+			if err := tx.Create(refundRecord).Error; err != nil {
+				return err
+			}
+			if err := settleRefund(tx, refundRecord, ""); err != nil {
+				return err
+			}
+		}
+		if retained > 0 {
+			if err := newPayment("damage_charge", "succeeded", retained, true); err != nil {
+				return err
+			}
+		}
+		// Damage beyond what the deposit covered is still owed by the customer,
+		// who pays it by checking out the rental
+		if outstanding := roundCurrency(rental.DamageCharge - retained); outstanding > 0 {
+			if err := newPayment("damage_charge", "pending", outstanding, false); err != nil {
+				return err
+			}
+		}
+
+		rental.DepositRefunded = refund
+		rental.Notes = appendNote(rental.Notes, req.Notes)
+		return tx.Save(&rental).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to settle deposit")
+		return
+	}
+
+	database.DB.Preload("User").Preload("Equipment").Preload("Variant").Preload("DamageReports.Photos").First(&rental, rental.ID)
+
+	c.JSON(http.StatusOK, DepositSettlementResponse{Rental: rental, Refund: refundRecord, Payments: created})
+}
+
+// Attach uploaded photos to a damage report
+func (h *StaffHandler) UploadDamagePhotos(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid damage report ID"})
+		return
+	}
+
+	db := database.DB
+	var report models.RentalDamageReport
+	if err := db.First(&report, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Damage report not found"})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["photos"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one photo is required"})
+		return
+	}
+
+	dir := filepath.Join(h.uploadPath, "damage-reports", strconv.Itoa(id))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store photos"})
+		return
+	}
+
+	for i, file := range form.File["photos"] {
+		ext := strings.ToLower(filepath.Ext(file.Filename))
+		if !allowedPhotoExtensions[ext] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported photo type: " + file.Filename})
+			return
+		}
+		if h.maxUploadSize > 0 && file.Size > h.maxUploadSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Photo too large: " + file.Filename})
+			return
+		}
+
+		name := fmt.Sprintf("%d_%d%s", time.Now().UnixNano(), i, ext)
+		if err := c.SaveUploadedFile(file, filepath.Join(dir, name)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store photos"})
+			return
+		}
+
+		photo := models.RentalDamagePhoto{
+			DamageReportID: report.ID,
+			URL:            fmt.Sprintf("/uploads/damage-reports/%d/%s", id, name),
+		}
+		if err := db.Create(&photo).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo"})
+			return
+		}
+	}
+
+	db.Preload("Photos").First(&report, report.ID)
+
+	c.JSON(http.StatusCreated, report)
+}
+
+func appendNote(existing, note string) string {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return existing
+	}
+	if existing == "" {
+		return note
+	}
+	return existing + "\n" + note
+}
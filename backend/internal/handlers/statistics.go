@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatisticsHandler struct{}
+
+func NewStatisticsHandler() *StatisticsHandler {
+	return &StatisticsHandler{}
+}
+
+type ParTypeStats struct {
+	Par             int      `json:"par"`
+	HolesPlayed     int      `json:"holes_played"`
+	AverageScore    *float64 `json:"average_score"`
+	AverageToPar    *float64 `json:"average_to_par"`
+	BirdiesOrBetter int      `json:"birdies_or_better"`
+	Pars            int      `json:"pars"`
+	Bogeys          int      `json:"bogeys"`
+	DoublesOrWorse  int      `json:"doubles_or_worse"`
+}
+
+type RoundTrend struct {
+	ScorecardID       uint     `json:"scorecard_id"`
+	PlayedDate        string   `json:"played_date"`
+	CourseName        string   `json:"course_name"`
+	HolesPlayed       int      `json:"holes_played"`
+	Score             int      `json:"score"`
+	ToPar             int      `json:"to_par"`
+	Putts             int      `json:"putts"`
+	FairwayPercentage *float64 `json:"fairway_percentage"`
+	GIRPercentage     *float64 `json:"gir_percentage"`
+	ScoreDifferential *float64 `json:"score_differential"`
+}
+
+type CourseStats struct {
+	CourseID     uint     `json:"course_id"`
+	CourseName   string   `json:"course_name"`
+	Rounds       int      `json:"rounds"`
+	AverageScore *float64 `json:"average_score"`
+	BestScore    *int     `json:"best_score"`
+}
+
+type HoleStats struct {
+	HoleID       uint     `json:"hole_id"`
+	CourseID     uint     `json:"course_id"`
+	HoleNumber   int      `json:"hole_number"`
+	Par          int      `json:"par"`
+	TimesPlayed  int      `json:"times_played"`
+	AverageScore *float64 `json:"average_score"`
+	AverageToPar *float64 `json:"average_to_par"`
+	AveragePutts *float64 `json:"average_putts"`
+}
+
+type PlayerStatistics struct {
+	From               string         `json:"from,omitempty"`
+	To                 string         `json:"to,omitempty"`
+	Rounds             int            `json:"rounds"`
+	CompleteRounds     int            `json:"complete_rounds"`
+	HolesPlayed        int            `json:"holes_played"`
+	ScoringAverage     *float64       `json:"scoring_average"`
+	BestScore          *int           `json:"best_score"`
+	ByParType          []ParTypeStats `json:"by_par_type"`
+	PuttsPerRound      *float64       `json:"putts_per_round"`
+	PuttsPerHole       *float64       `json:"putts_per_hole"`
+	PuttsPerGIR        *float64       `json:"putts_per_gir"`
+	GIRPercentage      *float64       `json:"gir_percentage"`
+	FairwayPercentage  *float64       `json:"fairway_percentage"`
+	ScramblingPercent  *float64       `json:"scrambling_percentage"`
+	SandSavePercentage *float64       `json:"sand_save_percentage"`
+	PenaltiesPerRound  *float64       `json:"penalties_per_round"`
+	Trend              []RoundTrend   `json:"trend"`
+	TrendAverage       *float64       `json:"trend_scoring_average"`
+	ByCourse           []CourseStats  `json:"by_course"`
+	ByHole             []HoleStats    `json:"by_hole"`
+}
+
+func ratio(numerator, denominator int) *float64 {
+	if denominator == 0 {
+		return nil
+	}
+	value := math.Round(float64(numerator)/float64(denominator)*100) / 100
+	return &value
+}
+
+func percentage(numerator, denominator int) *float64 {
+	if denominator == 0 {
+		return nil
+	}
+	value := math.Round(float64(numerator)/float64(denominator)*1000) / 10
+	return &value
+}
+
+// @Summary Get playing statistics
+// @Description Aggregate the authenticated user's scorecards into scoring, putting, driving and short-game statistics
+// @Tags statistics
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param course_id query int false "Course ID"
+// @Param last query int false "Number of recent rounds in the trend (default 10)"
+// @Success 200 {object} PlayerStatistics
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /statistics [get]
+func (h *StatisticsHandler) GetStatistics(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := database.DB.Preload("Course").Preload("Holes.Hole").
+		Where("user_id = ? AND total_score IS NOT NULL", userID)
+
+	stats := PlayerStatistics{
+		ByParType: []ParTypeStats{},
+		Trend:     []RoundTrend{},
+		ByCourse:  []CourseStats{},
+		ByHole:    []HoleStats{},
+	}
+
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format"})
+			return
+		}
+		query = query.Where("played_date >= ?", fromDate)
+		stats.From = from
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format"})
+			return
+		}
+		query = query.Where("played_date <= ?", toDate)
+		stats.To = to
+	}
+	if courseID := c.Query("course_id"); courseID != "" {
+		query = query.Where("course_id = ?", courseID)
+	}
+
+	last := 10
+	if l := c.Query("last"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid last parameter"})
+			return
+		}
+		last = n
+	}
+
+	var scorecards []models.Scorecard
+	if err := query.Order("played_date DESC, id DESC").Find(&scorecards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scorecards"})
+		return
+	}
+
+	type parAccumulator struct {
+		holes, strokes, toPar                 int
+		birdies, pars, bogeys, doublesOrWorse int
+	}
+	type holeAccumulator struct {
+		hole                   models.Hole
+		played, strokes, putts int
+	}
+	type courseAccumulator struct {
+		course        models.Course
+		rounds, total int
+		best          *int
+	}
+
+	byPar := map[int]*parAccumulator{}
+	byHole := map[uint]*holeAccumulator{}
+	byCourse := map[uint]*courseAccumulator{}
+
+	var (
+		completeStrokes, puttsTotal, penaltiesTotal  int
+		girHoles, girPutts, fairwayChances, fairways int
+		missedGreens, scrambles                      int
+		sandAttempts, sandSaves                      int
+		trendStrokes, trendRounds                    int
+	)
+
+	for i, scorecard := range scorecards {
+		stats.Rounds++
+		round := RoundTrend{
+			ScorecardID:       scorecard.ID,
+			PlayedDate:        scorecard.PlayedDate.Format("2006-01-02"),
+			CourseName:        scorecard.Course.Name,
+			ScoreDifferential: scorecard.ScoreDifferential,
+		}
+		var roundFairwayChances, roundFairways, roundGreens int
+
+		for _, entry := range scorecard.Holes {
+			par := entry.Hole.Par
+			toPar := entry.Strokes - par
+
+			round.HolesPlayed++
+			round.Score += entry.Strokes
+			round.ToPar += toPar
+			round.Putts += entry.Putts
+			stats.HolesPlayed++
+			puttsTotal += entry.Putts
+			penaltiesTotal += entry.Penalties
+
+			acc := byPar[par]
+			if acc == nil {
+				acc = &parAccumulator{}
+				byPar[par] = acc
+			}
+			acc.holes++
+			acc.strokes += entry.Strokes
+			acc.toPar += toPar
+			switch {
+			case toPar < 0:
+				acc.birdies++
+			case toPar == 0:
+				acc.pars++
+			case toPar == 1:
+				acc.bogeys++
+			default:
+				acc.doublesOrWorse++
+			}
+
+			if entry.GreenInRegulation {
+				girHoles++
+				girPutts += entry.Putts
+				roundGreens++
+			} else {
+				// Scrambling: missed the green but still made par or better
+				missedGreens++
+				if toPar <= 0 {
+					scrambles++
+				}
+			}
+			if par > 3 {
+				fairwayChances++
+				roundFairwayChances++
+				if entry.FairwayHit {
+					fairways++
+					roundFairways++
+				}
+			}
+			sandAttempts += entry.SandAttempts
+			sandSaves += entry.SandSaves
+
+			hole := byHole[entry.HoleID]
+			if hole == nil {
+				hole = &holeAccumulator{hole: entry.Hole}
+				byHole[entry.HoleID] = hole
+			}
+			hole.played++
+			hole.strokes += entry.Strokes
+			hole.putts += entry.Putts
+		}
+
+		complete := round.HolesPlayed > 0 && round.HolesPlayed == scorecard.Course.TotalHoles
+		if complete {
+			stats.CompleteRounds++
+			completeStrokes += round.Score
+			if stats.BestScore == nil || round.Score < *stats.BestScore {
+				score := round.Score
+				stats.BestScore = &score
+			}
+
+			course := byCourse[scorecard.CourseID]
+			if course == nil {
+				course = &courseAccumulator{course: scorecard.Course}
+				byCourse[scorecard.CourseID] = course
+			}
+			course.rounds++
+			course.total += round.Score
+			if course.best == nil || round.Score < *course.best {
+				score := round.Score
+				course.best = &score
+			}
+		}
+
+		round.FairwayPercentage = percentage(roundFairways, roundFairwayChances)
+		round.GIRPercentage = percentage(roundGreens, round.HolesPlayed)
+		if i < last {
+			stats.Trend = append(stats.Trend, round)
+			if complete {
+				trendStrokes += round.Score
+				trendRounds++
+			}
+		}
+	}
+
+	stats.ScoringAverage = ratio(completeStrokes, stats.CompleteRounds)
+	stats.TrendAverage = ratio(trendStrokes, trendRounds)
+	stats.PuttsPerRound = ratio(puttsTotal*18, stats.HolesPlayed)
+	stats.PuttsPerHole = ratio(puttsTotal, stats.HolesPlayed)
+	stats.PuttsPerGIR = ratio(girPutts, girHoles)
+	stats.GIRPercentage = percentage(girHoles, stats.HolesPlayed)
+	stats.FairwayPercentage = percentage(fairways, fairwayChances)
+	stats.ScramblingPercent = percentage(scrambles, missedGreens)
+	stats.SandSavePercentage = percentage(sandSaves, sandAttempts)
+	stats.PenaltiesPerRound = ratio(penaltiesTotal*18, stats.HolesPlayed)
+
+	for par, acc := range byPar {
+		stats.ByParType = append(stats.ByParType, ParTypeStats{
+			Par:             par,
+			HolesPlayed:     acc.holes,
+			AverageScore:    ratio(acc.strokes, acc.holes),
+			AverageToPar:    ratio(acc.toPar, acc.holes),
+			BirdiesOrBetter: acc.birdies,
+			Pars:            acc.pars,
+			Bogeys:          acc.bogeys,
+			DoublesOrWorse:  acc.doublesOrWorse,
+		})
+	}
+	sort.Slice(stats.ByParType, func(i, j int) bool { return stats.ByParType[i].Par < stats.ByParType[j].Par })
+
+	for _, acc := range byCourse {
+		stats.ByCourse = append(stats.ByCourse, CourseStats{
+			CourseID:     acc.course.ID,
+			CourseName:   acc.course.Name,
+			Rounds:       acc.rounds,
+			AverageScore: ratio(acc.total, acc.rounds),
+			BestScore:    acc.best,
+		})
+	}
+	sort.Slice(stats.ByCourse, func(i, j int) bool { return stats.ByCourse[i].Rounds > stats.ByCourse[j].Rounds })
+
+	for _, acc := range byHole {
+		stats.ByHole = append(stats.ByHole, HoleStats{
+			HoleID:       acc.hole.ID,
+			CourseID:     acc.hole.CourseID,
+			HoleNumber:   acc.hole.HoleNumber,
+			Par:          acc.hole.Par,
+			TimesPlayed:  acc.played,
+			AverageScore: ratio(acc.strokes, acc.played),
+			AverageToPar: ratio(acc.strokes-acc.hole.Par*acc.played, acc.played),
+			AveragePutts: ratio(acc.putts, acc.played),
+		})
+	}
+	sort.Slice(stats.ByHole, func(i, j int) bool {
+		if stats.ByHole[i].CourseID != stats.ByHole[j].CourseID {
+			return stats.ByHole[i].CourseID < stats.ByHole[j].CourseID
+		}
+		return stats.ByHole[i].HoleNumber < stats.ByHole[j].HoleNumber
+	})
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,787 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/handicap"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/scoring"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TeamRequest struct {
+	Name           string `json:"name" binding:"required"`
+	ParticipantIDs []uint `json:"participant_ids" binding:"required,min=1"`
+}
+
+type TeamHoleScore struct {
+	HoleNumber int `json:"hole_number" binding:"required,min=1"`
+	Strokes    int `json:"strokes" binding:"required,min=1,max=20"`
+}
+
+type TeamScoreRequest struct {
+	RoundNumber int             `json:"round_number" binding:"omitempty,min=1"`
+	Holes       []TeamHoleScore `json:"holes" binding:"required,min=1,dive"`
+}
+
+type TeamView struct {
+	models.TournamentTeam
+	Handicap         *int         `json:"handicap"`
+	PlayingHandicaps map[uint]int `json:"playing_handicaps,omitempty"`
+}
+
+type TeamScorecard struct {
+	TeamID      uint                 `json:"team_id"`
+	Name        string               `json:"name"`
+	Format      string               `json:"format"`
+	Mode        string               `json:"mode"`
+	RoundNumber int                  `json:"round_number"`
+	Handicap    *int                 `json:"handicap,omitempty"`
+	Holes       []scoring.HoleResult `json:"holes"`
+	Thru        int                  `json:"thru"`
+	Total       int                  `json:"total"`
+	ToPar       int                  `json:"to_par"`
+	Finished    bool                 `json:"finished"`
+}
+
+type TeamLeaderboardEntry struct {
+	scoring.Standing
+	TeamID   uint `json:"team_id"`
+	Handicap *int `json:"handicap,omitempty"`
+	Started  bool `json:"started"`
+}
+
+type TeamLeaderboard struct {
+	TournamentID uint                   `json:"tournament_id"`
+	Tournament   string                 `json:"tournament"`
+	Status       string                 `json:"status"`
+	Format       string                 `json:"format"`
+	Mode         string                 `json:"mode"`
+	BestBalls    int                    `json:"best_balls,omitempty"`
+	Rounds       int                    `json:"rounds"`
+	Par          int                    `json:"par"`
+	Entries      []TeamLeaderboardEntry `json:"entries"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+}
+
+// formatNumbers stores a list of numbers in a comma-separated column, e.g.
+// team allowances in percent as "25,20,15,10".
+func formatNumbers(values []float64) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseAllowances reads stored allowances back as fractions of handicap.
+func parseAllowances(value string) []float64 {
+	var allowances []float64
+	for _, part := range strings.Split(value, ",") {
+		if percent, err := strconv.ParseFloat(strings.TrimSpace(part), 64); err == nil && percent > 0 {
+			allowances = append(allowances, percent/100)
+		}
+	}
+	return allowances
+}
+
+// teamEvent is a scramble or best ball tournament with the course it is
+// played on.
+type teamEvent struct {
+	tournament models.Tournament
+	teeSet     *models.TeeSet
+	holes      []scoring.Hole
+}
+
+func loadTeamEvent(db *gorm.DB, tournament models.Tournament) (teamEvent, error) {
+	event := teamEvent{tournament: tournament}
+	if tournament.TournamentType != "scramble" && tournament.TournamentType != "best_ball" {
+		return event, newHTTPError(http.StatusBadRequest, "Tournament is not a team event")
+	}
+	if tournament.Course.ID == 0 {
+		if err := db.First(&event.tournament.Course, tournament.CourseID).Error; err != nil {
+			return event, err
+		}
+	}
+	courseHoles, err := loadCourseHoles(db, tournament.CourseID)
+	if err != nil {
+		return event, err
+	}
+	event.holes = scoringHoles(courseHoles)
+	event.teeSet = defaultTeeSet(db, tournament.CourseID)
+	return event, nil
+}
+
+func (e teamEvent) scramble() bool {
+	return e.tournament.TournamentType == "scramble"
+}
+
+// teamSize is the most players a team may have. Scrambles default to
+// fours and best ball to pairs.
+func (e teamEvent) teamSize() int {
+	if e.tournament.TeamSize != nil {
+		return *e.tournament.TeamSize
+	}
+	if e.scramble() {
+		return 4
+	}
+	return 2
+}
+
+// bestBalls is how many scores count on each hole of a best ball event.
+func (e teamEvent) bestBalls() int {
+	return max(e.tournament.BestBalls, 1)
+}
+
+// allowances gives the handicap allowances for a team of the given size.
+// Scrambles apply them from the lowest course handicap up; in best ball the
+// first applies to every player. The tournament may set its own.
+func (e teamEvent) allowances(players int) []float64 {
+	if custom := parseAllowances(e.tournament.TeamAllowances); len(custom) > 0 {
+		return custom
+	}
+	if e.scramble() {
+		allowances, _ := handicap.ScrambleAllowances(min(max(players, 2), 4))
+		return allowances
+	}
+	allowance, _ := handicap.Allowance("four_ball_stroke")
+	return []float64{allowance}
+}
+
+// handicaps works out a scramble team's handicap, or each player's playing
+// handicap in best ball, from the indexes recorded at registration. A
+// scramble team has no handicap unless every player has one.
+func (e teamEvent) handicaps(team models.TournamentTeam) (*int, map[uint]int) {
+	allowances := e.allowances(len(team.Members))
+	courseHandicaps := make(map[uint]int, len(team.Members))
+	for _, member := range team.Members {
+		index := member.Participant.HandicapAtRegistration
+		if index == nil {
+			continue
+		}
+		if ch := courseHandicapFor(*index, e.tournament.Course, e.teeSet, len(e.holes)); ch != nil {
+			courseHandicaps[member.ParticipantID] = *ch
+		}
+	}
+
+	if e.scramble() {
+		if len(courseHandicaps) == 0 || len(courseHandicaps) < len(team.Members) {
+			return nil, nil
+		}
+		values := make([]int, 0, len(courseHandicaps))
+		for _, ch := range courseHandicaps {
+			values = append(values, ch)
+		}
+		teamHandicap := handicap.TeamHandicap(values, allowances)
+		return &teamHandicap, nil
+	}
+
+	playing := make(map[uint]int, len(courseHandicaps))
+	for id, ch := range courseHandicaps {
+		playing[id] = handicap.PlayingHandicap(ch, allowances[0])
+	}
+	return nil, playing
+}
+
+// teamScores holds what has been posted for a tournament's teams: the team
+// ball in a scramble, or the players' own tournament rounds in best ball.
+type teamScores struct {
+	balls map[uint]map[int]map[int]int
+	cards map[uint]map[int]models.Scorecard
+}
+
+func loadTeamScores(db *gorm.DB, e teamEvent, teams []models.TournamentTeam) (teamScores, error) {
+	data := teamScores{
+		balls: make(map[uint]map[int]map[int]int),
+		cards: make(map[uint]map[int]models.Scorecard),
+	}
+	if e.scramble() {
+		teamIDs := make([]uint, len(teams))
+		for i, team := range teams {
+			teamIDs[i] = team.ID
+		}
+		var scores []models.TournamentTeamScore
+		if len(teamIDs) > 0 {
+			if err := db.Where("team_id IN ?", teamIDs).Find(&scores).Error; err != nil {
+				return data, err
+			}
+		}
+		for _, score := range scores {
+			if data.balls[score.TeamID] == nil {
+				data.balls[score.TeamID] = make(map[int]map[int]int)
+			}
+			if data.balls[score.TeamID][score.RoundNumber] == nil {
+				data.balls[score.TeamID][score.RoundNumber] = make(map[int]int)
+			}
+			data.balls[score.TeamID][score.RoundNumber][score.HoleNumber] = score.Strokes
+		}
+		return data, nil
+	}
+
+	var scorecards []models.Scorecard
+	if err := db.Preload("User").Preload("Holes.Hole").
+		Where("tournament_id = ? AND is_tournament_round = ?", e.tournament.ID, true).
+		Find(&scorecards).Error; err != nil {
+		return data, err
+	}
+	for _, scorecard := range scorecards {
+		if scorecard.TournamentRound == nil {
+			continue
+		}
+		if data.cards[scorecard.UserID] == nil {
+			data.cards[scorecard.UserID] = make(map[int]models.Scorecard)
+		}
+		data.cards[scorecard.UserID][*scorecard.TournamentRound] = scorecard
+	}
+	return data, nil
+}
+
+// rounds is the number of rounds in the event, counting any recorded
+// beyond its scheduled days.
+func (d teamScores) rounds(tournament models.Tournament) int {
+	var scorecards []models.Scorecard
+	for _, rounds := range d.cards {
+		for _, scorecard := range rounds {
+			scorecards = append(scorecards, scorecard)
+		}
+	}
+	rounds := tournamentRounds(tournament, scorecards)
+	for _, balls := range d.balls {
+		for round := range balls {
+			rounds = max(rounds, round)
+		}
+	}
+	return rounds
+}
+
+// scoreRound scores one team round. Best ball counts the lowest scores on
+// each hole among the players' own cards.
+func (e teamEvent) scoreRound(team models.TournamentTeam, data teamScores, round int, net bool) scoring.Result {
+	teamHandicap, playing := e.handicaps(team)
+	if e.scramble() {
+		card := scoring.Card{PlayerID: team.ID, Name: team.Name, Strokes: data.balls[team.ID][round]}
+		if teamHandicap != nil {
+			card.PlayingHandicap = *teamHandicap
+		}
+		return scoring.StrokePlay(e.holes, card, net && teamHandicap != nil)
+	}
+
+	cards := make([]scoring.Card, 0, len(team.Members))
+	for _, member := range team.Members {
+		if scorecard, ok := data.cards[member.Participant.UserID][round]; ok {
+			cards = append(cards, scoringCard(scorecard, playing[member.ParticipantID]))
+		}
+	}
+	result := scoring.BestBall(e.holes, cards, e.bestBalls(), net)
+	result.PlayerID = team.ID
+	result.Name = team.Name
+	return result
+}
+
+func resultToPar(result scoring.Result) int {
+	toPar := 0
+	for _, hole := range result.Holes {
+		toPar += hole.Net - hole.Par
+	}
+	return toPar
+}
+
+// loadTeams fetches a tournament's teams with their players.
+func loadTeams(db *gorm.DB, tournamentID uint) ([]models.TournamentTeam, error) {
+	var teams []models.TournamentTeam
+	err := db.Preload("Members.Participant.User").Where("tournament_id = ?", tournamentID).
+		Order("name ASC").Find(&teams).Error
+	return teams, err
+}
+
+// buildTeamLeaderboard totals every team's rounds and ranks them the same
+// way as the individual leaderboard.
+func buildTeamLeaderboard(db *gorm.DB, e teamEvent, mode string) (TeamLeaderboard, error) {
+	board := TeamLeaderboard{
+		TournamentID: e.tournament.ID,
+		Tournament:   e.tournament.Name,
+		Status:       e.tournament.Status,
+		Format:       e.tournament.TournamentType,
+		Mode:         mode,
+		Entries:      []TeamLeaderboardEntry{},
+		UpdatedAt:    time.Now(),
+	}
+	if !e.scramble() {
+		board.BestBalls = e.bestBalls()
+	}
+	for _, hole := range e.holes {
+		board.Par += hole.Par
+	}
+	if !e.scramble() {
+		board.Par *= e.bestBalls()
+	}
+
+	teams, err := loadTeams(db, e.tournament.ID)
+	if err != nil {
+		return board, err
+	}
+	data, err := loadTeamScores(db, e, teams)
+	if err != nil {
+		return board, err
+	}
+	board.Rounds = data.rounds(e.tournament)
+
+	entries := make([]TeamLeaderboardEntry, 0, len(teams))
+	for _, team := range teams {
+		entry := TeamLeaderboardEntry{
+			Standing: scoring.Standing{PlayerID: team.ID, Name: team.Name, Rounds: []int{}, Finished: true},
+			TeamID:   team.ID,
+		}
+		entry.Handicap, _ = e.handicaps(team)
+
+		for round := 1; round <= board.Rounds; round++ {
+			result := e.scoreRound(team, data, round, mode == "net")
+			if len(result.Holes) == 0 {
+				entry.Finished = false
+				continue
+			}
+			entry.Started = true
+			entry.Strokes += result.Total
+			entry.ToPar += resultToPar(result)
+			entry.Rounds = append(entry.Rounds, result.Total)
+			entry.Thru = len(result.Holes)
+			entry.LastRound = make([]int, len(result.Holes))
+			for i, hole := range result.Holes {
+				entry.LastRound[i] = hole.Net
+			}
+			if len(result.Holes) < len(e.holes) {
+				entry.Finished = false
+			}
+		}
+		if !entry.Started {
+			entry.Finished = false
+		}
+		entries = append(entries, entry)
+	}
+	board.Entries = rankTeamEntries(entries)
+	return board, nil
+}
+
+// rankTeamEntries ranks the teams that have started and lists the rest
+// afterwards without a position.
+func rankTeamEntries(entries []TeamLeaderboardEntry) []TeamLeaderboardEntry {
+	var standings []scoring.Standing
+	byTeam := make(map[uint]TeamLeaderboardEntry, len(entries))
+	var waiting []TeamLeaderboardEntry
+	for _, entry := range entries {
+		if !entry.Started {
+			waiting = append(waiting, entry)
+			continue
+		}
+		standings = append(standings, entry.Standing)
+		byTeam[entry.TeamID] = entry
+	}
+	scoring.Rank(standings)
+
+	ranked := make([]TeamLeaderboardEntry, 0, len(entries))
+	for _, standing := range standings {
+		entry := byTeam[standing.PlayerID]
+		entry.Standing = standing
+		ranked = append(ranked, entry)
+	}
+	return append(ranked, waiting...)
+}
+
+// teamRequestTournament reads the tournament of a team endpoint as a team
+// event.
+func teamRequestTournament(c *gin.Context) (teamEvent, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return teamEvent{}, false
+	}
+	db := database.DB
+	var tournament models.Tournament
+	if err := db.Preload("Course").First(&tournament, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return teamEvent{}, false
+	}
+	event, err := loadTeamEvent(db, tournament)
+	if err != nil {
+		respondError(c, err, "Failed to load tournament")
+		return teamEvent{}, false
+	}
+	return event, true
+}
+
+// saveTeam names a team and replaces its players. Players must be entered in
+// the tournament and cannot play for two teams.
+func saveTeam(tx *gorm.DB, e teamEvent, team *models.TournamentTeam, req TeamRequest) error {
+	ids := uniqueIDs(req.ParticipantIDs)
+	least := 2
+	if !e.scramble() {
+		least = max(least, e.bestBalls())
+	}
+	if len(ids) < least || len(ids) > e.teamSize() {
+		return newHTTPError(http.StatusBadRequest, "Teams need between "+strconv.Itoa(least)+" and "+strconv.Itoa(e.teamSize())+" players")
+	}
+
+	var count int64
+	tx.Model(&models.TournamentTeam{}).
+		Where("tournament_id = ? AND name = ? AND id <> ?", e.tournament.ID, req.Name, team.ID).Count(&count)
+	if count > 0 {
+		return newHTTPError(http.StatusConflict, "Team name is already taken")
+	}
+
+	tx.Model(&models.TournamentParticipant{}).Where("id IN ? AND tournament_id = ?", ids, e.tournament.ID).Count(&count)
+	if int(count) != len(ids) {
+		return newHTTPError(http.StatusBadRequest, "Players must be entered in the tournament")
+	}
+	tx.Model(&models.TournamentTeamMember{}).Where("participant_id IN ? AND team_id <> ?", ids, team.ID).Count(&count)
+	if count > 0 {
+		return newHTTPError(http.StatusConflict, "Player is already on another team")
+	}
+
+	team.TournamentID = e.tournament.ID
+	team.Name = req.Name
+	if err := tx.Save(team).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("team_id = ?", team.ID).Delete(&models.TournamentTeamMember{}).Error; err != nil {
+		return err
+	}
+	members := make([]models.TournamentTeamMember, len(ids))
+	for i, id := range ids {
+		members[i] = models.TournamentTeamMember{TeamID: team.ID, ParticipantID: id}
+	}
+	return tx.Create(&members).Error
+}
+
+// teamEditable rejects changes to teams once the tournament is over.
+func teamEditable(tournament models.Tournament) error {
+	if tournament.Status == "completed" || tournament.Status == "cancelled" {
+		return newHTTPError(http.StatusBadRequest, "Tournament is "+tournament.Status)
+	}
+	return nil
+}
+
+// @Summary List tournament teams
+// @Description Teams of a scramble or best ball event with their players and handicaps
+// @Tags tournaments
+// @Produce json
+// @Param id path int true "Tournament ID"
+// @Success 200 {array} TeamView
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id}/teams [get]
+func (h *TournamentHandler) GetTeams(c *gin.Context) {
+	event, ok := teamRequestTournament(c)
+	if !ok {
+		return
+	}
+
+	teams, err := loadTeams(database.DB, event.tournament.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch teams"})
+		return
+	}
+
+	views := make([]TeamView, len(teams))
+	for i, team := range teams {
+		views[i].TournamentTeam = team
+		views[i].Handicap, views[i].PlayingHandicaps = event.handicaps(team)
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// @Summary Get a team scorecard
+// @Description Hole-by-hole team scores for a round: the team ball in a scramble, the counting scores in best ball
+// @Tags tournaments
+// @Produce json
+// @Param id path int true "Tournament ID"
+// @Param team_id path int true "Team ID"
+// @Param round query int false "Round number" default(1)
+// @Param mode query string false "gross or net" default(gross)
+// @Success 200 {object} TeamScorecard
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id}/teams/{team_id}/scorecard [get]
+func (h *TournamentHandler) GetTeamScorecard(c *gin.Context) {
+	event, ok := teamRequestTournament(c)
+	if !ok {
+		return
+	}
+	round, err := strconv.Atoi(c.DefaultQuery("round", "1"))
+	if err != nil || round < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid round number"})
+		return
+	}
+	mode := c.DefaultQuery("mode", "gross")
+	if mode != "gross" && mode != "net" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be gross or net"})
+		return
+	}
+
+	db := database.DB
+	var team models.TournamentTeam
+	if err := db.Preload("Members.Participant.User").
+		Where("tournament_id = ?", event.tournament.ID).First(&team, c.Param("team_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+	data, err := loadTeamScores(db, event, []models.TournamentTeam{team})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team scores"})
+		return
+	}
+
+	result := event.scoreRound(team, data, round, mode == "net")
+	card := TeamScorecard{
+		TeamID:      team.ID,
+		Name:        team.Name,
+		Format:      event.tournament.TournamentType,
+		Mode:        mode,
+		RoundNumber: round,
+		Holes:       result.Holes,
+		Thru:        len(result.Holes),
+		Total:       result.Total,
+		ToPar:       resultToPar(result),
+		Finished:    len(result.Holes) == len(event.holes),
+	}
+	card.Handicap, _ = event.handicaps(team)
+	c.JSON(http.StatusOK, card)
+}
+
+// @Summary Get team leaderboard
+// @Description Gross or net team standings for a scramble or best ball event, ranked with card-off tie-breaks
+// @Tags tournaments
+// @Produce json
+// @Param id path int true "Tournament ID"
+// @Param mode query string false "gross or net" default(gross)
+// @Success 200 {object} TeamLeaderboard
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id}/leaderboard/teams [get]
+func (h *TournamentHandler) GetTeamLeaderboard(c *gin.Context) {
+	event, ok := teamRequestTournament(c)
+	if !ok {
+		return
+	}
+	mode := c.DefaultQuery("mode", "gross")
+	if mode != "gross" && mode != "net" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be gross or net"})
+		return
+	}
+
+	board, err := buildTeamLeaderboard(database.DB, event, mode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build leaderboard"})
+		return
+	}
+	c.JSON(http.StatusOK, board)
+}
+
+// @Summary Record scramble scores
+// @Description Post the team ball for one or more holes of a scramble round. Any player on the team may record scores.
+// @Tags tournaments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tournament ID"
+// @Param team_id path int true "Team ID"
+// @Param scores body TeamScoreRequest true "Hole scores"
+// @Success 200 {object} TeamScorecard
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id}/teams/{team_id}/scores [put]
+func (h *TournamentHandler) RecordTeamScores(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req TeamScoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.RoundNumber == 0 {
+		req.RoundNumber = 1
+	}
+
+	event, ok := teamRequestTournament(c)
+	if !ok {
+		return
+	}
+	if !event.scramble() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Best ball players post their own scorecards"})
+		return
+	}
+	if event.tournament.Status != "upcoming" && event.tournament.Status != "active" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tournament is " + event.tournament.Status})
+		return
+	}
+	rounds := int(event.tournament.EndDate.Sub(event.tournament.StartDate).Hours()/24) + 1
+	if req.RoundNumber > rounds {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tournament has " + strconv.Itoa(rounds) + " rounds"})
+		return
+	}
+	holeNumbers := make(map[int]bool, len(event.holes))
+	for _, hole := range event.holes {
+		holeNumbers[hole.Number] = true
+	}
+	for _, hole := range req.Holes {
+		if !holeNumbers[hole.HoleNumber] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Hole " + strconv.Itoa(hole.HoleNumber) + " is not on the course"})
+			return
+		}
+	}
+
+	db := database.DB
+	var team models.TournamentTeam
+	if err := db.Preload("Members.Participant.User").
+		Where("tournament_id = ?", event.tournament.ID).First(&team, c.Param("team_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+
+	// Players may score for their own team; staff may score for any
+	role := c.GetString("user_role")
+	allowed := role == "staff" || role == "admin"
+	for _, member := range team.Members {
+		if member.Participant.UserID == userID.(uint) {
+			allowed = true
+		}
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not on this team"})
+		return
+	}
+
+	scores := make([]models.TournamentTeamScore, len(req.Holes))
+	for i, hole := range req.Holes {
+		scores[i] = models.TournamentTeamScore{
+			TeamID:      team.ID,
+			RoundNumber: req.RoundNumber,
+			HoleNumber:  hole.HoleNumber,
+			Strokes:     hole.Strokes,
+		}
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "round_number"}, {Name: "hole_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"strokes", "updated_at"}),
+	}).Create(&scores).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record scores"})
+		return
+	}
+	if event.tournament.Status == "upcoming" {
+		db.Model(&event.tournament).Update("status", "active")
+	}
+
+	data, err := loadTeamScores(db, event, []models.TournamentTeam{team})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team scores"})
+		return
+	}
+	result := event.scoreRound(team, data, req.RoundNumber, false)
+	c.JSON(http.StatusOK, TeamScorecard{
+		TeamID:      team.ID,
+		Name:        team.Name,
+		Format:      event.tournament.TournamentType,
+		Mode:        "gross",
+		RoundNumber: req.RoundNumber,
+		Holes:       result.Holes,
+		Thru:        len(result.Holes),
+		Total:       result.Total,
+		ToPar:       resultToPar(result),
+		Finished:    len(result.Holes) == len(event.holes),
+	})
+}
+
+// Form a team from players entered in a scramble or best ball event
+func (h *TournamentHandler) CreateTeam(c *gin.Context) {
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	event, ok := teamRequestTournament(c)
+	if !ok {
+		return
+	}
+
+	var team models.TournamentTeam
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := teamEditable(event.tournament); err != nil {
+			return err
+		}
+		return saveTeam(tx, event, &team, req)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create team")
+		return
+	}
+
+	database.DB.Preload("Members.Participant.User").First(&team, team.ID)
+	c.JSON(http.StatusCreated, team)
+}
+
+// Rename a team or change its players
+func (h *TournamentHandler) UpdateTeam(c *gin.Context) {
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	event, ok := teamRequestTournament(c)
+	if !ok {
+		return
+	}
+
+	var team models.TournamentTeam
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("tournament_id = ?", event.tournament.ID).First(&team, c.Param("team_id")).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Team not found")
+		}
+		if err := teamEditable(event.tournament); err != nil {
+			return err
+		}
+		return saveTeam(tx, event, &team, req)
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update team")
+		return
+	}
+
+	database.DB.Preload("Members.Participant.User").First(&team, team.ID)
+	c.JSON(http.StatusOK, team)
+}
+
+// Break up a team, discarding any scramble scores it has posted
+func (h *TournamentHandler) DeleteTeam(c *gin.Context) {
+	event, ok := teamRequestTournament(c)
+	if !ok {
+		return
+	}
+	if err := teamEditable(event.tournament); err != nil {
+		respondError(c, err, "Failed to delete team")
+		return
+	}
+
+	result := database.DB.Where("tournament_id = ?", event.tournament.ID).
+		Delete(&models.TournamentTeam{}, c.Param("team_id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete team"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Team deleted successfully"})
+}
@@ -1,28 +1,51 @@
 package handlers
 
 import (
+	"fmt"
 	"golf-course-backend/internal/database"
 	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/promotions"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type TeeTimeHandler struct{}
 
+// The tee sheet runs from 06:00 to 15:00 in 15-minute slots
+const (
+	teeSheetOpen     = 6 * 60
+	teeSheetClose    = 15 * 60
+	teeSheetInterval = 15
+)
+
 func NewTeeTimeHandler() *TeeTimeHandler {
 	return &TeeTimeHandler{}
 }
 
 type TeeTimeRequest struct {
-	CourseID        uint   `json:"course_id" binding:"required"`
-	BookingDate     string `json:"booking_date" binding:"required"`
-	TeeTime         string `json:"tee_time" binding:"required"`
-	PlayersCount    int    `json:"players_count" binding:"required,min=1,max=4"`
-	CartRequired    bool   `json:"cart_required"`
-	SpecialRequests string `json:"special_requests"`
+	CourseID        uint                     `json:"course_id" binding:"required"`
+	TeeSetID        *uint                    `json:"tee_set_id"`
+	BookingDate     string                   `json:"booking_date" binding:"required"`
+	TeeTime         string                   `json:"tee_time" binding:"required"`
+	PlayersCount    int                      `json:"players_count" binding:"required,min=1,max=4"`
+	CartRequired    bool                     `json:"cart_required"`
+	CartCount       int                      `json:"cart_count" binding:"omitempty,min=1,max=4"`
+	SpecialRequests string                   `json:"special_requests"`
+	BundleID        *uint                    `json:"bundle_id"`
+	BundleQuantity  int                      `json:"bundle_quantity"`
+	BundleVariants  []BundleVariantSelection `json:"bundle_variants"`
+	PromoCode       string                   `json:"promo_code"`
+}
+
+// TeeTimeBookingResponse is a new booking with the promo code discount
+// breakdown when one was used.
+type TeeTimeBookingResponse struct {
+	models.TeeTime
+	Pricing *promotions.Breakdown `json:"pricing,omitempty"`
 }
 
 // @Summary Create tee time booking
@@ -32,7 +55,7 @@ type TeeTimeRequest struct {
 // @Produce json
 // @Security BearerAuth
 // @Param request body TeeTimeRequest true "Tee time booking request"
-// @Success 201 {object} models.TeeTime
+// @Success 201 {object} TeeTimeBookingResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /tee-times [post]
@@ -63,44 +86,128 @@ func (h *TeeTimeHandler) CreateTeeTime(c *gin.Context) {
 		return
 	}
 
+	if _, err := resolveTeeSet(database.DB, course.ID, req.TeeSetID); err != nil {
+		respondError(c, err, "Failed to create tee time")
+		return
+	}
+
+	var bundle *models.EquipmentBundle
+	if req.BundleID != nil {
+		bundle, err = loadBundle(database.DB, *req.BundleID)
+		if err != nil || !bundle.IsActive {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bundle not found"})
+			return
+		}
+	}
+
 	// Check if tee time is available
 	var existingTeeTime models.TeeTime
-	err = database.DB.Where("course_id = ? AND booking_date = ? AND tee_time = ?",
+	err = database.DB.Where("course_id = ? AND booking_date = ? AND tee_time = ? AND booking_status != 'cancelled'",
 		req.CourseID, bookingDate, req.TeeTime).First(&existingTeeTime).Error
 	if err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Tee time not available"})
 		return
 	}
 
+	// Two players share a cart unless the golfer asks for more
+	cartCount := 0
+	if req.CartRequired || req.CartCount > 0 {
+		req.CartRequired = true
+		cartCount = req.CartCount
+		if cartCount == 0 {
+			cartCount = (req.PlayersCount + 1) / 2
+		}
+	}
+
 	// Calculate total amount
-	totalAmount := course.GreenFee * float64(req.PlayersCount)
+	items := []promotions.Item{{
+		Product:     promotions.GreenFee,
+		Description: fmt.Sprintf("Green fees x %d", req.PlayersCount),
+		Amount:      course.GreenFee * float64(req.PlayersCount),
+	}}
 	if req.CartRequired {
-		totalAmount += course.CartFee
+		items = append(items, promotions.Item{
+			Product:     promotions.Cart,
+			Description: fmt.Sprintf("Cart fee x %d", cartCount),
+			Amount:      course.CartFee * float64(cartCount),
+		})
+	}
+	totalAmount := 0.0
+	for _, item := range items {
+		totalAmount += item.Amount
 	}
 
 	// Create tee time
 	teeTime := models.TeeTime{
 		CourseID:        req.CourseID,
 		UserID:          userID.(uint),
+		TeeSetID:        req.TeeSetID,
 		BookingDate:     bookingDate,
 		TeeTime:         req.TeeTime,
 		PlayersCount:    req.PlayersCount,
 		CartRequired:    req.CartRequired,
+		CartCount:       cartCount,
 		TotalAmount:     totalAmount,
 		SpecialRequests: req.SpecialRequests,
 		PaymentStatus:   "pending",
 		BookingStatus:   "confirmed",
 	}
 
-	if err := database.DB.Create(&teeTime).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tee time"})
+	// The booking, promo code redemption and any bundle reservation succeed
+	// or fail together
+	var pricing *promotions.Breakdown
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureCartCapacity(tx, bookingDate, req.TeeTime, cartCount); err != nil {
+			return err
+		}
+		var promotion *models.Promotion
+		if req.PromoCode != "" {
+			minutes, err := parseClock(req.TeeTime)
+			if err != nil {
+				return newHTTPError(http.StatusBadRequest, "Invalid tee time")
+			}
+			promotion, pricing, err = priceWithPromotion(tx, req.PromoCode, promoBooking{
+				UserID:    teeTime.UserID,
+				At:        bookingDate.Add(time.Duration(minutes) * time.Minute),
+				TimeKnown: true,
+				Items:     items,
+			})
+			if err != nil {
+				return err
+			}
+			teeTime.TotalAmount = pricing.Total
+			teeTime.DiscountAmount = pricing.Discount
+		}
+		if err := tx.Create(&teeTime).Error; err != nil {
+			return err
+		}
+		if promotion != nil {
+			if err := recordRedemption(tx, promotion, teeTime.UserID, "tee_time", teeTime.ID, pricing); err != nil {
+				return err
+			}
+		}
+		if bundle == nil {
+			return nil
+		}
+		_, err := reserveBundle(tx, bundle, bundleReservation{
+			UserID:     teeTime.UserID,
+			TeeTimeID:  &teeTime.ID,
+			RentalDate: bookingDate,
+			ReturnDate: bookingDate,
+			Quantity:   req.BundleQuantity,
+			Variants:   req.BundleVariants,
+		})
+		return err
+	})
+	if err != nil {
+		respondError(c, err, "Failed to create tee time")
 		return
 	}
 
 	// Preload relationships for response
-	database.DB.Preload("Course").Preload("User").First(&teeTime, teeTime.ID)
+	database.DB.Preload("Course").Preload("User").Preload("TeeSet").Preload("BundleRentals.Bundle").First(&teeTime, teeTime.ID)
 
-	c.JSON(http.StatusCreated, teeTime)
+	c.JSON(http.StatusCreated, TeeTimeBookingResponse{TeeTime: teeTime, Pricing: pricing})
 }
 
 // @Summary Get user's tee times
@@ -119,7 +226,7 @@ func (h *TeeTimeHandler) GetUserTeeTimes(c *gin.Context) {
 	}
 
 	var teeTimes []models.TeeTime
-	if err := database.DB.Preload("Course").Where("user_id = ?", userID).
+	if err := database.DB.Preload("Course").Preload("BundleRentals.Bundle").Preload("Shares").Where("user_id = ?", userID).
 		Order("booking_date DESC, tee_time DESC").Find(&teeTimes).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tee times"})
 		return
@@ -171,10 +278,16 @@ func (h *TeeTimeHandler) GetAvailableTeeTimes(c *gin.Context) {
 		Where("course_id = ? AND booking_date = ? AND booking_status != 'cancelled'", courseID, date).
 		Pluck("tee_time", &bookedTimes)
 
-	// Generate all possible tee times (6 AM to 3 PM, every 15 minutes)
+	// Carts are shared by every course, so availability depends on the whole day's bookings
+	cartCapacity, fleetConfigured, _ := cartFleetCapacity(database.DB)
+	cartBookings, _ := loadCartDemand(database.DB, date)
+	roundMinutes := roundDurationMinutes()
+
+	// Generate all possible tee times on the sheet
 	allTimes := []map[string]interface{}{}
-	start := time.Date(date.Year(), date.Month(), date.Day(), 6, 0, 0, 0, time.Local)
-	end := time.Date(date.Year(), date.Month(), date.Day(), 15, 0, 0, 0, time.Local)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	start := day.Add(teeSheetOpen * time.Minute)
+	end := day.Add(teeSheetClose * time.Minute)
 
 	// Create a map of booked times for quick lookup
 	bookedMap := make(map[string]bool)
@@ -184,7 +297,7 @@ func (h *TeeTimeHandler) GetAvailableTeeTimes(c *gin.Context) {
 
 	// Generate available time slots
 	id := 1
-	for t := start; t.Before(end); t = t.Add(15 * time.Minute) {
+	for t := start; t.Before(end); t = t.Add(teeSheetInterval * time.Minute) {
 		timeStr := t.Format("15:04")
 		if !bookedMap[timeStr] {
 			teeTime := map[string]interface{}{
@@ -196,6 +309,10 @@ func (h *TeeTimeHandler) GetAvailableTeeTimes(c *gin.Context) {
 				"price":           course.GreenFee,
 				"course_name":     course.Name,
 			}
+			if fleetConfigured {
+				minutes := t.Hour()*60 + t.Minute()
+				teeTime["carts_available"] = max(cartCapacity-peakCartDemand(cartBookings, minutes, roundMinutes), 0)
+			}
 			allTimes = append(allTimes, teeTime)
 			id++
 		}
@@ -203,3 +320,17 @@ func (h *TeeTimeHandler) GetAvailableTeeTimes(c *gin.Context) {
 
 	c.JSON(http.StatusOK, allTimes)
 }
+
+// cancelTeeTime cancels a booking, which frees its slot and any promo code
+// used on it, and calls off the bundle rentals reserved with it. It returns
+// the intents to cancel with the provider once the transaction commits.
+func cancelTeeTime(tx *gorm.DB, teeTime *models.TeeTime) ([]string, error) {
+	teeTime.BookingStatus = "cancelled"
+	if err := tx.Model(teeTime).Update("booking_status", "cancelled").Error; err != nil {
+		return nil, err
+	}
+	if err := voidRedemption(tx, "tee_time", teeTime.ID); err != nil {
+		return nil, err
+	}
+	return releaseTeeTimeBundles(tx, teeTime.ID)
+}
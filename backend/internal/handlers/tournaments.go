@@ -0,0 +1,708 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TournamentHandler struct {
+	payments *PaymentHandler
+}
+
+func NewTournamentHandler(payments *PaymentHandler) *TournamentHandler {
+	return &TournamentHandler{payments: payments}
+}
+
+type TournamentRequest struct {
+	Name                 string    `json:"name" binding:"required"`
+	Description          string    `json:"description"`
+	CourseID             uint      `json:"course_id" binding:"required"`
+	StartDate            string    `json:"start_date" binding:"required"`
+	EndDate              string    `json:"end_date"`
+	EntryFee             *float64  `json:"entry_fee" binding:"omitempty,min=0"`
+	MaxParticipants      *int      `json:"max_participants" binding:"omitempty,min=1"`
+	TournamentType       string    `json:"tournament_type" binding:"omitempty,oneof=stroke_play match_play scramble best_ball"`
+	Status               string    `json:"status" binding:"omitempty,oneof=upcoming active completed cancelled"`
+	PrizePool            *float64  `json:"prize_pool" binding:"omitempty,min=0"`
+	RegistrationDeadline string    `json:"registration_deadline"`
+	TeamSize             *int      `json:"team_size" binding:"omitempty,min=2,max=4"`
+	BestBalls            int       `json:"best_balls" binding:"omitempty,min=1,max=4"`
+	TeamAllowances       []float64 `json:"team_allowances" binding:"max=4,dive,gt=0,lte=100"`
+}
+
+type EntryFeeCheckoutRequest struct {
+	PaymentMethodID string `json:"payment_method_id"`
+}
+
+type TournamentPaymentRequest struct {
+	PaymentMethod string `json:"payment_method" binding:"required,oneof=credit_card debit_card cash bank_transfer"`
+}
+
+// registrationOpen reports whether entries are still being taken. Without a
+// deadline, registration closes the day before the first round.
+func registrationOpen(tournament models.Tournament, now time.Time) bool {
+	if tournament.Status != "upcoming" {
+		return false
+	}
+	today := now.Format("2006-01-02")
+	if tournament.RegistrationDeadline != nil {
+		return today <= tournament.RegistrationDeadline.Format("2006-01-02")
+	}
+	return today < tournament.StartDate.Format("2006-01-02")
+}
+
+// countParticipants fills in the number of entrants for each tournament.
+func countParticipants(db *gorm.DB, tournaments []models.Tournament) error {
+	if len(tournaments) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(tournaments))
+	for i, t := range tournaments {
+		ids[i] = t.ID
+	}
+
+	var counts []struct {
+		TournamentID uint
+		Count        int
+	}
+	if err := db.Model(&models.TournamentParticipant{}).
+		Select("tournament_id, COUNT(*) AS count").
+		Where("tournament_id IN ?", ids).
+		Group("tournament_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]int, len(counts))
+	for _, row := range counts {
+		byID[row.TournamentID] = row.Count
+	}
+	for i := range tournaments {
+		tournaments[i].ParticipantCount = byID[tournaments[i].ID]
+	}
+	return nil
+}
+
+// releaseEntryFee refunds a paid entry fee and cancels one still pending.
+// The refunds are approved here; returnEntryFees pays them out, and cancels
+// the intents returned, once the transaction has committed.
+func releaseEntryFee(tx *gorm.DB, tournamentID, userID, requestedBy uint, reason string) ([]*models.Refund, []string, error) {
+	var list []models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("reference_type = ? AND reference_id = ? AND user_id = ? AND payment_type = ? AND payment_status IN ?",
+			"tournament", tournamentID, userID, "charge", []string{"pending", "processing", "succeeded", "partially_refunded"}).
+		Find(&list).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var refunds []*models.Refund
+	var intentIDs []string
+	for i := range list {
+		payment := &list[i]
+		if payment.PaymentStatus == "pending" || payment.PaymentStatus == "processing" {
+			intentIDs = append(intentIDs, payment.StripePaymentIntentID)
+			if err := finalizePayment(tx, payment, "cancelled", reason); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		_, committed, err := refundTotals(tx, payment.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if committed >= roundCurrency(payment.Amount) {
+			continue
+		}
+		refund, err := openRefund(tx, payment.ID, nil, RefundRequest{ReasonCode: "cancellation", Notes: reason}, requestedBy)
+		if err != nil {
+			return nil, nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, intentIDs, nil
+}
+
+// returnEntryFees pays out the refunds releaseEntryFee approved and cancels
+// the intents of the payments it cancelled. A refund the provider turns down
+// is left failed for staff to issue again.
+func (h *TournamentHandler) returnEntryFees(ctx context.Context, refunds []*models.Refund, intentIDs []string) {
+	h.payments.cancelIntents(ctx, intentIDs...)
+	for _, refund := range refunds {
+		h.payments.completeRefund(ctx, refund)
+	}
+}
+
+// applyTournamentRequest copies a request onto a tournament after checking
+// its dates and course.
+func applyTournamentRequest(db *gorm.DB, tournament *models.Tournament, req TournamentRequest) error {
+	var course models.Course
+	if err := db.First(&course, req.CourseID).Error; err != nil {
+		return newHTTPError(http.StatusBadRequest, "Course not found")
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return newHTTPError(http.StatusBadRequest, "Invalid start date format")
+	}
+	endDate := startDate
+	if req.EndDate != "" {
+		if endDate, err = time.Parse("2006-01-02", req.EndDate); err != nil {
+			return newHTTPError(http.StatusBadRequest, "Invalid end date format")
+		}
+		if endDate.Before(startDate) {
+			return newHTTPError(http.StatusBadRequest, "End date must not be before the start date")
+		}
+	}
+
+	var deadline *time.Time
+	if req.RegistrationDeadline != "" {
+		value, err := time.Parse("2006-01-02", req.RegistrationDeadline)
+		if err != nil {
+			return newHTTPError(http.StatusBadRequest, "Invalid registration deadline format")
+		}
+		if value.After(startDate) {
+			return newHTTPError(http.StatusBadRequest, "Registration must close before the tournament starts")
+		}
+		deadline = &value
+	}
+	if req.TeamSize != nil && req.BestBalls > *req.TeamSize {
+		return newHTTPError(http.StatusBadRequest, "Best balls cannot exceed the team size")
+	}
+
+	tournament.Name = req.Name
+	tournament.Description = req.Description
+	tournament.CourseID = req.CourseID
+	tournament.StartDate = startDate
+	tournament.EndDate = endDate
+	tournament.EntryFee = req.EntryFee
+	tournament.MaxParticipants = req.MaxParticipants
+	tournament.PrizePool = req.PrizePool
+	tournament.RegistrationDeadline = deadline
+	tournament.TeamSize = req.TeamSize
+	tournament.TeamAllowances = formatNumbers(req.TeamAllowances)
+	if req.BestBalls > 0 {
+		tournament.BestBalls = req.BestBalls
+	}
+	if req.TournamentType != "" {
+		tournament.TournamentType = req.TournamentType
+	}
+	if req.Status != "" {
+		tournament.Status = req.Status
+	}
+	return nil
+}
+
+// @Summary List tournaments
+// @Description Tournaments with their entry counts, soonest first
+// @Tags tournaments
+// @Produce json
+// @Param status query string false "upcoming, active, completed or cancelled"
+// @Param course_id query int false "Course ID"
+// @Success 200 {array} models.Tournament
+// @Router /tournaments [get]
+func (h *TournamentHandler) GetTournaments(c *gin.Context) {
+	db := database.DB
+	query := db.Preload("Course")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status <> ?", "cancelled")
+	}
+	if courseID := c.Query("course_id"); courseID != "" {
+		query = query.Where("course_id = ?", courseID)
+	}
+
+	var tournaments []models.Tournament
+	if err := query.Order("start_date ASC").Find(&tournaments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tournaments"})
+		return
+	}
+	if err := countParticipants(db, tournaments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tournaments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tournaments)
+}
+
+// @Summary Get tournament
+// @Description Tournament details with its entry count
+// @Tags tournaments
+// @Produce json
+// @Param id path int true "Tournament ID"
+// @Success 200 {object} models.Tournament
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id} [get]
+func (h *TournamentHandler) GetTournament(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	db := database.DB
+	var tournament models.Tournament
+	if err := db.Preload("Course").First(&tournament, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return
+	}
+	list := []models.Tournament{tournament}
+	if err := countParticipants(db, list); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tournament"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list[0])
+}
+
+// @Summary Get my tournament entries
+// @Description Tournaments the authenticated user has entered
+// @Tags tournaments
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.TournamentParticipant
+// @Router /tournaments/entries [get]
+func (h *TournamentHandler) GetUserEntries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var entries []models.TournamentParticipant
+	if err := database.DB.Preload("Tournament.Course").
+		Where("user_id = ?", userID).
+		Order("registration_date DESC").
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// @Summary Register for tournament
+// @Description Enter a tournament; the player's handicap index is recorded and any entry fee is due
+// @Tags tournaments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tournament ID"
+// @Success 201 {object} models.TournamentParticipant
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /tournaments/{id}/register [post]
+func (h *TournamentHandler) Register(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	var participant models.TournamentParticipant
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the tournament so concurrent entries cannot overfill it
+		var tournament models.Tournament
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tournament, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Tournament not found")
+		}
+		if !registrationOpen(tournament, time.Now()) {
+			return newHTTPError(http.StatusBadRequest, "Registration is closed")
+		}
+
+		var existing int64
+		tx.Model(&models.TournamentParticipant{}).
+			Where("tournament_id = ? AND user_id = ?", tournament.ID, userID).
+			Count(&existing)
+		if existing > 0 {
+			return newHTTPError(http.StatusConflict, "Already registered")
+		}
+
+		if tournament.MaxParticipants != nil {
+			var entered int64
+			tx.Model(&models.TournamentParticipant{}).Where("tournament_id = ?", tournament.ID).Count(&entered)
+			if int(entered) >= *tournament.MaxParticipants {
+				return newHTTPError(http.StatusConflict, "Tournament is full")
+			}
+		}
+
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		participant = models.TournamentParticipant{
+			TournamentID:           tournament.ID,
+			UserID:                 user.ID,
+			HandicapAtRegistration: user.Handicap,
+			PaymentStatus:          "paid",
+		}
+		fee := 0.0
+		if tournament.EntryFee != nil {
+			fee = roundCurrency(*tournament.EntryFee)
+		}
+		if fee > 0 {
+			participant.PaymentStatus = "pending"
+		}
+		if err := tx.Create(&participant).Error; err != nil {
+			return err
+		}
+		if fee == 0 {
+			return nil
+		}
+
+		payment := models.Payment{
+			UserID:        user.ID,
+			ReferenceType: "tournament",
+			ReferenceID:   tournament.ID,
+			Amount:        fee,
+			Currency:      "USD",
+			PaymentType:   "charge",
+			PaymentStatus: "pending",
+		}
+		return tx.Create(&payment).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to register for tournament")
+		return
+	}
+
+	database.DB.Preload("Tournament").First(&participant, participant.ID)
+
+	c.JSON(http.StatusCreated, participant)
+}
+
+// @Summary Pay tournament entry fee
+// @Description Pay the pending entry fee for the authenticated user's entry by card. Returns a client secret for Stripe.js, or confirms straight away when a payment method is given.
+// @Tags tournaments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tournament ID"
+// @Param request body EntryFeeCheckoutRequest false "Card to confirm with"
+// @Success 201 {object} CheckoutResponse
+// @Failure 400 {object} map[string]string
+// @Failure 402 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id}/pay [post]
+func (h *TournamentHandler) PayEntryFee(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	var req EntryFeeCheckoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	h.payments.checkout(c, userID.(uint), CheckoutRequest{
+		ReferenceType:   "tournament",
+		ReferenceID:     uint(id),
+		PaymentMethodID: req.PaymentMethodID,
+	})
+}
+
+// Record an entry fee paid at the counter
+func (h *TournamentHandler) RecordEntryFee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req TournamentPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var participant models.TournamentParticipant
+	var replacedIntentID string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("tournament_id = ? AND user_id = ?", id, userID).
+			First(&participant).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Registration not found")
+		}
+		if participant.PaymentStatus == "paid" {
+			return newHTTPError(http.StatusBadRequest, "Entry fee already paid")
+		}
+
+		var payment models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference_type = ? AND reference_id = ? AND user_id = ? AND payment_type = ? AND payment_status IN ?",
+				"tournament", id, userID, "charge", []string{"pending", "processing", "failed"}).
+			Order("id DESC").First(&payment).Error; err != nil {
+			return newHTTPError(http.StatusBadRequest, "No entry fee is due")
+		}
+		if payment.PaymentStatus == "processing" {
+			return newHTTPError(http.StatusConflict, "A card payment for this entry is already being processed")
+		}
+
+		// A card payment the entrant started online is dropped for this one
+		if payment.StripePaymentIntentID != "" {
+			replacedIntentID = payment.StripePaymentIntentID
+			if err := finalizePayment(tx, &payment, "cancelled", "Paid at the counter"); err != nil {
+				return err
+			}
+			payment = models.Payment{
+				UserID:        payment.UserID,
+				ReferenceType: payment.ReferenceType,
+				ReferenceID:   payment.ReferenceID,
+				Amount:        payment.Amount,
+				Currency:      payment.Currency,
+				PaymentType:   "charge",
+				PaymentMethod: req.PaymentMethod,
+				PaymentStatus: "pending",
+			}
+			if err := tx.Create(&payment).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(&payment).Update("payment_method", req.PaymentMethod).Error; err != nil {
+			return err
+		}
+		return finalizePayment(tx, &payment, "succeeded", "")
+	})
+	if err != nil {
+		respondError(c, err, "Failed to record entry fee")
+		return
+	}
+	h.payments.cancelIntents(c.Request.Context(), replacedIntentID)
+
+	database.DB.Preload("Tournament").First(&participant, participant.ID)
+
+	c.JSON(http.StatusOK, participant)
+}
+
+// @Summary Withdraw from tournament
+// @Description Withdraw before registration closes; a paid entry fee is refunded
+// @Tags tournaments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tournament ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tournaments/{id}/register [delete]
+func (h *TournamentHandler) Withdraw(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	var refunds []*models.Refund
+	var intentIDs []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var tournament models.Tournament
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tournament, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Tournament not found")
+		}
+
+		var participant models.TournamentParticipant
+		if err := tx.Where("tournament_id = ? AND user_id = ?", tournament.ID, userID).First(&participant).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Registration not found")
+		}
+		if !registrationOpen(tournament, time.Now()) {
+			return newHTTPError(http.StatusBadRequest, "Withdrawals close with registration")
+		}
+
+		var err error
+		refunds, intentIDs, err = releaseEntryFee(tx, tournament.ID, participant.UserID, participant.UserID, "Withdrawn from tournament")
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&participant).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to withdraw from tournament")
+		return
+	}
+	h.returnEntryFees(c.Request.Context(), refunds, intentIDs)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Withdrawn from tournament", "refunds": refunds})
+}
+
+func (h *TournamentHandler) CreateTournament(c *gin.Context) {
+	var req TournamentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	tournament := models.Tournament{TournamentType: "stroke_play", Status: "upcoming"}
+	if err := applyTournamentRequest(db, &tournament, req); err != nil {
+		respondError(c, err, "Failed to create tournament")
+		return
+	}
+
+	if err := db.Create(&tournament).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tournament"})
+		return
+	}
+
+	db.Preload("Course").First(&tournament, tournament.ID)
+
+	c.JSON(http.StatusCreated, tournament)
+}
+
+// Update a tournament; cancelling it refunds every paid entry
+func (h *TournamentHandler) UpdateTournament(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	var req TournamentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := database.DB
+	var tournament models.Tournament
+	var refunds []*models.Refund
+	var intentIDs []string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tournament, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Tournament not found")
+		}
+		wasCancelled := tournament.Status == "cancelled"
+		if err := applyTournamentRequest(tx, &tournament, req); err != nil {
+			return err
+		}
+
+		var participants []models.TournamentParticipant
+		if err := tx.Where("tournament_id = ?", tournament.ID).Find(&participants).Error; err != nil {
+			return err
+		}
+		if tournament.MaxParticipants != nil && len(participants) > *tournament.MaxParticipants {
+			return newHTTPError(http.StatusBadRequest, "More players have already entered than the new limit")
+		}
+
+		if tournament.Status == "cancelled" && !wasCancelled {
+			for _, participant := range participants {
+				released, cancelled, err := releaseEntryFee(tx, tournament.ID, participant.UserID, c.GetUint("user_id"), "Tournament cancelled")
+				if err != nil {
+					return err
+				}
+				refunds = append(refunds, released...)
+				intentIDs = append(intentIDs, cancelled...)
+			}
+		}
+
+		return tx.Save(&tournament).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update tournament")
+		return
+	}
+	h.returnEntryFees(c.Request.Context(), refunds, intentIDs)
+
+	db.Preload("Course").First(&tournament, tournament.ID)
+
+	c.JSON(http.StatusOK, tournament)
+}
+
+// Delete a tournament nobody has paid to enter; others must be cancelled
+func (h *TournamentHandler) DeleteTournament(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var tournament models.Tournament
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tournament, id).Error; err != nil {
+			return newHTTPError(http.StatusNotFound, "Tournament not found")
+		}
+
+		var paid int64
+		tx.Model(&models.TournamentParticipant{}).
+			Where("tournament_id = ? AND payment_status = ?", tournament.ID, "paid").
+			Count(&paid)
+		if paid > 0 && tournament.Status != "cancelled" {
+			return newHTTPError(http.StatusConflict, "Players have paid to enter; cancel the tournament instead")
+		}
+
+		if err := tx.Model(&models.Payment{}).
+			Where("reference_type = ? AND reference_id = ? AND payment_status IN ?",
+				"tournament", tournament.ID, []string{"pending", "processing"}).
+			Update("payment_status", "cancelled").Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tournament).Error
+	})
+	if err != nil {
+		respondError(c, err, "Failed to delete tournament")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tournament deleted successfully"})
+}
+
+// List a tournament's entrants in the order they registered
+func (h *TournamentHandler) GetParticipants(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament ID"})
+		return
+	}
+
+	db := database.DB
+	var tournament models.Tournament
+	if err := db.First(&tournament, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tournament not found"})
+		return
+	}
+
+	var participants []models.TournamentParticipant
+	if err := db.Preload("User").
+		Where("tournament_id = ?", tournament.ID).
+		Order("registration_date ASC, id ASC").
+		Find(&participants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch participants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, participants)
+}
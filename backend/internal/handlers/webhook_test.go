@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golf-course-backend/internal/config"
+	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/models"
+	"golf-course-backend/internal/payments"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// setupWebhookTest points the handlers at a throwaway SQLite database with
+// the tables a webhook touches, and returns a payment handler backed by the
+// fake provider.
+func setupWebhookTest(t *testing.T) (*PaymentHandler, *payments.FakeProvider) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.RangeSession{},
+		&models.Payment{},
+		&models.Refund{},
+		&models.ProcessedWebhookEvent{},
+		&models.JournalEntry{},
+		&models.JournalLine{},
+		&models.SystemSetting{},
+		&models.PromotionRedemption{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+
+	provider := payments.NewFakeProvider()
+	handler := NewPaymentHandler(provider, config.StripeConfig{WebhookSecret: testWebhookSecret}, config.EmailConfig{}, "")
+	return handler, provider
+}
+
+// createRangePayment books a range session and a card payment for it in the
+// given status, with an intent the fake provider has charged.
+func createRangePayment(t *testing.T, provider *payments.FakeProvider, status string) (models.Payment, *payments.Intent) {
+	t.Helper()
+	session := models.RangeSession{
+		UserID:         1,
+		SessionDate:    time.Now(),
+		StartTime:      "10:00",
+		BallBucketSize: "medium",
+		BucketPrice:    20,
+		PaymentStatus:  "pending",
+		SessionStatus:  "booked",
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		t.Fatalf("create range session: %v", err)
+	}
+
+	intent, err := provider.CreateIntent(context.Background(), payments.IntentParams{
+		Amount:        payments.ToMinorUnits(session.BucketPrice),
+		Currency:      "USD",
+		PaymentMethod: payments.FakeCardSucceeds,
+	})
+	if err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	payment := models.Payment{
+		UserID:                1,
+		ReferenceType:         "range_session",
+		ReferenceID:           session.ID,
+		Amount:                session.BucketPrice,
+		Currency:              "USD",
+		PaymentType:           "charge",
+		PaymentMethod:         "credit_card",
+		StripePaymentIntentID: intent.ID,
+		PaymentStatus:         status,
+	}
+	if err := database.DB.Create(&payment).Error; err != nil {
+		t.Fatalf("create payment: %v", err)
+	}
+	return payment, intent
+}
+
+// signedEvent builds a webhook body for an intent and signs it as the
+// provider would.
+func signedEvent(t *testing.T, eventID, eventType string, intent *payments.Intent) ([]byte, string) {
+	t.Helper()
+	object, err := json.Marshal(intent)
+	if err != nil {
+		t.Fatalf("marshal intent: %v", err)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"id":      eventID,
+		"type":    eventType,
+		"created": time.Now().Unix(),
+		"data":    map[string]json.RawMessage{"object": object},
+	})
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+	return body, payments.SignPayload(body, testWebhookSecret, time.Now())
+}
+
+// postWebhook sends a webhook to the handler and decodes its reply.
+func postWebhook(t *testing.T, h *PaymentHandler, body []byte, signature string) (int, map[string]interface{}) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/payments/webhook", bytes.NewReader(body))
+	if signature != "" {
+		c.Request.Header.Set("Stripe-Signature", signature)
+	}
+	h.HandleWebhook(c)
+
+	var reply map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("decode reply %q: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, reply
+}
+
+func TestHandleWebhookSignature(t *testing.T) {
+	h, provider := setupWebhookTest(t)
+	_, intent := createRangePayment(t, provider, "processing")
+	body, signature := signedEvent(t, "evt_signature", "payment_intent.succeeded", intent)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+		want      int
+	}{
+		{name: "missing header", body: body, want: http.StatusBadRequest},
+		{name: "tampered body", body: bytes.Replace(body, []byte("evt_signature"), []byte("evt_tampered"), 1), signature: signature, want: http.StatusBadRequest},
+		{name: "stale timestamp", body: body, signature: payments.SignPayload(body, testWebhookSecret, time.Now().Add(-payments.DefaultTolerance-time.Minute)), want: http.StatusBadRequest},
+		{name: "wrong secret", body: body, signature: payments.SignPayload(body, "whsec_other", time.Now()), want: http.StatusBadRequest},
+		{name: "valid", body: body, signature: signature, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, reply := postWebhook(t, h, tt.body, tt.signature)
+			if code != tt.want {
+				t.Fatalf("status = %d, want %d (%v)", code, tt.want, reply)
+			}
+		})
+	}
+
+	// Only the verified delivery is recorded
+	var recorded int64
+	database.DB.Model(&models.ProcessedWebhookEvent{}).Count(&recorded)
+	if recorded != 1 {
+		t.Fatalf("recorded %d events, want 1", recorded)
+	}
+}
+
+func TestHandleWebhookReplay(t *testing.T) {
+	h, provider := setupWebhookTest(t)
+	payment, intent := createRangePayment(t, provider, "processing")
+	body, signature := signedEvent(t, "evt_replay", "payment_intent.succeeded", intent)
+
+	for i, wantDuplicate := range []bool{false, true, true} {
+		code, reply := postWebhook(t, h, body, signature)
+		if code != http.StatusOK {
+			t.Fatalf("delivery %d: status = %d (%v)", i+1, code, reply)
+		}
+		if reply["duplicate"] != wantDuplicate {
+			t.Fatalf("delivery %d: duplicate = %v, want %v", i+1, reply["duplicate"], wantDuplicate)
+		}
+	}
+
+	var event models.ProcessedWebhookEvent
+	if err := database.DB.Where("event_id = ?", "evt_replay").First(&event).Error; err != nil {
+		t.Fatalf("event not recorded: %v", err)
+	}
+	if event.PaymentID == nil || *event.PaymentID != payment.ID {
+		t.Fatalf("event payment = %v, want %d", event.PaymentID, payment.ID)
+	}
+
+	database.DB.First(&payment, payment.ID)
+	if payment.PaymentStatus != "succeeded" {
+		t.Fatalf("payment status = %q, want succeeded", payment.PaymentStatus)
+	}
+	var session models.RangeSession
+	database.DB.First(&session, payment.ReferenceID)
+	if session.PaymentStatus != "paid" {
+		t.Fatalf("range session payment status = %q, want paid", session.PaymentStatus)
+	}
+
+	// The sale is posted to the ledger once, however often it is delivered
+	var entries int64
+	database.DB.Model(&models.JournalEntry{}).Where("payment_id = ?", payment.ID).Count(&entries)
+	if entries != 1 {
+		t.Fatalf("posted %d journal entries, want 1", entries)
+	}
+}
+
+func TestHandleWebhookTransitions(t *testing.T) {
+	tests := []struct {
+		from      string
+		eventType string
+		allowed   bool
+		want      string
+	}{
+		{from: "pending", eventType: "payment_intent.processing", allowed: true, want: "processing"},
+		{from: "pending", eventType: "payment_intent.succeeded", allowed: true, want: "succeeded"},
+		{from: "processing", eventType: "payment_intent.amount_capturable_updated", allowed: true, want: "processing"},
+		{from: "processing", eventType: "payment_intent.succeeded", allowed: true, want: "succeeded"},
+		{from: "processing", eventType: "payment_intent.payment_failed", allowed: true, want: "failed"},
+		{from: "processing", eventType: "payment_intent.canceled", allowed: true, want: "cancelled"},
+		{from: "failed", eventType: "payment_intent.succeeded", allowed: true, want: "succeeded"},
+		{from: "succeeded", eventType: "payment_intent.payment_failed", want: "succeeded"},
+		{from: "succeeded", eventType: "payment_intent.processing", want: "succeeded"},
+		{from: "succeeded", eventType: "payment_intent.canceled", want: "succeeded"},
+		{from: "partially_refunded", eventType: "payment_intent.succeeded", want: "partially_refunded"},
+		{from: "refunded", eventType: "payment_intent.succeeded", want: "refunded"},
+		{from: "refunded", eventType: "payment_intent.canceled", want: "refunded"},
+		{from: "cancelled", eventType: "payment_intent.succeeded", want: "cancelled"},
+	}
+
+	h, provider := setupWebhookTest(t)
+	for i, tt := range tests {
+		t.Run(tt.from+" "+tt.eventType, func(t *testing.T) {
+			if got := webhookTransitionAllowed(tt.from, webhookStatuses[tt.eventType]); got != tt.allowed {
+				t.Fatalf("webhookTransitionAllowed(%q) = %v, want %v", tt.from, got, tt.allowed)
+			}
+
+			payment, intent := createRangePayment(t, provider, tt.from)
+			body, signature := signedEvent(t, fmt.Sprintf("evt_transition_%d", i), tt.eventType, intent)
+			if code, reply := postWebhook(t, h, body, signature); code != http.StatusOK {
+				t.Fatalf("status = %d (%v)", code, reply)
+			}
+
+			database.DB.First(&payment, payment.ID)
+			if payment.PaymentStatus != tt.want {
+				t.Fatalf("payment status = %q, want %q", payment.PaymentStatus, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleWebhookSupersededPayment(t *testing.T) {
+	h, provider := setupWebhookTest(t)
+	payment, intent := createRangePayment(t, provider, "cancelled")
+	database.DB.Model(&payment).Update("failure_reason", "Replaced by split payment")
+
+	// A second event for the same intent must not refund the card twice
+	for i := 0; i < 2; i++ {
+		body, signature := signedEvent(t, fmt.Sprintf("evt_superseded_%d", i), "payment_intent.succeeded", intent)
+		if code, reply := postWebhook(t, h, body, signature); code != http.StatusOK {
+			t.Fatalf("delivery %d: status = %d (%v)", i+1, code, reply)
+		}
+	}
+
+	database.DB.First(&payment, payment.ID)
+	if payment.PaymentStatus != "cancelled" {
+		t.Fatalf("payment status = %q, want cancelled", payment.PaymentStatus)
+	}
+	var session models.RangeSession
+	database.DB.First(&session, payment.ReferenceID)
+	if session.PaymentStatus != "pending" {
+		t.Fatalf("range session payment status = %q, want pending", session.PaymentStatus)
+	}
+
+	var refunds []models.Refund
+	database.DB.Where("payment_id = ?", payment.ID).Find(&refunds)
+	if len(refunds) != 1 || refunds[0].RefundStatus != "succeeded" || refunds[0].Amount != payment.Amount {
+		t.Fatalf("refunds = %+v, want one succeeded refund of %.2f", refunds, payment.Amount)
+	}
+	if refunds[0].ProviderRefundID == "" {
+		t.Fatal("refund was not made with the provider")
+	}
+
+	// Nothing was sold, so nothing reaches the ledger
+	var entries int64
+	database.DB.Model(&models.JournalEntry{}).Where("payment_id = ?", payment.ID).Count(&entries)
+	if entries != 0 {
+		t.Fatalf("posted %d journal entries, want 0", entries)
+	}
+}
+
+// callHandler runs a handler as the given user and decodes its reply.
+func callHandler(t *testing.T, handler gin.HandlerFunc, userID uint, id uint, body string) (int, map[string]interface{}) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(id)}}
+	c.Set("user_id", userID)
+	handler(c)
+
+	var reply map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("decode reply %q: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, reply
+}
+
+func TestRefundCancelsBookingOnPayout(t *testing.T) {
+	h, provider := setupWebhookTest(t)
+	payment, _ := createRangePayment(t, provider, "succeeded")
+	// Paid at the counter, so every customer refund waits for approval
+	database.DB.Model(&payment).Update("stripe_payment_intent_id", "")
+	redemption := models.PromotionRedemption{PromotionID: 1, UserID: payment.UserID, ReferenceType: "range_session", ReferenceID: payment.ReferenceID}
+	if err := database.DB.Create(&redemption).Error; err != nil {
+		t.Fatalf("create redemption: %v", err)
+	}
+
+	sessionStatus := func() string {
+		var session models.RangeSession
+		database.DB.First(&session, payment.ReferenceID)
+		return session.SessionStatus
+	}
+	request := func() uint {
+		code, reply := callHandler(t, h.RequestRefund, payment.UserID, payment.ID, `{"reason_code":"customer_request"}`)
+		if code != http.StatusCreated || reply["refund_status"] != "pending_approval" {
+			t.Fatalf("request refund: status = %d (%v)", code, reply)
+		}
+		return uint(reply["id"].(float64))
+	}
+
+	// A rejected request leaves the booking as it was
+	refundID := request()
+	if got := sessionStatus(); got != "booked" {
+		t.Fatalf("session status while waiting = %q, want booked", got)
+	}
+	if code, reply := callHandler(t, h.RejectRefund, 99, refundID, ""); code != http.StatusOK {
+		t.Fatalf("reject: status = %d (%v)", code, reply)
+	}
+	if got := sessionStatus(); got != "booked" {
+		t.Fatalf("session status after rejection = %q, want booked", got)
+	}
+
+	// A session that has started can no longer be refunded this way
+	refundID = request()
+	database.DB.Model(&models.RangeSession{}).Where("id = ?", payment.ReferenceID).Update("session_status", "active")
+	if code, reply := callHandler(t, h.ApproveRefund, 99, refundID, ""); code != http.StatusBadRequest {
+		t.Fatalf("approve after start: status = %d, want %d (%v)", code, http.StatusBadRequest, reply)
+	}
+
+	// Once the refund is paid out the booking is cancelled
+	database.DB.Model(&models.RangeSession{}).Where("id = ?", payment.ReferenceID).Update("session_status", "booked")
+	if code, reply := callHandler(t, h.ApproveRefund, 99, refundID, ""); code != http.StatusOK || reply["refund_status"] != "succeeded" {
+		t.Fatalf("approve: status = %d (%v)", code, reply)
+	}
+	if got := sessionStatus(); got != "cancelled" {
+		t.Fatalf("session status after payout = %q, want cancelled", got)
+	}
+
+	// The promo code no longer counts against the customer
+	database.DB.First(&redemption, redemption.ID)
+	if redemption.VoidedAt == nil {
+		t.Fatal("promo code redemption was not voided")
+	}
+}
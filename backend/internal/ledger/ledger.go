@@ -0,0 +1,238 @@
+// Package ledger is the course's double-entry bookkeeping: a chart of
+// accounts, the journal entries posted when money moves, and the reports
+// built from them. It knows nothing about the database; handlers persist
+// the lines it produces.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Account codes.
+const (
+	Cash             = "1000"
+	CustomerDeposits = "2000"
+	SalesTax         = "2100"
+	GiftCards        = "2200"
+	GreenFees        = "4000"
+	CartFees         = "4010"
+	RangeSales       = "4100"
+	EquipmentRentals = "4200"
+	DamageCharges    = "4210"
+	TournamentFees   = "4300"
+	OtherRevenue     = "4900"
+	Refunds          = "4950"
+)
+
+// Account types. Contra revenue accounts reduce revenue and carry a debit
+// balance.
+const (
+	Asset         = "asset"
+	Liability     = "liability"
+	Revenue       = "revenue"
+	ContraRevenue = "contra_revenue"
+)
+
+type Account struct {
+	Code string
+	Name string
+	Type string
+}
+
+// ChartOfAccounts lists every account entries may post to.
+var ChartOfAccounts = []Account{
+	{Cash, "Cash and card clearing", Asset},
+	{CustomerDeposits, "Customer deposits", Liability},
+	{SalesTax, "Sales tax payable", Liability},
+	{GiftCards, "Gift card balances", Liability},
+	{GreenFees, "Green fees", Revenue},
+	{CartFees, "Cart fees", Revenue},
+	{RangeSales, "Range sales", Revenue},
+	{EquipmentRentals, "Equipment rentals", Revenue},
+	{DamageCharges, "Damage charges", Revenue},
+	{TournamentFees, "Tournament entry fees", Revenue},
+	{OtherRevenue, "Other revenue", Revenue},
+	{Refunds, "Refunds", ContraRevenue},
+}
+
+// Lookup finds an account by code.
+func Lookup(code string) (Account, bool) {
+	for _, account := range ChartOfAccounts {
+		if account.Code == code {
+			return account, true
+		}
+	}
+	return Account{}, false
+}
+
+// Balance is an account's balance from its debit and credit totals, signed
+// so that its normal side is positive.
+func (a Account) Balance(debit, credit float64) float64 {
+	if a.Type == Asset || a.Type == ContraRevenue {
+		return round(debit - credit)
+	}
+	return round(credit - debit)
+}
+
+// Line is one side of a journal entry. Exactly one of Debit and Credit is
+// set.
+type Line struct {
+	Account string
+	Debit   float64
+	Credit  float64
+}
+
+var ErrUnbalanced = errors.New("ledger: debits and credits do not balance")
+
+// Validate checks that lines form a postable entry: every account exists,
+// every line is one-sided and positive, and debits equal credits.
+func Validate(lines []Line) error {
+	if len(lines) < 2 {
+		return errors.New("ledger: an entry needs at least two lines")
+	}
+	var debits, credits float64
+	for _, line := range lines {
+		if _, ok := Lookup(line.Account); !ok {
+			return fmt.Errorf("ledger: unknown account %q", line.Account)
+		}
+		if line.Debit < 0 || line.Credit < 0 || (line.Debit == 0) == (line.Credit == 0) {
+			return fmt.Errorf("ledger: line for %s must have either a debit or a credit", line.Account)
+		}
+		debits += line.Debit
+		credits += line.Credit
+	}
+	if round(debits) != round(credits) {
+		return ErrUnbalanced
+	}
+	return nil
+}
+
+// SplitTax separates the sales tax included in a gross amount.
+func SplitTax(gross, ratePercent float64) (net, tax float64) {
+	if ratePercent <= 0 {
+		return round(gross), 0
+	}
+	tax = round(gross * ratePercent / (100 + ratePercent))
+	return round(gross - tax), tax
+}
+
+// Allocate divides an amount across accounts in proportion to weights,
+// rounding to cents and giving any rounding difference to the largest
+// share so the parts add back up exactly.
+func Allocate(amount float64, weights map[string]float64) map[string]float64 {
+	codes := make([]string, 0, len(weights))
+	var total float64
+	for code, weight := range weights {
+		if weight > 0 {
+			codes = append(codes, code)
+			total += weight
+		}
+	}
+	parts := make(map[string]float64, len(codes))
+	if total == 0 {
+		return parts
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if weights[codes[i]] != weights[codes[j]] {
+			return weights[codes[i]] > weights[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+
+	allocated := 0.0
+	for _, code := range codes[1:] {
+		parts[code] = round(amount * weights[code] / total)
+		allocated += parts[code]
+	}
+	parts[codes[0]] = round(amount - allocated)
+	return parts
+}
+
+// Sale records money taken for goods or services: the gross amount comes
+// in, sales tax is owed on it and the rest is revenue, split by account.
+func Sale(gross, taxRatePercent float64, revenue map[string]float64) []Line {
+	return TenderedSale(Cash, gross, taxRatePercent, revenue)
+}
+
+// TenderedSale is a sale paid from another account than cash, such as a
+// gift card balance.
+func TenderedSale(tender string, gross, taxRatePercent float64, revenue map[string]float64) []Line {
+	net, tax := SplitTax(gross, taxRatePercent)
+	lines := []Line{{Account: tender, Debit: round(gross)}}
+	if tax > 0 {
+		lines = append(lines, Line{Account: SalesTax, Credit: tax})
+	}
+	return append(lines, creditLines(Allocate(net, revenue))...)
+}
+
+// Refund records money given back on a sale. The tax share is reclaimed
+// from the tax owed; the rest reduces revenue.
+func Refund(amount, tax float64) []Line {
+	return TenderedRefund(Cash, amount, tax)
+}
+
+// TenderedRefund is a refund paid back to the account the sale was
+// tendered from.
+func TenderedRefund(tender string, amount, tax float64) []Line {
+	lines := []Line{{Account: Refunds, Debit: round(amount - tax)}}
+	if tax > 0 {
+		lines = append(lines, Line{Account: SalesTax, Debit: round(tax)})
+	}
+	return append(lines, Line{Account: tender, Credit: round(amount)})
+}
+
+// GiftCardSold records money taken for a gift card, which is owed to the
+// holder as services until it is redeemed.
+func GiftCardSold(amount float64) []Line {
+	return []Line{
+		{Account: Cash, Debit: round(amount)},
+		{Account: GiftCards, Credit: round(amount)},
+	}
+}
+
+// DepositReceived records a refundable deposit, which is owed back to the
+// customer until it is returned or kept.
+func DepositReceived(amount float64) []Line {
+	return []Line{
+		{Account: Cash, Debit: round(amount)},
+		{Account: CustomerDeposits, Credit: round(amount)},
+	}
+}
+
+// DepositReturned records a deposit paid back.
+func DepositReturned(amount float64) []Line {
+	return []Line{
+		{Account: CustomerDeposits, Debit: round(amount)},
+		{Account: Cash, Credit: round(amount)},
+	}
+}
+
+// DepositApplied records part of a deposit kept to cover a charge.
+func DepositApplied(amount float64, account string) []Line {
+	return []Line{
+		{Account: CustomerDeposits, Debit: round(amount)},
+		{Account: account, Credit: round(amount)},
+	}
+}
+
+func creditLines(parts map[string]float64) []Line {
+	codes := make([]string, 0, len(parts))
+	for code := range parts {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	lines := make([]Line, 0, len(codes))
+	for _, code := range codes {
+		if parts[code] > 0 {
+			lines = append(lines, Line{Account: code, Credit: parts[code]})
+		}
+	}
+	return lines
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,90 @@
+package ledger
+
+// Totals are the debits and credits posted to one account over a period.
+type Totals struct {
+	Debit  float64
+	Credit float64
+}
+
+// AccountBalance is one account's activity over a period.
+type AccountBalance struct {
+	Code    string  `json:"code"`
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	Debit   float64 `json:"debit"`
+	Credit  float64 `json:"credit"`
+	Balance float64 `json:"balance"`
+}
+
+// RevenueReport is revenue by account, less refunds.
+type RevenueReport struct {
+	Accounts     []AccountBalance `json:"accounts"`
+	GrossRevenue float64          `json:"gross_revenue"`
+	Refunds      float64          `json:"refunds"`
+	NetRevenue   float64          `json:"net_revenue"`
+	SalesTax     float64          `json:"sales_tax"`
+}
+
+// TrialBalance lists every account's balance; debits and credits agree
+// when the ledger is sound.
+type TrialBalance struct {
+	Accounts []AccountBalance `json:"accounts"`
+	Debit    float64          `json:"debit"`
+	Credit   float64          `json:"credit"`
+	Balanced bool             `json:"balanced"`
+}
+
+func balances(totals map[string]Totals, include func(Account) bool) []AccountBalance {
+	var out []AccountBalance
+	for _, account := range ChartOfAccounts {
+		if !include(account) {
+			continue
+		}
+		t := totals[account.Code]
+		out = append(out, AccountBalance{
+			Code:    account.Code,
+			Name:    account.Name,
+			Type:    account.Type,
+			Debit:   round(t.Debit),
+			Credit:  round(t.Credit),
+			Balance: account.Balance(t.Debit, t.Credit),
+		})
+	}
+	return out
+}
+
+// BuildRevenueReport summarizes revenue from per-account totals.
+func BuildRevenueReport(totals map[string]Totals) RevenueReport {
+	report := RevenueReport{
+		Accounts: balances(totals, func(a Account) bool {
+			return a.Type == Revenue || a.Type == ContraRevenue
+		}),
+	}
+	for _, account := range report.Accounts {
+		switch account.Type {
+		case Revenue:
+			report.GrossRevenue += account.Balance
+		case ContraRevenue:
+			report.Refunds += account.Balance
+		}
+	}
+	// Tax collected net of tax given back on refunds
+	report.SalesTax = round(totals[SalesTax].Credit - totals[SalesTax].Debit)
+	report.GrossRevenue = round(report.GrossRevenue)
+	report.Refunds = round(report.Refunds)
+	report.NetRevenue = round(report.GrossRevenue - report.Refunds)
+	return report
+}
+
+// BuildTrialBalance lists every account from per-account totals.
+func BuildTrialBalance(totals map[string]Totals) TrialBalance {
+	tb := TrialBalance{Accounts: balances(totals, func(Account) bool { return true })}
+	for _, account := range tb.Accounts {
+		tb.Debit += account.Debit
+		tb.Credit += account.Credit
+	}
+	tb.Debit = round(tb.Debit)
+	tb.Credit = round(tb.Credit)
+	tb.Balanced = tb.Debit == tb.Credit
+	return tb
+}
@@ -0,0 +1,209 @@
+// Package matchplay builds seeded knockout brackets and keeps the state of a
+// match from its hole-by-hole results.
+package matchplay
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Side identifies a player in a match. Halved is used for holes neither
+// player won.
+type Side int
+
+const (
+	Halved Side = iota
+	Player1
+	Player2
+)
+
+// Opponent returns the other side of the match.
+func (s Side) Opponent() Side {
+	switch s {
+	case Player1:
+		return Player2
+	case Player2:
+		return Player1
+	default:
+		return Halved
+	}
+}
+
+// State is the standing of a match after the holes played so far.
+type State struct {
+	Leader         Side   `json:"leader"`
+	Up             int    `json:"up"`
+	HolesPlayed    int    `json:"holes_played"`
+	HolesRemaining int    `json:"holes_remaining"`
+	Dormie         bool   `json:"dormie"`
+	Decided        bool   `json:"decided"`
+	Winner         Side   `json:"winner"`
+	Status         string `json:"status"`
+	Result         string `json:"result,omitempty"`
+}
+
+// HoleWinner compares net scores on a hole. Strokes of zero or less mean the
+// player did not finish the hole and lose it unless neither did.
+func HoleWinner(strokes1, strokes2, received1, received2 int) Side {
+	switch {
+	case strokes1 <= 0 && strokes2 <= 0:
+		return Halved
+	case strokes1 <= 0:
+		return Player2
+	case strokes2 <= 0:
+		return Player1
+	}
+	net1, net2 := strokes1-received1, strokes2-received2
+	switch {
+	case net1 < net2:
+		return Player1
+	case net2 < net1:
+		return Player2
+	default:
+		return Halved
+	}
+}
+
+// Score plays through hole results over a match of the given length. A match
+// ends as soon as one player is more holes up than remain; if it is all
+// square after the last hole it continues until a hole is won. Results after
+// the match is decided are ignored.
+func Score(results []Side, holes int) State {
+	state := State{HolesRemaining: holes}
+	lead := 0 // positive when player 1 is up
+
+	for _, result := range results {
+		if state.Decided {
+			break
+		}
+		state.HolesPlayed++
+		switch result {
+		case Player1:
+			lead++
+		case Player2:
+			lead--
+		}
+		state.HolesRemaining = max(holes-state.HolesPlayed, 0)
+
+		up := lead
+		if up < 0 {
+			up = -up
+		}
+		if up > state.HolesRemaining {
+			state.Decided = true
+		}
+	}
+
+	state.Up = lead
+	state.Leader = Player1
+	if lead < 0 {
+		state.Up = -lead
+		state.Leader = Player2
+	}
+	if lead == 0 {
+		state.Leader = Halved
+	}
+	state.Dormie = !state.Decided && state.Up > 0 && state.Up == state.HolesRemaining
+
+	switch {
+	case state.Decided:
+		state.Winner = state.Leader
+		state.Result = resultLabel(state, holes)
+		state.Status = state.Result
+	case state.Up == 0:
+		state.Status = "All square"
+	case state.Dormie:
+		state.Status = "Dormie " + strconv.Itoa(state.Up)
+	default:
+		state.Status = strconv.Itoa(state.Up) + " up"
+	}
+	return state
+}
+
+// resultLabel writes a decided result the way it is posted: "3&2" when the
+// match ended early, "2 up" when it went the distance and "19th hole" when it
+// went to extra holes.
+func resultLabel(state State, holes int) string {
+	switch {
+	case state.HolesPlayed > holes:
+		return ordinal(state.HolesPlayed) + " hole"
+	case state.HolesRemaining > 0:
+		return fmt.Sprintf("%d&%d", state.Up, state.HolesRemaining)
+	default:
+		return strconv.Itoa(state.Up) + " up"
+	}
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// BracketSize is the smallest power of two that holds every player.
+func BracketSize(players int) int {
+	size := 1
+	for size < players {
+		size *= 2
+	}
+	return max(size, 2)
+}
+
+// SeedOrder lists seeds in bracket order so that the top seeds can only meet
+// in the later rounds, e.g. 1 8 4 5 2 7 3 6 for eight players.
+func SeedOrder(size int) []int {
+	order := []int{1}
+	for n := 2; n <= size; n *= 2 {
+		next := make([]int, 0, n)
+		for _, seed := range order {
+			next = append(next, seed, n+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+// Pairing is a first-round match between two seeds. A seed beyond the
+// number of players is a bye.
+type Pairing struct {
+	Seed1 int
+	Seed2 int
+}
+
+// FirstRound pairs the seeds for the opening round. Byes fall to the top
+// seeds.
+func FirstRound(players int) []Pairing {
+	order := SeedOrder(BracketSize(players))
+	pairings := make([]Pairing, 0, len(order)/2)
+	for i := 0; i < len(order); i += 2 {
+		pairings = append(pairings, Pairing{Seed1: order[i], Seed2: order[i+1]})
+	}
+	return pairings
+}
+
+// Rounds is the number of rounds needed to find a winner.
+func Rounds(players int) int {
+	rounds := 0
+	for size := BracketSize(players); size > 1; size /= 2 {
+		rounds++
+	}
+	return rounds
+}
+
+// NextMatch gives the match number in the following round that the winner
+// of a match goes through to, and which side of it they take.
+func NextMatch(match int) (int, Side) {
+	if match%2 == 1 {
+		return (match + 1) / 2, Player1
+	}
+	return match / 2, Player2
+}
@@ -40,35 +40,116 @@ type Course struct {
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	Holes        []Hole    `json:"holes,omitempty" gorm:"foreignKey:CourseID"`
+	TeeSets      []TeeSet  `json:"tee_sets,omitempty" gorm:"foreignKey:CourseID"`
 }
 
 type Hole struct {
-	ID            uint   `json:"id" gorm:"primaryKey"`
-	CourseID      uint   `json:"course_id" gorm:"not null"`
-	HoleNumber    int    `json:"hole_number" gorm:"not null"`
-	Par           int    `json:"par" gorm:"not null"`
-	Yardage       int    `json:"yardage"`
-	HandicapIndex int    `json:"handicap_index"`
-	Description   string `json:"description"`
-	Course        Course `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CourseID      uint      `json:"course_id" gorm:"not null"`
+	HoleNumber    int       `json:"hole_number" gorm:"not null"`
+	Par           int       `json:"par" gorm:"not null"`
+	Yardage       int       `json:"yardage"`
+	HandicapIndex int       `json:"handicap_index"`
+	Description   string    `json:"description"`
+	Course        Course    `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Tees          []HoleTee `json:"tees,omitempty" gorm:"foreignKey:HoleID"`
+}
+
+type TeeSet struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CourseID     uint      `json:"course_id" gorm:"not null"`
+	Name         string    `json:"name" gorm:"not null"`
+	Color        string    `json:"color"`
+	CourseRating float64   `json:"course_rating" gorm:"not null"`
+	SlopeRating  int       `json:"slope_rating" gorm:"not null"`
+	TotalYardage int       `json:"total_yardage" gorm:"default:0"`
+	IsDefault    bool      `json:"is_default" gorm:"default:false"`
+	IsActive     bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	HoleTees     []HoleTee `json:"hole_tees,omitempty" gorm:"foreignKey:TeeSetID"`
+}
+
+type HoleTee struct {
+	ID       uint `json:"id" gorm:"primaryKey"`
+	HoleID   uint `json:"hole_id" gorm:"not null"`
+	TeeSetID uint `json:"tee_set_id" gorm:"not null"`
+	Yardage  int  `json:"yardage" gorm:"not null"`
 }
 
 type TeeTime struct {
-	ID              uint      `json:"id" gorm:"primaryKey"`
-	CourseID        uint      `json:"course_id" gorm:"not null"`
-	UserID          uint      `json:"user_id" gorm:"not null"`
-	BookingDate     time.Time `json:"booking_date" gorm:"not null"`
-	TeeTime         string    `json:"tee_time" gorm:"not null"`
-	PlayersCount    int       `json:"players_count" gorm:"default:1"`
-	CartRequired    bool      `json:"cart_required" gorm:"default:false"`
-	TotalAmount     float64   `json:"total_amount"`
-	PaymentStatus   string    `json:"payment_status" gorm:"default:'pending'"`
-	BookingStatus   string    `json:"booking_status" gorm:"default:'confirmed'"`
-	SpecialRequests string    `json:"special_requests"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-	Course          Course    `json:"course,omitempty" gorm:"constraint:OnDelete:CASCADE"`
-	User            User      `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	ID              uint             `json:"id" gorm:"primaryKey"`
+	CourseID        uint             `json:"course_id" gorm:"not null"`
+	UserID          uint             `json:"user_id" gorm:"not null"`
+	TeeSetID        *uint            `json:"tee_set_id"`
+	TournamentID    *uint            `json:"tournament_id"`
+	LeagueWeekID    *uint            `json:"league_week_id"`
+	BookingDate     time.Time        `json:"booking_date" gorm:"not null"`
+	TeeTime         string           `json:"tee_time" gorm:"not null"`
+	PlayersCount    int              `json:"players_count" gorm:"default:1"`
+	CartRequired    bool             `json:"cart_required" gorm:"default:false"`
+	CartCount       int              `json:"cart_count" gorm:"default:0"`
+	TotalAmount     float64          `json:"total_amount"`
+	DiscountAmount  float64          `json:"discount_amount"`
+	SplitPayment    bool             `json:"split_payment" gorm:"default:false"`
+	PaymentStatus   string           `json:"payment_status" gorm:"default:'pending'"`
+	BookingStatus   string           `json:"booking_status" gorm:"default:'confirmed'"`
+	SpecialRequests string           `json:"special_requests"`
+	CheckedInAt     *time.Time       `json:"checked_in_at"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	Course          Course           `json:"course,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	User            User             `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	TeeSet          *TeeSet          `json:"tee_set,omitempty" gorm:"constraint:OnDelete:SET NULL"`
+	BundleRentals   []BundleRental   `json:"bundle_rentals,omitempty" gorm:"foreignKey:TeeTimeID"`
+	CartAssignments []CartAssignment `json:"cart_assignments,omitempty" gorm:"foreignKey:TeeTimeID"`
+	Shares          []TeeTimeShare   `json:"shares,omitempty" gorm:"foreignKey:TeeTimeID"`
+}
+
+type GolfCart struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	CartNumber    string     `json:"cart_number" gorm:"unique;not null"`
+	CartType      string     `json:"cart_type" gorm:"default:'two_seater'"`
+	PowerType     string     `json:"power_type" gorm:"default:'electric'"`
+	BatteryLevel  *int       `json:"battery_level"`
+	FuelLevel     *int       `json:"fuel_level"`
+	Status        string     `json:"status" gorm:"default:'available'"`
+	Notes         string     `json:"notes"`
+	LastServiceAt *time.Time `json:"last_service_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type CartAssignment struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	CartID     uint       `json:"cart_id" gorm:"not null"`
+	TeeTimeID  uint       `json:"tee_time_id" gorm:"not null"`
+	AssignedBy uint       `json:"assigned_by" gorm:"not null"`
+	AssignedAt time.Time  `json:"assigned_at"`
+	ReturnedAt *time.Time `json:"returned_at"`
+	LevelOut   *int       `json:"level_out"`
+	LevelIn    *int       `json:"level_in"`
+	Cart       GolfCart   `json:"cart,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	TeeTime    *TeeTime   `json:"tee_time,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// TeeTimeShare is one player's part of a tee time paid by split payment.
+type TeeTimeShare struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	TeeTimeID     uint       `json:"tee_time_id" gorm:"not null"`
+	PlayerNumber  int        `json:"player_number" gorm:"not null"`
+	PlayerName    string     `json:"player_name"`
+	PlayerEmail   string     `json:"player_email"`
+	Amount        float64    `json:"amount" gorm:"not null"`
+	PaymentToken  string     `json:"payment_token" gorm:"uniqueIndex;not null"`
+	PaymentStatus string     `json:"payment_status" gorm:"default:'pending'"`
+	PaidBy        *uint      `json:"paid_by"`
+	PaidAt        *time.Time `json:"paid_at"`
+	SettledBy     *uint      `json:"settled_by"`
+	InvitedAt     *time.Time `json:"invited_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	TeeTime       *TeeTime   `json:"tee_time,omitempty" gorm:"constraint:OnDelete:CASCADE"`
 }
 
 type RangeSession struct {
@@ -79,6 +160,7 @@ type RangeSession struct {
 	DurationMinutes int       `json:"duration_minutes" gorm:"default:60"`
 	BallBucketSize  string    `json:"ball_bucket_size" gorm:"not null"`
 	BucketPrice     float64   `json:"bucket_price"`
+	DiscountAmount  float64   `json:"discount_amount"`
 	BayNumber       *int      `json:"bay_number"`
 	PaymentStatus   string    `json:"payment_status" gorm:"default:'pending'"`
 	SessionStatus   string    `json:"session_status" gorm:"default:'booked'"`
@@ -88,35 +170,166 @@ type RangeSession struct {
 }
 
 type Equipment struct {
+	ID                uint               `json:"id" gorm:"primaryKey"`
+	Name              string             `json:"name" gorm:"not null"`
+	Category          string             `json:"category" gorm:"not null"`
+	Description       string             `json:"description"`
+	RentalPricePerDay float64            `json:"rental_price_per_day"`
+	QuantityAvailable int                `json:"quantity_available" gorm:"default:0"`
+	ConditionStatus   string             `json:"condition_status" gorm:"default:'good'"`
+	ImageURL          string             `json:"image_url"`
+	IsAvailable       bool               `json:"is_available" gorm:"default:true"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	Variants          []EquipmentVariant `json:"variants,omitempty" gorm:"foreignKey:EquipmentID"`
+}
+
+type EquipmentVariant struct {
 	ID                uint      `json:"id" gorm:"primaryKey"`
-	Name              string    `json:"name" gorm:"not null"`
-	Category          string    `json:"category" gorm:"not null"`
-	Description       string    `json:"description"`
-	RentalPricePerDay float64   `json:"rental_price_per_day"`
+	EquipmentID       uint      `json:"equipment_id" gorm:"not null"`
+	SKU               string    `json:"sku"`
+	Size              string    `json:"size"`
+	Handedness        string    `json:"handedness"`
+	ShaftFlex         string    `json:"shaft_flex"`
+	Gender            string    `json:"gender"`
 	QuantityAvailable int       `json:"quantity_available" gorm:"default:0"`
-	ConditionStatus   string    `json:"condition_status" gorm:"default:'good'"`
-	ImageURL          string    `json:"image_url"`
 	IsAvailable       bool      `json:"is_available" gorm:"default:true"`
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 type EquipmentRental struct {
-	ID            uint       `json:"id" gorm:"primaryKey"`
-	UserID        uint       `json:"user_id" gorm:"not null"`
-	EquipmentID   uint       `json:"equipment_id" gorm:"not null"`
-	RentalDate    time.Time  `json:"rental_date" gorm:"not null"`
-	ReturnDate    *time.Time `json:"return_date"`
-	Quantity      int        `json:"quantity" gorm:"default:1"`
-	RentalPrice   float64    `json:"rental_price"`
-	DepositAmount float64    `json:"deposit_amount"`
-	PaymentStatus string     `json:"payment_status" gorm:"default:'pending'"`
-	RentalStatus  string     `json:"rental_status" gorm:"default:'rented'"`
-	Notes         string     `json:"notes"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
-	User          User       `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
-	Equipment     Equipment  `json:"equipment,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	ID              uint                 `json:"id" gorm:"primaryKey"`
+	UserID          uint                 `json:"user_id" gorm:"not null"`
+	EquipmentID     uint                 `json:"equipment_id" gorm:"not null"`
+	VariantID       *uint                `json:"variant_id"`
+	BundleRentalID  *uint                `json:"bundle_rental_id"`
+	RentalDate      time.Time            `json:"rental_date" gorm:"not null"`
+	ReturnDate      *time.Time           `json:"return_date"`
+	Quantity        int                  `json:"quantity" gorm:"default:1"`
+	RentalPrice     float64              `json:"rental_price"`
+	DiscountAmount  float64              `json:"discount_amount"`
+	DepositAmount   float64              `json:"deposit_amount"`
+	PaymentStatus   string               `json:"payment_status" gorm:"default:'pending'"`
+	RentalStatus    string               `json:"rental_status" gorm:"default:'rented'"`
+	Notes           string               `json:"notes"`
+	PickedUpAt      *time.Time           `json:"picked_up_at"`
+	PickedUpBy      *uint                `json:"picked_up_by"`
+	ReturnedAt      *time.Time           `json:"returned_at"`
+	InspectedAt     *time.Time           `json:"inspected_at"`
+	InspectedBy     *uint                `json:"inspected_by"`
+	ReturnCondition string               `json:"return_condition"`
+	DamageCharge    float64              `json:"damage_charge"`
+	DepositStatus   string               `json:"deposit_status" gorm:"default:'pending'"`
+	DepositRefunded float64              `json:"deposit_refunded"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+	User            User                 `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Equipment       Equipment            `json:"equipment,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Variant         *EquipmentVariant    `json:"variant,omitempty" gorm:"constraint:OnDelete:SET NULL"`
+	DamageReports   []RentalDamageReport `json:"damage_reports,omitempty" gorm:"foreignKey:RentalID"`
+}
+
+type EquipmentBundle struct {
+	ID                uint                  `json:"id" gorm:"primaryKey"`
+	Name              string                `json:"name" gorm:"not null"`
+	Description       string                `json:"description"`
+	BundlePricePerDay float64               `json:"bundle_price_per_day"`
+	ImageURL          string                `json:"image_url"`
+	IsActive          bool                  `json:"is_active" gorm:"default:true"`
+	CreatedAt         time.Time             `json:"created_at"`
+	UpdatedAt         time.Time             `json:"updated_at"`
+	Items             []EquipmentBundleItem `json:"items,omitempty" gorm:"foreignKey:BundleID"`
+}
+
+type EquipmentBundleItem struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	BundleID    uint      `json:"bundle_id" gorm:"not null"`
+	EquipmentID uint      `json:"equipment_id" gorm:"not null"`
+	Quantity    int       `json:"quantity" gorm:"default:1"`
+	Equipment   Equipment `json:"equipment,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type BundleRental struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	UserID         uint              `json:"user_id" gorm:"not null"`
+	BundleID       uint              `json:"bundle_id" gorm:"not null"`
+	TeeTimeID      *uint             `json:"tee_time_id"`
+	RentalDate     time.Time         `json:"rental_date" gorm:"not null"`
+	ReturnDate     *time.Time        `json:"return_date"`
+	Quantity       int               `json:"quantity" gorm:"default:1"`
+	RentalPrice    float64           `json:"rental_price"`
+	ComponentValue float64           `json:"component_value"`
+	DepositAmount  float64           `json:"deposit_amount"`
+	PaymentStatus  string            `json:"payment_status" gorm:"default:'pending'"`
+	RentalStatus   string            `json:"rental_status" gorm:"default:'reserved'"`
+	Notes          string            `json:"notes"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	User           User              `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Bundle         EquipmentBundle   `json:"bundle,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Rentals        []EquipmentRental `json:"rentals,omitempty" gorm:"foreignKey:BundleRentalID"`
+}
+
+type RentalDamageReport struct {
+	ID           uint                `json:"id" gorm:"primaryKey"`
+	RentalID     uint                `json:"rental_id" gorm:"not null"`
+	ReportedBy   uint                `json:"reported_by" gorm:"not null"`
+	Severity     string              `json:"severity" gorm:"default:'minor'"`
+	Notes        string              `json:"notes"`
+	UnitsDamaged int                 `json:"units_damaged" gorm:"default:0"`
+	DamageCharge float64             `json:"damage_charge"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Photos       []RentalDamagePhoto `json:"photos,omitempty" gorm:"foreignKey:DamageReportID"`
+}
+
+type RentalDamagePhoto struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	DamageReportID uint      `json:"damage_report_id" gorm:"not null"`
+	URL            string    `json:"url" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type WorkOrder struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	AssetType      string            `json:"asset_type" gorm:"not null"`
+	EquipmentID    *uint             `json:"equipment_id"`
+	VariantID      *uint             `json:"variant_id"`
+	CartID         *uint             `json:"cart_id"`
+	RentalID       *uint             `json:"rental_id"`
+	Quantity       int               `json:"quantity" gorm:"default:1"`
+	UnitsWithdrawn int               `json:"units_withdrawn" gorm:"default:0"`
+	Title          string            `json:"title" gorm:"not null"`
+	Description    string            `json:"description"`
+	Priority       string            `json:"priority" gorm:"default:'medium'"`
+	Status         string            `json:"status" gorm:"default:'open'"`
+	ReportedBy     uint              `json:"reported_by" gorm:"not null"`
+	AssigneeID     *uint             `json:"assignee_id"`
+	LaborCost      float64           `json:"labor_cost"`
+	PartsCost      float64           `json:"parts_cost"`
+	TotalCost      float64           `json:"total_cost"`
+	Resolution     string            `json:"resolution"`
+	ResolutionNote string            `json:"resolution_note"`
+	StartedAt      *time.Time        `json:"started_at"`
+	ClosedAt       *time.Time        `json:"closed_at"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	Equipment      *Equipment        `json:"equipment,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Variant        *EquipmentVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnDelete:SET NULL"`
+	Cart           *GolfCart         `json:"cart,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Assignee       *User             `json:"assignee,omitempty" gorm:"foreignKey:AssigneeID;constraint:OnDelete:SET NULL"`
+	Parts          []WorkOrderPart   `json:"parts,omitempty" gorm:"foreignKey:WorkOrderID"`
+}
+
+type WorkOrderPart struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WorkOrderID uint      `json:"work_order_id" gorm:"not null"`
+	Name        string    `json:"name" gorm:"not null"`
+	PartNumber  string    `json:"part_number"`
+	Quantity    int       `json:"quantity" gorm:"default:1"`
+	UnitCost    float64   `json:"unit_cost"`
+	TotalCost   float64   `json:"total_cost"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type Scorecard struct {
@@ -124,19 +337,25 @@ type Scorecard struct {
 	UserID             uint            `json:"user_id" gorm:"not null"`
 	CourseID           uint            `json:"course_id" gorm:"not null"`
 	TeeTimeID          *uint           `json:"tee_time_id"`
+	TeeSetID           *uint           `json:"tee_set_id"`
 	PlayedDate         time.Time       `json:"played_date" gorm:"not null"`
 	TotalScore         *int            `json:"total_score"`
 	TotalPutts         *int            `json:"total_putts"`
 	FairwaysHit        *int            `json:"fairways_hit"`
 	GreensInRegulation *int            `json:"greens_in_regulation"`
 	HandicapUsed       *float64        `json:"handicap_used"`
+	AdjustedGrossScore *int            `json:"adjusted_gross_score"`
+	ScoreDifferential  *float64        `json:"score_differential"`
 	WeatherConditions  string          `json:"weather_conditions"`
 	Notes              string          `json:"notes"`
 	IsTournamentRound  bool            `json:"is_tournament_round" gorm:"default:false"`
+	TournamentID       *uint           `json:"tournament_id"`
+	TournamentRound    *int            `json:"tournament_round"`
 	CreatedAt          time.Time       `json:"created_at"`
 	User               User            `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
 	Course             Course          `json:"course,omitempty" gorm:"constraint:OnDelete:CASCADE"`
 	TeeTime            *TeeTime        `json:"tee_time,omitempty" gorm:"constraint:OnDelete:SET NULL"`
+	TeeSet             *TeeSet         `json:"tee_set,omitempty" gorm:"constraint:OnDelete:SET NULL"`
 	Holes              []ScorecardHole `json:"holes,omitempty" gorm:"foreignKey:ScorecardID"`
 }
 
@@ -148,12 +367,231 @@ type ScorecardHole struct {
 	Putts             int       `json:"putts" gorm:"default:0"`
 	FairwayHit        bool      `json:"fairway_hit" gorm:"default:false"`
 	GreenInRegulation bool      `json:"green_in_regulation" gorm:"default:false"`
+	SandAttempts      int       `json:"sand_attempts" gorm:"default:0"`
 	SandSaves         int       `json:"sand_saves" gorm:"default:0"`
 	Penalties         int       `json:"penalties" gorm:"default:0"`
 	Scorecard         Scorecard `json:"-" gorm:"constraint:OnDelete:CASCADE"`
 	Hole              Hole      `json:"hole,omitempty" gorm:"constraint:OnDelete:CASCADE"`
 }
 
+type HandicapRevision struct {
+	ID                    uint       `json:"id" gorm:"primaryKey"`
+	UserID                uint       `json:"user_id" gorm:"not null"`
+	ScorecardID           uint       `json:"scorecard_id" gorm:"not null"`
+	PlayedDate            time.Time  `json:"played_date"`
+	CourseHandicap        *int       `json:"course_handicap"`
+	AdjustedGrossScore    int        `json:"adjusted_gross_score"`
+	ScoreDifferential     float64    `json:"score_differential"`
+	HandicapIndex         *float64   `json:"handicap_index"`
+	LowHandicapIndex      *float64   `json:"low_handicap_index"`
+	DifferentialsUsed     int        `json:"differentials_used"`
+	ExceptionalAdjustment float64    `json:"exceptional_adjustment"`
+	CapApplied            string     `json:"cap_applied"`
+	CreatedAt             time.Time  `json:"created_at"`
+	Scorecard             *Scorecard `json:"scorecard,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type Tournament struct {
+	ID                   uint                    `json:"id" gorm:"primaryKey"`
+	Name                 string                  `json:"name" gorm:"not null"`
+	Description          string                  `json:"description"`
+	CourseID             uint                    `json:"course_id" gorm:"not null"`
+	StartDate            time.Time               `json:"start_date" gorm:"not null"`
+	EndDate              time.Time               `json:"end_date" gorm:"not null"`
+	EntryFee             *float64                `json:"entry_fee"`
+	MaxParticipants      *int                    `json:"max_participants"`
+	TournamentType       string                  `json:"tournament_type" gorm:"default:'stroke_play'"`
+	Status               string                  `json:"status" gorm:"default:'upcoming'"`
+	PrizePool            *float64                `json:"prize_pool"`
+	RegistrationDeadline *time.Time              `json:"registration_deadline"`
+	TeamSize             *int                    `json:"team_size"`
+	BestBalls            int                     `json:"best_balls" gorm:"default:1"`
+	TeamAllowances       string                  `json:"team_allowances"`
+	CreatedAt            time.Time               `json:"created_at"`
+	UpdatedAt            time.Time               `json:"updated_at"`
+	Course               Course                  `json:"course,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Participants         []TournamentParticipant `json:"participants,omitempty" gorm:"foreignKey:TournamentID"`
+	ParticipantCount     int                     `json:"participant_count" gorm:"-"`
+}
+
+type TournamentParticipant struct {
+	ID                     uint        `json:"id" gorm:"primaryKey"`
+	TournamentID           uint        `json:"tournament_id" gorm:"not null"`
+	UserID                 uint        `json:"user_id" gorm:"not null"`
+	RegistrationDate       time.Time   `json:"registration_date" gorm:"autoCreateTime"`
+	HandicapAtRegistration *float64    `json:"handicap_at_registration"`
+	PaymentStatus          string      `json:"payment_status" gorm:"default:'pending'"`
+	FinalScore             *int        `json:"final_score"`
+	FinalPosition          *int        `json:"final_position"`
+	PrizeAmount            *float64    `json:"prize_amount"`
+	Tournament             *Tournament `json:"tournament,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	User                   User        `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type TournamentGroup struct {
+	ID           uint                    `json:"id" gorm:"primaryKey"`
+	TournamentID uint                    `json:"tournament_id" gorm:"not null"`
+	RoundNumber  int                     `json:"round_number" gorm:"default:1"`
+	GroupNumber  int                     `json:"group_number" gorm:"not null"`
+	Flight       *int                    `json:"flight"`
+	PlayDate     time.Time               `json:"play_date" gorm:"not null"`
+	TeeTime      string                  `json:"tee_time" gorm:"not null"`
+	StartingHole int                     `json:"starting_hole" gorm:"default:1"`
+	TeeTimeID    *uint                   `json:"tee_time_id"`
+	CreatedAt    time.Time               `json:"created_at"`
+	Players      []TournamentGroupPlayer `json:"players,omitempty" gorm:"foreignKey:GroupID"`
+}
+
+type TournamentGroupPlayer struct {
+	ID            uint                  `json:"id" gorm:"primaryKey"`
+	GroupID       uint                  `json:"group_id" gorm:"not null"`
+	ParticipantID uint                  `json:"participant_id" gorm:"not null"`
+	Position      int                   `json:"position" gorm:"default:1"`
+	Participant   TournamentParticipant `json:"participant,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type TournamentMatch struct {
+	ID            uint                   `json:"id" gorm:"primaryKey"`
+	TournamentID  uint                   `json:"tournament_id" gorm:"not null"`
+	RoundNumber   int                    `json:"round_number" gorm:"not null"`
+	MatchNumber   int                    `json:"match_number" gorm:"not null"`
+	Player1ID     *uint                  `json:"player1_id"`
+	Player2ID     *uint                  `json:"player2_id"`
+	Seed1         *int                   `json:"seed1"`
+	Seed2         *int                   `json:"seed2"`
+	TeeSetID      *uint                  `json:"tee_set_id"`
+	UseHandicaps  bool                   `json:"use_handicaps" gorm:"default:true"`
+	StrokesGiven  int                    `json:"strokes_given" gorm:"default:0"`
+	StrokesTo     *string                `json:"strokes_to"`
+	ScheduledDate *time.Time             `json:"scheduled_date"`
+	TeeTime       *string                `json:"tee_time"`
+	Status        string                 `json:"status" gorm:"default:'pending'"`
+	WinnerID      *uint                  `json:"winner_id"`
+	Result        string                 `json:"result"`
+	DecidedBy     *string                `json:"decided_by"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	Player1       *TournamentParticipant `json:"player1,omitempty" gorm:"foreignKey:Player1ID"`
+	Player2       *TournamentParticipant `json:"player2,omitempty" gorm:"foreignKey:Player2ID"`
+	Holes         []TournamentMatchHole  `json:"holes,omitempty" gorm:"foreignKey:MatchID"`
+}
+
+type TournamentMatchHole struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	MatchID        uint   `json:"match_id" gorm:"not null"`
+	HoleNumber     int    `json:"hole_number" gorm:"not null"`
+	Player1Strokes *int   `json:"player1_strokes"`
+	Player2Strokes *int   `json:"player2_strokes"`
+	Winner         string `json:"winner" gorm:"not null"`
+	Conceded       bool   `json:"conceded" gorm:"default:false"`
+}
+
+type TournamentTeam struct {
+	ID           uint                   `json:"id" gorm:"primaryKey"`
+	TournamentID uint                   `json:"tournament_id" gorm:"not null"`
+	Name         string                 `json:"name" gorm:"not null"`
+	CreatedAt    time.Time              `json:"created_at"`
+	Members      []TournamentTeamMember `json:"members,omitempty" gorm:"foreignKey:TeamID"`
+}
+
+type TournamentTeamMember struct {
+	ID            uint                  `json:"id" gorm:"primaryKey"`
+	TeamID        uint                  `json:"team_id" gorm:"not null"`
+	ParticipantID uint                  `json:"participant_id" gorm:"not null"`
+	Participant   TournamentParticipant `json:"participant,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type TournamentTeamScore struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	TeamID      uint      `json:"team_id" gorm:"not null"`
+	RoundNumber int       `json:"round_number" gorm:"default:1"`
+	HoleNumber  int       `json:"hole_number" gorm:"not null"`
+	Strokes     int       `json:"strokes" gorm:"not null"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type League struct {
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	Name                string         `json:"name" gorm:"not null"`
+	Description         string         `json:"description"`
+	CourseID            uint           `json:"course_id" gorm:"not null"`
+	DayOfWeek           int            `json:"day_of_week" gorm:"not null"`
+	FirstTeeTime        string         `json:"first_tee_time" gorm:"not null"`
+	TeeTimes            int            `json:"tee_times" gorm:"default:4"`
+	ScoringFormat       string         `json:"scoring_format" gorm:"default:'net_stroke'"`
+	HandicapAllowance   float64        `json:"handicap_allowance" gorm:"default:95"`
+	PointsTable         string         `json:"points_table" gorm:"default:'10,8,6,5,4,3,2,1'"`
+	ParticipationPoints float64        `json:"participation_points" gorm:"default:1"`
+	AbsencePoints       float64        `json:"absence_points" gorm:"default:0"`
+	IsActive            bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	Course              Course         `json:"course,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Seasons             []LeagueSeason `json:"seasons,omitempty" gorm:"foreignKey:LeagueID"`
+}
+
+type LeagueSeason struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	LeagueID  uint           `json:"league_id" gorm:"not null"`
+	Name      string         `json:"name" gorm:"not null"`
+	StartDate time.Time      `json:"start_date" gorm:"not null"`
+	EndDate   time.Time      `json:"end_date" gorm:"not null"`
+	Status    string         `json:"status" gorm:"default:'upcoming'"`
+	CreatedAt time.Time      `json:"created_at"`
+	League    *League        `json:"league,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Members   []LeagueMember `json:"members,omitempty" gorm:"foreignKey:SeasonID"`
+	Weeks     []LeagueWeek   `json:"weeks,omitempty" gorm:"foreignKey:SeasonID"`
+}
+
+type LeagueMember struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	SeasonID uint      `json:"season_id" gorm:"not null"`
+	UserID   uint      `json:"user_id" gorm:"not null"`
+	JoinedAt time.Time `json:"joined_at" gorm:"autoCreateTime"`
+	IsActive bool      `json:"is_active" gorm:"default:true"`
+	User     User      `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type LeagueWeek struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	SeasonID   uint            `json:"season_id" gorm:"not null"`
+	WeekNumber int             `json:"week_number" gorm:"not null"`
+	PlayDate   time.Time       `json:"play_date" gorm:"not null"`
+	Status     string          `json:"status" gorm:"default:'scheduled'"`
+	Season     *LeagueSeason   `json:"season,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Absences   []LeagueAbsence `json:"absences,omitempty" gorm:"foreignKey:WeekID"`
+	Results    []LeagueResult  `json:"results,omitempty" gorm:"foreignKey:WeekID"`
+}
+
+type LeagueAbsence struct {
+	ID               uint         `json:"id" gorm:"primaryKey"`
+	WeekID           uint         `json:"week_id" gorm:"not null"`
+	MemberID         uint         `json:"member_id" gorm:"not null"`
+	SubstituteUserID *uint        `json:"substitute_user_id"`
+	Reason           string       `json:"reason"`
+	CreatedAt        time.Time    `json:"created_at"`
+	Member           LeagueMember `json:"member,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Substitute       *User        `json:"substitute,omitempty" gorm:"foreignKey:SubstituteUserID;constraint:OnDelete:SET NULL"`
+}
+
+type LeagueResult struct {
+	ID               uint         `json:"id" gorm:"primaryKey"`
+	WeekID           uint         `json:"week_id" gorm:"not null"`
+	MemberID         uint         `json:"member_id" gorm:"not null"`
+	UserID           *uint        `json:"user_id"`
+	ScorecardID      *uint        `json:"scorecard_id"`
+	Status           string       `json:"status" gorm:"not null"`
+	GrossScore       *int         `json:"gross_score"`
+	PlayingHandicap  *int         `json:"playing_handicap"`
+	NetScore         *int         `json:"net_score"`
+	StablefordPoints *int         `json:"stableford_points"`
+	Position         *int         `json:"position"`
+	Points           float64      `json:"points"`
+	CreatedAt        time.Time    `json:"created_at"`
+	Member           LeagueMember `json:"member,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	User             *User        `json:"user,omitempty" gorm:"constraint:OnDelete:SET NULL"`
+}
+
 type Payment struct {
 	ID                    uint       `json:"id" gorm:"primaryKey"`
 	UserID                uint       `json:"user_id" gorm:"not null"`
@@ -161,7 +599,10 @@ type Payment struct {
 	ReferenceID           uint       `json:"reference_id" gorm:"not null"`
 	Amount                float64    `json:"amount" gorm:"not null"`
 	Currency              string     `json:"currency" gorm:"default:'USD'"`
+	PaymentType           string     `json:"payment_type" gorm:"default:'charge'"`
 	PaymentMethod         string     `json:"payment_method" gorm:"default:'credit_card'"`
+	FromDeposit           bool       `json:"from_deposit" gorm:"default:false"`
+	GiftCardID            *uint      `json:"gift_card_id"`
 	StripePaymentIntentID string     `json:"stripe_payment_intent_id"`
 	PaymentStatus         string     `json:"payment_status" gorm:"default:'pending'"`
 	FailureReason         string     `json:"failure_reason"`
@@ -169,6 +610,163 @@ type Payment struct {
 	CreatedAt             time.Time  `json:"created_at"`
 	UpdatedAt             time.Time  `json:"updated_at"`
 	User                  User       `json:"user,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Refunds               []Refund   `json:"refunds,omitempty"`
+}
+
+type GiftCard struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	Code           string                `json:"code" gorm:"uniqueIndex;not null"`
+	InitialAmount  float64               `json:"initial_amount" gorm:"not null"`
+	Balance        float64               `json:"balance"`
+	Currency       string                `json:"currency" gorm:"default:'USD'"`
+	CardStatus     string                `json:"card_status" gorm:"default:'pending'"`
+	PurchasedBy    *uint                 `json:"purchased_by"`
+	IssuedBy       *uint                 `json:"issued_by"`
+	RecipientName  string                `json:"recipient_name"`
+	RecipientEmail string                `json:"recipient_email"`
+	Message        string                `json:"message"`
+	ActivatedAt    *time.Time            `json:"activated_at"`
+	ExpiresAt      *time.Time            `json:"expires_at"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	Transactions   []GiftCardTransaction `json:"transactions,omitempty"`
+}
+
+type GiftCardTransaction struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	GiftCardID      uint      `json:"gift_card_id" gorm:"not null"`
+	TransactionType string    `json:"transaction_type" gorm:"not null"`
+	Amount          float64   `json:"amount"`
+	BalanceAfter    float64   `json:"balance_after"`
+	PaymentID       *uint     `json:"payment_id"`
+	RefundID        *uint     `json:"refund_id"`
+	CreatedBy       *uint     `json:"created_by"`
+	Notes           string    `json:"notes"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type Refund struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	PaymentID        uint       `json:"payment_id" gorm:"not null"`
+	Amount           float64    `json:"amount" gorm:"not null"`
+	ReasonCode       string     `json:"reason_code" gorm:"not null"`
+	Notes            string     `json:"notes"`
+	RefundStatus     string     `json:"refund_status" gorm:"default:'pending_approval'"`
+	ProviderRefundID string     `json:"provider_refund_id"`
+	FailureReason    string     `json:"failure_reason"`
+	CancelsBooking   bool       `json:"cancels_booking"`
+	RequestedBy      *uint      `json:"requested_by"`
+	ReviewedBy       *uint      `json:"reviewed_by"`
+	ReviewedAt       *time.Time `json:"reviewed_at"`
+	ProcessedAt      *time.Time `json:"processed_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	Payment          *Payment   `json:"payment,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type LedgerAccount struct {
+	Code        string    `json:"code" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null"`
+	AccountType string    `json:"account_type" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type JournalEntry struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	SourceKey     string        `json:"source_key" gorm:"uniqueIndex;not null"`
+	EntryType     string        `json:"entry_type" gorm:"not null"`
+	Description   string        `json:"description"`
+	PaymentID     *uint         `json:"payment_id"`
+	RefundID      *uint         `json:"refund_id"`
+	ReferenceType string        `json:"reference_type"`
+	ReferenceID   *uint         `json:"reference_id"`
+	PostedAt      time.Time     `json:"posted_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+	Lines         []JournalLine `json:"lines,omitempty" gorm:"foreignKey:EntryID"`
+}
+
+type JournalLine struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	EntryID     uint    `json:"entry_id" gorm:"not null"`
+	AccountCode string  `json:"account_code" gorm:"not null"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+}
+
+type Invoice struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	InvoiceNumber string        `json:"invoice_number" gorm:"uniqueIndex;not null"`
+	UserID        uint          `json:"user_id" gorm:"not null"`
+	PaymentID     *uint         `json:"payment_id" gorm:"uniqueIndex"`
+	PeriodStart   *time.Time    `json:"period_start" gorm:"type:date"`
+	PeriodEnd     *time.Time    `json:"period_end" gorm:"type:date"`
+	BillingName   string        `json:"billing_name"`
+	BillingEmail  string        `json:"billing_email"`
+	Subtotal      float64       `json:"subtotal"`
+	TaxAmount     float64       `json:"tax_amount"`
+	Total         float64       `json:"total"`
+	Currency      string        `json:"currency" gorm:"default:'USD'"`
+	IssuedAt      time.Time     `json:"issued_at"`
+	EmailedTo     string        `json:"emailed_to"`
+	EmailedAt     *time.Time    `json:"emailed_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+	Lines         []InvoiceLine `json:"lines,omitempty"`
+}
+
+type InvoiceLine struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	InvoiceID   uint    `json:"invoice_id" gorm:"not null"`
+	Description string  `json:"description" gorm:"not null"`
+	Quantity    int     `json:"quantity" gorm:"default:1"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+	TaxAmount   float64 `json:"tax_amount"`
+	PaymentID   *uint   `json:"payment_id"`
+}
+
+type Promotion struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Code           string     `json:"code" gorm:"uniqueIndex;not null"`
+	Name           string     `json:"name" gorm:"not null"`
+	Description    string     `json:"description"`
+	DiscountType   string     `json:"discount_type" gorm:"not null"`
+	DiscountValue  float64    `json:"discount_value" gorm:"not null"`
+	AppliesTo      string     `json:"applies_to"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	DaysOfWeek     string     `json:"days_of_week"`
+	StartTime      *string    `json:"start_time"`
+	EndTime        *string    `json:"end_time"`
+	MaxRedemptions *int       `json:"max_redemptions"`
+	MaxPerUser     *int       `json:"max_per_user"`
+	MembersOnly    bool       `json:"members_only" gorm:"default:false"`
+	IsActive       bool       `json:"is_active" gorm:"default:true"`
+	CreatedBy      *uint      `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type PromotionRedemption struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	PromotionID    uint       `json:"promotion_id" gorm:"not null"`
+	UserID         uint       `json:"user_id" gorm:"not null"`
+	ReferenceType  string     `json:"reference_type" gorm:"not null"`
+	ReferenceID    uint       `json:"reference_id" gorm:"not null"`
+	Subtotal       float64    `json:"subtotal"`
+	DiscountAmount float64    `json:"discount_amount"`
+	Breakdown      string     `json:"breakdown" gorm:"type:json"`
+	VoidedAt       *time.Time `json:"voided_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Promotion      *Promotion `json:"promotion,omitempty"`
+	User           *User      `json:"user,omitempty"`
+}
+
+type ProcessedWebhookEvent struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	EventID     string    `json:"event_id" gorm:"uniqueIndex;not null"`
+	EventType   string    `json:"event_type" gorm:"not null"`
+	PaymentID   *uint     `json:"payment_id"`
+	ProcessedAt time.Time `json:"processed_at" gorm:"autoCreateTime"`
 }
 
 type WeatherLog struct {
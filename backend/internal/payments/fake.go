@@ -0,0 +1,173 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Test payment methods understood by FakeProvider, named after Stripe's.
+const (
+	FakeCardSucceeds = "pm_card_visa"
+	FakeCardDeclined = "pm_card_chargeDeclined"
+	FakeCard3DS      = "pm_card_authenticationRequired"
+)
+
+// FakeProvider keeps intents in memory. Intents confirmed with
+// FakeCardDeclined fail and FakeCard3DS waits for customer action; any
+// other payment method succeeds.
+type FakeProvider struct {
+	mu          sync.Mutex
+	seq         int
+	intents     map[string]*Intent
+	refunded    map[string]int64
+	idempotency map[string]string
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		intents:     make(map[string]*Intent),
+		refunded:    make(map[string]int64),
+		idempotency: make(map[string]string),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) nextID(prefix string) string {
+	p.seq++
+	return fmt.Sprintf("%s_fake_%06d", prefix, p.seq)
+}
+
+func (p *FakeProvider) CreateIntent(ctx context.Context, params IntentParams) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if params.Amount <= 0 {
+		return nil, &Error{StatusCode: http.StatusBadRequest, Type: "invalid_request_error", Message: "Amount must be positive"}
+	}
+	if id, ok := p.idempotency[params.IdempotencyKey]; ok && params.IdempotencyKey != "" {
+		copied := *p.intents[id]
+		return &copied, nil
+	}
+
+	id := p.nextID("pi")
+	intent := &Intent{
+		ID:            id,
+		Amount:        params.Amount,
+		Currency:      strings.ToUpper(params.Currency),
+		Status:        StatusRequiresPaymentMethod,
+		CaptureMethod: "automatic",
+		ClientSecret:  id + "_secret",
+		Metadata:      params.Metadata,
+	}
+	if params.ManualCapture {
+		intent.CaptureMethod = "manual"
+	}
+
+	switch params.PaymentMethod {
+	case "":
+	case FakeCardDeclined:
+		intent.FailureReason = "Your card was declined."
+	case FakeCard3DS:
+		intent.Status = StatusRequiresAction
+	default:
+		if params.ManualCapture {
+			intent.Status = StatusRequiresCapture
+		} else {
+			intent.Status = StatusSucceeded
+			intent.AmountReceived = intent.Amount
+		}
+	}
+
+	p.intents[id] = intent
+	if params.IdempotencyKey != "" {
+		p.idempotency[params.IdempotencyKey] = id
+	}
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) CaptureIntent(ctx context.Context, id string, amount int64) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[id]
+	if !ok {
+		return nil, notFound(id)
+	}
+	if intent.Status != StatusRequiresCapture {
+		return nil, &Error{StatusCode: http.StatusBadRequest, Type: "invalid_request_error",
+			Code: "payment_intent_unexpected_state", Message: "This PaymentIntent could not be captured because it has a status of " + intent.Status + "."}
+	}
+	if amount <= 0 || amount > intent.Amount {
+		amount = intent.Amount
+	}
+	intent.AmountReceived = amount
+	intent.Status = StatusSucceeded
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) RefundIntent(ctx context.Context, params RefundParams) (*Refund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[params.IntentID]
+	if !ok {
+		return nil, notFound(params.IntentID)
+	}
+	if intent.Status != StatusSucceeded {
+		return nil, &Error{StatusCode: http.StatusBadRequest, Type: "invalid_request_error",
+			Code: "charge_not_captured", Message: "This PaymentIntent has not been captured."}
+	}
+	remaining := intent.AmountReceived - p.refunded[intent.ID]
+	amount := params.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		return nil, &Error{StatusCode: http.StatusBadRequest, Type: "invalid_request_error",
+			Code: "charge_already_refunded", Message: "Refund amount is greater than the unrefunded amount."}
+	}
+	p.refunded[intent.ID] += amount
+	return &Refund{ID: p.nextID("re"), IntentID: intent.ID, Amount: amount, Status: StatusSucceeded}, nil
+}
+
+func (p *FakeProvider) CancelIntent(ctx context.Context, id string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[id]
+	if !ok {
+		return nil, notFound(id)
+	}
+	if intent.Status == StatusSucceeded || intent.Status == StatusCanceled {
+		return nil, &Error{StatusCode: http.StatusBadRequest, Type: "invalid_request_error",
+			Code: "payment_intent_unexpected_state", Message: "This PaymentIntent could not be canceled because it has a status of " + intent.Status + "."}
+	}
+	intent.Status = StatusCanceled
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *FakeProvider) RetrieveIntent(ctx context.Context, id string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[id]
+	if !ok {
+		return nil, notFound(id)
+	}
+	copied := *intent
+	return &copied, nil
+}
+
+func notFound(id string) *Error {
+	return &Error{StatusCode: http.StatusNotFound, Type: "invalid_request_error",
+		Code: "resource_missing", Message: "No such payment_intent: '" + id + "'"}
+}
@@ -0,0 +1,105 @@
+// Package payments talks to card payment gateways. Handlers depend on the
+// PaymentProvider interface; Stripe is used in production and FakeProvider
+// stands in for it in local development and tests.
+package payments
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Payment intent statuses, as reported by the gateway.
+const (
+	StatusRequiresPaymentMethod = "requires_payment_method"
+	StatusRequiresConfirmation  = "requires_confirmation"
+	StatusRequiresAction        = "requires_action"
+	StatusProcessing            = "processing"
+	StatusRequiresCapture       = "requires_capture"
+	StatusCanceled              = "canceled"
+	StatusSucceeded             = "succeeded"
+)
+
+// Intent is a payment attempt held by the gateway. Amounts are in the
+// currency's minor unit, e.g. cents.
+type Intent struct {
+	ID             string            `json:"id"`
+	Amount         int64             `json:"amount"`
+	AmountReceived int64             `json:"amount_received"`
+	Currency       string            `json:"currency"`
+	Status         string            `json:"status"`
+	CaptureMethod  string            `json:"capture_method"`
+	ClientSecret   string            `json:"client_secret"`
+	FailureReason  string            `json:"failure_reason,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+// Refund returns money from a captured intent.
+type Refund struct {
+	ID       string `json:"id"`
+	IntentID string `json:"payment_intent"`
+	Amount   int64  `json:"amount"`
+	Status   string `json:"status"`
+}
+
+// IntentParams describes a payment to collect. When PaymentMethod is given
+// the intent is confirmed straight away; otherwise the client confirms it
+// with the returned client secret. ManualCapture only authorizes the card
+// until CaptureIntent is called.
+type IntentParams struct {
+	Amount         int64
+	Currency       string
+	Description    string
+	Metadata       map[string]string
+	PaymentMethod  string
+	ManualCapture  bool
+	IdempotencyKey string
+}
+
+// RefundParams describes a refund. An Amount of zero refunds whatever is
+// left of the intent.
+type RefundParams struct {
+	IntentID       string
+	Amount         int64
+	Reason         string
+	IdempotencyKey string
+}
+
+// PaymentProvider is a card payment gateway.
+type PaymentProvider interface {
+	Name() string
+	CreateIntent(ctx context.Context, params IntentParams) (*Intent, error)
+	// CaptureIntent collects an authorized intent. An amount of zero
+	// captures the full authorization.
+	CaptureIntent(ctx context.Context, id string, amount int64) (*Intent, error)
+	RefundIntent(ctx context.Context, params RefundParams) (*Refund, error)
+	RetrieveIntent(ctx context.Context, id string) (*Intent, error)
+	// CancelIntent abandons an intent that has not been collected, voiding
+	// any authorization on the card.
+	CancelIntent(ctx context.Context, id string) (*Intent, error)
+}
+
+// Error is a request the gateway turned down.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Type       string `json:"type"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("payments: %s (%s)", e.Message, e.Code)
+	}
+	return "payments: " + e.Message
+}
+
+// ToMinorUnits converts an amount such as 12.50 into cents.
+func ToMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// FromMinorUnits converts cents back into an amount.
+func FromMinorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}
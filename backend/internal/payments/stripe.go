@@ -0,0 +1,180 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stripeAPIURL = "https://api.stripe.com"
+
+// StripeProvider calls the Stripe REST API directly over HTTP.
+type StripeProvider struct {
+	secretKey string
+	baseURL   string
+	client    *http.Client
+}
+
+func NewStripeProvider(secretKey string) *StripeProvider {
+	return &StripeProvider{
+		secretKey: secretKey,
+		baseURL:   stripeAPIURL,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *StripeProvider) Name() string {
+	return "stripe"
+}
+
+// stripeIntent is a PaymentIntent as Stripe returns it.
+type stripeIntent struct {
+	ID               string            `json:"id"`
+	Amount           int64             `json:"amount"`
+	AmountReceived   int64             `json:"amount_received"`
+	Currency         string            `json:"currency"`
+	Status           string            `json:"status"`
+	CaptureMethod    string            `json:"capture_method"`
+	ClientSecret     string            `json:"client_secret"`
+	Metadata         map[string]string `json:"metadata"`
+	LastPaymentError *struct {
+		Message string `json:"message"`
+	} `json:"last_payment_error"`
+}
+
+func (s stripeIntent) intent() *Intent {
+	intent := &Intent{
+		ID:             s.ID,
+		Amount:         s.Amount,
+		AmountReceived: s.AmountReceived,
+		Currency:       strings.ToUpper(s.Currency),
+		Status:         s.Status,
+		CaptureMethod:  s.CaptureMethod,
+		ClientSecret:   s.ClientSecret,
+		Metadata:       s.Metadata,
+	}
+	if s.LastPaymentError != nil {
+		intent.FailureReason = s.LastPaymentError.Message
+	}
+	return intent
+}
+
+func (p *StripeProvider) CreateIntent(ctx context.Context, params IntentParams) (*Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(params.Amount, 10))
+	form.Set("currency", strings.ToLower(params.Currency))
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+	if params.ManualCapture {
+		form.Set("capture_method", "manual")
+	}
+	if params.PaymentMethod != "" {
+		form.Set("payment_method", params.PaymentMethod)
+		form.Set("payment_method_types[]", "card")
+		form.Set("confirm", "true")
+	} else {
+		form.Set("automatic_payment_methods[enabled]", "true")
+	}
+
+	var out stripeIntent
+	if err := p.do(ctx, http.MethodPost, "/v1/payment_intents", form, params.IdempotencyKey, &out); err != nil {
+		return nil, err
+	}
+	return out.intent(), nil
+}
+
+func (p *StripeProvider) CaptureIntent(ctx context.Context, id string, amount int64) (*Intent, error) {
+	form := url.Values{}
+	if amount > 0 {
+		form.Set("amount_to_capture", strconv.FormatInt(amount, 10))
+	}
+	var out stripeIntent
+	if err := p.do(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(id)+"/capture", form, "", &out); err != nil {
+		return nil, err
+	}
+	return out.intent(), nil
+}
+
+func (p *StripeProvider) RefundIntent(ctx context.Context, params RefundParams) (*Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", params.IntentID)
+	if params.Amount > 0 {
+		form.Set("amount", strconv.FormatInt(params.Amount, 10))
+	}
+	if params.Reason != "" {
+		form.Set("metadata[reason]", params.Reason)
+	}
+	var out Refund
+	if err := p.do(ctx, http.MethodPost, "/v1/refunds", form, params.IdempotencyKey, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (p *StripeProvider) CancelIntent(ctx context.Context, id string) (*Intent, error) {
+	var out stripeIntent
+	if err := p.do(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(id)+"/cancel", url.Values{}, "", &out); err != nil {
+		return nil, err
+	}
+	return out.intent(), nil
+}
+
+func (p *StripeProvider) RetrieveIntent(ctx context.Context, id string) (*Intent, error) {
+	var out stripeIntent
+	if err := p.do(ctx, http.MethodGet, "/v1/payment_intents/"+url.PathEscape(id), nil, "", &out); err != nil {
+		return nil, err
+	}
+	return out.intent(), nil
+}
+
+// do sends a form-encoded request and decodes the JSON reply into out.
+// Stripe errors come back as *Error.
+func (p *StripeProvider) do(ctx context.Context, method, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	var body io.Reader
+	if form != nil && method != http.MethodGet {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.secretKey, "")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("payments: stripe request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var reply struct {
+			Error Error `json:"error"`
+		}
+		if err := json.Unmarshal(data, &reply); err != nil || reply.Error.Message == "" {
+			reply.Error.Message = http.StatusText(resp.StatusCode)
+		}
+		reply.Error.StatusCode = resp.StatusCode
+		return &reply.Error
+	}
+	return json.Unmarshal(data, out)
+}
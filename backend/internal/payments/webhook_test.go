@@ -0,0 +1,88 @@
+package payments
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded"}`)
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  string
+		want    error
+	}{
+		{
+			name:    "valid",
+			payload: payload,
+			header:  SignPayload(payload, secret, now),
+		},
+		{
+			name:    "valid within tolerance",
+			payload: payload,
+			header:  SignPayload(payload, secret, now.Add(-DefaultTolerance+time.Second)),
+		},
+		{
+			name:    "rolled secret",
+			payload: payload,
+			header:  SignPayload(payload, "whsec_old", now) + ",v1=" + strings.Split(SignPayload(payload, secret, now), "v1=")[1],
+		},
+		{
+			name:    "tampered body",
+			payload: []byte(`{"id":"evt_1","type":"payment_intent.canceled"}`),
+			header:  SignPayload(payload, secret, now),
+			want:    ErrInvalidSignature,
+		},
+		{
+			name:    "wrong secret",
+			payload: payload,
+			header:  SignPayload(payload, "whsec_other", now),
+			want:    ErrInvalidSignature,
+		},
+		{
+			name:    "tampered timestamp",
+			payload: payload,
+			header:  strings.Replace(SignPayload(payload, secret, now), "t=1700000000", "t=1700000001", 1),
+			want:    ErrInvalidSignature,
+		},
+		{
+			name:    "stale timestamp",
+			payload: payload,
+			header:  SignPayload(payload, secret, now.Add(-DefaultTolerance-time.Second)),
+			want:    ErrSignatureExpired,
+		},
+		{
+			name:    "missing header",
+			payload: payload,
+			header:  "",
+			want:    ErrMissingSignature,
+		},
+		{
+			name:    "no v1 signature",
+			payload: payload,
+			header:  "t=1700000000",
+			want:    ErrMissingSignature,
+		},
+		{
+			name:    "malformed timestamp",
+			payload: payload,
+			header:  "t=soon,v1=00",
+			want:    ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.payload, tt.header, secret, DefaultTolerance, now)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("VerifySignature() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
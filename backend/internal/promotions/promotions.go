@@ -0,0 +1,214 @@
+// Package promotions decides whether a promo code can be used for a booking
+// and works out the discount it gives. Loading codes and recording
+// redemptions is left to the caller.
+package promotions
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Discount types.
+const (
+	Percentage = "percentage"
+	Fixed      = "fixed"
+)
+
+// Products a promotion can apply to.
+const (
+	GreenFee    = "green_fee"
+	Cart        = "cart"
+	RangeBucket = "range_bucket"
+	Rental      = "rental"
+)
+
+// Reasons a promo code is turned down.
+var (
+	ErrInactive         = errors.New("promotions: code is not active")
+	ErrNotStarted       = errors.New("promotions: code is not valid yet")
+	ErrExpired          = errors.New("promotions: code has expired")
+	ErrWrongDay         = errors.New("promotions: code is not valid on that day")
+	ErrWrongTime        = errors.New("promotions: code is not valid at that time")
+	ErrMembersOnly      = errors.New("promotions: code is for members only")
+	ErrLimitReached     = errors.New("promotions: code has been fully redeemed")
+	ErrUserLimitReached = errors.New("promotions: code already used by this customer")
+	ErrNotApplicable    = errors.New("promotions: code does not apply to this booking")
+)
+
+// Rule is a promotion's terms. Empty Products and Days, and zero limits,
+// mean no restriction. StartMinute and EndMinute bound the time of day of
+// the booking in minutes after midnight when EndMinute is set.
+type Rule struct {
+	Code           string
+	DiscountType   string
+	DiscountValue  float64
+	Products       []string
+	ValidFrom      *time.Time
+	ValidUntil     *time.Time
+	Days           []time.Weekday
+	StartMinute    int
+	EndMinute      int
+	MaxRedemptions int
+	MaxPerUser     int
+	MembersOnly    bool
+	Active         bool
+}
+
+// Context is the booking a code is being used for.
+type Context struct {
+	// Now is when the code is being redeemed and must fall in the
+	// validity window.
+	Now time.Time
+	// At is when the booking is for. TimeKnown is false for bookings by the
+	// day, such as rentals, which cannot meet a time-of-day restriction.
+	At        time.Time
+	TimeKnown bool
+	IsMember  bool
+	// Redemptions so far, in total and by this user
+	Redemptions     int
+	UserRedemptions int
+}
+
+// Item is one priced part of a booking.
+type Item struct {
+	Product     string
+	Description string
+	Amount      float64
+}
+
+type LineDiscount struct {
+	Product     string  `json:"product"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	Discount    float64 `json:"discount"`
+	Total       float64 `json:"total"`
+}
+
+// Breakdown is how a booking's price comes out after a promotion.
+type Breakdown struct {
+	PromoCode string         `json:"promo_code"`
+	Lines     []LineDiscount `json:"lines"`
+	Subtotal  float64        `json:"subtotal"`
+	Discount  float64        `json:"discount"`
+	Total     float64        `json:"total"`
+}
+
+// Check reports why a code cannot be used for a booking, or nil if it can.
+func (r Rule) Check(ctx Context) error {
+	if !r.Active {
+		return ErrInactive
+	}
+	if r.ValidFrom != nil && ctx.Now.Before(*r.ValidFrom) {
+		return ErrNotStarted
+	}
+	if r.ValidUntil != nil && ctx.Now.After(*r.ValidUntil) {
+		return ErrExpired
+	}
+	if len(r.Days) > 0 {
+		allowed := false
+		for _, day := range r.Days {
+			if ctx.At.Weekday() == day {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrWrongDay
+		}
+	}
+	if r.EndMinute > 0 {
+		minute := ctx.At.Hour()*60 + ctx.At.Minute()
+		if !ctx.TimeKnown || minute < r.StartMinute || minute >= r.EndMinute {
+			return ErrWrongTime
+		}
+	}
+	if r.MembersOnly && !ctx.IsMember {
+		return ErrMembersOnly
+	}
+	if r.MaxRedemptions > 0 && ctx.Redemptions >= r.MaxRedemptions {
+		return ErrLimitReached
+	}
+	if r.MaxPerUser > 0 && ctx.UserRedemptions >= r.MaxPerUser {
+		return ErrUserLimitReached
+	}
+	return nil
+}
+
+func (r Rule) appliesTo(product string) bool {
+	if len(r.Products) == 0 {
+		return true
+	}
+	for _, p := range r.Products {
+		if p == product {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply discounts the items the promotion covers. A percentage comes off
+// each item; a fixed amount is shared across them in proportion to price
+// and never takes an item below zero.
+func (r Rule) Apply(items []Item) (Breakdown, error) {
+	breakdown := Breakdown{PromoCode: r.Code, Lines: make([]LineDiscount, len(items))}
+	var eligible []int
+	var eligibleTotal float64
+	for i, item := range items {
+		breakdown.Lines[i] = LineDiscount{Product: item.Product, Description: item.Description, Amount: round(item.Amount)}
+		breakdown.Subtotal += item.Amount
+		if r.appliesTo(item.Product) && item.Amount > 0 {
+			eligible = append(eligible, i)
+			eligibleTotal += item.Amount
+		}
+	}
+	if len(eligible) == 0 {
+		return Breakdown{}, ErrNotApplicable
+	}
+
+	switch r.DiscountType {
+	case Percentage:
+		percent := math.Min(r.DiscountValue, 100)
+		for _, i := range eligible {
+			breakdown.Lines[i].Discount = round(items[i].Amount * percent / 100)
+		}
+	case Fixed:
+		discount := round(math.Min(r.DiscountValue, eligibleTotal))
+		// The largest item takes any rounding difference
+		sort.SliceStable(eligible, func(a, b int) bool { return items[eligible[a]].Amount > items[eligible[b]].Amount })
+		allocated := 0.0
+		for _, i := range eligible[1:] {
+			breakdown.Lines[i].Discount = round(discount * items[i].Amount / eligibleTotal)
+			allocated += breakdown.Lines[i].Discount
+		}
+		breakdown.Lines[eligible[0]].Discount = round(discount - allocated)
+	default:
+		return Breakdown{}, ErrNotApplicable
+	}
+
+	for i := range breakdown.Lines {
+		line := &breakdown.Lines[i]
+		line.Total = round(line.Amount - line.Discount)
+		breakdown.Discount += line.Discount
+	}
+	breakdown.Subtotal = round(breakdown.Subtotal)
+	breakdown.Discount = round(breakdown.Discount)
+	breakdown.Total = round(breakdown.Subtotal - breakdown.Discount)
+	return breakdown, nil
+}
+
+// DiscountFor is the discount given on one product.
+func (b Breakdown) DiscountFor(product string) float64 {
+	var discount float64
+	for _, line := range b.Lines {
+		if line.Product == product {
+			discount += line.Discount
+		}
+	}
+	return round(discount)
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
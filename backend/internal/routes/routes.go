@@ -2,23 +2,37 @@ package routes
 
 import (
 	"golf-course-backend/internal/auth"
+	"golf-course-backend/internal/config"
 	"golf-course-backend/internal/handlers"
 	"golf-course-backend/internal/middleware"
+	"golf-course-backend/internal/payments"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
+func SetupRoutes(r *gin.Engine, cfg *config.Config, authService *auth.AuthService, paymentProvider payments.PaymentProvider) {
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	courseHandler := handlers.NewCourseHandler()
 	teeTimeHandler := handlers.NewTeeTimeHandler()
 	rangeHandler := handlers.NewRangeHandler()
-	equipmentHandler := handlers.NewEquipmentHandler()
+	bundleHandler := handlers.NewBundleHandler()
+	scorecardHandler := handlers.NewScorecardHandler()
+	handicapHandler := handlers.NewHandicapHandler()
+	statisticsHandler := handlers.NewStatisticsHandler()
+	paymentHandler := handlers.NewPaymentHandler(paymentProvider, cfg.Stripe, cfg.Email, cfg.Server.FrontendURL)
+	equipmentHandler := handlers.NewEquipmentHandler(paymentHandler)
+	tournamentHandler := handlers.NewTournamentHandler(paymentHandler)
+	leagueHandler := handlers.NewLeagueHandler()
+	ledgerHandler := handlers.NewLedgerHandler()
+	invoiceHandler := handlers.NewInvoiceHandler(cfg.Email)
+	promotionHandler := handlers.NewPromotionHandler()
 	weatherHandler := handlers.NewWeatherHandler()
 	dashboardHandler := handlers.NewDashboardHandler()
 	adminHandler := handlers.NewAdminHandler()
-	staffHandler := handlers.NewStaffHandler()
+	staffHandler := handlers.NewStaffHandler(cfg.Upload, paymentProvider)
+	cartHandler := handlers.NewCartHandler()
+	maintenanceHandler := handlers.NewMaintenanceHandler()
 	healthHandler := handlers.NewHealthHandler()
 
 	// Global middleware (order matters!)
@@ -31,6 +45,9 @@ func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
 	r.GET("/health/ready", healthHandler.ReadinessCheck)
 	r.GET("/health/live", healthHandler.LivenessCheck)
 
+	// Uploaded files (damage photos etc.)
+	r.Static("/uploads", cfg.Upload.Path)
+
 	// API version 1
 	v1 := r.Group("/api/v1")
 
@@ -47,6 +64,7 @@ func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
 	{
 		courses.GET("", courseHandler.GetCourses)
 		courses.GET("/:id", courseHandler.GetCourse)
+		courses.GET("/:id/tee-sets", courseHandler.GetCourseTeeSets)
 	}
 
 	// Weather (public)
@@ -61,6 +79,9 @@ func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
 	{
 		equipment.GET("", equipmentHandler.GetEquipment)
 		equipment.GET("/:id", equipmentHandler.GetEquipmentByID)
+		equipment.GET("/bundles", bundleHandler.GetBundles)
+		equipment.GET("/bundles/:id", bundleHandler.GetBundle)
+		equipment.GET("/bundles/:id/availability", bundleHandler.GetBundleAvailability)
 	}
 
 	// Range (public for pricing)
@@ -69,12 +90,42 @@ func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
 		rangePublic.GET("/bucket-prices", rangeHandler.GetBucketPrices)
 	}
 
+	// Tournaments (public for viewing)
+	tournamentsPublic := v1.Group("/tournaments")
+	{
+		tournamentsPublic.GET("", tournamentHandler.GetTournaments)
+		tournamentsPublic.GET("/:id", tournamentHandler.GetTournament)
+		tournamentsPublic.GET("/:id/pairings", tournamentHandler.GetPairings)
+		tournamentsPublic.GET("/:id/leaderboard", tournamentHandler.GetLeaderboard)
+		tournamentsPublic.GET("/:id/leaderboard/stream", tournamentHandler.StreamLeaderboard)
+		tournamentsPublic.GET("/:id/leaderboard/teams", tournamentHandler.GetTeamLeaderboard)
+		tournamentsPublic.GET("/:id/teams", tournamentHandler.GetTeams)
+		tournamentsPublic.GET("/:id/teams/:team_id/scorecard", tournamentHandler.GetTeamScorecard)
+		tournamentsPublic.GET("/:id/bracket", tournamentHandler.GetBracket)
+	}
+
+	// Leagues (public for viewing schedules and standings)
+	leaguesPublic := v1.Group("/leagues")
+	{
+		leaguesPublic.GET("", leagueHandler.GetLeagues)
+		leaguesPublic.GET("/:id", leagueHandler.GetLeague)
+	}
+	v1.GET("/league-seasons/:id", leagueHandler.GetSeason)
+	v1.GET("/league-seasons/:id/standings", leagueHandler.GetStandings)
+	v1.GET("/league-weeks/:id", leagueHandler.GetWeek)
+
+	// Payment provider webhooks (authenticated by signature)
+	v1.POST("/payments/webhook", paymentHandler.HandleWebhook)
+
 	// Tee times (public for checking availability)
 	teeTimesPublic := v1.Group("/tee-times")
 	{
 		teeTimesPublic.GET("/available", teeTimeHandler.GetAvailableTeeTimes)
 	}
 
+	// Split payment links (public so invited players can see what they owe)
+	v1.GET("/tee-time-shares/:token", paymentHandler.GetShareInvitation)
+
 	// Protected routes (require authentication)
 	protected := v1.Group("")
 	protected.Use(middleware.AuthMiddleware(authService))
@@ -91,7 +142,11 @@ func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
 		{
 			teeTimes.POST("", teeTimeHandler.CreateTeeTime)
 			teeTimes.GET("", teeTimeHandler.GetUserTeeTimes)
+			teeTimes.POST("/:id/split", paymentHandler.SplitTeeTime)
+			teeTimes.GET("/:id/shares", paymentHandler.GetTeeTimeShares)
+			teeTimes.POST("/:id/shares/:share_id/invite", paymentHandler.InviteSharePlayer)
 		}
+		protected.POST("/tee-time-shares/:token/checkout", paymentHandler.CheckoutShare)
 
 		// Range sessions
 		rangeSessions := protected.Group("/range/sessions")
@@ -106,7 +161,79 @@ func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
 			equipmentRentals.POST("", equipmentHandler.RentEquipment)
 			equipmentRentals.GET("", equipmentHandler.GetUserRentals)
 			equipmentRentals.PUT("/:id/return", equipmentHandler.ReturnEquipment)
+			equipmentRentals.PUT("/:id/cancel", equipmentHandler.CancelRental)
+		}
+
+		// Bundle rentals
+		bundleRentals := protected.Group("/equipment/bundles")
+		{
+			bundleRentals.POST("/:id/rentals", bundleHandler.RentBundle)
+			bundleRentals.GET("/rentals", bundleHandler.GetUserBundleRentals)
+			bundleRentals.PUT("/rentals/:id/return", bundleHandler.ReturnBundle)
 		}
+
+		// Scorecards
+		scorecards := protected.Group("/scorecards")
+		{
+			scorecards.POST("", scorecardHandler.CreateScorecard)
+			scorecards.GET("", scorecardHandler.GetUserScorecards)
+			scorecards.POST("/games", scorecardHandler.SettleGame)
+			scorecards.GET("/:id", scorecardHandler.GetScorecard)
+			scorecards.GET("/:id/scoring", scorecardHandler.GetScorecardScoring)
+			scorecards.PUT("/:id/holes", scorecardHandler.UpdateHoleScores)
+			scorecards.DELETE("/:id", scorecardHandler.DeleteScorecard)
+		}
+
+		// Handicap
+		protected.GET("/handicap", handicapHandler.GetHandicap)
+		protected.GET("/handicap/history", handicapHandler.GetHandicapHistory)
+		protected.POST("/handicap/playing", handicapHandler.CalculatePlayingHandicaps)
+
+		// Playing statistics
+		protected.GET("/statistics", statisticsHandler.GetStatistics)
+
+		// Tournament entries
+		tournaments := protected.Group("/tournaments")
+		{
+			tournaments.GET("/entries", tournamentHandler.GetUserEntries)
+			tournaments.POST("/:id/register", tournamentHandler.Register)
+			tournaments.DELETE("/:id/register", tournamentHandler.Withdraw)
+			tournaments.POST("/:id/pay", tournamentHandler.PayEntryFee)
+			tournaments.PUT("/:id/teams/:team_id/scores", tournamentHandler.RecordTeamScores)
+		}
+
+		// Payments
+		userPayments := protected.Group("/payments")
+		{
+			userPayments.GET("", paymentHandler.GetPayments)
+			userPayments.POST("/checkout", paymentHandler.Checkout)
+			userPayments.GET("/balance", paymentHandler.GetBookingBalance)
+			userPayments.POST("/:id/refresh", paymentHandler.RefreshPayment)
+			userPayments.POST("/:id/refunds", paymentHandler.RequestRefund)
+			userPayments.GET("/:id/receipt", invoiceHandler.GetReceipt)
+			userPayments.POST("/:id/receipt/email", invoiceHandler.EmailReceipt)
+		}
+
+		giftCards := protected.Group("/gift-cards")
+		{
+			giftCards.GET("", paymentHandler.GetGiftCards)
+			giftCards.POST("", paymentHandler.PurchaseGiftCard)
+			giftCards.POST("/balance", paymentHandler.GetGiftCardBalance)
+		}
+
+		// Invoices
+		invoices := protected.Group("/invoices")
+		{
+			invoices.GET("", invoiceHandler.GetInvoices)
+			invoices.POST("", invoiceHandler.CreateInvoice)
+			invoices.GET("/:id", invoiceHandler.GetInvoice)
+			invoices.GET("/:id/pdf", invoiceHandler.GetInvoicePDF)
+			invoices.POST("/:id/email", invoiceHandler.EmailInvoice)
+		}
+
+		// League absences and substitutes
+		protected.POST("/league-weeks/:id/absence", leagueHandler.ReportAbsence)
+		protected.DELETE("/league-weeks/:id/absence", leagueHandler.CancelAbsence)
 	}
 
 	// Admin routes
@@ -119,10 +246,46 @@ func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
 		admin.PUT("/courses/:id", adminHandler.UpdateCourse)
 		admin.DELETE("/courses/:id", adminHandler.DeleteCourse)
 
+		// Holes and tee sets
+		admin.PUT("/courses/:id/holes", adminHandler.UpsertCourseHoles)
+		admin.POST("/courses/:id/holes", adminHandler.CreateHole)
+		admin.PUT("/holes/:id", adminHandler.UpdateHole)
+		admin.DELETE("/holes/:id", adminHandler.DeleteHole)
+		admin.POST("/courses/:id/tee-sets", adminHandler.CreateTeeSet)
+		admin.PUT("/tee-sets/:id", adminHandler.UpdateTeeSet)
+		admin.DELETE("/tee-sets/:id", adminHandler.DeleteTeeSet)
+
 		// Equipment Management
 		admin.POST("/equipment", adminHandler.CreateEquipment)
 		admin.PUT("/equipment/:id", adminHandler.UpdateEquipment)
 		admin.DELETE("/equipment/:id", adminHandler.DeleteEquipment)
+		admin.POST("/equipment/:id/variants", adminHandler.CreateEquipmentVariant)
+		admin.PUT("/equipment/variants/:variant_id", adminHandler.UpdateEquipmentVariant)
+		admin.DELETE("/equipment/variants/:variant_id", adminHandler.DeleteEquipmentVariant)
+
+		// Bundle Management
+		admin.POST("/bundles", adminHandler.CreateBundle)
+		admin.PUT("/bundles/:id", adminHandler.UpdateBundle)
+		admin.DELETE("/bundles/:id", adminHandler.DeleteBundle)
+
+		// Cart fleet management
+		admin.POST("/carts", cartHandler.CreateCart)
+		admin.PUT("/carts/:id", cartHandler.UpdateCart)
+		admin.DELETE("/carts/:id", cartHandler.DeleteCart)
+
+		// Tournament Management
+		admin.POST("/tournaments", tournamentHandler.CreateTournament)
+		admin.PUT("/tournaments/:id", tournamentHandler.UpdateTournament)
+		admin.DELETE("/tournaments/:id", tournamentHandler.DeleteTournament)
+		admin.GET("/tournaments/:id/participants", tournamentHandler.GetParticipants)
+		admin.POST("/tournaments/:id/finalize", tournamentHandler.FinalizeTournament)
+
+		// League Management
+		admin.POST("/leagues", leagueHandler.CreateLeague)
+		admin.PUT("/leagues/:id", leagueHandler.UpdateLeague)
+		admin.DELETE("/leagues/:id", leagueHandler.DeleteLeague)
+		admin.POST("/leagues/:id/seasons", leagueHandler.CreateSeason)
+		admin.DELETE("/league-seasons/:id", leagueHandler.DeleteSeason)
 
 		// User Management
 		admin.GET("/users", adminHandler.GetAllUsers)
@@ -135,6 +298,19 @@ func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
 
 		// Reports and Stats
 		admin.GET("/stats", adminHandler.GetAdminStats)
+
+		// Financial ledger
+		admin.GET("/reports/revenue", ledgerHandler.GetRevenueReport)
+		admin.GET("/ledger/trial-balance", ledgerHandler.GetTrialBalance)
+		admin.GET("/ledger/entries", ledgerHandler.GetJournalEntries)
+		admin.POST("/ledger/backfill", ledgerHandler.BackfillLedger)
+
+		// Promotions
+		admin.GET("/promotions", promotionHandler.GetPromotions)
+		admin.POST("/promotions", promotionHandler.CreatePromotion)
+		admin.GET("/promotions/:id", promotionHandler.GetPromotion)
+		admin.PUT("/promotions/:id", promotionHandler.UpdatePromotion)
+		admin.DELETE("/promotions/:id", promotionHandler.DeletePromotion)
 	}
 
 	// Staff routes
@@ -155,6 +331,74 @@ func SetupRoutes(r *gin.Engine, authService *auth.AuthService) {
 		staff.GET("/bookings/today", staffHandler.GetTodaysBookings)
 		staff.GET("/rentals/active", staffHandler.GetActiveRentals)
 
+		// Rental pickup, return inspection and deposit settlement
+		staff.GET("/rentals/:id", staffHandler.GetRental)
+		staff.POST("/rentals/:id/pickup", staffHandler.PickupRental)
+		staff.POST("/rentals/:id/inspect", staffHandler.InspectRentalReturn)
+		staff.POST("/rentals/:id/deposit/settle", staffHandler.SettleRentalDeposit)
+		staff.POST("/damage-reports/:id/photos", staffHandler.UploadDamagePhotos)
+
+		// Golf carts
+		staff.GET("/carts/board", cartHandler.GetCartBoard)
+		staff.POST("/carts/:id/return", cartHandler.ReturnCart)
+		staff.PUT("/carts/:id/status", cartHandler.UpdateCartStatus)
+		staff.POST("/tee-times/:id/check-in", cartHandler.CheckInTeeTime)
+
+		// Maintenance work orders
+		staff.POST("/work-orders", maintenanceHandler.CreateWorkOrder)
+		staff.GET("/work-orders", maintenanceHandler.GetWorkOrders)
+		staff.GET("/work-orders/:id", maintenanceHandler.GetWorkOrder)
+		staff.PUT("/work-orders/:id", maintenanceHandler.UpdateWorkOrder)
+		staff.POST("/work-orders/:id/parts", maintenanceHandler.AddWorkOrderPart)
+		staff.POST("/work-orders/:id/close", maintenanceHandler.CloseWorkOrder)
+		staff.GET("/equipment/:id/maintenance", maintenanceHandler.GetEquipmentMaintenanceHistory)
+		staff.GET("/carts/:id/maintenance", maintenanceHandler.GetCartMaintenanceHistory)
+
+		// Tournament pairings and tee assignments
+		staff.POST("/tournaments/:id/pairings", tournamentHandler.GeneratePairings)
+		staff.DELETE("/tournaments/:id/pairings", tournamentHandler.DeletePairings)
+
+		// Entry fees paid at the counter
+		staff.POST("/tournaments/:id/entries/:user_id/pay", tournamentHandler.RecordEntryFee)
+
+		// Match play brackets
+		staff.POST("/tournaments/:id/bracket", tournamentHandler.GenerateBracket)
+		staff.PUT("/matches/:id/schedule", tournamentHandler.ScheduleMatch)
+		staff.POST("/matches/:id/holes", tournamentHandler.RecordMatchHole)
+		staff.POST("/matches/:id/concede", tournamentHandler.ConcedeMatch)
+		staff.POST("/matches/:id/walkover", tournamentHandler.AwardWalkover)
+
+		// Scramble and best ball teams
+		staff.POST("/tournaments/:id/teams", tournamentHandler.CreateTeam)
+		staff.PUT("/tournaments/:id/teams/:team_id", tournamentHandler.UpdateTeam)
+		staff.DELETE("/tournaments/:id/teams/:team_id", tournamentHandler.DeleteTeam)
+
+		// League rosters and weekly scoring
+		staff.POST("/league-seasons/:id/members", leagueHandler.AddMember)
+		staff.DELETE("/league-seasons/:id/members/:user_id", leagueHandler.RemoveMember)
+		staff.POST("/league-weeks/:id/results", leagueHandler.ScoreWeek)
+		staff.POST("/league-weeks/:id/cancel", leagueHandler.CancelWeek)
+
+		// Card payments
+		staff.POST("/payments/:id/capture", paymentHandler.CapturePayment)
+		staff.GET("/payments/balance", paymentHandler.GetStaffBookingBalance)
+		staff.POST("/payments/:id/refunds", paymentHandler.IssueRefund)
+
+		// Refunds
+		staff.GET("/refunds", paymentHandler.GetRefunds)
+		staff.POST("/refunds/:id/approve", paymentHandler.ApproveRefund)
+		staff.POST("/refunds/:id/reject", paymentHandler.RejectRefund)
+
+		// Gift cards
+		staff.GET("/gift-cards", paymentHandler.GetStaffGiftCards)
+		staff.POST("/gift-cards", paymentHandler.SellGiftCard)
+		staff.GET("/gift-cards/:id", paymentHandler.GetGiftCard)
+		staff.PUT("/gift-cards/:id/status", paymentHandler.UpdateGiftCardStatus)
+
+		// Invoices
+		staff.GET("/invoices", invoiceHandler.GetCustomerInvoices)
+		staff.POST("/invoices", invoiceHandler.CreateCustomerInvoice)
+
 		// Staff stats
 		staff.GET("/stats", staffHandler.GetStaffStats)
 	}
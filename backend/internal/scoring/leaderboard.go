@@ -0,0 +1,104 @@
+package scoring
+
+import (
+	"sort"
+	"strconv"
+)
+
+// cardOffHoles are the closing stretches compared, longest first, when
+// finished players are tied.
+var cardOffHoles = []int{9, 6, 3, 1}
+
+// Standing is one player's place on a stroke-play leaderboard.
+type Standing struct {
+	PlayerID uint   `json:"player_id"`
+	Name     string `json:"name"`
+	Strokes  int    `json:"strokes"`
+	ToPar    int    `json:"to_par"`
+	Rounds   []int  `json:"rounds"`
+	Thru     int    `json:"thru"`
+	Finished bool   `json:"finished"`
+	Position int    `json:"position"`
+	Display  string `json:"display"`
+	// LastRound holds the hole scores of the final round in hole order and
+	// is used to break ties by card-off.
+	LastRound []int `json:"-"`
+}
+
+// CardOff compares two finished rounds over the last 9, 6, 3 and final hole.
+// It returns a negative number when a wins, positive when b wins and zero
+// when they cannot be separated.
+func CardOff(a, b []int) int {
+	if len(a) != len(b) {
+		return 0
+	}
+	for _, n := range cardOffHoles {
+		if n > len(a) {
+			continue
+		}
+		if diff := sum(a[len(a)-n:]) - sum(b[len(b)-n:]); diff != 0 {
+			return diff
+		}
+	}
+	return 0
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// Rank orders standings by score to par and assigns positions. Ties between
+// players who have both finished are broken by card-off; any other tie shares
+// the position and is shown as "T<n>". Players still on the course are
+// listed after the finished players on the same score.
+func Rank(standings []Standing) {
+	compare := func(a, b Standing) int {
+		if a.ToPar != b.ToPar {
+			return a.ToPar - b.ToPar
+		}
+		if a.Finished && b.Finished {
+			return CardOff(a.LastRound, b.LastRound)
+		}
+		return 0
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if c := compare(a, b); c != 0 {
+			return c < 0
+		}
+		// Among ties, list finished players first, then players further
+		// through their round
+		if a.Finished != b.Finished {
+			return a.Finished
+		}
+		if a.Thru != b.Thru {
+			return a.Thru > b.Thru
+		}
+		return a.Name < b.Name
+	})
+
+	for i := range standings {
+		if i > 0 && compare(standings[i-1], standings[i]) == 0 {
+			standings[i].Position = standings[i-1].Position
+		} else {
+			standings[i].Position = i + 1
+		}
+	}
+	for i := range standings {
+		tied := (i > 0 && standings[i-1].Position == standings[i].Position) ||
+			(i+1 < len(standings) && standings[i+1].Position == standings[i].Position)
+		standings[i].Display = positionLabel(standings[i].Position, tied)
+	}
+}
+
+func positionLabel(position int, tied bool) string {
+	if tied {
+		return "T" + strconv.Itoa(position)
+	}
+	return strconv.Itoa(position)
+}
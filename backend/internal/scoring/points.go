@@ -0,0 +1,42 @@
+package scoring
+
+import "sort"
+
+// PositionPoints ranks scores and awards points from a table by finishing
+// place. Lower scores win unless higherWins is set. Tied players share a
+// position and split the points for the places they cover equally; places
+// beyond the table earn nothing. Results are in the order scores were given.
+func PositionPoints(scores []int, higherWins bool, table []float64) ([]int, []float64) {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if higherWins {
+			return scores[order[a]] > scores[order[b]]
+		}
+		return scores[order[a]] < scores[order[b]]
+	})
+
+	positions := make([]int, len(scores))
+	points := make([]float64, len(scores))
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && scores[order[end]] == scores[order[start]] {
+			end++
+		}
+		share := 0.0
+		for place := start; place < end; place++ {
+			if place < len(table) {
+				share += table[place]
+			}
+		}
+		share /= float64(end - start)
+		for _, i := range order[start:end] {
+			positions[i] = start + 1
+			points[i] = share
+		}
+		start = end
+	}
+	return positions, points
+}
@@ -0,0 +1,279 @@
+// Package scoring settles alternative formats of play (Stableford,
+// par/bogey, skins and team best ball) from hole-by-hole scores and playing
+// handicaps.
+package scoring
+
+import (
+	"sort"
+
+	"golf-course-backend/internal/handicap"
+)
+
+// Hole describes one hole of the course being played.
+type Hole struct {
+	Number      int `json:"number"`
+	Par         int `json:"par"`
+	StrokeIndex int `json:"stroke_index"`
+}
+
+// Card is one player's scores keyed by hole number. A hole missing from
+// Strokes, or scored as zero, was not completed.
+type Card struct {
+	PlayerID        uint
+	Name            string
+	PlayingHandicap int
+	Strokes         map[int]int
+}
+
+// HoleResult is a player's outcome on a single hole.
+type HoleResult struct {
+	Number   int `json:"number"`
+	Par      int `json:"par"`
+	Strokes  int `json:"strokes"`
+	Received int `json:"received"`
+	Net      int `json:"net"`
+	Points   int `json:"points"`
+}
+
+// Result is a player's outcome over the round.
+type Result struct {
+	PlayerID        uint         `json:"player_id"`
+	Name            string       `json:"name"`
+	PlayingHandicap int          `json:"playing_handicap"`
+	Net             bool         `json:"net"`
+	Holes           []HoleResult `json:"holes"`
+	Total           int          `json:"total"`
+	Wins            int          `json:"wins,omitempty"`
+	Halves          int          `json:"halves,omitempty"`
+	Losses          int          `json:"losses,omitempty"`
+}
+
+// SkinHole records who, if anyone, won the skin on a hole.
+type SkinHole struct {
+	Number   int   `json:"number"`
+	WinnerID *uint `json:"winner_id"`
+	Score    *int  `json:"score"`
+	Value    int   `json:"value"`
+	Carried  bool  `json:"carried"`
+}
+
+// SkinsResult is the outcome of a skins game.
+type SkinsResult struct {
+	Net       bool         `json:"net"`
+	Carryover bool         `json:"carryover"`
+	Holes     []SkinHole   `json:"holes"`
+	Skins     map[uint]int `json:"skins"`
+	Unclaimed int          `json:"unclaimed"`
+}
+
+// received returns the handicap strokes a card gets on a hole.
+func received(card Card, hole Hole, holes int, net bool) int {
+	if !net {
+		return 0
+	}
+	strokeIndex := hole.StrokeIndex
+	if strokeIndex <= 0 {
+		strokeIndex = hole.Number
+	}
+	return handicap.StrokesReceived(card.PlayingHandicap, strokeIndex, holes)
+}
+
+// StablefordPoints awards two points for a net par, one more for each
+// stroke better and one fewer for each stroke worse, down to zero.
+func StablefordPoints(strokes, par, received int) int {
+	if strokes <= 0 {
+		return 0
+	}
+	points := 2 + par + received - strokes
+	if points < 0 {
+		return 0
+	}
+	return points
+}
+
+// ParBogeyResult scores a hole against par: +1 for beating it, 0 for a
+// half and -1 for losing it. An unfinished hole is a loss.
+func ParBogeyResult(strokes, par, received int) int {
+	if strokes <= 0 {
+		return -1
+	}
+	net := strokes - received
+	switch {
+	case net < par:
+		return 1
+	case net == par:
+		return 0
+	default:
+		return -1
+	}
+}
+
+func newResult(card Card, net bool) Result {
+	return Result{
+		PlayerID:        card.PlayerID,
+		Name:            card.Name,
+		PlayingHandicap: card.PlayingHandicap,
+		Net:             net,
+		Holes:           []HoleResult{},
+	}
+}
+
+// Stableford totals a card's Stableford points.
+func Stableford(holes []Hole, card Card, net bool) Result {
+	result := newResult(card, net)
+	for _, hole := range holes {
+		strokes := card.Strokes[hole.Number]
+		r := received(card, hole, len(holes), net)
+		points := StablefordPoints(strokes, hole.Par, r)
+		result.Holes = append(result.Holes, HoleResult{
+			Number:   hole.Number,
+			Par:      hole.Par,
+			Strokes:  strokes,
+			Received: r,
+			Net:      strokes - r,
+			Points:   points,
+		})
+		result.Total += points
+	}
+	return result
+}
+
+// ParBogey plays a card against par; the total is holes won minus holes lost.
+func ParBogey(holes []Hole, card Card, net bool) Result {
+	result := newResult(card, net)
+	for _, hole := range holes {
+		strokes := card.Strokes[hole.Number]
+		r := received(card, hole, len(holes), net)
+		points := ParBogeyResult(strokes, hole.Par, r)
+		switch points {
+		case 1:
+			result.Wins++
+		case 0:
+			result.Halves++
+		default:
+			result.Losses++
+		}
+		result.Holes = append(result.Holes, HoleResult{
+			Number:   hole.Number,
+			Par:      hole.Par,
+			Strokes:  strokes,
+			Received: r,
+			Net:      strokes - r,
+			Points:   points,
+		})
+		result.Total += points
+	}
+	return result
+}
+
+// Skins awards each hole to the player with the outright lowest score. With
+// carryover a tied hole's skin is added to the next one; skins still tied
+// after the last hole are left unclaimed.
+func Skins(holes []Hole, cards []Card, net, carryover bool) SkinsResult {
+	result := SkinsResult{
+		Net:       net,
+		Carryover: carryover,
+		Holes:     []SkinHole{},
+		Skins:     make(map[uint]int, len(cards)),
+	}
+	for _, card := range cards {
+		result.Skins[card.PlayerID] = 0
+	}
+
+	pot := 0
+	for _, hole := range holes {
+		pot++
+		skin := SkinHole{Number: hole.Number, Value: pot}
+
+		best, winners := 0, 0
+		var winner uint
+		for _, card := range cards {
+			strokes := card.Strokes[hole.Number]
+			if strokes <= 0 {
+				continue
+			}
+			score := strokes - received(card, hole, len(holes), net)
+			switch {
+			case winners == 0 || score < best:
+				best, winners, winner = score, 1, card.PlayerID
+			case score == best:
+				winners++
+			}
+		}
+
+		if winners > 0 {
+			score := best
+			skin.Score = &score
+		}
+		if winners == 1 {
+			id := winner
+			skin.WinnerID = &id
+			result.Skins[winner] += pot
+			pot = 0
+		} else {
+			skin.Carried = carryover
+			if !carryover {
+				result.Unclaimed += pot
+				pot = 0
+			}
+		}
+		result.Holes = append(result.Holes, skin)
+	}
+	result.Unclaimed += pot
+	return result
+}
+
+// StrokePlay totals a single card hole by hole, gross or net. Holes not yet
+// completed are left out.
+func StrokePlay(holes []Hole, card Card, net bool) Result {
+	result := Result{
+		PlayerID:        card.PlayerID,
+		Name:            card.Name,
+		PlayingHandicap: card.PlayingHandicap,
+		Net:             net,
+		Holes:           []HoleResult{},
+	}
+	for _, hole := range holes {
+		strokes := card.Strokes[hole.Number]
+		if strokes <= 0 {
+			continue
+		}
+		entry := HoleResult{Number: hole.Number, Par: hole.Par, Strokes: strokes}
+		entry.Received = received(card, hole, len(holes), net)
+		entry.Net = strokes - entry.Received
+		result.Holes = append(result.Holes, entry)
+		result.Total += entry.Net
+	}
+	return result
+}
+
+// BestBall scores a team hole by hole as the total of its best count net
+// scores. A hole counts only once at least count players have finished it.
+func BestBall(holes []Hole, cards []Card, count int, net bool) Result {
+	result := Result{Net: net, Holes: []HoleResult{}}
+	for _, hole := range holes {
+		type score struct{ strokes, received int }
+		var scores []score
+		for _, card := range cards {
+			if strokes := card.Strokes[hole.Number]; strokes > 0 {
+				scores = append(scores, score{strokes, received(card, hole, len(holes), net)})
+			}
+		}
+		if len(scores) < count || count <= 0 {
+			continue
+		}
+		sort.Slice(scores, func(i, j int) bool {
+			return scores[i].strokes-scores[i].received < scores[j].strokes-scores[j].received
+		})
+
+		entry := HoleResult{Number: hole.Number, Par: hole.Par * count}
+		for _, s := range scores[:count] {
+			entry.Strokes += s.strokes
+			entry.Received += s.received
+		}
+		entry.Net = entry.Strokes - entry.Received
+		result.Holes = append(result.Holes, entry)
+		result.Total += entry.Net
+	}
+	return result
+}
@@ -4,6 +4,7 @@ import (
 	"golf-course-backend/internal/auth"
 	"golf-course-backend/internal/config"
 	"golf-course-backend/internal/database"
+	"golf-course-backend/internal/payments"
 	"golf-course-backend/internal/routes"
 	"log"
 
@@ -45,6 +46,19 @@ func main() {
 	// Initialize auth service
 	authService := auth.NewAuthService(cfg.JWT.Secret, cfg.JWT.ExpiryHours)
 
+	// Card payments go through Stripe when it is configured. The fake
+	// provider takes no money, so release mode only uses it when asked to.
+	var paymentProvider payments.PaymentProvider
+	switch {
+	case cfg.Stripe.SecretKey != "":
+		paymentProvider = payments.NewStripeProvider(cfg.Stripe.SecretKey)
+	case cfg.Server.GinMode == gin.ReleaseMode && !cfg.Stripe.UseFake:
+		log.Fatal("STRIPE_SECRET_KEY must be set in release mode (set PAYMENTS_FAKE=true to use the fake payment provider)")
+	default:
+		log.Println("STRIPE_SECRET_KEY not set, using the in-memory fake payment provider")
+		paymentProvider = payments.NewFakeProvider()
+	}
+
 	// Create Gin router
 	r := gin.Default()
 
@@ -52,7 +66,7 @@ func main() {
 	r.MaxMultipartMemory = 10 << 20 // 10 MB
 
 	// Setup routes (includes health check)
-	routes.SetupRoutes(r, authService)
+	routes.SetupRoutes(r, cfg, authService, paymentProvider)
 
 	// Start server
 	log.Printf("🚀 Starting Golf Course Management API on port %s", cfg.Server.Port)
@@ -2,15 +2,55 @@
 -- This is a conversion of the MySQL schema to PostgreSQL
 
 -- Drop existing tables if they exist (in correct order to handle foreign keys)
+DROP TABLE IF EXISTS system_settings CASCADE;
+DROP TABLE IF EXISTS weather_logs CASCADE;
+DROP TABLE IF EXISTS processed_webhook_events CASCADE;
+DROP TABLE IF EXISTS promotion_redemptions CASCADE;
+DROP TABLE IF EXISTS promotions CASCADE;
+DROP TABLE IF EXISTS invoice_lines CASCADE;
+DROP TABLE IF EXISTS invoices CASCADE;
+DROP TABLE IF EXISTS journal_lines CASCADE;
+DROP TABLE IF EXISTS journal_entries CASCADE;
+DROP TABLE IF EXISTS ledger_accounts CASCADE;
+DROP TABLE IF EXISTS gift_card_transactions CASCADE;
+DROP TABLE IF EXISTS refunds CASCADE;
+DROP TABLE IF EXISTS payments CASCADE;
+DROP TABLE IF EXISTS gift_cards CASCADE;
+DROP TABLE IF EXISTS league_results CASCADE;
+DROP TABLE IF EXISTS league_absences CASCADE;
+DROP TABLE IF EXISTS handicap_revisions CASCADE;
 DROP TABLE IF EXISTS scorecard_holes CASCADE;
 DROP TABLE IF EXISTS scorecards CASCADE;
+DROP TABLE IF EXISTS tournament_team_scores CASCADE;
+DROP TABLE IF EXISTS tournament_team_members CASCADE;
+DROP TABLE IF EXISTS tournament_teams CASCADE;
+DROP TABLE IF EXISTS tournament_match_holes CASCADE;
+DROP TABLE IF EXISTS tournament_matches CASCADE;
+DROP TABLE IF EXISTS tournament_group_players CASCADE;
+DROP TABLE IF EXISTS tournament_groups CASCADE;
+DROP TABLE IF EXISTS work_order_parts CASCADE;
+DROP TABLE IF EXISTS work_orders CASCADE;
+DROP TABLE IF EXISTS rental_damage_photos CASCADE;
+DROP TABLE IF EXISTS rental_damage_reports CASCADE;
 DROP TABLE IF EXISTS equipment_rentals CASCADE;
+DROP TABLE IF EXISTS bundle_rentals CASCADE;
+DROP TABLE IF EXISTS equipment_bundle_items CASCADE;
+DROP TABLE IF EXISTS equipment_bundles CASCADE;
+DROP TABLE IF EXISTS equipment_variants CASCADE;
 DROP TABLE IF EXISTS equipment CASCADE;
 DROP TABLE IF EXISTS range_sessions CASCADE;
+DROP TABLE IF EXISTS tee_time_shares CASCADE;
+DROP TABLE IF EXISTS cart_assignments CASCADE;
+DROP TABLE IF EXISTS golf_carts CASCADE;
 DROP TABLE IF EXISTS tee_times CASCADE;
-DROP TABLE IF EXISTS payments CASCADE;
-DROP TABLE IF EXISTS weather_logs CASCADE;
-DROP TABLE IF EXISTS system_settings CASCADE;
+DROP TABLE IF EXISTS league_weeks CASCADE;
+DROP TABLE IF EXISTS league_members CASCADE;
+DROP TABLE IF EXISTS league_seasons CASCADE;
+DROP TABLE IF EXISTS leagues CASCADE;
+DROP TABLE IF EXISTS tournament_participants CASCADE;
+DROP TABLE IF EXISTS tournaments CASCADE;
+DROP TABLE IF EXISTS hole_tees CASCADE;
+DROP TABLE IF EXISTS tee_sets CASCADE;
 DROP TABLE IF EXISTS holes CASCADE;
 DROP TABLE IF EXISTS courses CASCADE;
 DROP TABLE IF EXISTS users CASCADE;
@@ -18,233 +58,958 @@ DROP TABLE IF EXISTS users CASCADE;
 -- Users table
 CREATE TABLE users (
     id SERIAL PRIMARY KEY,
-    username VARCHAR(50) UNIQUE NOT NULL,
-    email VARCHAR(100) UNIQUE NOT NULL,
+    email VARCHAR(255) UNIQUE NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
-    full_name VARCHAR(100),
+    first_name VARCHAR(100) NOT NULL,
+    last_name VARCHAR(100) NOT NULL,
     phone VARCHAR(20),
-    role VARCHAR(20) DEFAULT 'user',
-    is_active BOOLEAN DEFAULT true,
-    membership_type VARCHAR(50),
-    membership_expiry TIMESTAMP,
-    handicap DECIMAL(4,2) DEFAULT 0.00,
+    date_of_birth DATE,
+    role VARCHAR(20) DEFAULT 'customer' CHECK (role IN ('admin', 'staff', 'member', 'customer')),
+    membership_type VARCHAR(20) DEFAULT 'basic' CHECK (membership_type IN ('premium', 'standard', 'basic')),
+    membership_expiry DATE,
+    handicap DECIMAL(3,1),
+    avatar_url VARCHAR(500),
+    is_active BOOLEAN DEFAULT TRUE,
+    email_verified BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- Courses table
+-- Golf courses table
 CREATE TABLE courses (
     id SERIAL PRIMARY KEY,
-    name VARCHAR(100) NOT NULL,
+    name VARCHAR(255) NOT NULL,
     description TEXT,
-    num_holes INTEGER NOT NULL,
-    par INTEGER NOT NULL,
-    length_yards INTEGER,
-    difficulty_rating DECIMAL(3,1),
+    address TEXT,
+    phone VARCHAR(20),
+    email VARCHAR(255),
+    par INTEGER DEFAULT 72,
+    total_holes INTEGER DEFAULT 18,
+    course_rating DECIMAL(3,1),
     slope_rating INTEGER,
-    is_active BOOLEAN DEFAULT true,
     green_fee DECIMAL(10,2),
     cart_fee DECIMAL(10,2),
+    is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- Holes table
+-- Course holes table
 CREATE TABLE holes (
     id SERIAL PRIMARY KEY,
     course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
     hole_number INTEGER NOT NULL,
     par INTEGER NOT NULL,
-    length_yards INTEGER NOT NULL,
+    yardage INTEGER,
     handicap_index INTEGER,
     description TEXT,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(course_id, hole_number)
 );
 
--- Tee Times table
+-- Tee sets (black/blue/white/red) with their own rating and slope
+CREATE TABLE tee_sets (
+    id SERIAL PRIMARY KEY,
+    course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(20),
+    course_rating DECIMAL(3,1) NOT NULL,
+    slope_rating INTEGER NOT NULL,
+    total_yardage INTEGER DEFAULT 0,
+    is_default BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Yardage of each hole from each tee set
+CREATE TABLE hole_tees (
+    id SERIAL PRIMARY KEY,
+    hole_id INTEGER NOT NULL REFERENCES holes(id) ON DELETE CASCADE,
+    tee_set_id INTEGER NOT NULL REFERENCES tee_sets(id) ON DELETE CASCADE,
+    yardage INTEGER NOT NULL,
+    UNIQUE(hole_id, tee_set_id)
+);
+
+-- Tournaments table
+CREATE TABLE tournaments (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    entry_fee DECIMAL(10,2),
+    max_participants INTEGER,
+    tournament_type VARCHAR(20) DEFAULT 'stroke_play' CHECK (tournament_type IN ('stroke_play', 'match_play', 'scramble', 'best_ball')),
+    status VARCHAR(20) DEFAULT 'upcoming' CHECK (status IN ('upcoming', 'active', 'completed', 'cancelled')),
+    prize_pool DECIMAL(12,2),
+    registration_deadline DATE,
+    team_size INTEGER,
+    best_balls INTEGER DEFAULT 1,
+    team_allowances VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tournament participants table
+CREATE TABLE tournament_participants (
+    id SERIAL PRIMARY KEY,
+    tournament_id INTEGER NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    registration_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    handicap_at_registration DECIMAL(3,1),
+    payment_status VARCHAR(20) DEFAULT 'pending' CHECK (payment_status IN ('pending', 'paid', 'failed')),
+    final_score INTEGER,
+    final_position INTEGER,
+    prize_amount DECIMAL(10,2),
+    UNIQUE(tournament_id, user_id)
+);
+
+-- Leagues play weekly on a fixed day and block of tee times
+CREATE TABLE leagues (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    day_of_week SMALLINT NOT NULL,
+    first_tee_time TIME NOT NULL,
+    tee_times INTEGER DEFAULT 4,
+    scoring_format VARCHAR(20) DEFAULT 'net_stroke' CHECK (scoring_format IN ('net_stroke', 'stableford')),
+    handicap_allowance DECIMAL(5,2) DEFAULT 95.00,
+    points_table VARCHAR(100) DEFAULT '10,8,6,5,4,3,2,1',
+    participation_points DECIMAL(5,2) DEFAULT 1.00,
+    absence_points DECIMAL(5,2) DEFAULT 0.00,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE league_seasons (
+    id SERIAL PRIMARY KEY,
+    league_id INTEGER NOT NULL REFERENCES leagues(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status VARCHAR(20) DEFAULT 'upcoming' CHECK (status IN ('upcoming', 'active', 'completed')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Season rosters
+CREATE TABLE league_members (
+    id SERIAL PRIMARY KEY,
+    season_id INTEGER NOT NULL REFERENCES league_seasons(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    is_active BOOLEAN DEFAULT TRUE,
+    UNIQUE(season_id, user_id)
+);
+
+CREATE TABLE league_weeks (
+    id SERIAL PRIMARY KEY,
+    season_id INTEGER NOT NULL REFERENCES league_seasons(id) ON DELETE CASCADE,
+    week_number INTEGER NOT NULL,
+    play_date DATE NOT NULL,
+    status VARCHAR(20) DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'completed', 'cancelled')),
+    UNIQUE(season_id, week_number)
+);
+
+-- Tee times table; cancelled bookings drop out of unique_tee_time so the
+-- slot can be booked again
 CREATE TABLE tee_times (
     id SERIAL PRIMARY KEY,
     course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    tee_time TIMESTAMP NOT NULL,
-    num_players INTEGER DEFAULT 1,
-    status VARCHAR(20) DEFAULT 'confirmed',
-    cart_required BOOLEAN DEFAULT false,
+    tee_set_id INTEGER REFERENCES tee_sets(id) ON DELETE SET NULL,
+    tournament_id INTEGER REFERENCES tournaments(id) ON DELETE CASCADE,
+    league_week_id INTEGER REFERENCES league_weeks(id) ON DELETE CASCADE,
+    booking_date DATE NOT NULL,
+    tee_time TIME NOT NULL,
+    players_count INTEGER DEFAULT 1,
+    cart_required BOOLEAN DEFAULT FALSE,
+    cart_count INTEGER DEFAULT 0,
     total_amount DECIMAL(10,2),
+    discount_amount DECIMAL(10,2) DEFAULT 0.00,
+    split_payment BOOLEAN DEFAULT FALSE,
+    payment_status VARCHAR(20) DEFAULT 'pending' CHECK (payment_status IN ('pending', 'paid', 'failed', 'refunded')),
+    booking_status VARCHAR(20) DEFAULT 'confirmed' CHECK (booking_status IN ('confirmed', 'checked_in', 'cancelled', 'completed', 'blocked')),
+    special_requests TEXT,
+    checked_in_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Golf cart fleet
+CREATE TABLE golf_carts (
+    id SERIAL PRIMARY KEY,
+    cart_number VARCHAR(20) UNIQUE NOT NULL,
+    cart_type VARCHAR(20) DEFAULT 'two_seater' CHECK (cart_type IN ('two_seater', 'four_seater', 'single_rider')),
+    power_type VARCHAR(20) DEFAULT 'electric' CHECK (power_type IN ('electric', 'gas')),
+    battery_level INTEGER,
+    fuel_level INTEGER,
+    status VARCHAR(20) DEFAULT 'available' CHECK (status IN ('available', 'in_use', 'charging', 'maintenance', 'retired')),
     notes TEXT,
+    last_service_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- Range Sessions table
+-- Carts handed out to a booking at check-in
+CREATE TABLE cart_assignments (
+    id SERIAL PRIMARY KEY,
+    cart_id INTEGER NOT NULL REFERENCES golf_carts(id) ON DELETE CASCADE,
+    tee_time_id INTEGER NOT NULL REFERENCES tee_times(id) ON DELETE CASCADE,
+    assigned_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    returned_at TIMESTAMP,
+    level_out INTEGER,
+    level_in INTEGER
+);
+
+-- Each player's share of a tee time whose payment is split; invited players
+-- pay through a link carrying the payment token
+CREATE TABLE tee_time_shares (
+    id SERIAL PRIMARY KEY,
+    tee_time_id INTEGER NOT NULL REFERENCES tee_times(id) ON DELETE CASCADE,
+    player_number INTEGER NOT NULL,
+    player_name VARCHAR(100),
+    player_email VARCHAR(255),
+    amount DECIMAL(10,2) NOT NULL,
+    payment_token VARCHAR(64) NOT NULL UNIQUE,
+    payment_status VARCHAR(20) DEFAULT 'pending' CHECK (payment_status IN ('pending', 'paid', 'failed', 'refunded')),
+    paid_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    paid_at TIMESTAMP,
+    settled_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    invited_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tee_time_id, player_number)
+);
+
+-- Golf range sessions table
 CREATE TABLE range_sessions (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    session_start TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    session_end TIMESTAMP,
-    bucket_size VARCHAR(20),
-    amount_paid DECIMAL(10,2),
+    session_date DATE NOT NULL,
+    start_time TIME NOT NULL,
+    duration_minutes INTEGER DEFAULT 60,
+    ball_bucket_size VARCHAR(20) NOT NULL CHECK (ball_bucket_size IN ('small', 'medium', 'large', 'jumbo')),
+    bucket_price DECIMAL(8,2),
+    discount_amount DECIMAL(8,2) DEFAULT 0.00,
     bay_number INTEGER,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    payment_status VARCHAR(20) DEFAULT 'pending' CHECK (payment_status IN ('pending', 'paid', 'failed', 'refunded')),
+    session_status VARCHAR(20) DEFAULT 'booked' CHECK (session_status IN ('booked', 'active', 'completed', 'cancelled')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Equipment table
 CREATE TABLE equipment (
     id SERIAL PRIMARY KEY,
-    name VARCHAR(100) NOT NULL,
-    equipment_type VARCHAR(50),
+    name VARCHAR(255) NOT NULL,
+    category VARCHAR(20) NOT NULL CHECK (category IN ('clubs', 'bags', 'carts', 'accessories')),
     description TEXT,
-    quantity INTEGER DEFAULT 0,
-    available_quantity INTEGER DEFAULT 0,
-    rental_price DECIMAL(10,2),
-    condition VARCHAR(20) DEFAULT 'good',
-    is_active BOOLEAN DEFAULT true,
+    rental_price_per_day DECIMAL(8,2),
+    quantity_available INTEGER DEFAULT 0,
+    condition_status VARCHAR(20) DEFAULT 'good' CHECK (condition_status IN ('excellent', 'good', 'fair', 'maintenance')),
+    image_url VARCHAR(500),
+    is_available BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- Equipment Rentals table
-CREATE TABLE equipment_rentals (
+-- Equipment variants (size, handedness, flex, gender) with their own stock
+CREATE TABLE equipment_variants (
+    id SERIAL PRIMARY KEY,
+    equipment_id INTEGER NOT NULL REFERENCES equipment(id) ON DELETE CASCADE,
+    sku VARCHAR(100),
+    size VARCHAR(20),
+    handedness VARCHAR(20) CHECK (handedness IN ('right', 'left')),
+    shaft_flex VARCHAR(20) CHECK (shaft_flex IN ('ladies', 'senior', 'regular', 'stiff', 'extra_stiff')),
+    gender VARCHAR(20) CHECK (gender IN ('mens', 'womens', 'unisex', 'junior')),
+    quantity_available INTEGER DEFAULT 0,
+    is_available BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Rental bundles sold as a single product (e.g. "Full Round Kit")
+CREATE TABLE equipment_bundles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    bundle_price_per_day DECIMAL(8,2),
+    image_url VARCHAR(500),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE equipment_bundle_items (
     id SERIAL PRIMARY KEY,
+    bundle_id INTEGER NOT NULL REFERENCES equipment_bundles(id) ON DELETE CASCADE,
     equipment_id INTEGER NOT NULL REFERENCES equipment(id) ON DELETE CASCADE,
+    quantity INTEGER DEFAULT 1,
+    UNIQUE(bundle_id, equipment_id)
+);
+
+CREATE TABLE bundle_rentals (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    bundle_id INTEGER NOT NULL REFERENCES equipment_bundles(id) ON DELETE CASCADE,
+    tee_time_id INTEGER REFERENCES tee_times(id) ON DELETE SET NULL,
+    rental_date DATE NOT NULL,
+    return_date DATE,
+    quantity INTEGER DEFAULT 1,
+    rental_price DECIMAL(8,2),
+    component_value DECIMAL(8,2),
+    deposit_amount DECIMAL(8,2),
+    payment_status VARCHAR(20) DEFAULT 'pending' CHECK (payment_status IN ('pending', 'paid', 'failed', 'refunded')),
+    rental_status VARCHAR(20) DEFAULT 'reserved' CHECK (rental_status IN ('reserved', 'returned', 'cancelled')),
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Equipment rentals table
+CREATE TABLE equipment_rentals (
+    id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    rental_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    return_date TIMESTAMP,
+    equipment_id INTEGER NOT NULL REFERENCES equipment(id) ON DELETE CASCADE,
+    variant_id INTEGER REFERENCES equipment_variants(id) ON DELETE SET NULL,
+    bundle_rental_id INTEGER REFERENCES bundle_rentals(id) ON DELETE SET NULL,
+    rental_date DATE NOT NULL,
+    return_date DATE,
     quantity INTEGER DEFAULT 1,
-    rental_price DECIMAL(10,2),
-    deposit_amount DECIMAL(10,2),
-    status VARCHAR(20) DEFAULT 'active',
+    rental_price DECIMAL(8,2),
+    discount_amount DECIMAL(8,2) DEFAULT 0.00,
+    deposit_amount DECIMAL(8,2),
+    payment_status VARCHAR(20) DEFAULT 'pending' CHECK (payment_status IN ('pending', 'paid', 'failed', 'refunded')),
+    rental_status VARCHAR(20) DEFAULT 'rented' CHECK (rental_status IN ('rented', 'returned', 'overdue', 'damaged', 'cancelled')),
+    notes TEXT,
+    picked_up_at TIMESTAMP,
+    picked_up_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    returned_at TIMESTAMP,
+    inspected_at TIMESTAMP,
+    inspected_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    return_condition VARCHAR(20) CHECK (return_condition IN ('excellent', 'good', 'fair', 'damaged')),
+    damage_charge DECIMAL(8,2) DEFAULT 0,
+    deposit_status VARCHAR(20) DEFAULT 'pending' CHECK (deposit_status IN ('pending', 'not_required', 'held', 'refunded', 'partially_refunded', 'forfeited')),
+    deposit_refunded DECIMAL(8,2) DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Damage recorded when staff inspect a returned rental
+CREATE TABLE rental_damage_reports (
+    id SERIAL PRIMARY KEY,
+    rental_id INTEGER NOT NULL REFERENCES equipment_rentals(id) ON DELETE CASCADE,
+    reported_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    severity VARCHAR(20) DEFAULT 'minor' CHECK (severity IN ('minor', 'moderate', 'severe')),
     notes TEXT,
+    units_damaged INTEGER DEFAULT 0,
+    damage_charge DECIMAL(8,2) DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE rental_damage_photos (
+    id SERIAL PRIMARY KEY,
+    damage_report_id INTEGER NOT NULL REFERENCES rental_damage_reports(id) ON DELETE CASCADE,
+    url VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Maintenance work orders against equipment units and carts
+CREATE TABLE work_orders (
+    id SERIAL PRIMARY KEY,
+    asset_type VARCHAR(20) NOT NULL CHECK (asset_type IN ('equipment', 'golf_cart')),
+    equipment_id INTEGER REFERENCES equipment(id) ON DELETE CASCADE,
+    variant_id INTEGER REFERENCES equipment_variants(id) ON DELETE SET NULL,
+    cart_id INTEGER REFERENCES golf_carts(id) ON DELETE CASCADE,
+    rental_id INTEGER REFERENCES equipment_rentals(id) ON DELETE SET NULL,
+    quantity INTEGER DEFAULT 1,
+    units_withdrawn INTEGER DEFAULT 0,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    priority VARCHAR(20) DEFAULT 'medium' CHECK (priority IN ('low', 'medium', 'high', 'urgent')),
+    status VARCHAR(20) DEFAULT 'open' CHECK (status IN ('open', 'in_progress', 'closed')),
+    reported_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    labor_cost DECIMAL(10,2) DEFAULT 0,
+    parts_cost DECIMAL(10,2) DEFAULT 0,
+    total_cost DECIMAL(10,2) DEFAULT 0,
+    resolution VARCHAR(20) CHECK (resolution IN ('repaired', 'replaced', 'written_off')),
+    resolution_note TEXT,
+    started_at TIMESTAMP,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE work_order_parts (
+    id SERIAL PRIMARY KEY,
+    work_order_id INTEGER NOT NULL REFERENCES work_orders(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    part_number VARCHAR(100),
+    quantity INTEGER DEFAULT 1,
+    unit_cost DECIMAL(10,2) DEFAULT 0,
+    total_cost DECIMAL(10,2) DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Tournament pairings: one row per group per round
+CREATE TABLE tournament_groups (
+    id SERIAL PRIMARY KEY,
+    tournament_id INTEGER NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    round_number INTEGER NOT NULL DEFAULT 1,
+    group_number INTEGER NOT NULL,
+    flight INTEGER,
+    play_date DATE NOT NULL,
+    tee_time TIME NOT NULL,
+    starting_hole INTEGER DEFAULT 1,
+    tee_time_id INTEGER REFERENCES tee_times(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tournament_id, round_number, group_number)
+);
+
+CREATE TABLE tournament_group_players (
+    id SERIAL PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES tournament_groups(id) ON DELETE CASCADE,
+    participant_id INTEGER NOT NULL REFERENCES tournament_participants(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 1,
+    UNIQUE(group_id, participant_id)
+);
+
+-- Match play brackets: every match of every round, filled in as winners advance
+CREATE TABLE tournament_matches (
+    id SERIAL PRIMARY KEY,
+    tournament_id INTEGER NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    round_number INTEGER NOT NULL,
+    match_number INTEGER NOT NULL,
+    player1_id INTEGER REFERENCES tournament_participants(id) ON DELETE SET NULL,
+    player2_id INTEGER REFERENCES tournament_participants(id) ON DELETE SET NULL,
+    seed1 INTEGER,
+    seed2 INTEGER,
+    tee_set_id INTEGER REFERENCES tee_sets(id) ON DELETE SET NULL,
+    use_handicaps BOOLEAN DEFAULT TRUE,
+    strokes_given INTEGER DEFAULT 0,
+    strokes_to VARCHAR(20) CHECK (strokes_to IN ('player1', 'player2')),
+    scheduled_date DATE,
+    tee_time TIME,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'scheduled', 'in_progress', 'completed')),
+    winner_id INTEGER REFERENCES tournament_participants(id) ON DELETE SET NULL,
+    result VARCHAR(20),
+    decided_by VARCHAR(20) CHECK (decided_by IN ('holes', 'concession', 'walkover', 'bye')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tournament_id, round_number, match_number)
+);
+
+CREATE TABLE tournament_match_holes (
+    id SERIAL PRIMARY KEY,
+    match_id INTEGER NOT NULL REFERENCES tournament_matches(id) ON DELETE CASCADE,
+    hole_number INTEGER NOT NULL,
+    player1_strokes INTEGER,
+    player2_strokes INTEGER,
+    winner VARCHAR(20) NOT NULL CHECK (winner IN ('halved', 'player1', 'player2')),
+    conceded BOOLEAN DEFAULT FALSE,
+    UNIQUE(match_id, hole_number)
+);
+
+-- Teams for scramble and best ball events
+CREATE TABLE tournament_teams (
+    id SERIAL PRIMARY KEY,
+    tournament_id INTEGER NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tournament_id, name)
+);
+
+CREATE TABLE tournament_team_members (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES tournament_teams(id) ON DELETE CASCADE,
+    participant_id INTEGER NOT NULL REFERENCES tournament_participants(id) ON DELETE CASCADE,
+    UNIQUE(participant_id)
+);
+
+-- One ball per team and hole in a scramble
+CREATE TABLE tournament_team_scores (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES tournament_teams(id) ON DELETE CASCADE,
+    round_number INTEGER NOT NULL DEFAULT 1,
+    hole_number INTEGER NOT NULL,
+    strokes INTEGER NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(team_id, round_number, hole_number)
+);
+
 -- Scorecards table
 CREATE TABLE scorecards (
     id SERIAL PRIMARY KEY,
-    tee_time_id INTEGER NOT NULL REFERENCES tee_times(id) ON DELETE CASCADE,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    tee_time_id INTEGER REFERENCES tee_times(id) ON DELETE SET NULL,
+    tee_set_id INTEGER REFERENCES tee_sets(id) ON DELETE SET NULL,
+    played_date DATE NOT NULL,
     total_score INTEGER,
     total_putts INTEGER,
     fairways_hit INTEGER,
     greens_in_regulation INTEGER,
-    play_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    weather_condition VARCHAR(50),
+    handicap_used DECIMAL(3,1),
+    adjusted_gross_score INTEGER,
+    score_differential DECIMAL(4,1),
+    weather_conditions VARCHAR(100),
+    notes TEXT,
+    is_tournament_round BOOLEAN DEFAULT FALSE,
+    tournament_id INTEGER REFERENCES tournaments(id) ON DELETE SET NULL,
+    tournament_round INTEGER,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    UNIQUE(tournament_id, tournament_round, user_id)
 );
 
--- Scorecard Holes table
+-- Scorecard holes table
 CREATE TABLE scorecard_holes (
     id SERIAL PRIMARY KEY,
     scorecard_id INTEGER NOT NULL REFERENCES scorecards(id) ON DELETE CASCADE,
     hole_id INTEGER NOT NULL REFERENCES holes(id) ON DELETE CASCADE,
     strokes INTEGER NOT NULL,
     putts INTEGER DEFAULT 0,
-    fairway_hit BOOLEAN DEFAULT false,
-    green_in_regulation BOOLEAN DEFAULT false,
+    fairway_hit BOOLEAN DEFAULT FALSE,
+    green_in_regulation BOOLEAN DEFAULT FALSE,
+    sand_attempts INTEGER DEFAULT 0,
+    sand_saves INTEGER DEFAULT 0,
     penalties INTEGER DEFAULT 0,
-    notes TEXT,
     UNIQUE(scorecard_id, hole_id)
 );
 
+-- Handicap index revisions, one per posted round
+CREATE TABLE handicap_revisions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scorecard_id INTEGER NOT NULL REFERENCES scorecards(id) ON DELETE CASCADE,
+    played_date DATE NOT NULL,
+    course_handicap INTEGER,
+    adjusted_gross_score INTEGER NOT NULL,
+    score_differential DECIMAL(4,1) NOT NULL,
+    handicap_index DECIMAL(3,1),
+    low_handicap_index DECIMAL(3,1),
+    differentials_used INTEGER DEFAULT 0,
+    exceptional_adjustment DECIMAL(3,1) DEFAULT 0,
+    cap_applied VARCHAR(10),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- League absences, optionally covered by a substitute
+CREATE TABLE league_absences (
+    id SERIAL PRIMARY KEY,
+    week_id INTEGER NOT NULL REFERENCES league_weeks(id) ON DELETE CASCADE,
+    member_id INTEGER NOT NULL REFERENCES league_members(id) ON DELETE CASCADE,
+    substitute_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reason VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(week_id, member_id)
+);
+
+-- Weekly league results; a substitute's result counts for the member they replaced
+CREATE TABLE league_results (
+    id SERIAL PRIMARY KEY,
+    week_id INTEGER NOT NULL REFERENCES league_weeks(id) ON DELETE CASCADE,
+    member_id INTEGER NOT NULL REFERENCES league_members(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    scorecard_id INTEGER REFERENCES scorecards(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('played', 'substitute', 'absent', 'no_show')),
+    gross_score INTEGER,
+    playing_handicap INTEGER,
+    net_score INTEGER,
+    stableford_points INTEGER,
+    position INTEGER,
+    points DECIMAL(6,2) DEFAULT 0.00,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(week_id, member_id)
+);
+
+-- Gift cards hold a balance that can be spent on any booking or rental
+CREATE TABLE gift_cards (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    initial_amount DECIMAL(10,2) NOT NULL,
+    balance DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    currency VARCHAR(3) DEFAULT 'USD',
+    card_status VARCHAR(20) DEFAULT 'pending' CHECK (card_status IN ('pending', 'active', 'disabled')),
+    purchased_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    issued_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    recipient_name VARCHAR(200),
+    recipient_email VARCHAR(255),
+    message TEXT,
+    activated_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Payments table
 CREATE TABLE payments (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    tee_time_id INTEGER REFERENCES tee_times(id) ON DELETE SET NULL,
-    equipment_rental_id INTEGER REFERENCES equipment_rentals(id) ON DELETE SET NULL,
+    reference_type VARCHAR(20) NOT NULL CHECK (reference_type IN ('tee_time', 'range_session', 'equipment_rental', 'tournament', 'membership', 'gift_card', 'tee_time_share')),
+    reference_id INTEGER NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    currency VARCHAR(3) DEFAULT 'USD',
+    payment_type VARCHAR(20) DEFAULT 'charge' CHECK (payment_type IN ('charge', 'deposit', 'deposit_refund', 'damage_charge')),
+    payment_method VARCHAR(20) DEFAULT 'credit_card' CHECK (payment_method IN ('credit_card', 'debit_card', 'cash', 'bank_transfer', 'gift_card')),
+    from_deposit BOOLEAN DEFAULT FALSE,
+    gift_card_id INTEGER REFERENCES gift_cards(id) ON DELETE SET NULL,
+    stripe_payment_intent_id VARCHAR(255),
+    payment_status VARCHAR(20) DEFAULT 'pending' CHECK (payment_status IN ('pending', 'processing', 'succeeded', 'failed', 'cancelled', 'partially_refunded', 'refunded')),
+    failure_reason TEXT,
+    processed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Refunds against a payment; a payment can be refunded in several parts
+CREATE TABLE refunds (
+    id SERIAL PRIMARY KEY,
+    payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL,
+    reason_code VARCHAR(20) NOT NULL CHECK (reason_code IN ('customer_request', 'cancellation', 'weather', 'service_issue', 'duplicate', 'deposit_return', 'other')),
+    notes TEXT,
+    refund_status VARCHAR(20) DEFAULT 'pending_approval' CHECK (refund_status IN ('pending_approval', 'approved', 'succeeded', 'failed', 'rejected')),
+    provider_refund_id VARCHAR(255),
+    failure_reason TEXT,
+    cancels_booking BOOLEAN DEFAULT FALSE,
+    requested_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    processed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every change to a gift card balance; amount is negative when spent
+CREATE TABLE gift_card_transactions (
+    id SERIAL PRIMARY KEY,
+    gift_card_id INTEGER NOT NULL REFERENCES gift_cards(id) ON DELETE CASCADE,
+    transaction_type VARCHAR(20) NOT NULL CHECK (transaction_type IN ('activation', 'redemption', 'refund')),
     amount DECIMAL(10,2) NOT NULL,
-    payment_type VARCHAR(50),
-    payment_method VARCHAR(50),
-    payment_status VARCHAR(20) DEFAULT 'pending',
-    transaction_id VARCHAR(100),
-    payment_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    balance_after DECIMAL(10,2) NOT NULL,
+    payment_id INTEGER REFERENCES payments(id) ON DELETE SET NULL,
+    refund_id INTEGER REFERENCES refunds(id) ON DELETE SET NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Chart of accounts for the financial ledger
+CREATE TABLE ledger_accounts (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    account_type VARCHAR(20) NOT NULL CHECK (account_type IN ('asset', 'liability', 'revenue', 'contra_revenue')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Journal entries posted when money moves; source_key makes posting idempotent
+CREATE TABLE journal_entries (
+    id SERIAL PRIMARY KEY,
+    source_key VARCHAR(100) NOT NULL UNIQUE,
+    entry_type VARCHAR(20) NOT NULL CHECK (entry_type IN ('sale', 'refund', 'deposit_received', 'deposit_returned', 'deposit_applied', 'gift_card_sold')),
+    description VARCHAR(255),
+    payment_id INTEGER REFERENCES payments(id) ON DELETE SET NULL,
+    refund_id INTEGER REFERENCES refunds(id) ON DELETE SET NULL,
+    reference_type VARCHAR(50),
+    reference_id INTEGER,
+    posted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- Weather Logs table
+CREATE TABLE journal_lines (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    account_code VARCHAR(10) NOT NULL REFERENCES ledger_accounts(code),
+    debit DECIMAL(10,2) DEFAULT 0.00,
+    credit DECIMAL(10,2) DEFAULT 0.00
+);
+
+-- Invoices and receipts; one per payment, or one per customer for a period
+CREATE TABLE invoices (
+    id SERIAL PRIMARY KEY,
+    invoice_number VARCHAR(30) NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_id INTEGER UNIQUE REFERENCES payments(id) ON DELETE SET NULL,
+    period_start DATE,
+    period_end DATE,
+    billing_name VARCHAR(200),
+    billing_email VARCHAR(255),
+    subtotal DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    total DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    currency VARCHAR(3) DEFAULT 'USD',
+    issued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    emailed_to VARCHAR(255),
+    emailed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, period_start, period_end)
+);
+
+CREATE TABLE invoice_lines (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    description VARCHAR(255) NOT NULL,
+    quantity INTEGER DEFAULT 1,
+    unit_price DECIMAL(10,2) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    tax_amount DECIMAL(10,2) DEFAULT 0.00,
+    payment_id INTEGER REFERENCES payments(id) ON DELETE SET NULL
+);
+
+-- Promo codes managed by admins; empty applies_to and days_of_week mean no restriction
+CREATE TABLE promotions (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    discount_value DECIMAL(10,2) NOT NULL,
+    applies_to VARCHAR(100) DEFAULT '',
+    valid_from TIMESTAMP,
+    valid_until TIMESTAMP,
+    days_of_week VARCHAR(20) DEFAULT '',
+    start_time TIME,
+    end_time TIME,
+    max_redemptions INTEGER,
+    max_per_user INTEGER,
+    members_only BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Each use of a promo code; a booking takes at most one code
+CREATE TABLE promotion_redemptions (
+    id SERIAL PRIMARY KEY,
+    promotion_id INTEGER NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reference_type VARCHAR(20) NOT NULL CHECK (reference_type IN ('tee_time', 'range_session', 'equipment_rental')),
+    reference_id INTEGER NOT NULL,
+    subtotal DECIMAL(10,2) NOT NULL,
+    discount_amount DECIMAL(10,2) NOT NULL,
+    breakdown JSONB,
+    voided_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(reference_type, reference_id)
+);
+
+-- Payment webhook events already handled, so redeliveries are ignored
+CREATE TABLE processed_webhook_events (
+    id SERIAL PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL UNIQUE,
+    event_type VARCHAR(100) NOT NULL,
+    payment_id INTEGER REFERENCES payments(id) ON DELETE SET NULL,
+    processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Weather logs table
 CREATE TABLE weather_logs (
     id SERIAL PRIMARY KEY,
-    course_id INTEGER REFERENCES courses(id) ON DELETE CASCADE,
+    course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
     temperature DECIMAL(5,2),
     humidity INTEGER,
     wind_speed DECIMAL(5,2),
-    condition VARCHAR(50),
+    wind_direction VARCHAR(10),
+    weather_condition VARCHAR(100),
     precipitation DECIMAL(5,2),
     visibility DECIMAL(5,2),
-    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    api_response JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(course_id, date)
 );
 
--- System Settings table
+-- System settings table
 CREATE TABLE system_settings (
     id SERIAL PRIMARY KEY,
     setting_key VARCHAR(100) UNIQUE NOT NULL,
     setting_value TEXT,
     description TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Create indexes for better query performance
 CREATE INDEX idx_users_email ON users(email);
-CREATE INDEX idx_users_username ON users(username);
 CREATE INDEX idx_users_role ON users(role);
-CREATE INDEX idx_tee_times_date ON tee_times(tee_time);
+CREATE INDEX idx_tee_times_date ON tee_times(booking_date);
 CREATE INDEX idx_tee_times_user ON tee_times(user_id);
-CREATE INDEX idx_tee_times_course ON tee_times(course_id);
+CREATE INDEX idx_cart_assignments_cart ON cart_assignments(cart_id, returned_at);
+CREATE INDEX idx_work_orders_status ON work_orders(status, priority);
+CREATE INDEX idx_work_orders_equipment ON work_orders(equipment_id);
+CREATE INDEX idx_work_orders_cart ON work_orders(cart_id);
+CREATE INDEX idx_range_sessions_date ON range_sessions(session_date);
+CREATE INDEX idx_range_sessions_user ON range_sessions(user_id);
+CREATE INDEX idx_equipment_rentals_user ON equipment_rentals(user_id);
+CREATE INDEX idx_equipment_variants_equipment ON equipment_variants(equipment_id);
+CREATE INDEX idx_bundle_rentals_user ON bundle_rentals(user_id);
 CREATE INDEX idx_scorecards_user ON scorecards(user_id);
-CREATE INDEX idx_scorecards_course ON scorecards(course_id);
-CREATE INDEX idx_scorecards_date ON scorecards(play_date);
+CREATE INDEX idx_handicap_revisions_user ON handicap_revisions(user_id, played_date);
+CREATE INDEX idx_tournaments_start ON tournaments(start_date, status);
+CREATE INDEX idx_tournament_participants_user ON tournament_participants(user_id);
 CREATE INDEX idx_payments_user ON payments(user_id);
-CREATE INDEX idx_equipment_rentals_user ON equipment_rentals(user_id);
-CREATE INDEX idx_equipment_rentals_status ON equipment_rentals(status);
-
--- Insert default admin user (password: 'admin123' - CHANGE THIS!)
--- Password hash for 'admin123' using bcrypt
-INSERT INTO users (username, email, password_hash, full_name, role, is_active)
-VALUES ('admin', 'admin@golfcourse.com', '$2a$10$YourHashHere', 'System Administrator', 'admin', true);
-
--- Insert sample course
-INSERT INTO courses (name, description, num_holes, par, length_yards, difficulty_rating, slope_rating, green_fee, cart_fee)
-VALUES ('Championship Course', 'Our premier 18-hole championship golf course', 18, 72, 6800, 72.5, 135, 75.00, 25.00);
-
--- Insert sample holes for the course
-INSERT INTO holes (course_id, hole_number, par, length_yards, handicap_index)
-SELECT 1, generate_series, 
-       CASE WHEN generate_series IN (3, 8, 12, 17) THEN 3
-            WHEN generate_series IN (2, 5, 11, 14) THEN 5
-            ELSE 4 END,
-       CASE WHEN generate_series IN (3, 8, 12, 17) THEN 175
-            WHEN generate_series IN (2, 5, 11, 14) THEN 525
-            ELSE 400 END,
-       generate_series
-FROM generate_series(1, 18);
-
--- Insert sample equipment
-INSERT INTO equipment (name, equipment_type, description, quantity, available_quantity, rental_price)
-VALUES 
-    ('Golf Cart - Standard', 'cart', 'Standard 2-person golf cart', 20, 20, 25.00),
-    ('Golf Cart - Premium', 'cart', 'Premium 2-person cart with GPS', 10, 10, 35.00),
-    ('Club Set - Beginner', 'clubs', 'Full set for beginners', 15, 15, 30.00),
-    ('Club Set - Premium', 'clubs', 'Premium club set', 8, 8, 50.00),
-    ('Range Finder', 'accessories', 'Laser range finder', 12, 12, 15.00),
-    ('Golf Umbrella', 'accessories', 'Large golf umbrella', 25, 25, 5.00);
+CREATE INDEX idx_payments_status ON payments(payment_status);
+CREATE INDEX idx_payments_reference ON payments(reference_type, reference_id);
+CREATE INDEX idx_refunds_payment ON refunds(payment_id);
+CREATE INDEX idx_refunds_status ON refunds(refund_status);
+CREATE INDEX idx_journal_entries_posted ON journal_entries(posted_at);
+CREATE INDEX idx_journal_lines_account ON journal_lines(account_code);
+CREATE INDEX idx_gift_card_transactions_card ON gift_card_transactions(gift_card_id, created_at);
+CREATE INDEX idx_promotion_redemptions_promotion ON promotion_redemptions(promotion_id, user_id);
+CREATE UNIQUE INDEX unique_tee_time ON tee_times(course_id, booking_date, tee_time) WHERE booking_status <> 'cancelled';
+
+-- Insert default course
+INSERT INTO courses (name, description, address, phone, email, par, total_holes, course_rating, slope_rating, green_fee, cart_fee) 
+VALUES (
+    'Pine Valley Golf Club',
+    'Championship 18-hole golf course with stunning views and challenging play for all skill levels.',
+    '123 Golf Course Drive, Pine Valley, CA 90210',
+    '+1-555-GOLF-123',
+    'info@pinevalleygolf.com',
+    72,
+    18,
+    72.5,
+    135,
+    75.00,
+    25.00
+);
+
+-- Insert holes for the default course
+INSERT INTO holes (course_id, hole_number, par, yardage, handicap_index, description) VALUES
+(1, 1, 4, 385, 10, 'Gentle opening hole with bunkers guarding the green'),
+(1, 2, 3, 165, 16, 'Short par 3 over water to elevated green'),
+(1, 3, 5, 520, 2, 'Long par 5 with dogleg right'),
+(1, 4, 4, 410, 8, 'Straight par 4 with fairway bunkers'),
+(1, 5, 3, 185, 14, 'Challenging par 3 with deep bunkers'),
+(1, 6, 4, 375, 12, 'Medium length par 4 with narrow fairway'),
+(1, 7, 5, 545, 4, 'Reachable par 5 for long hitters'),
+(1, 8, 4, 425, 6, 'Uphill par 4 to tiered green'),
+(1, 9, 3, 175, 18, 'Scenic par 3 finishing the front nine'),
+(1, 10, 4, 395, 9, 'Downhill par 4 starting the back nine'),
+(1, 11, 3, 155, 17, 'Short par 3 with pin placement challenges'),
+(1, 12, 5, 560, 1, 'Longest hole on the course, par 5'),
+(1, 13, 4, 440, 5, 'Demanding par 4 with water hazard'),
+(1, 14, 3, 195, 13, 'Long par 3 requiring accurate iron play'),
+(1, 15, 4, 365, 11, 'Shorter par 4 with strategic positioning'),
+(1, 16, 5, 515, 3, 'Risk/reward par 5 with water carry'),
+(1, 17, 4, 450, 7, 'Challenging par 4 with OB left'),
+(1, 18, 3, 205, 15, 'Spectacular finishing hole par 3');
+
+-- Insert tee sets for the default course
+INSERT INTO tee_sets (course_id, name, color, course_rating, slope_rating, is_default) VALUES
+(1, 'Championship', 'black', 74.1, 139, FALSE),
+(1, 'Men''s', 'blue', 72.5, 135, TRUE),
+(1, 'Senior', 'white', 70.6, 128, FALSE),
+(1, 'Forward', 'red', 68.9, 120, FALSE);
+
+INSERT INTO hole_tees (hole_id, tee_set_id, yardage)
+SELECT id, 1, yardage + 25 FROM holes WHERE course_id = 1
+UNION ALL SELECT id, 2, yardage FROM holes WHERE course_id = 1
+UNION ALL SELECT id, 3, yardage - 25 FROM holes WHERE course_id = 1
+UNION ALL SELECT id, 4, yardage - 70 FROM holes WHERE course_id = 1;
+
+UPDATE tee_sets SET total_yardage = (
+    SELECT SUM(yardage) FROM hole_tees WHERE hole_tees.tee_set_id = tee_sets.id
+) WHERE course_id = 1;
+
+-- Insert default equipment
+INSERT INTO equipment (name, category, description, rental_price_per_day, quantity_available, condition_status) VALUES
+('Beginner Club Set', 'clubs', 'Complete set of clubs perfect for beginners', 25.00, 10, 'good'),
+('Intermediate Club Set', 'clubs', 'Quality club set for intermediate players', 35.00, 8, 'excellent'),
+('Premium Club Set', 'clubs', 'Professional grade clubs for advanced players', 50.00, 5, 'excellent'),
+('Golf Cart Bag', 'bags', 'Large golf bag with cart strap', 10.00, 15, 'good'),
+('Stand Bag', 'bags', 'Lightweight stand bag for walking', 8.00, 12, 'good'),
+('Electric Golf Cart', 'carts', 'Electric golf cart for two players', 30.00, 20, 'excellent'),
+('Push Cart', 'carts', 'Manual push cart for golf bags', 15.00, 25, 'good'),
+('Golf Shoes', 'accessories', 'Spike golf shoes various sizes', 12.00, 30, 'good'),
+('Golf Gloves', 'accessories', 'Leather golf gloves all sizes', 5.00, 50, 'excellent'),
+('Range Finder', 'accessories', 'GPS range finder device', 20.00, 8, 'excellent'),
+('Golf Balls (Dozen)', 'accessories', 'Dozen premium golf balls for the round', 18.00, 100, 'excellent');
+
+-- Insert variants for equipment stocked by size or handedness
+INSERT INTO equipment_variants (equipment_id, sku, size, handedness, shaft_flex, gender, quantity_available) VALUES
+(1, 'BCS-RH-REG-M', NULL, 'right', 'regular', 'mens', 6),
+(1, 'BCS-LH-REG-M', NULL, 'left', 'regular', 'mens', 2),
+(1, 'BCS-RH-LAD-W', NULL, 'right', 'ladies', 'womens', 2),
+(8, 'SHOE-7', '7', NULL, NULL, 'unisex', 4),
+(8, 'SHOE-8', '8', NULL, NULL, 'unisex', 5),
+(8, 'SHOE-9', '9', NULL, NULL, 'unisex', 6),
+(8, 'SHOE-10', '10', NULL, NULL, 'unisex', 6),
+(8, 'SHOE-11', '11', NULL, NULL, 'unisex', 5),
+(8, 'SHOE-12', '12', NULL, NULL, 'unisex', 4);
+
+-- Insert default bundles
+INSERT INTO equipment_bundles (name, description, bundle_price_per_day) VALUES
+('Full Round Kit', 'Intermediate clubs, electric cart, a dozen balls and a range finder', 85.00);
+
+INSERT INTO equipment_bundle_items (bundle_id, equipment_id, quantity) VALUES
+(1, 2, 1),
+(1, 6, 1),
+(1, 11, 1),
+(1, 10, 1);
+
+-- Insert cart fleet
+INSERT INTO golf_carts (cart_number, cart_type, power_type, battery_level, fuel_level) VALUES
+('C01', 'two_seater', 'electric', 100, NULL),
+('C02', 'two_seater', 'electric', 100, NULL),
+('C03', 'two_seater', 'electric', 100, NULL),
+('C04', 'two_seater', 'electric', 100, NULL),
+('C05', 'two_seater', 'electric', 100, NULL),
+('C06', 'two_seater', 'electric', 100, NULL),
+('C07', 'two_seater', 'electric', 100, NULL),
+('C08', 'two_seater', 'electric', 100, NULL),
+('C09', 'two_seater', 'electric', 100, NULL),
+('C10', 'two_seater', 'electric', 100, NULL),
+('C11', 'two_seater', 'gas', NULL, 100),
+('C12', 'two_seater', 'gas', NULL, 100);
+
+-- Insert chart of accounts
+INSERT INTO ledger_accounts (code, name, account_type) VALUES
+('1000', 'Cash and card clearing', 'asset'),
+('2000', 'Customer deposits', 'liability'),
+('2100', 'Sales tax payable', 'liability'),
+('2200', 'Gift card balances', 'liability'),
+('4000', 'Green fees', 'revenue'),
+('4010', 'Cart fees', 'revenue'),
+('4100', 'Range sales', 'revenue'),
+('4200', 'Equipment rentals', 'revenue'),
+('4210', 'Damage charges', 'revenue'),
+('4300', 'Tournament entry fees', 'revenue'),
+('4900', 'Other revenue', 'revenue'),
+('4950', 'Refunds', 'contra_revenue');
 
 -- Insert system settings
-INSERT INTO system_settings (setting_key, setting_value, description)
-VALUES 
-    ('booking_advance_days', '30', 'Maximum days in advance for tee time booking'),
-    ('cancellation_hours', '24', 'Minimum hours before tee time for free cancellation'),
-    ('max_players_per_booking', '4', 'Maximum players per tee time booking'),
-    ('range_open_time', '06:00', 'Driving range opening time'),
-    ('range_close_time', '20:00', 'Driving range closing time'),
-    ('small_bucket_balls', '50', 'Number of balls in small bucket'),
-    ('medium_bucket_balls', '100', 'Number of balls in medium bucket'),
-    ('large_bucket_balls', '150', 'Number of balls in large bucket'),
-    ('small_bucket_price', '8.00', 'Price for small bucket'),
-    ('medium_bucket_price', '12.00', 'Price for medium bucket'),
-    ('large_bucket_price', '15.00', 'Price for large bucket');
+INSERT INTO system_settings (setting_key, setting_value, description) VALUES
+('booking_advance_days', '30', 'Maximum days in advance for tee time booking'),
+('cancellation_hours', '24', 'Minimum hours before cancellation without penalty'),
+('refund_approval_threshold', '50', 'Customer refund requests above this amount wait for staff approval'),
+('sales_tax_rate', '0', 'Sales tax percentage included in prices'),
+('invoice_prefix', 'INV-', 'Prefix for invoice and receipt numbers'),
+('invoice_next_number', '1', 'Next invoice number; advanced under a row lock as invoices are issued'),
+('gift_card_min_amount', '10', 'Smallest gift card value that can be sold'),
+('gift_card_max_amount', '500', 'Largest gift card value that can be sold'),
+('range_session_duration', '60', 'Default range session duration in minutes'),
+('round_duration_minutes', '270', 'Time a cart is out for one round, including turnaround'),
+('cart_charge_threshold', '80', 'Battery level below which a returned cart goes on charge'),
+('leaderboard_refresh_seconds', '15', 'How often the live tournament leaderboard is refreshed'),
+('small_bucket_balls', '50', 'Number of balls in small bucket'),
+('medium_bucket_balls', '75', 'Number of balls in medium bucket'),
+('large_bucket_balls', '100', 'Number of balls in large bucket'),
+('jumbo_bucket_balls', '150', 'Number of balls in jumbo bucket'),
+('small_bucket_price', '8.00', 'Price for small ball bucket'),
+('medium_bucket_price', '12.00', 'Price for medium ball bucket'),
+('large_bucket_price', '16.00', 'Price for large ball bucket'),
+('jumbo_bucket_price', '22.00', 'Price for jumbo ball bucket'),
+('weather_api_key', '', 'OpenWeatherMap API key'),
+('stripe_publishable_key', '', 'Stripe publishable key'),
+('stripe_secret_key', '', 'Stripe secret key');
 
 -- Create function to update timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -256,15 +1021,47 @@ END;
 $$ language 'plpgsql';
 
 -- Create triggers for updated_at columns
-CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users 
+CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_courses_updated_at BEFORE UPDATE ON courses
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_tee_sets_updated_at BEFORE UPDATE ON tee_sets
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_tournaments_updated_at BEFORE UPDATE ON tournaments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_leagues_updated_at BEFORE UPDATE ON leagues
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_tee_times_updated_at BEFORE UPDATE ON tee_times
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_golf_carts_updated_at BEFORE UPDATE ON golf_carts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_tee_time_shares_updated_at BEFORE UPDATE ON tee_time_shares
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_range_sessions_updated_at BEFORE UPDATE ON range_sessions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_equipment_updated_at BEFORE UPDATE ON equipment
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_equipment_variants_updated_at BEFORE UPDATE ON equipment_variants
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_equipment_bundles_updated_at BEFORE UPDATE ON equipment_bundles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_bundle_rentals_updated_at BEFORE UPDATE ON bundle_rentals
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_equipment_rentals_updated_at BEFORE UPDATE ON equipment_rentals
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_work_orders_updated_at BEFORE UPDATE ON work_orders
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_tournament_matches_updated_at BEFORE UPDATE ON tournament_matches
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_tournament_team_scores_updated_at BEFORE UPDATE ON tournament_team_scores
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-CREATE TRIGGER update_courses_updated_at BEFORE UPDATE ON courses 
+CREATE TRIGGER update_gift_cards_updated_at BEFORE UPDATE ON gift_cards
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-CREATE TRIGGER update_tee_times_updated_at BEFORE UPDATE ON tee_times 
+CREATE TRIGGER update_payments_updated_at BEFORE UPDATE ON payments
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-CREATE TRIGGER update_equipment_updated_at BEFORE UPDATE ON equipment 
+CREATE TRIGGER update_refunds_updated_at BEFORE UPDATE ON refunds
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-CREATE TRIGGER update_scorecards_updated_at BEFORE UPDATE ON scorecards 
+CREATE TRIGGER update_promotions_updated_at BEFORE UPDATE ON promotions
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-CREATE TRIGGER update_system_settings_updated_at BEFORE UPDATE ON system_settings 
+CREATE TRIGGER update_system_settings_updated_at BEFORE UPDATE ON system_settings
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
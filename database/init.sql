@@ -56,24 +56,207 @@ CREATE TABLE holes (
     UNIQUE KEY unique_course_hole (course_id, hole_number)
 );
 
--- Tee times table
+-- Tee sets (black/blue/white/red) with their own rating and slope
+CREATE TABLE tee_sets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    course_id INT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(20),
+    course_rating DECIMAL(3,1) NOT NULL,
+    slope_rating INT NOT NULL,
+    total_yardage INT DEFAULT 0,
+    is_default BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE
+);
+
+-- Yardage of each hole from each tee set
+CREATE TABLE hole_tees (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    hole_id INT NOT NULL,
+    tee_set_id INT NOT NULL,
+    yardage INT NOT NULL,
+    FOREIGN KEY (hole_id) REFERENCES holes(id) ON DELETE CASCADE,
+    FOREIGN KEY (tee_set_id) REFERENCES tee_sets(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_hole_tee (hole_id, tee_set_id)
+);
+
+-- Tournaments table
+CREATE TABLE tournaments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    course_id INT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    entry_fee DECIMAL(10,2),
+    max_participants INT,
+    tournament_type ENUM('stroke_play', 'match_play', 'scramble', 'best_ball') DEFAULT 'stroke_play',
+    status ENUM('upcoming', 'active', 'completed', 'cancelled') DEFAULT 'upcoming',
+    prize_pool DECIMAL(12,2),
+    registration_deadline DATE,
+    team_size INT,
+    best_balls INT DEFAULT 1,
+    team_allowances VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE
+);
+
+-- Tournament participants table
+CREATE TABLE tournament_participants (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    tournament_id INT NOT NULL,
+    user_id INT NOT NULL,
+    registration_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    handicap_at_registration DECIMAL(3,1),
+    payment_status ENUM('pending', 'paid', 'failed') DEFAULT 'pending',
+    final_score INT,
+    final_position INT,
+    prize_amount DECIMAL(10,2),
+    FOREIGN KEY (tournament_id) REFERENCES tournaments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_tournament_participant (tournament_id, user_id)
+);
+
+-- Leagues play weekly on a fixed day and block of tee times
+CREATE TABLE leagues (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    course_id INT NOT NULL,
+    day_of_week TINYINT NOT NULL,
+    first_tee_time TIME NOT NULL,
+    tee_times INT DEFAULT 4,
+    scoring_format ENUM('net_stroke', 'stableford') DEFAULT 'net_stroke',
+    handicap_allowance DECIMAL(5,2) DEFAULT 95.00,
+    points_table VARCHAR(100) DEFAULT '10,8,6,5,4,3,2,1',
+    participation_points DECIMAL(5,2) DEFAULT 1.00,
+    absence_points DECIMAL(5,2) DEFAULT 0.00,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE
+);
+
+CREATE TABLE league_seasons (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    league_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    status ENUM('upcoming', 'active', 'completed') DEFAULT 'upcoming',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (league_id) REFERENCES leagues(id) ON DELETE CASCADE
+);
+
+-- Season rosters
+CREATE TABLE league_members (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    season_id INT NOT NULL,
+    user_id INT NOT NULL,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    is_active BOOLEAN DEFAULT TRUE,
+    FOREIGN KEY (season_id) REFERENCES league_seasons(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_league_member (season_id, user_id)
+);
+
+CREATE TABLE league_weeks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    season_id INT NOT NULL,
+    week_number INT NOT NULL,
+    play_date DATE NOT NULL,
+    status ENUM('scheduled', 'completed', 'cancelled') DEFAULT 'scheduled',
+    FOREIGN KEY (season_id) REFERENCES league_seasons(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_league_week (season_id, week_number)
+);
+
+-- Tee times table; cancelled bookings drop out of unique_tee_time so the
+-- slot can be booked again
 CREATE TABLE tee_times (
     id INT AUTO_INCREMENT PRIMARY KEY,
     course_id INT NOT NULL,
     user_id INT NOT NULL,
+    tee_set_id INT,
+    tournament_id INT,
+    league_week_id INT,
     booking_date DATE NOT NULL,
     tee_time TIME NOT NULL,
     players_count INT DEFAULT 1,
     cart_required BOOLEAN DEFAULT FALSE,
+    cart_count INT DEFAULT 0,
     total_amount DECIMAL(10,2),
+    discount_amount DECIMAL(10,2) DEFAULT 0.00,
+    split_payment BOOLEAN DEFAULT FALSE,
     payment_status ENUM('pending', 'paid', 'failed', 'refunded') DEFAULT 'pending',
-    booking_status ENUM('confirmed', 'cancelled', 'completed') DEFAULT 'confirmed',
+    booking_status ENUM('confirmed', 'checked_in', 'cancelled', 'completed', 'blocked') DEFAULT 'confirmed',
     special_requests TEXT,
+    checked_in_at TIMESTAMP NULL,
+    active_slot TINYINT GENERATED ALWAYS AS (IF(booking_status = 'cancelled', NULL, 1)) VIRTUAL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    UNIQUE KEY unique_tee_time (course_id, booking_date, tee_time)
+    FOREIGN KEY (tee_set_id) REFERENCES tee_sets(id) ON DELETE SET NULL,
+    FOREIGN KEY (tournament_id) REFERENCES tournaments(id) ON DELETE CASCADE,
+    FOREIGN KEY (league_week_id) REFERENCES league_weeks(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_tee_time (course_id, booking_date, tee_time, active_slot)
+);
+
+-- Golf cart fleet
+CREATE TABLE golf_carts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    cart_number VARCHAR(20) UNIQUE NOT NULL,
+    cart_type ENUM('two_seater', 'four_seater', 'single_rider') DEFAULT 'two_seater',
+    power_type ENUM('electric', 'gas') DEFAULT 'electric',
+    battery_level INT,
+    fuel_level INT,
+    status ENUM('available', 'in_use', 'charging', 'maintenance', 'retired') DEFAULT 'available',
+    notes TEXT,
+    last_service_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Carts handed out to a booking at check-in
+CREATE TABLE cart_assignments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    cart_id INT NOT NULL,
+    tee_time_id INT NOT NULL,
+    assigned_by INT NOT NULL,
+    assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    returned_at TIMESTAMP NULL,
+    level_out INT,
+    level_in INT,
+    FOREIGN KEY (cart_id) REFERENCES golf_carts(id) ON DELETE CASCADE,
+    FOREIGN KEY (tee_time_id) REFERENCES tee_times(id) ON DELETE CASCADE,
+    FOREIGN KEY (assigned_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Each player's share of a tee time whose payment is split; invited players
+-- pay through a link carrying the payment token
+CREATE TABLE tee_time_shares (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    tee_time_id INT NOT NULL,
+    player_number INT NOT NULL,
+    player_name VARCHAR(100),
+    player_email VARCHAR(255),
+    amount DECIMAL(10,2) NOT NULL,
+    payment_token VARCHAR(64) NOT NULL UNIQUE,
+    payment_status ENUM('pending', 'paid', 'failed', 'refunded') DEFAULT 'pending',
+    paid_by INT,
+    paid_at TIMESTAMP NULL,
+    settled_by INT,
+    invited_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (tee_time_id) REFERENCES tee_times(id) ON DELETE CASCADE,
+    FOREIGN KEY (paid_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (settled_by) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_tee_time_player (tee_time_id, player_number)
 );
 
 -- Golf range sessions table
@@ -85,6 +268,7 @@ CREATE TABLE range_sessions (
     duration_minutes INT DEFAULT 60,
     ball_bucket_size ENUM('small', 'medium', 'large', 'jumbo') NOT NULL,
     bucket_price DECIMAL(8,2),
+    discount_amount DECIMAL(8,2) DEFAULT 0.00,
     bay_number INT,
     payment_status ENUM('pending', 'paid', 'failed', 'refunded') DEFAULT 'pending',
     session_status ENUM('booked', 'active', 'completed', 'cancelled') DEFAULT 'booked',
@@ -108,23 +292,265 @@ CREATE TABLE equipment (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 );
 
+-- Equipment variants (size, handedness, flex, gender) with their own stock
+CREATE TABLE equipment_variants (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    equipment_id INT NOT NULL,
+    sku VARCHAR(100),
+    size VARCHAR(20),
+    handedness ENUM('right', 'left'),
+    shaft_flex ENUM('ladies', 'senior', 'regular', 'stiff', 'extra_stiff'),
+    gender ENUM('mens', 'womens', 'unisex', 'junior'),
+    quantity_available INT DEFAULT 0,
+    is_available BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (equipment_id) REFERENCES equipment(id) ON DELETE CASCADE
+);
+
+-- Rental bundles sold as a single product (e.g. "Full Round Kit")
+CREATE TABLE equipment_bundles (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    bundle_price_per_day DECIMAL(8,2),
+    image_url VARCHAR(500),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE equipment_bundle_items (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    bundle_id INT NOT NULL,
+    equipment_id INT NOT NULL,
+    quantity INT DEFAULT 1,
+    FOREIGN KEY (bundle_id) REFERENCES equipment_bundles(id) ON DELETE CASCADE,
+    FOREIGN KEY (equipment_id) REFERENCES equipment(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_bundle_item (bundle_id, equipment_id)
+);
+
+CREATE TABLE bundle_rentals (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    bundle_id INT NOT NULL,
+    tee_time_id INT,
+    rental_date DATE NOT NULL,
+    return_date DATE,
+    quantity INT DEFAULT 1,
+    rental_price DECIMAL(8,2),
+    component_value DECIMAL(8,2),
+    deposit_amount DECIMAL(8,2),
+    payment_status ENUM('pending', 'paid', 'failed', 'refunded') DEFAULT 'pending',
+    rental_status ENUM('reserved', 'returned', 'cancelled') DEFAULT 'reserved',
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (bundle_id) REFERENCES equipment_bundles(id) ON DELETE CASCADE,
+    FOREIGN KEY (tee_time_id) REFERENCES tee_times(id) ON DELETE SET NULL
+);
+
 -- Equipment rentals table
 CREATE TABLE equipment_rentals (
     id INT AUTO_INCREMENT PRIMARY KEY,
     user_id INT NOT NULL,
     equipment_id INT NOT NULL,
+    variant_id INT,
+    bundle_rental_id INT,
     rental_date DATE NOT NULL,
     return_date DATE,
     quantity INT DEFAULT 1,
     rental_price DECIMAL(8,2),
+    discount_amount DECIMAL(8,2) DEFAULT 0.00,
     deposit_amount DECIMAL(8,2),
     payment_status ENUM('pending', 'paid', 'failed', 'refunded') DEFAULT 'pending',
-    rental_status ENUM('rented', 'returned', 'overdue', 'damaged') DEFAULT 'rented',
+    rental_status ENUM('rented', 'returned', 'overdue', 'damaged', 'cancelled') DEFAULT 'rented',
     notes TEXT,
+    picked_up_at TIMESTAMP NULL,
+    picked_up_by INT,
+    returned_at TIMESTAMP NULL,
+    inspected_at TIMESTAMP NULL,
+    inspected_by INT,
+    return_condition ENUM('excellent', 'good', 'fair', 'damaged'),
+    damage_charge DECIMAL(8,2) DEFAULT 0,
+    deposit_status ENUM('pending', 'not_required', 'held', 'refunded', 'partially_refunded', 'forfeited') DEFAULT 'pending',
+    deposit_refunded DECIMAL(8,2) DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    FOREIGN KEY (equipment_id) REFERENCES equipment(id) ON DELETE CASCADE
+    FOREIGN KEY (equipment_id) REFERENCES equipment(id) ON DELETE CASCADE,
+    FOREIGN KEY (variant_id) REFERENCES equipment_variants(id) ON DELETE SET NULL,
+    FOREIGN KEY (bundle_rental_id) REFERENCES bundle_rentals(id) ON DELETE SET NULL,
+    FOREIGN KEY (picked_up_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (inspected_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Damage recorded when staff inspect a returned rental
+CREATE TABLE rental_damage_reports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    rental_id INT NOT NULL,
+    reported_by INT NOT NULL,
+    severity ENUM('minor', 'moderate', 'severe') DEFAULT 'minor',
+    notes TEXT,
+    units_damaged INT DEFAULT 0,
+    damage_charge DECIMAL(8,2) DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rental_id) REFERENCES equipment_rentals(id) ON DELETE CASCADE,
+    FOREIGN KEY (reported_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE rental_damage_photos (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    damage_report_id INT NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (damage_report_id) REFERENCES rental_damage_reports(id) ON DELETE CASCADE
+);
+
+-- Maintenance work orders against equipment units and carts
+CREATE TABLE work_orders (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    asset_type ENUM('equipment', 'golf_cart') NOT NULL,
+    equipment_id INT,
+    variant_id INT,
+    cart_id INT,
+    rental_id INT,
+    quantity INT DEFAULT 1,
+    units_withdrawn INT DEFAULT 0,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    priority ENUM('low', 'medium', 'high', 'urgent') DEFAULT 'medium',
+    status ENUM('open', 'in_progress', 'closed') DEFAULT 'open',
+    reported_by INT NOT NULL,
+    assignee_id INT,
+    labor_cost DECIMAL(10,2) DEFAULT 0,
+    parts_cost DECIMAL(10,2) DEFAULT 0,
+    total_cost DECIMAL(10,2) DEFAULT 0,
+    resolution ENUM('repaired', 'replaced', 'written_off'),
+    resolution_note TEXT,
+    started_at TIMESTAMP NULL,
+    closed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (equipment_id) REFERENCES equipment(id) ON DELETE CASCADE,
+    FOREIGN KEY (variant_id) REFERENCES equipment_variants(id) ON DELETE SET NULL,
+    FOREIGN KEY (cart_id) REFERENCES golf_carts(id) ON DELETE CASCADE,
+    FOREIGN KEY (rental_id) REFERENCES equipment_rentals(id) ON DELETE SET NULL,
+    FOREIGN KEY (reported_by) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (assignee_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE work_order_parts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    work_order_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    part_number VARCHAR(100),
+    quantity INT DEFAULT 1,
+    unit_cost DECIMAL(10,2) DEFAULT 0,
+    total_cost DECIMAL(10,2) DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (work_order_id) REFERENCES work_orders(id) ON DELETE CASCADE
+);
+
+-- Tournament pairings: one row per group per round
+CREATE TABLE tournament_groups (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    tournament_id INT NOT NULL,
+    round_number INT NOT NULL DEFAULT 1,
+    group_number INT NOT NULL,
+    flight INT,
+    play_date DATE NOT NULL,
+    tee_time TIME NOT NULL,
+    starting_hole INT DEFAULT 1,
+    tee_time_id INT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (tournament_id) REFERENCES tournaments(id) ON DELETE CASCADE,
+    FOREIGN KEY (tee_time_id) REFERENCES tee_times(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_tournament_group (tournament_id, round_number, group_number)
+);
+
+CREATE TABLE tournament_group_players (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    participant_id INT NOT NULL,
+    position INT NOT NULL DEFAULT 1,
+    FOREIGN KEY (group_id) REFERENCES tournament_groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (participant_id) REFERENCES tournament_participants(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_group_player (group_id, participant_id)
+);
+
+-- Match play brackets: every match of every round, filled in as winners advance
+CREATE TABLE tournament_matches (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    tournament_id INT NOT NULL,
+    round_number INT NOT NULL,
+    match_number INT NOT NULL,
+    player1_id INT,
+    player2_id INT,
+    seed1 INT,
+    seed2 INT,
+    tee_set_id INT,
+    use_handicaps BOOLEAN DEFAULT TRUE,
+    strokes_given INT DEFAULT 0,
+    strokes_to ENUM('player1', 'player2'),
+    scheduled_date DATE,
+    tee_time TIME,
+    status ENUM('pending', 'scheduled', 'in_progress', 'completed') DEFAULT 'pending',
+    winner_id INT,
+    result VARCHAR(20),
+    decided_by ENUM('holes', 'concession', 'walkover', 'bye'),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (tournament_id) REFERENCES tournaments(id) ON DELETE CASCADE,
+    FOREIGN KEY (player1_id) REFERENCES tournament_participants(id) ON DELETE SET NULL,
+    FOREIGN KEY (player2_id) REFERENCES tournament_participants(id) ON DELETE SET NULL,
+    FOREIGN KEY (winner_id) REFERENCES tournament_participants(id) ON DELETE SET NULL,
+    FOREIGN KEY (tee_set_id) REFERENCES tee_sets(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_tournament_match (tournament_id, round_number, match_number)
+);
+
+CREATE TABLE tournament_match_holes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    match_id INT NOT NULL,
+    hole_number INT NOT NULL,
+    player1_strokes INT,
+    player2_strokes INT,
+    winner ENUM('halved', 'player1', 'player2') NOT NULL,
+    conceded BOOLEAN DEFAULT FALSE,
+    FOREIGN KEY (match_id) REFERENCES tournament_matches(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_match_hole (match_id, hole_number)
+);
+
+-- Teams for scramble and best ball events
+CREATE TABLE tournament_teams (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    tournament_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (tournament_id) REFERENCES tournaments(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_tournament_team (tournament_id, name)
+);
+
+CREATE TABLE tournament_team_members (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    team_id INT NOT NULL,
+    participant_id INT NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES tournament_teams(id) ON DELETE CASCADE,
+    FOREIGN KEY (participant_id) REFERENCES tournament_participants(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_team_member (participant_id)
+);
+
+-- One ball per team and hole in a scramble
+CREATE TABLE tournament_team_scores (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    team_id INT NOT NULL,
+    round_number INT NOT NULL DEFAULT 1,
+    hole_number INT NOT NULL,
+    strokes INT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (team_id) REFERENCES tournament_teams(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_team_score (team_id, round_number, hole_number)
 );
 
 -- Scorecards table
@@ -133,19 +559,27 @@ CREATE TABLE scorecards (
     user_id INT NOT NULL,
     course_id INT NOT NULL,
     tee_time_id INT,
+    tee_set_id INT,
     played_date DATE NOT NULL,
     total_score INT,
     total_putts INT,
     fairways_hit INT,
     greens_in_regulation INT,
     handicap_used DECIMAL(3,1),
+    adjusted_gross_score INT,
+    score_differential DECIMAL(4,1),
     weather_conditions VARCHAR(100),
     notes TEXT,
     is_tournament_round BOOLEAN DEFAULT FALSE,
+    tournament_id INT,
+    tournament_round INT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
     FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE,
-    FOREIGN KEY (tee_time_id) REFERENCES tee_times(id) ON DELETE SET NULL
+    FOREIGN KEY (tee_time_id) REFERENCES tee_times(id) ON DELETE SET NULL,
+    FOREIGN KEY (tee_set_id) REFERENCES tee_sets(id) ON DELETE SET NULL,
+    FOREIGN KEY (tournament_id) REFERENCES tournaments(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_tournament_round (tournament_id, tournament_round, user_id)
 );
 
 -- Scorecard holes table
@@ -157,6 +591,7 @@ CREATE TABLE scorecard_holes (
     putts INT DEFAULT 0,
     fairway_hit BOOLEAN DEFAULT FALSE,
     green_in_regulation BOOLEAN DEFAULT FALSE,
+    sand_attempts INT DEFAULT 0,
     sand_saves INT DEFAULT 0,
     penalties INT DEFAULT 0,
     FOREIGN KEY (scorecard_id) REFERENCES scorecards(id) ON DELETE CASCADE,
@@ -164,57 +599,263 @@ CREATE TABLE scorecard_holes (
     UNIQUE KEY unique_scorecard_hole (scorecard_id, hole_id)
 );
 
--- Tournaments table
-CREATE TABLE tournaments (
+-- Handicap index revisions, one per posted round
+CREATE TABLE handicap_revisions (
     id INT AUTO_INCREMENT PRIMARY KEY,
-    name VARCHAR(255) NOT NULL,
-    description TEXT,
-    course_id INT NOT NULL,
-    start_date DATE NOT NULL,
-    end_date DATE NOT NULL,
-    entry_fee DECIMAL(10,2),
-    max_participants INT,
-    tournament_type ENUM('stroke_play', 'match_play', 'scramble', 'best_ball') DEFAULT 'stroke_play',
-    status ENUM('upcoming', 'active', 'completed', 'cancelled') DEFAULT 'upcoming',
-    prize_pool DECIMAL(12,2),
-    registration_deadline DATE,
+    user_id INT NOT NULL,
+    scorecard_id INT NOT NULL,
+    played_date DATE NOT NULL,
+    course_handicap INT,
+    adjusted_gross_score INT NOT NULL,
+    score_differential DECIMAL(4,1) NOT NULL,
+    handicap_index DECIMAL(3,1),
+    low_handicap_index DECIMAL(3,1),
+    differentials_used INT DEFAULT 0,
+    exceptional_adjustment DECIMAL(3,1) DEFAULT 0,
+    cap_applied VARCHAR(10),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    FOREIGN KEY (course_id) REFERENCES courses(id) ON DELETE CASCADE
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (scorecard_id) REFERENCES scorecards(id) ON DELETE CASCADE
 );
 
--- Tournament participants table
-CREATE TABLE tournament_participants (
+-- League absences, optionally covered by a substitute
+CREATE TABLE league_absences (
     id INT AUTO_INCREMENT PRIMARY KEY,
-    tournament_id INT NOT NULL,
-    user_id INT NOT NULL,
-    registration_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    handicap_at_registration DECIMAL(3,1),
-    payment_status ENUM('pending', 'paid', 'failed') DEFAULT 'pending',
-    final_score INT,
-    final_position INT,
-    prize_amount DECIMAL(10,2),
-    FOREIGN KEY (tournament_id) REFERENCES tournaments(id) ON DELETE CASCADE,
-    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    UNIQUE KEY unique_tournament_participant (tournament_id, user_id)
+    week_id INT NOT NULL,
+    member_id INT NOT NULL,
+    substitute_user_id INT,
+    reason VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (week_id) REFERENCES league_weeks(id) ON DELETE CASCADE,
+    FOREIGN KEY (member_id) REFERENCES league_members(id) ON DELETE CASCADE,
+    FOREIGN KEY (substitute_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_league_absence (week_id, member_id)
+);
+
+-- Weekly league results; a substitute's result counts for the member they replaced
+CREATE TABLE league_results (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    week_id INT NOT NULL,
+    member_id INT NOT NULL,
+    user_id INT,
+    scorecard_id INT,
+    status ENUM('played', 'substitute', 'absent', 'no_show') NOT NULL,
+    gross_score INT,
+    playing_handicap INT,
+    net_score INT,
+    stableford_points INT,
+    position INT,
+    points DECIMAL(6,2) DEFAULT 0.00,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (week_id) REFERENCES league_weeks(id) ON DELETE CASCADE,
+    FOREIGN KEY (member_id) REFERENCES league_members(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (scorecard_id) REFERENCES scorecards(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_league_result (week_id, member_id)
+);
+
+-- Gift cards hold a balance that can be spent on any booking or rental
+CREATE TABLE gift_cards (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    initial_amount DECIMAL(10,2) NOT NULL,
+    balance DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    currency VARCHAR(3) DEFAULT 'USD',
+    card_status ENUM('pending', 'active', 'disabled') DEFAULT 'pending',
+    purchased_by INT,
+    issued_by INT,
+    recipient_name VARCHAR(200),
+    recipient_email VARCHAR(255),
+    message TEXT,
+    activated_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (purchased_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (issued_by) REFERENCES users(id) ON DELETE SET NULL
 );
 
 -- Payments table
 CREATE TABLE payments (
     id INT AUTO_INCREMENT PRIMARY KEY,
     user_id INT NOT NULL,
-    reference_type ENUM('tee_time', 'range_session', 'equipment_rental', 'tournament', 'membership') NOT NULL,
+    reference_type ENUM('tee_time', 'range_session', 'equipment_rental', 'tournament', 'membership', 'gift_card', 'tee_time_share') NOT NULL,
     reference_id INT NOT NULL,
     amount DECIMAL(10,2) NOT NULL,
     currency VARCHAR(3) DEFAULT 'USD',
-    payment_method ENUM('credit_card', 'debit_card', 'cash', 'bank_transfer') DEFAULT 'credit_card',
+    payment_type ENUM('charge', 'deposit', 'deposit_refund', 'damage_charge') DEFAULT 'charge',
+    payment_method ENUM('credit_card', 'debit_card', 'cash', 'bank_transfer', 'gift_card') DEFAULT 'credit_card',
+    from_deposit BOOLEAN DEFAULT FALSE,
+    gift_card_id INT,
     stripe_payment_intent_id VARCHAR(255),
-    payment_status ENUM('pending', 'processing', 'succeeded', 'failed', 'cancelled', 'refunded') DEFAULT 'pending',
+    payment_status ENUM('pending', 'processing', 'succeeded', 'failed', 'cancelled', 'partially_refunded', 'refunded') DEFAULT 'pending',
     failure_reason TEXT,
     processed_at TIMESTAMP NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (gift_card_id) REFERENCES gift_cards(id) ON DELETE SET NULL
+);
+
+-- Refunds against a payment; a payment can be refunded in several parts
+CREATE TABLE refunds (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    payment_id INT NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    reason_code ENUM('customer_request', 'cancellation', 'weather', 'service_issue', 'duplicate', 'deposit_return', 'other') NOT NULL,
+    notes TEXT,
+    refund_status ENUM('pending_approval', 'approved', 'succeeded', 'failed', 'rejected') DEFAULT 'pending_approval',
+    provider_refund_id VARCHAR(255),
+    failure_reason TEXT,
+    cancels_booking BOOLEAN DEFAULT FALSE,
+    requested_by INT,
+    reviewed_by INT,
+    reviewed_at TIMESTAMP NULL,
+    processed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE CASCADE,
+    FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Every change to a gift card balance; amount is negative when spent
+CREATE TABLE gift_card_transactions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    gift_card_id INT NOT NULL,
+    transaction_type ENUM('activation', 'redemption', 'refund') NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    balance_after DECIMAL(10,2) NOT NULL,
+    payment_id INT,
+    refund_id INT,
+    created_by INT,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (gift_card_id) REFERENCES gift_cards(id) ON DELETE CASCADE,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE SET NULL,
+    FOREIGN KEY (refund_id) REFERENCES refunds(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Chart of accounts for the financial ledger
+CREATE TABLE ledger_accounts (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    account_type ENUM('asset', 'liability', 'revenue', 'contra_revenue') NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Journal entries posted when money moves; source_key makes posting idempotent
+CREATE TABLE journal_entries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    source_key VARCHAR(100) NOT NULL UNIQUE,
+    entry_type ENUM('sale', 'refund', 'deposit_received', 'deposit_returned', 'deposit_applied', 'gift_card_sold') NOT NULL,
+    description VARCHAR(255),
+    payment_id INT,
+    refund_id INT,
+    reference_type VARCHAR(50),
+    reference_id INT,
+    posted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE SET NULL,
+    FOREIGN KEY (refund_id) REFERENCES refunds(id) ON DELETE SET NULL
+);
+
+CREATE TABLE journal_lines (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    entry_id INT NOT NULL,
+    account_code VARCHAR(10) NOT NULL,
+    debit DECIMAL(10,2) DEFAULT 0.00,
+    credit DECIMAL(10,2) DEFAULT 0.00,
+    FOREIGN KEY (entry_id) REFERENCES journal_entries(id) ON DELETE CASCADE,
+    FOREIGN KEY (account_code) REFERENCES ledger_accounts(code)
+);
+
+-- Invoices and receipts; one per payment, or one per customer for a period
+CREATE TABLE invoices (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    invoice_number VARCHAR(30) NOT NULL UNIQUE,
+    user_id INT NOT NULL,
+    payment_id INT UNIQUE,
+    period_start DATE NULL,
+    period_end DATE NULL,
+    billing_name VARCHAR(200),
+    billing_email VARCHAR(255),
+    subtotal DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    total DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+    currency VARCHAR(3) DEFAULT 'USD',
+    issued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    emailed_to VARCHAR(255),
+    emailed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_invoice_period (user_id, period_start, period_end)
+);
+
+CREATE TABLE invoice_lines (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    invoice_id INT NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    quantity INT DEFAULT 1,
+    unit_price DECIMAL(10,2) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    tax_amount DECIMAL(10,2) DEFAULT 0.00,
+    payment_id INT,
+    FOREIGN KEY (invoice_id) REFERENCES invoices(id) ON DELETE CASCADE,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE SET NULL
+);
+
+-- Promo codes managed by admins; empty applies_to and days_of_week mean no restriction
+CREATE TABLE promotions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    discount_type ENUM('percentage', 'fixed') NOT NULL,
+    discount_value DECIMAL(10,2) NOT NULL,
+    applies_to VARCHAR(100) DEFAULT '',
+    valid_from TIMESTAMP NULL,
+    valid_until TIMESTAMP NULL,
+    days_of_week VARCHAR(20) DEFAULT '',
+    start_time TIME NULL,
+    end_time TIME NULL,
+    max_redemptions INT,
+    max_per_user INT,
+    members_only BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by INT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Each use of a promo code; a booking takes at most one code
+CREATE TABLE promotion_redemptions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    promotion_id INT NOT NULL,
+    user_id INT NOT NULL,
+    reference_type ENUM('tee_time', 'range_session', 'equipment_rental') NOT NULL,
+    reference_id INT NOT NULL,
+    subtotal DECIMAL(10,2) NOT NULL,
+    discount_amount DECIMAL(10,2) NOT NULL,
+    breakdown JSON,
+    voided_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (promotion_id) REFERENCES promotions(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_redemption_reference (reference_type, reference_id)
+);
+
+-- Payment webhook events already handled, so redeliveries are ignored
+CREATE TABLE processed_webhook_events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL UNIQUE,
+    event_type VARCHAR(100) NOT NULL,
+    payment_id INT,
+    processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE SET NULL
 );
 
 -- Weather logs table
@@ -283,6 +924,23 @@ INSERT INTO holes (course_id, hole_number, par, yardage, handicap_index, descrip
 (1, 17, 4, 450, 7, 'Challenging par 4 with OB left'),
 (1, 18, 3, 205, 15, 'Spectacular finishing hole par 3');
 
+-- Insert tee sets for the default course
+INSERT INTO tee_sets (course_id, name, color, course_rating, slope_rating, is_default) VALUES
+(1, 'Championship', 'black', 74.1, 139, FALSE),
+(1, 'Men''s', 'blue', 72.5, 135, TRUE),
+(1, 'Senior', 'white', 70.6, 128, FALSE),
+(1, 'Forward', 'red', 68.9, 120, FALSE);
+
+INSERT INTO hole_tees (hole_id, tee_set_id, yardage)
+SELECT id, 1, yardage + 25 FROM holes WHERE course_id = 1
+UNION ALL SELECT id, 2, yardage FROM holes WHERE course_id = 1
+UNION ALL SELECT id, 3, yardage - 25 FROM holes WHERE course_id = 1
+UNION ALL SELECT id, 4, yardage - 70 FROM holes WHERE course_id = 1;
+
+UPDATE tee_sets SET total_yardage = (
+    SELECT SUM(yardage) FROM hole_tees WHERE hole_tees.tee_set_id = tee_sets.id
+) WHERE course_id = 1;
+
 -- Insert default equipment
 INSERT INTO equipment (name, category, description, rental_price_per_day, quantity_available, condition_status) VALUES
 ('Beginner Club Set', 'clubs', 'Complete set of clubs perfect for beginners', 25.00, 10, 'good'),
@@ -294,13 +952,75 @@ INSERT INTO equipment (name, category, description, rental_price_per_day, quanti
 ('Push Cart', 'carts', 'Manual push cart for golf bags', 15.00, 25, 'good'),
 ('Golf Shoes', 'accessories', 'Spike golf shoes various sizes', 12.00, 30, 'good'),
 ('Golf Gloves', 'accessories', 'Leather golf gloves all sizes', 5.00, 50, 'excellent'),
-('Range Finder', 'accessories', 'GPS range finder device', 20.00, 8, 'excellent');
+('Range Finder', 'accessories', 'GPS range finder device', 20.00, 8, 'excellent'),
+('Golf Balls (Dozen)', 'accessories', 'Dozen premium golf balls for the round', 18.00, 100, 'excellent');
+
+-- Insert variants for equipment stocked by size or handedness
+INSERT INTO equipment_variants (equipment_id, sku, size, handedness, shaft_flex, gender, quantity_available) VALUES
+(1, 'BCS-RH-REG-M', NULL, 'right', 'regular', 'mens', 6),
+(1, 'BCS-LH-REG-M', NULL, 'left', 'regular', 'mens', 2),
+(1, 'BCS-RH-LAD-W', NULL, 'right', 'ladies', 'womens', 2),
+(8, 'SHOE-7', '7', NULL, NULL, 'unisex', 4),
+(8, 'SHOE-8', '8', NULL, NULL, 'unisex', 5),
+(8, 'SHOE-9', '9', NULL, NULL, 'unisex', 6),
+(8, 'SHOE-10', '10', NULL, NULL, 'unisex', 6),
+(8, 'SHOE-11', '11', NULL, NULL, 'unisex', 5),
+(8, 'SHOE-12', '12', NULL, NULL, 'unisex', 4);
+
+-- Insert default bundles
+INSERT INTO equipment_bundles (name, description, bundle_price_per_day) VALUES
+('Full Round Kit', 'Intermediate clubs, electric cart, a dozen balls and a range finder', 85.00);
+
+INSERT INTO equipment_bundle_items (bundle_id, equipment_id, quantity) VALUES
+(1, 2, 1),
+(1, 6, 1),
+(1, 11, 1),
+(1, 10, 1);
+
+-- Insert cart fleet
+INSERT INTO golf_carts (cart_number, cart_type, power_type, battery_level, fuel_level) VALUES
+('C01', 'two_seater', 'electric', 100, NULL),
+('C02', 'two_seater', 'electric', 100, NULL),
+('C03', 'two_seater', 'electric', 100, NULL),
+('C04', 'two_seater', 'electric', 100, NULL),
+('C05', 'two_seater', 'electric', 100, NULL),
+('C06', 'two_seater', 'electric', 100, NULL),
+('C07', 'two_seater', 'electric', 100, NULL),
+('C08', 'two_seater', 'electric', 100, NULL),
+('C09', 'two_seater', 'electric', 100, NULL),
+('C10', 'two_seater', 'electric', 100, NULL),
+('C11', 'two_seater', 'gas', NULL, 100),
+('C12', 'two_seater', 'gas', NULL, 100);
+
+-- Insert chart of accounts
+INSERT INTO ledger_accounts (code, name, account_type) VALUES
+('1000', 'Cash and card clearing', 'asset'),
+('2000', 'Customer deposits', 'liability'),
+('2100', 'Sales tax payable', 'liability'),
+('2200', 'Gift card balances', 'liability'),
+('4000', 'Green fees', 'revenue'),
+('4010', 'Cart fees', 'revenue'),
+('4100', 'Range sales', 'revenue'),
+('4200', 'Equipment rentals', 'revenue'),
+('4210', 'Damage charges', 'revenue'),
+('4300', 'Tournament entry fees', 'revenue'),
+('4900', 'Other revenue', 'revenue'),
+('4950', 'Refunds', 'contra_revenue');
 
 -- Insert system settings
 INSERT INTO system_settings (setting_key, setting_value, description) VALUES
 ('booking_advance_days', '30', 'Maximum days in advance for tee time booking'),
 ('cancellation_hours', '24', 'Minimum hours before cancellation without penalty'),
+('refund_approval_threshold', '50', 'Customer refund requests above this amount wait for staff approval'),
+('sales_tax_rate', '0', 'Sales tax percentage included in prices'),
+('invoice_prefix', 'INV-', 'Prefix for invoice and receipt numbers'),
+('invoice_next_number', '1', 'Next invoice number; advanced under a row lock as invoices are issued'),
+('gift_card_min_amount', '10', 'Smallest gift card value that can be sold'),
+('gift_card_max_amount', '500', 'Largest gift card value that can be sold'),
 ('range_session_duration', '60', 'Default range session duration in minutes'),
+('round_duration_minutes', '270', 'Time a cart is out for one round, including turnaround'),
+('cart_charge_threshold', '80', 'Battery level below which a returned cart goes on charge'),
+('leaderboard_refresh_seconds', '15', 'How often the live tournament leaderboard is refreshed'),
 ('small_bucket_balls', '50', 'Number of balls in small bucket'),
 ('medium_bucket_balls', '75', 'Number of balls in medium bucket'),
 ('large_bucket_balls', '100', 'Number of balls in large bucket'),
@@ -318,9 +1038,25 @@ CREATE INDEX idx_users_email ON users(email);
 CREATE INDEX idx_users_role ON users(role);
 CREATE INDEX idx_tee_times_date ON tee_times(booking_date);
 CREATE INDEX idx_tee_times_user ON tee_times(user_id);
+CREATE INDEX idx_cart_assignments_cart ON cart_assignments(cart_id, returned_at);
+CREATE INDEX idx_work_orders_status ON work_orders(status, priority);
+CREATE INDEX idx_work_orders_equipment ON work_orders(equipment_id);
+CREATE INDEX idx_work_orders_cart ON work_orders(cart_id);
 CREATE INDEX idx_range_sessions_date ON range_sessions(session_date);
 CREATE INDEX idx_range_sessions_user ON range_sessions(user_id);
 CREATE INDEX idx_equipment_rentals_user ON equipment_rentals(user_id);
+CREATE INDEX idx_equipment_variants_equipment ON equipment_variants(equipment_id);
+CREATE INDEX idx_bundle_rentals_user ON bundle_rentals(user_id);
 CREATE INDEX idx_scorecards_user ON scorecards(user_id);
+CREATE INDEX idx_handicap_revisions_user ON handicap_revisions(user_id, played_date);
+CREATE INDEX idx_tournaments_start ON tournaments(start_date, status);
+CREATE INDEX idx_tournament_participants_user ON tournament_participants(user_id);
 CREATE INDEX idx_payments_user ON payments(user_id);
 CREATE INDEX idx_payments_status ON payments(payment_status);
+CREATE INDEX idx_payments_reference ON payments(reference_type, reference_id);
+CREATE INDEX idx_refunds_payment ON refunds(payment_id);
+CREATE INDEX idx_refunds_status ON refunds(refund_status);
+CREATE INDEX idx_journal_entries_posted ON journal_entries(posted_at);
+CREATE INDEX idx_journal_lines_account ON journal_lines(account_code);
+CREATE INDEX idx_gift_card_transactions_card ON gift_card_transactions(gift_card_id, created_at);
+CREATE INDEX idx_promotion_redemptions_promotion ON promotion_redemptions(promotion_id, user_id);